@@ -0,0 +1,169 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"text/tabwriter"
+
+	"github.com/nafiz1001/wego/iface"
+)
+
+// agroView is set by the -agro flag.
+var agroView bool
+
+// Soil temperatures at sowing depth in degrees celsius most seeds need to
+// germinate, and soil moisture in m³/m³ below which seeds should be watered
+// and above which the soil is too wet to work.
+const (
+	agroCoolSeasonC = 5
+	agroMostC       = 10
+	agroWarmSeasonC = 16
+	agroDry         = 0.15
+	agroWet         = 0.4
+)
+
+// agroSowDepth and agroMoistureLayer are the indexes of the soil temperature
+// and moisture at sowing depth in iface.SoilDepthsCm and iface.SoilLayersCm.
+const (
+	agroSowDepth      = 1 // 6 cm
+	agroMoistureLayer = 2 // 3 to 9 cm
+)
+
+// agroAdvice returns what can be sown at a lowest soil temperature of minC at
+// sowing depth and a moisture of the sowing layer, which is nil if unknown.
+func agroAdvice(minC float32, moisture *float32) string {
+	if moisture != nil && *moisture >= agroWet {
+		return "too wet to work the soil"
+	}
+	var ret string
+	switch {
+	case minC < agroCoolSeasonC:
+		return "too cold to sow"
+	case minC < agroMostC:
+		ret = "cool-season crops (peas, spinach, lettuce)"
+	case minC < agroWarmSeasonC:
+		ret = "most vegetables (carrots, beets, onions)"
+	default:
+		ret = "warm-season crops too (beans, squash, corn)"
+	}
+	if moisture != nil && *moisture < agroDry {
+		ret += ", water first"
+	}
+	return ret
+}
+
+// convertCm converts a depth or thickness in cm to the unit system and returns
+// the unit label.
+func convertCm(cm float32, unit iface.UnitSystem) (float32, string) {
+	v, u := unit.DistanceUnit.FromCm(cm)
+	return v, iface.NumberLocale.Unit(u)
+}
+
+// agroRange formats the lowest and highest of vals converted by conv, like
+// "8–11 °C", or one number if they round to the same.
+func agroRange(vals []float32, conv func(float32) (float32, string)) string {
+	min, max := vals[0], vals[0]
+	for _, v := range vals {
+		if v < min {
+			min = v
+		}
+		if v > max {
+			max = v
+		}
+	}
+	lo, u := conv(min)
+	hi, _ := conv(max)
+	l, h := iface.FormatInt(int(math.Round(float64(lo)))), iface.FormatInt(int(math.Round(float64(hi))))
+	if l == h {
+		return l + " " + u
+	}
+	return l + "–" + h + " " + u
+}
+
+// printAgro prints the soil of every day of r for gardeners and farmers
+// deciding when to sow: the range of the soil temperatures at each depth, the
+// moisture of the sowing layer, how deep the ground freezes and what can be
+// sown, for the -agro flag. The spray windows of each day follow.
+func printAgro(r iface.Data, unit iface.UnitSystem) {
+	fmt.Printf("Soil at %s\n\n", r.Location)
+	depth := func(cm float32) (float32, string) { return convertCm(cm, unit) }
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprint(w, "DAY")
+	for _, d := range iface.SoilDepthsCm {
+		fmt.Fprintf(w, "\t%s", agroRange([]float32{d}, depth))
+	}
+	layer := iface.SoilLayersCm[agroMoistureLayer]
+	fmt.Fprintf(w, "\tMOISTURE %s\tFROST\tSOWING\n", agroRange(layer[:], depth))
+
+	found := false
+	for _, d := range r.Forecast {
+		var temps [len(iface.SoilDepthsCm)][]float32
+		var moisture []float32
+		var frost float32
+		for _, s := range d.Slots {
+			if s.Soil == nil {
+				continue
+			}
+			for i, t := range s.Soil.TempC {
+				if t != nil {
+					temps[i] = append(temps[i], *t)
+				}
+			}
+			if m := s.Soil.Moisture[agroMoistureLayer]; m != nil {
+				moisture = append(moisture, *m)
+			}
+			if f, ok := s.Soil.FrostDepthCm(); ok && f > frost {
+				frost = f
+			}
+		}
+		if len(temps[agroSowDepth]) == 0 {
+			continue
+		}
+		found = true
+
+		fmt.Fprint(w, d.Date.Format("Mon 02. Jan"))
+		for _, ts := range temps {
+			if len(ts) == 0 {
+				fmt.Fprint(w, "\t-")
+				continue
+			}
+			fmt.Fprintf(w, "\t%s", agroRange(ts, unit.Temp))
+		}
+		var mean *float32
+		if len(moisture) > 0 {
+			var sum float32
+			for _, m := range moisture {
+				sum += m
+			}
+			m := sum / float32(len(moisture))
+			mean = &m
+			fmt.Fprintf(w, "\t%s m³/m³", iface.FormatFloat(m, 2))
+		} else {
+			fmt.Fprint(w, "\t-")
+		}
+		if frost > 0 {
+			fmt.Fprintf(w, "\t%s", agroRange([]float32{frost}, depth))
+		} else {
+			fmt.Fprint(w, "\t-")
+		}
+		min := temps[agroSowDepth][0]
+		for _, t := range temps[agroSowDepth] {
+			if t < min {
+				min = t
+			}
+		}
+		fmt.Fprintf(w, "\t%s\n", agroAdvice(min, mean))
+	}
+	w.Flush()
+	if !found {
+		fmt.Println("No soil data for the forecast days.")
+	}
+
+	fmt.Println("\nSpray windows:")
+	w = tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	for i, windows := range sprayWindows(r) {
+		fmt.Fprintf(w, "%s\t%s\n", r.Forecast[i].Date.Format("Mon 02. Jan"), windows)
+	}
+	w.Flush()
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/url"
+	"os"
+
+	"github.com/nafiz1001/wego/iface"
+)
+
+// aqiURL is set by the -aqi-url flag.
+var aqiURL string
+
+// aqiFailAbove is set by the -aqi-fail-above flag.
+var aqiFailAbove int
+
+// aqiFailStatus is the exit status of wego if the AQI exceeds -aqi-fail-above.
+const aqiFailStatus = 3
+
+// fetchAirQuality returns the current air quality at loc from the Open-Meteo
+// air quality API.
+func fetchAirQuality(loc iface.LatLon) (*iface.AirQuality, error) {
+	u, err := url.Parse(aqiURL)
+	if err != nil {
+		return nil, err
+	}
+	q := u.Query()
+	q.Set("latitude", fmt.Sprintf("%.2f", loc.Latitude))
+	q.Set("longitude", fmt.Sprintf("%.2f", loc.Longitude))
+	q.Set("current", "us_aqi,pm2_5,pm10,ozone,nitrogen_dioxide")
+	u.RawQuery = q.Encode()
+
+	var resp struct {
+		Current *struct {
+			AQI  *float32 `json:"us_aqi"`
+			PM25 *float32 `json:"pm2_5"`
+			PM10 *float32 `json:"pm10"`
+			O3   *float32 `json:"ozone"`
+			NO2  *float32 `json:"nitrogen_dioxide"`
+		} `json:"current"`
+	}
+	if err := fetchFeed(u.String(), func(r io.Reader) error {
+		return json.NewDecoder(r).Decode(&resp)
+	}); err != nil {
+		return nil, err
+	}
+	c := resp.Current
+	if c == nil {
+		return nil, fmt.Errorf("%s: no current air quality", aqiURL)
+	}
+
+	ret := &iface.AirQuality{PM25Ugm3: c.PM25, PM10Ugm3: c.PM10, O3Ugm3: c.O3, NO2Ugm3: c.NO2}
+	if c.AQI != nil {
+		aqi := int(*c.AQI + 0.5)
+		ret.AQI = &aqi
+		c := iface.AQICategories[iface.AQICategory(aqi)]
+		ret.Category, ret.Advice = c.Name, c.Advice
+	}
+	return ret, nil
+}
+
+// applyAirQuality sets the air quality of r for -aqi, unless the backend did.
+func applyAirQuality(r *iface.Data) {
+	if r.AirQuality != nil {
+		return
+	}
+	if r.GeoLoc == nil {
+		log.Println("Unable to get the air quality: the backend returned no coordinates")
+		return
+	}
+	aq, err := fetchAirQuality(*r.GeoLoc)
+	if err != nil {
+		log.Printf("Unable to get the air quality: %v", err)
+		return
+	}
+	r.AirQuality = aq
+}
+
+// checkAirQuality exits with aqiFailStatus if the AQI of any of rs exceeds
+// -aqi-fail-above, so scripts can close the windows or turn on a purifier.
+func checkAirQuality(rs ...iface.Data) {
+	if aqiFailAbove <= 0 {
+		return
+	}
+	for _, r := range rs {
+		if aq := r.AirQuality; aq != nil && aq.AQI != nil && *aq.AQI > aqiFailAbove {
+			log.Printf("The AQI of %d at %s exceeds %d", *aq.AQI, r.Location, aqiFailAbove)
+			os.Exit(aqiFailStatus)
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/nafiz1001/wego/cache"
+	"github.com/nafiz1001/wego/iface"
+)
+
+// alertsURL is set by the -alerts-url flag.
+var alertsURL string
+
+// capAlert is a weather alert in the Common Alerting Protocol, as published
+// by the GeoJSON alerts API of the National Weather Service.
+type capAlert struct {
+	ID          string    `json:"id"` // the CAP identifier
+	Sent        time.Time `json:"sent"`
+	MessageType string    `json:"messageType"` // Alert, Update or Cancel
+	Severity    string    `json:"severity"`
+	Event       string    `json:"event"`
+	Headline    string    `json:"headline"`
+	Description string    `json:"description"`
+	References  []struct {
+		Identifier string `json:"identifier"`
+	} `json:"references"`
+}
+
+// alertsKey is the cache key of the alerts seen by the daemon for location.
+func alertsKey(location string) string {
+	return "alerts-" + location
+}
+
+// fetchAlerts returns the active alerts for loc.
+func fetchAlerts(loc iface.LatLon) ([]capAlert, error) {
+	var feed struct {
+		Features []struct {
+			Properties capAlert `json:"properties"`
+		} `json:"features"`
+	}
+	url := strings.Replace(alertsURL, "{loc}", fmt.Sprintf("%.4f,%.4f", loc.Latitude, loc.Longitude), 1)
+	if err := fetchFeed(url, func(r io.Reader) error {
+		return json.NewDecoder(r).Decode(&feed)
+	}); err != nil {
+		return nil, err
+	}
+	ret := make([]capAlert, 0, len(feed.Features))
+	for _, f := range feed.Features {
+		ret = append(ret, f.Properties)
+	}
+	return ret, nil
+}
+
+// backendAlerts returns the alerts reported by the backend as CAP alerts.
+// Backends give no identifiers, so an alert is identified by its severity,
+// headline and the time it takes effect, which makes an upgraded alert a new
+// one.
+func backendAlerts(alerts []iface.Alert) []capAlert {
+	ret := make([]capAlert, 0, len(alerts))
+	for _, a := range alerts {
+		ret = append(ret, capAlert{
+			ID:          fmt.Sprintf("%s|%s|%s", a.Severity, a.Headline, a.Effective.Format(time.RFC3339)),
+			Sent:        a.Effective,
+			MessageType: "Alert",
+			Severity:    a.Severity,
+			Event:       a.Headline,
+			Headline:    a.Headline,
+		})
+	}
+	return ret
+}
+
+// newAlerts returns the alerts which were not seen before, along with whether
+// each one upgrades an alert it updates. seen maps the CAP identifiers of the
+// alerts of the previous fetch to their severity. Cancellations and updates
+// which do not raise the severity are left out.
+func newAlerts(seen map[string]string, alerts []capAlert) (ret []capAlert, upgraded []bool) {
+	for _, a := range alerts {
+		if _, ok := seen[a.ID]; ok || a.MessageType == "Cancel" {
+			continue
+		}
+		prev := -1
+		for _, ref := range a.References {
+			if s, ok := seen[ref.Identifier]; ok && iface.AlertSeverities[s] > prev {
+				prev = iface.AlertSeverities[s]
+			}
+		}
+		if prev >= iface.AlertSeverities[a.Severity] {
+			continue
+		}
+		ret = append(ret, a)
+		upgraded = append(upgraded, prev >= 0)
+	}
+	return
+}
+
+// checkAlerts sends a notification for every newly issued or upgraded alert
+// for the location of r. The alerts are the ones of the backend if it reports
+// any, else they are fetched from -alerts-url. The alerts seen are kept in the
+// cache, so restarting the daemon does not repeat notifications.
+func checkAlerts(location string, r iface.Data) error {
+	var alerts []capAlert
+	if be, ok := iface.AllBackends[r.ForecastSource]; ok && capabilities(be).Alerts {
+		alerts = backendAlerts(r.Alerts)
+	} else {
+		if r.GeoLoc == nil {
+			return fmt.Errorf("the backend returned no coordinates")
+		}
+		var err error
+		if alerts, err = fetchAlerts(*r.GeoLoc); err != nil {
+			return err
+		}
+	}
+
+	// without a cached state every active alert is new
+	seen := map[string]string{}
+	cache.LoadState(alertsKey(location), &seen)
+	fresh, upgraded := newAlerts(seen, alerts)
+	for i, a := range fresh {
+		notifyAlert(r.Location, a, upgraded[i])
+	}
+
+	next := make(map[string]string, len(alerts))
+	for _, a := range alerts {
+		next[a.ID] = a.Severity
+	}
+	return cache.StoreState(alertsKey(location), next)
+}
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/nafiz1001/wego/iface"
+)
+
+func TestBackendAlerts(t *testing.T) {
+	issued := time.Date(2023, 1, 10, 6, 0, 0, 0, time.UTC)
+	warning := iface.Alert{Severity: "Moderate", Headline: "snowfall warning", Effective: issued}
+
+	alerts := backendAlerts([]iface.Alert{warning})
+	fresh, _ := newAlerts(map[string]string{}, alerts)
+	if len(fresh) != 1 || fresh[0].Headline != warning.Headline {
+		t.Fatalf("newAlerts = %v, want the warning", fresh)
+	}
+
+	seen := map[string]string{alerts[0].ID: alerts[0].Severity}
+	if fresh, _ := newAlerts(seen, backendAlerts([]iface.Alert{warning})); len(fresh) != 0 {
+		t.Errorf("the warning was new again on the next fetch: %v", fresh)
+	}
+	warning.Severity = "Severe"
+	if fresh, _ := newAlerts(seen, backendAlerts([]iface.Alert{warning})); len(fresh) != 1 {
+		t.Errorf("the raised warning was not new: %v", fresh)
+	}
+}
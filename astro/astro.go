@@ -0,0 +1,104 @@
+// Package astro computes the position of the sun and the moon for a location,
+// so wego can show their events even if a backend does not supply them.
+//
+// The calculations follow the sunrise equation as described in
+// https://en.wikipedia.org/wiki/Sunrise_equation and are accurate to about a
+// minute, which is plenty for weather forecasts.
+package astro
+
+import (
+	"math"
+	"time"
+)
+
+const (
+	j2000 = 2451545.0 // julian date of 2000-01-01 12:00 UTC
+	unix0 = 2440587.5 // julian date of 1970-01-01 00:00 UTC
+
+	// sunrise and sunset are defined as the moment the upper rim of the sun
+	// touches the horizon, including atmospheric refraction.
+	horizonDeg = -0.833
+)
+
+func rad(deg float64) float64 { return deg * math.Pi / 180 }
+func deg(rad float64) float64 { return rad * 180 / math.Pi }
+
+func julian(t time.Time) float64 {
+	return float64(t.UnixNano())/float64(24*time.Hour) + unix0
+}
+
+func fromJulian(j float64) time.Time {
+	return time.Unix(0, int64((j-unix0)*float64(24*time.Hour))).UTC()
+}
+
+// transit returns the julian date of the solar transit (solar noon) at
+// longitude lon closest to the julian date j and the declination of the sun at
+// that moment in radians.
+func transit(j float64, lon float64) (jTransit float64, decl float64) {
+	n := math.Round(j - j2000 + lon/360)
+	jStar := n - lon/360
+
+	m := math.Mod(357.5291+0.98560028*jStar, 360)
+	c := 1.9148*math.Sin(rad(m)) + 0.02*math.Sin(rad(2*m)) + 0.0003*math.Sin(rad(3*m))
+	lambda := math.Mod(m+c+180+102.9372, 360)
+
+	jTransit = j2000 + jStar + 0.0053*math.Sin(rad(m)) - 0.0069*math.Sin(rad(2*lambda))
+	decl = math.Asin(math.Sin(rad(lambda)) * math.Sin(rad(23.4397)))
+	return
+}
+
+// SolarNoon returns the time the sun reaches its highest point on the date of
+// day (in the location of day) at the given coordinates in degrees.
+func SolarNoon(day time.Time, lat, lon float64) time.Time {
+	y, m, d := day.Date()
+	jt, _ := transit(julian(time.Date(y, m, d, 12, 0, 0, 0, day.Location())), lon)
+	return fromJulian(jt).In(day.Location())
+}
+
+// SunriseSunset returns the times of sunrise and sunset on the date of day (in
+// the location of day) at the given coordinates in degrees. Both are zero if
+// the sun does not rise or set on that day (polar day or polar night), use
+// Daylight to tell them apart.
+func SunriseSunset(day time.Time, lat, lon float64) (rise, set time.Time) {
+	y, m, d := day.Date()
+	jt, decl := transit(julian(time.Date(y, m, d, 12, 0, 0, 0, day.Location())), lon)
+
+	cosOmega := (math.Sin(rad(horizonDeg)) - math.Sin(rad(lat))*math.Sin(decl)) / (math.Cos(rad(lat)) * math.Cos(decl))
+	if cosOmega < -1 || cosOmega > 1 {
+		return
+	}
+	omega := deg(math.Acos(cosOmega))
+
+	rise = fromJulian(jt - omega/360).In(day.Location())
+	set = fromJulian(jt + omega/360).In(day.Location())
+	return
+}
+
+// Elevation returns the angle of the center of the sun above the horizon in
+// degrees at time t and the given coordinates in degrees.
+func Elevation(t time.Time, lat, lon float64) float64 {
+	j := julian(t)
+	jt, decl := transit(j, lon)
+	hourAngle := rad((j - jt) * 360)
+	return deg(math.Asin(math.Sin(rad(lat))*math.Sin(decl) + math.Cos(rad(lat))*math.Cos(decl)*math.Cos(hourAngle)))
+}
+
+// Daylight returns how long the sun is above the horizon on the date of day
+// (in the location of day) at the given coordinates in degrees. It is 24 hours
+// during polar day and zero during polar night.
+func Daylight(day time.Time, lat, lon float64) time.Duration {
+	if rise, set := SunriseSunset(day, lat, lon); !rise.IsZero() {
+		return set.Sub(rise)
+	}
+	if IsDay(SolarNoon(day, lat, lon), lat, lon) {
+		return 24 * time.Hour
+	}
+	return 0
+}
+
+// IsDay reports whether the sun is above the horizon at time t and the given
+// coordinates in degrees. Unlike comparing t with sunrise and sunset, this also
+// works during polar day and polar night.
+func IsDay(t time.Time, lat, lon float64) bool {
+	return Elevation(t, lat, lon) > horizonDeg
+}
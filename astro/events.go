@@ -0,0 +1,247 @@
+package astro
+
+import (
+	"math"
+	"time"
+)
+
+// Eclipse is a solar or lunar eclipse, with the time of its greatest eclipse
+// (for the earth as a whole) in UTC.
+type Eclipse struct {
+	Greatest time.Time
+	Solar    bool
+	Kind     string // total, annular, hybrid, partial or penumbral
+}
+
+func utc(year int, month time.Month, day, hour, min int) time.Time {
+	return time.Date(year, month, day, hour, min, 0, 0, time.UTC)
+}
+
+// Eclipses are the eclipses until 2030, from the catalogs of Fred Espenak
+// (NASA GSFC). The list needs to be extended to show later ones.
+var Eclipses = []Eclipse{
+	{utc(2026, time.February, 17, 12, 13), true, "annular"},
+	{utc(2026, time.March, 3, 11, 34), false, "total"},
+	{utc(2026, time.August, 12, 17, 47), true, "total"},
+	{utc(2026, time.August, 28, 4, 13), false, "partial"},
+	{utc(2027, time.February, 6, 16, 0), true, "annular"},
+	{utc(2027, time.February, 20, 23, 13), false, "penumbral"},
+	{utc(2027, time.July, 18, 16, 3), false, "penumbral"},
+	{utc(2027, time.August, 2, 10, 7), true, "total"},
+	{utc(2027, time.August, 17, 7, 14), false, "penumbral"},
+	{utc(2028, time.January, 12, 4, 13), false, "partial"},
+	{utc(2028, time.January, 26, 15, 8), true, "annular"},
+	{utc(2028, time.July, 6, 18, 20), false, "partial"},
+	{utc(2028, time.July, 22, 2, 56), true, "total"},
+	{utc(2028, time.December, 31, 16, 52), false, "total"},
+	{utc(2029, time.January, 14, 17, 13), true, "partial"},
+	{utc(2029, time.June, 12, 4, 6), true, "partial"},
+	{utc(2029, time.June, 26, 3, 22), false, "total"},
+	{utc(2029, time.July, 11, 15, 37), true, "partial"},
+	{utc(2029, time.December, 5, 15, 3), true, "partial"},
+	{utc(2029, time.December, 20, 22, 42), false, "total"},
+	{utc(2030, time.June, 1, 6, 29), true, "annular"},
+	{utc(2030, time.June, 15, 18, 33), false, "partial"},
+	{utc(2030, time.November, 25, 6, 51), true, "total"},
+	{utc(2030, time.December, 9, 22, 28), false, "penumbral"},
+}
+
+// MeteorShower is a yearly meteor shower of the IMO working list. Its peak
+// falls on about the same night every year, give or take a day.
+type MeteorShower struct {
+	Name string
+	// Month and Day of the evening starting the night of the peak
+	Month time.Month
+	Day   int
+	// ZHR is the number of meteors an hour under a perfect sky with the
+	// radiant overhead.
+	ZHR int
+	// RadiantDecl is the declination of the radiant in degrees.
+	RadiantDecl float64
+}
+
+// MeteorShowers are the major meteor showers.
+var MeteorShowers = []MeteorShower{
+	{"Quadrantids", time.January, 3, 110, 49},
+	{"Lyrids", time.April, 22, 18, 34},
+	{"Eta Aquariids", time.May, 5, 50, -1},
+	{"Southern Delta Aquariids", time.July, 30, 25, -16},
+	{"Perseids", time.August, 12, 100, 58},
+	{"Draconids", time.October, 8, 10, 54},
+	{"Orionids", time.October, 21, 20, 16},
+	{"Leonids", time.November, 17, 15, 22},
+	{"Geminids", time.December, 13, 150, 33},
+	{"Ursids", time.December, 22, 10, 75},
+}
+
+// RadiantMaxAltitude returns the highest altitude in degrees a radiant at
+// declination decl reaches at latitude lat, negative if it never rises.
+func RadiantMaxAltitude(decl, lat float64) float64 {
+	return 90 - math.Abs(lat-decl)
+}
+
+// MoonIllumination returns the illuminated fraction of the disk of the moon at
+// time t.
+func MoonIllumination(t time.Time) float64 {
+	return (1 - math.Cos(2*math.Pi*MoonPhase(t))) / 2
+}
+
+const (
+	earthRadiusKm = 6371
+	moonRadiusKm  = 1737.4
+	sunRadiusDeg  = 0.2666
+)
+
+// lunarTerms are the largest periodic terms of the longitude in 1e-6 degrees
+// and the distance in m of the moon, with the multiples of the mean
+// elongation, the mean anomalies of the sun and the moon and the argument of
+// latitude in their argument, from Meeus, "Astronomical Algorithms", table
+// 47.A.
+var lunarTerms = []struct {
+	d, ms, m, f float64
+	lon, dist   float64
+}{
+	{0, 0, 1, 0, 6288774, -20905355},
+	{2, 0, -1, 0, 1274027, -3699111},
+	{2, 0, 0, 0, 658314, -2955968},
+	{0, 0, 2, 0, 213618, -569925},
+	{0, 1, 0, 0, -185116, 48888},
+	{0, 0, 0, 2, -114332, -3149},
+	{2, 0, -2, 0, 58793, 246158},
+	{2, -1, -1, 0, 57066, -152138},
+	{2, 0, 1, 0, 53322, -170733},
+	{2, -1, 0, 0, 45758, -204586},
+	{0, 1, -1, 0, -40923, -129620},
+	{1, 0, 0, 0, -34720, 108743},
+	{0, 1, 1, 0, -30383, 104755},
+	{2, 0, 0, -2, 15327, 10321},
+	{0, 0, 1, 2, -12528, 0},
+	{0, 0, 1, -2, 10980, 79661},
+	{4, 0, -1, 0, 10675, -34782},
+	{0, 0, 3, 0, 10034, -23210},
+	{4, 0, -2, 0, 8548, -21636},
+	{2, 1, -1, 0, -7888, 24208},
+	{2, 1, 0, 0, -6766, 30824},
+	{1, 0, -1, 0, -5163, -8379},
+	{1, 1, 0, 0, 4987, -16675},
+	{2, -1, 1, 0, 4036, -12831},
+}
+
+// latitudeTerms are the largest periodic terms of the latitude of the moon in
+// 1e-6 degrees, from table 47.B.
+var latitudeTerms = []struct {
+	d, ms, m, f float64
+	lat         float64
+}{
+	{0, 0, 0, 1, 5128122},
+	{0, 0, 1, 1, 280602},
+	{0, 0, 1, -1, 277693},
+	{2, 0, 0, -1, 173237},
+	{2, 0, -1, 1, 55413},
+	{2, 0, -1, -1, 46271},
+	{2, 0, 0, 1, 32573},
+	{0, 0, 2, 1, 17198},
+	{2, 0, 1, -1, 9266},
+	{0, 0, 2, -1, 8822},
+	{2, -1, 0, -1, 8216},
+	{2, 0, -2, -1, 4324},
+	{2, 0, 1, 1, 4200},
+}
+
+// moonCoordsPrecise is moonCoords with the terms of Meeus, which place the
+// moon within a few hundredths of a degree. The evection alone moves it by
+// more than its diameter, which does not matter for moonrise but decides
+// whether an eclipse is seen.
+func moonCoordsPrecise(d float64) (ra, decl, distKm float64) {
+	l := 218.3164477 + 13.17639648*d
+	dd := rad(297.8501921 + 12.19074912*d) // mean elongation
+	ms := rad(357.5291092 + 0.98560028*d)  // mean anomaly of the sun
+	m := rad(134.9633964 + 13.06499295*d)  // mean anomaly of the moon
+	f := rad(93.2720950 + 13.22935024*d)   // argument of latitude
+
+	lon, lat, dist := l, 0.0, 385000.56
+	for _, t := range lunarTerms {
+		arg := t.d*dd + t.ms*ms + t.m*m + t.f*f
+		lon += t.lon / 1e6 * math.Sin(arg)
+		dist += t.dist / 1e3 * math.Cos(arg)
+	}
+	for _, t := range latitudeTerms {
+		lat += t.lat / 1e6 * math.Sin(t.d*dd+t.ms*ms+t.m*m+t.f*f)
+	}
+	ra, decl = equatorial(rad(lon), rad(lat))
+	return ra, decl, dist
+}
+
+// sunCoordsOfDate is sunCoords referred to the equinox of the date like
+// moonCoordsPrecise, instead of drifting from it with the precession by
+// almost a degree a century.
+func sunCoordsOfDate(d float64) (ra, decl float64) {
+	m := rad(357.5291092 + 0.98560028*d)
+	c := 1.914602*math.Sin(m) + 0.019993*math.Sin(2*m) + 0.000289*math.Sin(3*m)
+	return equatorial(rad(280.46646+0.98564736*d+c-0.00569), 0)
+}
+
+// horizontal returns the altitude and azimuth in radians of the right
+// ascension ra and declination decl d days after J2000 at the given
+// coordinates in degrees.
+func horizontal(ra, decl, d, lat, lon float64) (alt, az float64) {
+	hourAngle := rad(280.16+360.9856235*d) + rad(lon) - ra
+	phi := rad(lat)
+	alt = math.Asin(math.Sin(phi)*math.Sin(decl) + math.Cos(phi)*math.Cos(decl)*math.Cos(hourAngle))
+	az = math.Atan2(math.Sin(hourAngle), math.Cos(hourAngle)*math.Sin(phi)-math.Tan(decl)*math.Cos(phi))
+	return
+}
+
+// solarEclipseAt returns the magnitude of a solar eclipse at time t and the
+// given coordinates in degrees: the fraction of the diameter of the sun
+// covered by the moon, 0 if they do not overlap or the sun is down. The kind
+// is total, annular or partial.
+func solarEclipseAt(t time.Time, lat, lon float64) (mag float64, kind string) {
+	d := julian(t) - j2000
+	sRA, sDecl := sunCoordsOfDate(d)
+	sAlt, sAz := horizontal(sRA, sDecl, d, lat, lon)
+	if deg(sAlt) < horizonDeg {
+		return 0, ""
+	}
+	mRA, mDecl, mDist := moonCoordsPrecise(d)
+	mAlt, mAz := horizontal(mRA, mDecl, d, lat, lon)
+	// seen from the surface instead of the center of the earth, the moon is
+	// lower in the sky by its parallax
+	mAlt -= math.Asin(earthRadiusKm / mDist * math.Cos(mAlt))
+
+	sep := deg(math.Acos(math.Min(1, math.Sin(sAlt)*math.Sin(mAlt)+math.Cos(sAlt)*math.Cos(mAlt)*math.Cos(sAz-mAz))))
+	moonRadiusDeg := deg(math.Asin(moonRadiusKm / (mDist - earthRadiusKm*math.Sin(mAlt))))
+	switch {
+	case sep >= sunRadiusDeg+moonRadiusDeg:
+		return 0, ""
+	case sep <= moonRadiusDeg-sunRadiusDeg:
+		kind = "total"
+	case sep <= sunRadiusDeg-moonRadiusDeg:
+		kind = "annular"
+	default:
+		kind = "partial"
+	}
+	return (sunRadiusDeg + moonRadiusDeg - sep) / (2 * sunRadiusDeg), kind
+}
+
+// LocalSolarEclipse returns the time, magnitude and kind (total, annular or
+// partial) of the largest phase of the solar eclipse e seen at the given
+// coordinates in degrees, a magnitude of 0 if it cannot be seen there. The
+// positions are accurate to a few hundredths of a degree, so locations close
+// to the edge of the path of totality may see a deep partial eclipse instead.
+func LocalSolarEclipse(e Eclipse, lat, lon float64) (max time.Time, mag float64, kind string) {
+	for t := e.Greatest.Add(-4 * time.Hour); !t.After(e.Greatest.Add(4 * time.Hour)); t = t.Add(2 * time.Minute) {
+		if m, k := solarEclipseAt(t, lat, lon); m > mag {
+			max, mag, kind = t, m, k
+		}
+	}
+	return
+}
+
+// LunarEclipseVisible reports whether the moon is above the horizon at the
+// greatest phase of the lunar eclipse e at the given coordinates in degrees,
+// and returns its altitude then.
+func LunarEclipseVisible(e Eclipse, lat, lon float64) (bool, float64) {
+	alt := MoonElevation(e.Greatest, lat, lon)
+	return alt > moonHorizonDeg, alt
+}
@@ -0,0 +1,146 @@
+package astro
+
+import (
+	"math"
+	"time"
+)
+
+// The position of the moon follows the low precision series of SunCalc by
+// Vladimir Agafonkin (https://github.com/mourner/suncalc), after "Astronomy
+// Answers" by Aart Jansen. Rise and set times are accurate to a few minutes.
+
+const (
+	obliquity = 23.4397 // of the ecliptic in degrees
+
+	// moonrise and moonset are the moment the center of the moon is at this
+	// altitude, its mean apparent radius above the horizon.
+	moonHorizonDeg = 0.133
+
+	sunDistanceKm = 149598000
+)
+
+// equatorial converts the ecliptic longitude l and latitude b in radians to
+// the right ascension and declination.
+func equatorial(l, b float64) (ra, decl float64) {
+	e := rad(obliquity)
+	ra = math.Atan2(math.Sin(l)*math.Cos(e)-math.Tan(b)*math.Sin(e), math.Cos(l))
+	decl = math.Asin(math.Sin(b)*math.Cos(e) + math.Cos(b)*math.Sin(e)*math.Sin(l))
+	return
+}
+
+// sunCoords returns the right ascension and declination of the sun in radians
+// d days after J2000.
+func sunCoords(d float64) (ra, decl float64) {
+	m := rad(357.5291 + 0.98560028*d)
+	c := rad(1.9148*math.Sin(m) + 0.02*math.Sin(2*m) + 0.0003*math.Sin(3*m))
+	return equatorial(m+c+rad(102.9372)+math.Pi, 0)
+}
+
+// moonCoords returns the right ascension and declination of the moon in
+// radians and its distance in km d days after J2000.
+func moonCoords(d float64) (ra, decl, distKm float64) {
+	l := rad(218.316 + 13.176396*d) // mean ecliptic longitude
+	m := rad(134.963 + 13.064993*d) // mean anomaly
+	f := rad(93.272 + 13.229350*d)  // mean distance
+
+	ra, decl = equatorial(l+rad(6.289)*math.Sin(m), rad(5.128)*math.Sin(f))
+	return ra, decl, 385001 - 20905*math.Cos(m)
+}
+
+// MoonElevation returns the angle of the center of the moon above the horizon
+// in degrees at time t and the given coordinates in degrees, including
+// atmospheric refraction.
+func MoonElevation(t time.Time, lat, lon float64) float64 {
+	d := julian(t) - j2000
+	ra, decl, _ := moonCoords(d)
+	hourAngle := rad(280.16+360.9856235*d) + rad(lon) - ra
+	h := math.Asin(math.Sin(rad(lat))*math.Sin(decl) + math.Cos(rad(lat))*math.Cos(decl)*math.Cos(hourAngle))
+
+	refraction := 0.0002967 / math.Tan(math.Max(h, 0)+0.00312536/(math.Max(h, 0)+0.08901179))
+	return deg(h + refraction)
+}
+
+// MoonPhase returns the phase of the moon at time t as the elapsed fraction
+// of the lunation in [0, 1): 0 is new moon, 0.25 first quarter, 0.5 full moon
+// and 0.75 last quarter.
+func MoonPhase(t time.Time) float64 {
+	d := julian(t) - j2000
+	sRA, sDecl := sunCoords(d)
+	mRA, mDecl, mDist := moonCoords(d)
+
+	elongation := math.Acos(math.Sin(sDecl)*math.Sin(mDecl) + math.Cos(sDecl)*math.Cos(mDecl)*math.Cos(sRA-mRA))
+	inc := math.Atan2(sunDistanceKm*math.Sin(elongation), mDist-sunDistanceKm*math.Cos(elongation))
+	angle := math.Atan2(math.Cos(sDecl)*math.Sin(sRA-mRA), math.Sin(sDecl)*math.Cos(mDecl)-math.Cos(sDecl)*math.Sin(mDecl)*math.Cos(sRA-mRA))
+
+	sign := 1.0
+	if angle < 0 {
+		sign = -1
+	}
+	return math.Mod(0.5+0.5*inc*sign/math.Pi+1, 1)
+}
+
+// MoonriseMoonset returns the times of moonrise and moonset on the date of day
+// (in the location of day) at the given coordinates in degrees. Either is zero
+// if the moon does not rise or set on that day, which happens about once a
+// month and more often close to the poles.
+func MoonriseMoonset(day time.Time, lat, lon float64) (rise, set time.Time) {
+	y, m, d := day.Date()
+	start := time.Date(y, m, d, 0, 0, 0, 0, day.Location())
+	at := func(hours float64) time.Time {
+		return start.Add(time.Duration(hours * float64(time.Hour)))
+	}
+	h := func(hours float64) float64 {
+		return MoonElevation(at(hours), lat, lon) - moonHorizonDeg
+	}
+
+	// fit a parabola through the elevations of every two hours and find its
+	// roots between them
+	var riseH, setH float64
+	h0 := h(0)
+	for i := 1.0; i <= 24; i += 2 {
+		h1, h2 := h(i), h(i+1)
+		a := (h0+h2)/2 - h1
+		b := (h2 - h0) / 2
+		xe := -b / (2 * a)
+		ye := (a*xe+b)*xe + h1
+		disc := b*b - 4*a*h1
+
+		roots := 0
+		var x1, x2 float64
+		if disc >= 0 {
+			dx := math.Sqrt(disc) / (math.Abs(a) * 2)
+			x1, x2 = xe-dx, xe+dx
+			if math.Abs(x1) <= 1 {
+				roots++
+			}
+			if math.Abs(x2) <= 1 {
+				roots++
+			}
+			if x1 < -1 {
+				x1 = x2
+			}
+		}
+		switch {
+		case roots == 1 && h0 < 0:
+			riseH = i + x1
+		case roots == 1:
+			setH = i + x1
+		case roots == 2 && ye < 0:
+			riseH, setH = i+x2, i+x1
+		case roots == 2:
+			riseH, setH = i+x1, i+x2
+		}
+		if riseH != 0 && setH != 0 {
+			break
+		}
+		h0 = h2
+	}
+
+	if riseH != 0 {
+		rise = at(riseH)
+	}
+	if setH != 0 {
+		set = at(setH)
+	}
+	return
+}
@@ -0,0 +1,249 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/nafiz1001/wego/frontends"
+	"github.com/nafiz1001/wego/iface"
+)
+
+// atDayParts are the hours the parts of the day refer to, like the columns
+// of the ascii-art-table frontend.
+var atDayParts = map[string]int{
+	"morning":   8,
+	"noon":      12,
+	"midday":    12,
+	"afternoon": 15,
+	"evening":   19,
+	"night":     22,
+	"tonight":   22,
+	"midnight":  0,
+}
+
+// parseClock parses a time of the day like "18:00", "18", "6pm" or "6:30pm".
+// suffix is the following word, which may be "am" or "pm". It returns
+// whether it was used.
+func parseClock(w, suffix string) (hour, minute int, usedSuffix bool, ok bool) {
+	half := ""
+	for _, h := range []string{"am", "pm"} {
+		if strings.HasSuffix(w, h) {
+			w, half = strings.TrimSuffix(w, h), h
+		} else if suffix == h {
+			half, usedSuffix = h, true
+		}
+	}
+	w = strings.TrimSuffix(w, "h")
+	hm := strings.SplitN(w, ":", 2)
+	hour, err := strconv.Atoi(hm[0])
+	if err != nil {
+		return 0, 0, false, false
+	}
+	if len(hm) == 2 {
+		if minute, err = strconv.Atoi(hm[1]); err != nil || minute < 0 || minute > 59 {
+			return 0, 0, false, false
+		}
+	}
+	switch half {
+	case "am", "pm":
+		if hour < 1 || hour > 12 {
+			return 0, 0, false, false
+		}
+		hour %= 12
+		if half == "pm" {
+			hour += 12
+		}
+	}
+	return hour, minute, usedSuffix, hour >= 0 && hour <= 23
+}
+
+// parseAtTime parses a time like "saturday 18:00", "sat 6pm", "tomorrow
+// morning", "tonight", "18:00", "2026-07-01 14:00" or "in 3 hours" relative
+// to now. Days without a time refer to noon, times without a day to the next
+// time they come.
+func parseAtTime(s string, now time.Time) (time.Time, error) {
+	words := strings.Fields(strings.ToLower(s))
+	if len(words) == 0 {
+		return time.Time{}, fmt.Errorf("no time given")
+	}
+
+	if words[0] == "in" {
+		if len(words) == 2 {
+			d, err := time.ParseDuration(words[1])
+			if err != nil {
+				return time.Time{}, fmt.Errorf("invalid duration in %q, use e.g. \"in 3 hours\" or \"in 90m\"", s)
+			}
+			return now.Add(d), nil
+		}
+		if len(words) == 3 {
+			n, err := strconv.Atoi(words[1])
+			if err == nil {
+				switch strings.TrimSuffix(words[2], "s") {
+				case "min", "minute":
+					return now.Add(time.Duration(n) * time.Minute), nil
+				case "h", "hour":
+					return now.Add(time.Duration(n) * time.Hour), nil
+				case "day":
+					return now.AddDate(0, 0, n), nil
+				}
+			}
+		}
+		return time.Time{}, fmt.Errorf("invalid duration in %q, use e.g. \"in 3 hours\" or \"in 2 days\"", s)
+	}
+
+	var (
+		date         time.Time
+		weekday      = -1
+		next         bool
+		hour, minute = -1, 0
+	)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	for i := 0; i < len(words); i++ {
+		w := words[i]
+		suffix := ""
+		if i+1 < len(words) {
+			suffix = words[i+1]
+		}
+		if h, ok := atDayParts[w]; ok {
+			hour, minute = h, 0
+			if w == "tonight" {
+				// late at night, tonight is now
+				date = today
+				if now.Hour() >= h {
+					hour, minute = now.Hour(), now.Minute()
+				}
+			}
+			continue
+		}
+		switch w {
+		case "at", "on", "this", "in", "the":
+			continue
+		case "now":
+			return now, nil
+		case "today":
+			date = today
+			continue
+		case "tomorrow":
+			date = today.AddDate(0, 0, 1)
+			continue
+		case "next":
+			next = true
+			continue
+		}
+		if d, err := time.ParseInLocation("2006-01-02", w, now.Location()); err == nil {
+			date = d
+			continue
+		}
+		if h, m, used, ok := parseClock(w, suffix); ok {
+			hour, minute = h, m
+			if used {
+				i++
+			}
+			continue
+		}
+		found := false
+		for d := time.Sunday; d <= time.Saturday; d++ {
+			if long := strings.ToLower(d.String()); len(w) >= 2 && strings.HasPrefix(long, w) {
+				weekday, found = int(d), true
+				break
+			}
+		}
+		if !found {
+			return time.Time{}, fmt.Errorf("unknown word %q in %q, use e.g. \"saturday 18:00\", \"tomorrow morning\" or \"in 3 hours\"", w, s)
+		}
+	}
+
+	if weekday >= 0 {
+		ahead := (weekday - int(now.Weekday()) + 7) % 7
+		if ahead == 0 && next {
+			ahead = 7
+		}
+		date = today.AddDate(0, 0, ahead)
+	}
+	if date.IsZero() && hour < 0 {
+		return time.Time{}, fmt.Errorf("no day or time in %q", s)
+	}
+	if hour < 0 {
+		hour = 12
+	}
+	if date.IsZero() {
+		ret := time.Date(today.Year(), today.Month(), today.Day(), hour, minute, 0, 0, now.Location())
+		if ret.Before(now) {
+			ret = ret.AddDate(0, 0, 1)
+		}
+		return ret, nil
+	}
+	ret := time.Date(date.Year(), date.Month(), date.Day(), hour, minute, 0, 0, now.Location())
+	if weekday >= 0 && !next && ret.Before(now) {
+		ret = ret.AddDate(0, 0, 7)
+	}
+	return ret, nil
+}
+
+// runAt prints the forecast slot closest to the time given as argument, like
+// wego at "saturday 18:00", with all its values.
+func runAt(backend string, location string, numdays int, unit iface.UnitSystem) {
+	if len(commandArgs) == 0 {
+		log.Fatal(`Usage: wego at "saturday 18:00" [location]`)
+	}
+	now := iface.Now()
+	t, err := parseAtTime(commandArgs[0], now)
+	if err != nil {
+		log.Fatal(err)
+	}
+	// fetch enough days to reach it
+	if n := int(t.Sub(now).Hours()/24) + 2; n > numdays {
+		numdays = n
+	}
+	r := fetch(backend, location, numdays)
+
+	// the time is meant in the time zone of the location
+	for _, d := range r.Forecast {
+		if len(d.Slots) > 0 {
+			if t, err = parseAtTime(commandArgs[0], iface.Now().In(d.Slots[0].Time.Location())); err != nil {
+				log.Fatal(err)
+			}
+			break
+		}
+	}
+	s, ok := closestSlot(r, t)
+	if !ok {
+		log.Fatalf("The forecast for %s does not reach %s", r.Location, t.Format("Mon 02. Jan 15:04"))
+	}
+
+	fmt.Printf("Weather in %s on %s\n", r.Location, t.Format("Monday 02. Jan 15:04"))
+	if !s.Time.Equal(t) {
+		fmt.Printf("(forecast for %s)\n", s.Time.Format("15:04"))
+	}
+	slot := iface.Data{Current: s, GeoLoc: r.GeoLoc}
+	fmt.Printf("\n%s\n\n", frontends.FormatLine("%c %C", slot, unit))
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	row := func(known bool, name, format string) {
+		if known {
+			fmt.Fprintf(w, "%s\t%s\n", name, frontends.FormatLine(format, slot, unit))
+		}
+	}
+	row(s.TempC != nil, "Temperature", "%t")
+	row(s.FeelsLikeC != nil, "Feels like", "%f")
+	_, hasDewPoint := s.DewPointC()
+	row(hasDewPoint, "Dew point", "%D")
+	row(s.Humidity != nil, "Humidity", "%h")
+	row(s.WindspeedKmph != nil && s.WinddirDegree == nil, "Wind", "%w")
+	row(s.WindspeedKmph != nil && s.WinddirDegree != nil, "Wind", "%w from %d")
+	row(s.WindGustKmph != nil, "Gusts", "%g")
+	row(s.PrecipM != nil, "Precipitation", "%p")
+	row(s.ChanceOfRainPercent != nil, "Chance of rain", "%r")
+	if s.CloudCoverPercent != nil {
+		fmt.Fprintf(w, "Cloud cover\t%s%%\n", iface.FormatInt(*s.CloudCoverPercent))
+	}
+	row(s.VisibleDistM != nil, "Visibility", "%v")
+	row(s.PressureHPa != nil, "Pressure", "%P")
+	row(s.UVIndex != nil, "UV index", "%U")
+	w.Flush()
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseAtTime(t *testing.T) {
+	// a Thursday afternoon
+	now := time.Date(2026, 7, 2, 14, 20, 0, 0, time.UTC)
+	late := time.Date(2026, 7, 2, 23, 10, 0, 0, time.UTC)
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2026, 7, day, hour, minute, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		s    string
+		now  time.Time
+		want time.Time
+	}{
+		{"now", now, now},
+		{"18:00", now, at(2, 18, 0)},
+		{"9:30", now, at(3, 9, 30)},
+		{"18h", now, at(2, 18, 0)},
+		{"6pm", now, at(2, 18, 0)},
+		{"6:30 pm", now, at(2, 18, 30)},
+		{"12am", now, at(3, 0, 0)},
+		{"12pm", now, at(3, 12, 0)},
+		{"11 am", now, at(3, 11, 0)},
+		{"tomorrow morning", now, at(3, 8, 0)},
+		{"today", now, at(2, 12, 0)},
+		{"saturday 18:00", now, at(4, 18, 0)},
+		{"sat 6pm", now, at(4, 18, 0)},
+		{"on sunday", now, at(5, 12, 0)},
+		{"thursday 16:00", now, at(2, 16, 0)},
+		{"thursday 10:00", now, at(9, 10, 0)},
+		{"next thursday", now, at(9, 12, 0)},
+		{"next saturday", now, at(4, 12, 0)},
+		{"2026-07-01 14:00", now, at(1, 14, 0)},
+		{"tonight", now, at(2, 22, 0)},
+		{"tonight", late, late},
+		{"tonight 23:30", late, at(2, 23, 30)},
+		{"midnight", now, at(3, 0, 0)},
+		{"in 3 hours", now, now.Add(3 * time.Hour)},
+		{"in 90m", now, now.Add(90 * time.Minute)},
+		{"in 2 days", now, now.AddDate(0, 0, 2)},
+	}
+	for _, tt := range tests {
+		got, err := parseAtTime(tt.s, tt.now)
+		if err != nil {
+			t.Errorf("%q: %v", tt.s, err)
+		} else if !got.Equal(tt.want) {
+			t.Errorf("%q = %v, want %v", tt.s, got, tt.want)
+		}
+	}
+}
+
+func TestParseAtTimeErrors(t *testing.T) {
+	now := time.Date(2026, 7, 2, 14, 20, 0, 0, time.UTC)
+	for _, s := range []string{"", "next", "13pm", "0am", "25:00", "18:75", "s", "someday", "in a while", "in 3 fortnights"} {
+		if got, err := parseAtTime(s, now); err == nil {
+			t.Errorf("%q = %v, want an error", s, got)
+		}
+	}
+}
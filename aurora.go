@@ -0,0 +1,204 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/nafiz1001/wego/astro"
+	"github.com/nafiz1001/wego/iface"
+)
+
+// auroraURL is set by the -aurora-url flag.
+var auroraURL string
+
+// position of the north geomagnetic pole (IGRF-13, epoch 2020)
+const (
+	geomagPoleLat = 80.65
+	geomagPoleLon = -72.68
+)
+
+// The equatorward edge of the auroral oval is at about auroraOvalDeg -
+// auroraOvalPerKp*Kp geomagnetic latitude. From up to auroraHorizonDeg further
+// away from the pole the aurora can still be seen low on the horizon.
+const (
+	auroraOvalDeg    = 66.5
+	auroraOvalPerKp  = 2.1
+	auroraHorizonDeg = 4
+	auroraDarkSunDeg = -12 // nautical twilight
+)
+
+// auroraClearSky estimates the chance of seeing the sky through the clouds for
+// the weather codes, which is all wego knows about cloud cover.
+var auroraClearSky = map[iface.WeatherCode]float64{
+	iface.CodeSunny:        1,
+	iface.CodePartlyCloudy: 0.6,
+	iface.CodeHaze:         0.6,
+	iface.CodeSmoke:        0.4,
+	iface.CodeCloudy:       0.3,
+	iface.CodeUnknown:      0.5,
+}
+
+// kpPeriod is a three hour period of the planetary K-index forecast.
+type kpPeriod struct {
+	Start time.Time
+	Kp    float64
+}
+
+// fetchKp returns the Kp forecast of SWPC, a table with a header row and one
+// row per three hour period like ["2026-10-16 03:00:00", "4.67", "predicted",
+// "G1"].
+func fetchKp() ([]kpPeriod, error) {
+	var rows [][]interface{}
+	if err := fetchFeed(auroraURL, func(r io.Reader) error {
+		return json.NewDecoder(r).Decode(&rows)
+	}); err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("%s: empty Kp forecast", auroraURL)
+	}
+
+	timeCol, kpCol := -1, -1
+	for i, h := range rows[0] {
+		switch h {
+		case "time_tag":
+			timeCol = i
+		case "kp", "Kp":
+			kpCol = i
+		}
+	}
+	if timeCol < 0 || kpCol < 0 {
+		return nil, fmt.Errorf("%s: no time_tag and kp columns in the Kp forecast", auroraURL)
+	}
+
+	var ret []kpPeriod
+	for _, row := range rows[1:] {
+		if len(row) <= timeCol || len(row) <= kpCol {
+			continue
+		}
+		ts, _ := row[timeCol].(string)
+		start, err := time.Parse("2006-01-02 15:04:05", ts)
+		if err != nil {
+			continue
+		}
+		var kp float64
+		switch v := row[kpCol].(type) {
+		case string:
+			if kp, err = strconv.ParseFloat(v, 64); err != nil {
+				continue
+			}
+		case float64:
+			kp = v
+		default:
+			continue
+		}
+		ret = append(ret, kpPeriod{start, kp})
+	}
+	return ret, nil
+}
+
+// geomagneticLatitude returns the latitude of the coordinates relative to the
+// geomagnetic dipole in degrees.
+func geomagneticLatitude(lat, lon float64) float64 {
+	p, pp := degToRad(lat), degToRad(geomagPoleLat)
+	return radToDeg(math.Asin(math.Sin(p)*math.Sin(pp) + math.Cos(p)*math.Cos(pp)*math.Cos(degToRad(lon-geomagPoleLon))))
+}
+
+// auroraKp returns the Kp needed to see the aurora overhead and on the horizon
+// at geomagnetic latitude mlat.
+func auroraKp(mlat float64) (overhead, horizon float64) {
+	mlat = math.Abs(mlat)
+	return (auroraOvalDeg - mlat) / auroraOvalPerKp, (auroraOvalDeg - auroraHorizonDeg - mlat) / auroraOvalPerKp
+}
+
+// auroraGeoChance returns the chance to see the aurora at geomagnetic latitude
+// mlat during Kp if the sky is clear.
+func auroraGeoChance(mlat, kp float64) float64 {
+	overhead, horizon := auroraKp(mlat)
+	switch {
+	case kp >= overhead:
+		return 1
+	case kp >= horizon:
+		return 0.2 + 0.6*(kp-horizon)/(overhead-horizon)
+	}
+	return 0
+}
+
+// runAurora reports the odds to see the aurora tonight at the location, from
+// the Kp forecast of the NOAA Space Weather Prediction Center, the geomagnetic
+// latitude and the cloud cover of the forecast.
+func runAurora(backend string, location string, numdays int, unit iface.UnitSystem) {
+	r := fetch(backend, location, 2)
+	if r.GeoLoc == nil {
+		log.Fatal("The backend returned no coordinates for the location")
+	}
+	lat, lon := float64(r.GeoLoc.Latitude), float64(r.GeoLoc.Longitude)
+	kps, err := fetchKp()
+	if err != nil {
+		log.Fatal("Unable to fetch the Kp forecast: ", err)
+	}
+
+	// tonight is the next stretch of darkness within a day
+	var night []time.Time
+	for t := iface.Now().Truncate(time.Hour); t.Before(iface.Now().Add(24 * time.Hour)); t = t.Add(time.Hour) {
+		if astro.Elevation(t, lat, lon) < auroraDarkSunDeg {
+			night = append(night, t)
+		} else if len(night) > 0 {
+			break
+		}
+	}
+	mlat := geomagneticLatitude(lat, lon)
+	overhead, horizon := auroraKp(mlat)
+
+	fmt.Printf("Aurora tonight in %s\n\n", r.Location)
+	fmt.Printf("Geomagnetic latitude %.1f°, needs Kp %.1f to be seen on the horizon, %.1f overhead\n", mlat, math.Max(horizon, 0), math.Max(overhead, 0))
+	if len(night) == 0 {
+		fmt.Println("It does not get dark enough in the next 24 hours.")
+		return
+	}
+
+	var best, bestKp, bestSky float64
+	var bestTime time.Time
+	for _, t := range night {
+		kp := -1.0
+		for _, p := range kps {
+			if !t.Before(p.Start) && t.Before(p.Start.Add(3*time.Hour)) {
+				kp = p.Kp
+			}
+		}
+		if kp < 0 {
+			continue
+		}
+		sky := auroraClearSky[iface.CodeUnknown]
+		if s, ok := closestSlot(r, t); ok {
+			if c, known := auroraClearSky[s.Code]; known {
+				sky = c
+			} else {
+				sky = 0.05
+			}
+		}
+		if chance := auroraGeoChance(mlat, kp) * sky; chance > best || bestTime.IsZero() {
+			best, bestKp, bestSky, bestTime = chance, kp, sky, t
+		}
+	}
+	if bestTime.IsZero() {
+		fmt.Println("The Kp forecast does not cover tonight.")
+		return
+	}
+	loc := time.Local
+	for _, d := range r.Forecast {
+		if len(d.Slots) > 0 {
+			loc = d.Slots[0].Time.Location()
+			break
+		}
+	}
+	fmt.Printf("Dark from %s to %s, best chance around %s with Kp %.1f and %d%% chance of clear sky\n",
+		night[0].In(loc).Format("15:04"), night[len(night)-1].Add(time.Hour).In(loc).Format("15:04"),
+		bestTime.In(loc).Format("15:04"), bestKp, int(bestSky*100+0.5))
+	fmt.Printf("Odds to see the aurora: %d%%\n", int(best*100+0.5))
+}
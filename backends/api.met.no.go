@@ -0,0 +1,380 @@
+package backends
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/nafiz1001/wego/cache"
+	"github.com/nafiz1001/wego/geocode"
+	"github.com/nafiz1001/wego/httpclient"
+	"github.com/nafiz1001/wego/iface"
+)
+
+type metnoConfig struct {
+	userAgent string
+	baseURL   string
+	proxy     string
+	debug     bool
+}
+
+// metnoDetails are the values of a time step of the complete locationforecast.
+type metnoDetails struct {
+	AirPressureAtSeaLevel *float32 `json:"air_pressure_at_sea_level"`
+	AirTemperature        *float32 `json:"air_temperature"`
+	AirTemperatureP10     *float32 `json:"air_temperature_percentile_10"`
+	AirTemperatureP90     *float32 `json:"air_temperature_percentile_90"`
+	CloudAreaFraction     *float32 `json:"cloud_area_fraction"`
+	RelativeHumidity      *float32 `json:"relative_humidity"`
+	WindFromDirection     *float32 `json:"wind_from_direction"`
+	WindSpeed             *float32 `json:"wind_speed"` // m/s
+	PrecipitationAmount   *float32 `json:"precipitation_amount"`
+	UVIndexClearSky       *float32 `json:"ultraviolet_index_clear_sky"`
+}
+
+// metnoPeriod is the forecast for the period following a time step.
+type metnoPeriod struct {
+	Summary struct {
+		SymbolCode string `json:"symbol_code"`
+	} `json:"summary"`
+	Details metnoDetails `json:"details"`
+}
+
+type metnoResponse struct {
+	Geometry struct {
+		Coordinates []float32 `json:"coordinates"` // lon, lat, altitude
+	} `json:"geometry"`
+	Properties struct {
+		Timeseries []struct {
+			Time time.Time `json:"time"`
+			Data struct {
+				Instant struct {
+					Details metnoDetails `json:"details"`
+				} `json:"instant"`
+				Next1Hours  *metnoPeriod `json:"next_1_hours"`
+				Next6Hours  *metnoPeriod `json:"next_6_hours"`
+				Next12Hours *metnoPeriod `json:"next_12_hours"`
+			} `json:"data"`
+		} `json:"timeseries"`
+	} `json:"properties"`
+}
+
+// metnoCached are the headers of a response kept in the cache until it
+// expires, as the terms of service of api.met.no ask for. The body is cached
+// in a file of its own, so it is not read into memory to decode it.
+type metnoCached struct {
+	Expires      time.Time
+	LastModified string
+}
+
+// metnoSymbols maps the weather symbols of met.no, without the _day, _night
+// or _polartwilight suffix, to condition phrases. See
+// https://api.met.no/weatherapi/weathericon/2.0/documentation
+var metnoSymbols = map[string]string{
+	"clearsky":                     "clear",
+	"fair":                         "partly cloudy",
+	"partlycloudy":                 "partly cloudy",
+	"cloudy":                       "overcast",
+	"fog":                          "fog",
+	"lightrain":                    "light rain",
+	"rain":                         "moderate rain",
+	"heavyrain":                    "heavy rain",
+	"lightrainshowers":             "light rain showers",
+	"rainshowers":                  "rain showers",
+	"heavyrainshowers":             "heavy rain showers",
+	"lightsleet":                   "light sleet",
+	"sleet":                        "rain and snow",
+	"heavysleet":                   "rain and snow",
+	"lightsleetshowers":            "light sleet showers",
+	"sleetshowers":                 "light sleet showers",
+	"heavysleetshowers":            "light sleet showers",
+	"lightsnow":                    "light snow",
+	"snow":                         "moderate snow",
+	"heavysnow":                    "heavy snow",
+	"lightsnowshowers":             "light snow showers",
+	"snowshowers":                  "light snow showers",
+	"heavysnowshowers":             "heavy snow showers",
+	"lightrainandthunder":          "thunderstorm",
+	"rainandthunder":               "thunderstorm",
+	"heavyrainandthunder":          "thunderstorm with heavy rain",
+	"lightrainshowersandthunder":   "thunderstorm",
+	"rainshowersandthunder":        "thunderstorm",
+	"heavyrainshowersandthunder":   "thunderstorm with heavy rain",
+	"lightsleetandthunder":         "thunderstorm with snow",
+	"sleetandthunder":              "thunderstorm with snow",
+	"heavysleetandthunder":         "thunderstorm with snow",
+	"lightssleetshowersandthunder": "thunderstorm with snow", // sic
+	"sleetshowersandthunder":       "thunderstorm with snow",
+	"heavysleetshowersandthunder":  "thunderstorm with snow",
+	"lightsnowandthunder":          "thunderstorm with snow",
+	"snowandthunder":               "thunderstorm with snow",
+	"heavysnowandthunder":          "thunderstorm with snow",
+	"lightssnowshowersandthunder":  "thunderstorm with snow", // sic
+	"snowshowersandthunder":        "thunderstorm with snow",
+	"heavysnowshowersandthunder":   "thunderstorm with snow",
+}
+
+func (c *metnoConfig) Capabilities() iface.Capabilities {
+	return iface.Capabilities{
+		Description: "MET Norway locationforecast for locations world wide, hourly for the first days",
+		Hourly:      true,
+		MaxDays:     10,
+	}
+}
+
+func (c *metnoConfig) Setup() {
+	flag.StringVar(&c.userAgent, "metno-user-agent", "wego https://github.com/nafiz1001/wego", "met.no backend: the User-Agent `STRING` identifying you to api.met.no, their terms ask for a contact like an email address in it, e.g. \"wego you@example.com\"")
+	flag.StringVar(&c.baseURL, "metno-url", "https://api.met.no/weatherapi/locationforecast/2.0", "met.no backend: the base `URL` of the locationforecast api, e.g. of a mirror or caching proxy")
+	flag.StringVar(&c.proxy, "metno-proxy", "", "met.no backend: the http or socks5 proxy `URL` to connect through, e.g. socks5://127.0.0.1:9050")
+	flag.BoolVar(&c.debug, "metno-debug", false, "met.no backend: print raw requests and responses")
+}
+
+// fetch returns the forecast at uri. Responses are cached until they expire,
+// and then only downloaded again if they changed, as the terms of service of
+// api.met.no require.
+func (c *metnoConfig) fetch(ctx context.Context, uri string) (*metnoResponse, error) {
+	key := "metno-" + uri
+	var cached metnoCached
+	_, cacheErr := cache.Load(key, &cached)
+	var cachedBody *os.File
+	if cacheErr == nil {
+		// the body may have been removed to keep the cache small
+		if cachedBody, _, cacheErr = cache.OpenFile(key, ".body"); cacheErr == nil {
+			defer cachedBody.Close()
+		}
+	}
+	if cacheErr == nil && time.Now().Before(cached.Expires) {
+		return c.decode(uri, cachedBody)
+	}
+
+	header := http.Header{"User-Agent": {c.userAgent}}
+	if cacheErr == nil && cached.LastModified != "" {
+		header.Set("If-Modified-Since", cached.LastModified)
+	}
+	if c.debug {
+		fmt.Printf("Fetching %s\n", uri)
+	}
+	res, err := httpclient.Get(ctx, c.proxy, uri, header)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get (%s) %v", uri, err)
+	}
+	defer res.Body.Close()
+
+	var resp *metnoResponse
+	switch res.StatusCode {
+	case http.StatusOK:
+		r, body, err := readBody(res.Body)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read response body (%s): %v", uri, err)
+		}
+		if c.debug && body != nil {
+			fmt.Printf("Response (%s):\n%s\n", uri, string(body))
+		}
+		// the body is cached while it is decoded
+		w, err := cache.CreateFile(key, ".body")
+		if err != nil {
+			log.Println("Unable to cache the met.no response:", err)
+		} else {
+			defer w.Close()
+			r = io.TeeReader(r, w)
+		}
+		if resp, err = c.decode(uri, r); err != nil {
+			return nil, err
+		}
+		if w == nil {
+			return resp, nil
+		} else if _, err := w.Commit(); err != nil {
+			log.Println("Unable to cache the met.no response:", err)
+			return resp, nil
+		}
+		cached = metnoCached{LastModified: res.Header.Get("Last-Modified")}
+	case http.StatusNotModified:
+		if c.debug {
+			fmt.Printf("Response (%s): not modified\n", uri)
+		}
+		if cacheErr != nil {
+			return nil, fmt.Errorf("unable to get (%s): not modified, but nothing is cached", uri)
+		}
+		if resp, err = c.decode(uri, cachedBody); err != nil {
+			return nil, err
+		}
+	case http.StatusForbidden:
+		return nil, fmt.Errorf("api.met.no refused the request (%s), set -metno-user-agent to identify yourself: %s", uri, res.Status)
+	default:
+		return nil, fmt.Errorf("unable to get (%s): %s", uri, res.Status)
+	}
+
+	// a missing or invalid date leaves it expired, so it is revalidated
+	cached.Expires, _ = http.ParseTime(res.Header.Get("Expires"))
+	if err := cache.Store(key, cached); err != nil {
+		log.Println("Unable to cache the met.no response:", err)
+	}
+	return resp, nil
+}
+
+func (c *metnoConfig) decode(uri string, r io.Reader) (*metnoResponse, error) {
+	var resp metnoResponse
+	if err := json.NewDecoder(r).Decode(&resp); err != nil {
+		return nil, fmt.Errorf("unable to unmarshal response (%s): %v", uri, err)
+	}
+	return &resp, nil
+}
+
+// metnoCode returns the weather code and condition phrase of a symbol like
+// "rainshowers_day" and whether the suffix tells that it is day, or nil if
+// there is none. It fails for unknown symbols in strict mode.
+func metnoCode(symbol string) (iface.WeatherCode, string, *bool, error) {
+	var isDay *bool
+	name := symbol
+	if i := strings.IndexByte(symbol, '_'); i >= 0 {
+		name = symbol[:i]
+		day := symbol[i+1:] != "night"
+		isDay = &day
+	}
+	phrase, ok := metnoSymbols[name]
+	if !ok {
+		return iface.CodeUnknown, "", isDay, parseErrorf("met.no: unknown weather symbol %q", symbol)
+	}
+	return conditionCode(phrase), phrase, isDay, nil
+}
+
+// parseCond returns the condition at t from the instant values and the
+// forecast of the shortest period following it.
+func (c *metnoConfig) parseCond(t time.Time, d metnoDetails, next *metnoPeriod, hours float32) (iface.Cond, error) {
+	var ret iface.Cond
+	ret.Time = t.In(time.Local)
+	ret.Code = iface.CodeUnknown
+	ret.TempC = d.AirTemperature
+	ret.FeelsLikeC = d.AirTemperature
+	ret.PressureHPa = d.AirPressureAtSeaLevel
+	if d.WindSpeed != nil {
+		kmph := *d.WindSpeed * 3.6
+		ret.WindspeedKmph = &kmph
+	}
+	if d.WindFromDirection != nil {
+		dir := (int(*d.WindFromDirection+0.5)%360 + 360) % 360
+		ret.WinddirDegree = &dir
+	}
+	if d.RelativeHumidity != nil {
+		h := int(*d.RelativeHumidity + 0.5)
+		ret.Humidity = &h
+	}
+	if d.CloudAreaFraction != nil {
+		cc := int(*d.CloudAreaFraction + 0.5)
+		ret.CloudCoverPercent = &cc
+	}
+	// only the clear sky value is forecast, clouds reduce it
+	ret.UVIndex = d.UVIndexClearSky
+
+	if next != nil {
+		var phrase string
+		var err error
+		if ret.Code, phrase, ret.IsDay, err = metnoCode(next.Summary.SymbolCode); err != nil {
+			return ret, err
+		}
+		if phrase != "" {
+			ret.Desc = strings.ToUpper(phrase[:1]) + phrase[1:]
+		}
+		if p := next.Details.PrecipitationAmount; p != nil && hours > 0 {
+			m := *p / 1000 / hours
+			ret.PrecipM = &m
+		}
+	}
+	return ret, nil
+}
+
+// metnoSpreadNoConfidenceC is the mean spread between the 10th and 90th
+// percentile of the temperature at which a day has no confidence left.
+const metnoSpreadNoConfidenceC = 10
+
+func (c *metnoConfig) Fetch(ctx context.Context, location string, numdays int) (iface.Data, error) {
+	var ret iface.Data
+
+	place, err := geocode.Locate(ctx, location)
+	if err != nil {
+		return ret, fmt.Errorf("%v\nThe met.no backend needs a latitude,longitude pair or the name of a place as location, e.g. `59.913,10.739` or `Oslo`", err)
+	}
+	lat, lon := place.Latitude, place.Longitude
+
+	// the terms ask for at most 4 decimals, so responses can be cached
+	// the complete forecast has the percentiles of the ensemble
+	uri := fmt.Sprintf("%s/complete?lat=%.4f&lon=%.4f", strings.TrimSuffix(c.baseURL, "/"), lat, lon)
+	if iface.ElevationM != nil {
+		uri += fmt.Sprintf("&altitude=%d", int(*iface.ElevationM+0.5))
+	}
+	resp, err := c.fetch(ctx, uri)
+	if err != nil {
+		return ret, fmt.Errorf("failed to fetch weather data: %v", err)
+	}
+	if len(resp.Properties.Timeseries) == 0 {
+		return ret, fmt.Errorf("failed to fetch weather data: the met.no response contains no forecast")
+	}
+	ret, err = c.parse(resp, numdays)
+	ret.Location = place.Name
+	return ret, err
+}
+
+// parse returns the current conditions and the forecast of numdays days of
+// resp.
+func (c *metnoConfig) parse(resp *metnoResponse, numdays int) (iface.Data, error) {
+	var ret iface.Data
+	if coords := resp.Geometry.Coordinates; len(coords) >= 2 {
+		ret.GeoLoc = &iface.LatLon{Latitude: coords[1], Longitude: coords[0]}
+	}
+
+	var day *iface.Day
+	var spreads []float32
+	for i, step := range resp.Properties.Timeseries {
+		next, hours := step.Data.Next1Hours, float32(1)
+		if next == nil {
+			next, hours = step.Data.Next6Hours, 6
+		}
+		if next == nil {
+			next, hours = step.Data.Next12Hours, 0 // no amounts for 12 hours
+		}
+		cond, err := c.parseCond(step.Time, step.Data.Instant.Details, next, hours)
+		if err != nil {
+			return ret, err
+		}
+		if i == 0 {
+			ret.Current = cond
+		}
+		if numdays < 1 {
+			break
+		}
+
+		y, m, d := cond.Time.Date()
+		if day == nil || day.Date.Day() != d {
+			if day != nil {
+				day.Confidence = spreadConfidence(spreads, metnoSpreadNoConfidenceC)
+				spreads = nil
+				ret.Forecast = append(ret.Forecast, *day)
+				if len(ret.Forecast) >= numdays {
+					day = nil
+					break
+				}
+			}
+			day = &iface.Day{Date: time.Date(y, m, d, 0, 0, 0, 0, time.Local)}
+		}
+		day.Slots = append(day.Slots, cond)
+		if d := step.Data.Instant.Details; d.AirTemperatureP10 != nil && d.AirTemperatureP90 != nil {
+			spreads = append(spreads, *d.AirTemperatureP90-*d.AirTemperatureP10)
+		}
+	}
+	if day != nil {
+		day.Confidence = spreadConfidence(spreads, metnoSpreadNoConfidenceC)
+		ret.Forecast = append(ret.Forecast, *day)
+	}
+	return ret, nil
+}
+
+func init() {
+	iface.AllBackends["met.no"] = &metnoConfig{}
+}
@@ -0,0 +1,87 @@
+package backends
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/nafiz1001/wego/iface"
+)
+
+// parsers parse the sample responses of testdata like the backends do.
+func parsers(tb testing.TB) []struct {
+	name  string
+	parse func() error
+} {
+	msc, forecast, owm, wwo, metno := &mscConfig{}, &forecastConfig{}, &openWeatherConfig{}, &wwoConfig{}, &metnoConfig{}
+	msc.Init()
+	forecast.Init()
+	owm.Init()
+	wwo.Init()
+	stations, site := seed(tb, "msc_site_list.csv"), seed(tb, "msc_site.xml")
+	forecastBody, owmBody, wwoBody := seed(tb, "forecast.io.json"), seed(tb, "openweathermap.json"), seed(tb, "worldweatheronline.json")
+	metnoBody := seed(tb, "api.met.no.json")
+
+	data := func(_ iface.Data, err error) error { return err }
+	return []struct {
+		name  string
+		parse func() error
+	}{
+		{"msc-stations", func() error {
+			_, err := parseStationList(bytes.NewReader(stations))
+			return err
+		}},
+		{"msc-site", func() error { return data(parseMSC(msc, site)) }},
+		{"forecast.io", func() error { return data(parseForecast(forecast, forecastBody)) }},
+		{"openweathermap", func() error { return data(parseOpenWeather(owm, owmBody)) }},
+		{"worldweatheronline", func() error { return data(parseWWO(wwo, wwoBody, "")) }},
+		{"worldweatheronline-lang", func() error { return data(parseWWO(wwo, wwoBody, "de")) }},
+		{"met.no", func() error { return data(parseMetno(metno, metnoBody)) }},
+	}
+}
+
+func BenchmarkParse(b *testing.B) {
+	for _, p := range parsers(b) {
+		p := p
+		b.Run(p.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if err := p.parse(); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// parseAllocs are the allocations the parsers may make for the sample
+// responses, with some headroom over the current numbers.
+var parseAllocs = map[string]float64{
+	"msc-stations":            40,
+	"msc-site":                3000,
+	"forecast.io":             160,
+	"openweathermap":          64,
+	"worldweatheronline":      140,
+	"worldweatheronline-lang": 720,
+	"met.no":                  360,
+}
+
+func TestParseAllocs(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping allocation budgets in short mode")
+	}
+	if raceEnabled {
+		t.Skip("skipping allocation budgets with the race detector")
+	}
+	for _, p := range parsers(t) {
+		var err error
+		allocs := testing.AllocsPerRun(20, func() { err = p.parse() })
+		if err != nil {
+			t.Fatalf("%s: %v", p.name, err)
+		}
+		if budget, ok := parseAllocs[p.name]; !ok {
+			t.Errorf("%s: no allocation budget", p.name)
+		} else if allocs > budget {
+			t.Errorf("%s: %.0f allocations, the budget is %.0f", p.name, allocs, budget)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package backends
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/nafiz1001/wego/iface"
+)
+
+// TestMetnoFetchCache checks that met.no responses are decoded while they are
+// cached, and decoded from the cache when they are not modified.
+func TestMetnoFetchCache(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	defer func(low bool) { iface.LowMemory = low }(iface.LowMemory)
+	iface.LowMemory = true
+	body, err := ioutil.ReadFile(filepath.Join("testdata", "api.met.no.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var requests, notModified int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("Expires", time.Now().Add(-time.Minute).Format(http.TimeFormat))
+		if r.Header.Get("If-Modified-Since") != "" {
+			notModified++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("Last-Modified", "Mon, 02 Jan 2006 15:04:05 GMT")
+		w.Write(body)
+	}))
+	defer srv.Close()
+
+	c := &metnoConfig{userAgent: "wego-test"}
+	for i := 0; i < 2; i++ {
+		resp, err := c.fetch(context.Background(), srv.URL)
+		if err != nil {
+			t.Fatalf("fetch %d: %v", i+1, err)
+		}
+		if len(resp.Properties.Timeseries) == 0 {
+			t.Errorf("fetch %d: no time series", i+1)
+		}
+	}
+	if requests != 2 || notModified != 1 {
+		t.Errorf("%d requests, %d not modified, want 2 and 1", requests, notModified)
+	}
+}
+
+// TestMSCStationIndex checks that the station list is parsed while it is
+// cached, and kept parsed until the cached copy changes.
+func TestMSCStationIndex(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	body, err := ioutil.ReadFile(filepath.Join("testdata", "msc_site_list.csv"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var requests int
+	gone := false
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if gone {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write(body)
+	}))
+	defer srv.Close()
+
+	c := &mscConfig{baseURL: srv.URL, stationsTTL: time.Hour}
+	first, err := c.stationIndex(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(first.stations) == 0 || first.stored.IsZero() {
+		t.Fatalf("downloaded %d stations stored at %v", len(first.stations), first.stored)
+	}
+
+	// a fresh copy is reused, parsed again only if it changed
+	if got, err := c.stationIndex(context.Background()); err != nil || got != first {
+		t.Errorf("the parsed station list was not reused: %v", err)
+	}
+	c.stations = nil
+	got, err := c.stationIndex(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got.stations) != len(first.stations) || !got.stored.Equal(first.stored) {
+		t.Errorf("cached copy has %d stations stored at %v, want %d at %v", len(got.stations), got.stored, len(first.stations), first.stored)
+	}
+	if requests != 1 {
+		t.Errorf("%d requests, want 1", requests)
+	}
+
+	// a stale copy is used if the download fails
+	gone = true
+	c.stationsTTL = 0
+	if _, err := c.stationIndex(context.Background()); err != nil {
+		t.Errorf("the cached copy was not used when the download failed: %v", err)
+	}
+}
@@ -0,0 +1,77 @@
+package backends
+
+import "github.com/nafiz1001/wego/iface"
+
+// conditionCodes is the vocabulary shared by the backends to describe the
+// weather. Backends map the codes of their provider to these phrases instead
+// of to weather codes directly, so that the same weather shows the same icon
+// regardless of the backend.
+var conditionCodes = map[string]iface.WeatherCode{
+	"clear":         iface.CodeSunny,
+	"partly cloudy": iface.CodePartlyCloudy,
+	"cloudy":        iface.CodeCloudy,
+	"overcast":      iface.CodeVeryCloudy,
+	"mist":          iface.CodeFog,
+	"fog":           iface.CodeFog,
+	"haze":          iface.CodeHaze,
+	"dust":          iface.CodeHaze,
+	"smoke":         iface.CodeSmoke,
+	"volcanic ash":  iface.CodeSmoke,
+
+	"light drizzle":      iface.CodeLightRain,
+	"drizzle":            iface.CodeLightRain,
+	"heavy drizzle":      iface.CodeHeavyRain,
+	"light rain":         iface.CodeLightRain,
+	"rain":               iface.CodeLightRain,
+	"moderate rain":      iface.CodeHeavyRain,
+	"heavy rain":         iface.CodeHeavyRain,
+	"light rain showers": iface.CodeLightShowers,
+	"rain showers":       iface.CodeLightShowers,
+	"heavy rain showers": iface.CodeHeavyShowers,
+	"freezing drizzle":   iface.CodeFreezingRain,
+	"freezing rain":      iface.CodeFreezingRain,
+
+	"light sleet":         iface.CodeLightSleet,
+	"light sleet showers": iface.CodeLightSleetShowers,
+	"rain and snow":       iface.CodeRainSnowMix,
+	"ice pellets":         iface.CodeIcePellets,
+	"hail":                iface.CodeHail,
+
+	"light snow":         iface.CodeLightSnow,
+	"snow":               iface.CodeLightSnow,
+	"moderate snow":      iface.CodeHeavySnow,
+	"heavy snow":         iface.CodeHeavySnow,
+	"light snow showers": iface.CodeLightSnowShowers,
+	"heavy snow showers": iface.CodeHeavySnowShowers,
+	"blowing snow":       iface.CodeBlowingSnow,
+
+	"thunderstorm":                 iface.CodeThunderyShowers,
+	"thunderstorm with heavy rain": iface.CodeThunderyHeavyRain,
+	"thunderstorm with snow":       iface.CodeThunderySnowShowers,
+	"severe thunderstorm":          iface.CodeSevereThunderstorm,
+	"tornado":                      iface.CodeFunnelCloud,
+
+	// there is no fitting icon for these, partly cloudy is the closest one for
+	// wind without any precipitation
+	"windy":          iface.CodePartlyCloudy,
+	"breeze":         iface.CodeUnknown,
+	"gale":           iface.CodeUnknown,
+	"calm":           iface.CodeUnknown,
+	"sand":           iface.CodeUnknown,
+	"squalls":        iface.CodeUnknown,
+	"tropical storm": iface.CodeUnknown,
+	"hurricane":      iface.CodeUnknown,
+	"cold":           iface.CodeUnknown,
+	"hot":            iface.CodeUnknown,
+}
+
+// conditionCode returns the weather code for condition, a phrase of the
+// vocabulary in conditionCodes. It panics for phrases not in the vocabulary,
+// as these are bugs in the code map of a backend.
+func conditionCode(condition string) iface.WeatherCode {
+	code, ok := conditionCodes[condition]
+	if !ok {
+		panic("unknown weather condition " + condition)
+	}
+	return code
+}
@@ -0,0 +1,91 @@
+package backends
+
+import (
+	"testing"
+
+	"github.com/nafiz1001/wego/iface"
+)
+
+// TestEquivalentCodes checks that the codes of the providers for the same
+// weather map to the same weather code. Zero values stand for providers
+// without an equivalent code.
+func TestEquivalentCodes(t *testing.T) {
+	owm, wwo, forecast, msc := &openWeatherConfig{}, &wwoConfig{}, &forecastConfig{}, &mscConfig{}
+	owm.Init()
+	wwo.Init()
+	forecast.Init()
+	msc.Init()
+
+	tests := []struct {
+		want     iface.WeatherCode
+		owm      int
+		wwo      int
+		forecast string
+		msc      string
+		metno    string
+	}{
+		{iface.CodeSunny, 800, 113, "clear-day", "00", "clearsky_day"},
+		{iface.CodeSunny, 800, 113, "clear-night", "30", "clearsky_night"},
+		{iface.CodePartlyCloudy, 801, 116, "partly-cloudy-day", "02", "partlycloudy_day"},
+		{iface.CodePartlyCloudy, 801, 116, "partly-cloudy-night", "32", "partlycloudy_night"},
+		{iface.CodeCloudy, 802, 119, "cloudy", "03", ""},
+		{iface.CodeVeryCloudy, 804, 122, "", "10", "cloudy"},
+		{iface.CodeFog, 741, 248, "fog", "24", "fog"},
+		{iface.CodeLightRain, 300, 266, "", "28", ""}, // light drizzle
+		{iface.CodeLightRain, 500, 296, "rain", "12", "lightrain"},
+		{iface.CodeLightSnow, 601, 326, "snow", "16", "lightsnow"},
+		{iface.CodeLightSleet, 611, 317, "sleet", "", "lightsleet"},
+		{iface.CodeThunderyShowers, 211, 200, "thunderstorm", "09", "lightrainandthunder"},
+		{iface.CodePartlyCloudy, 905, 0, "wind", "43", ""},
+	}
+	for _, tt := range tests {
+		if got := owm.codes[tt.owm]; tt.owm != 0 && got != tt.want {
+			t.Errorf("openweathermap %d = %v, want %v", tt.owm, got, tt.want)
+		}
+		if got := wwo.codes[tt.wwo]; tt.wwo != 0 && got != tt.want {
+			t.Errorf("worldweatheronline %d = %v, want %v", tt.wwo, got, tt.want)
+		}
+		if got := forecast.codes[tt.forecast]; tt.forecast != "" && got != tt.want {
+			t.Errorf("forecast.io %q = %v, want %v", tt.forecast, got, tt.want)
+		}
+		if got := msc.codes[tt.msc]; tt.msc != "" && got != tt.want {
+			t.Errorf("dd.weather.gc.ca %q = %v, want %v", tt.msc, got, tt.want)
+		}
+		if tt.metno == "" {
+			continue
+		}
+		if got, _, _, err := metnoCode(tt.metno); err != nil || got != tt.want {
+			t.Errorf("met.no %q = %v, %v, want %v", tt.metno, got, err, tt.want)
+		}
+	}
+}
+
+// TestConditionPhrases builds the code maps of the backends, which panic on
+// phrases missing from conditionCodes.
+func TestConditionPhrases(t *testing.T) {
+	maps := []struct {
+		name  string
+		build func()
+	}{
+		{"forecast.io", func() { forecastCodes() }},
+		{"openweathermap", func() { openWeatherCodes() }},
+		{"worldweatheronline", func() { wwoCodes() }},
+		{"dd.weather.gc.ca", func() { mscCodes() }},
+	}
+	for _, m := range maps {
+		func() {
+			defer func() {
+				if err := recover(); err != nil {
+					t.Errorf("%s: %v", m.name, err)
+				}
+			}()
+			m.build()
+		}()
+	}
+
+	for symbol, phrase := range metnoSymbols {
+		if _, ok := conditionCodes[phrase]; !ok {
+			t.Errorf("met.no %s: unknown weather condition %s", symbol, phrase)
+		}
+	}
+}
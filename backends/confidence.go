@@ -0,0 +1,22 @@
+package backends
+
+// spreadConfidence returns the confidence in the forecast of a day from the
+// spreads of an ensemble at its time steps, e.g. between the 10th and 90th
+// percentile of the temperature. It is 100 without any spread down to 0 at a
+// mean spread of none. It is nil without spreads.
+func spreadConfidence(spreads []float32, none float32) *int {
+	if len(spreads) == 0 {
+		return nil
+	}
+	var sum float32
+	for _, s := range spreads {
+		sum += s
+	}
+	conf := int(100 - 100*sum/float32(len(spreads))/none + 0.5)
+	if conf < 0 {
+		conf = 0
+	} else if conf > 100 {
+		conf = 100
+	}
+	return &conf
+}
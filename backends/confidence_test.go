@@ -0,0 +1,26 @@
+package backends
+
+import "testing"
+
+func TestSpreadConfidence(t *testing.T) {
+	tests := []struct {
+		spreads []float32
+		want    int
+	}{
+		{[]float32{0, 0}, 100},
+		{[]float32{1, 3}, 80},
+		{[]float32{5}, 50},
+		{[]float32{9.96}, 0},
+		{[]float32{10, 30}, 0},
+		{[]float32{-1}, 100},
+	}
+	for _, tt := range tests {
+		got := spreadConfidence(tt.spreads, 10)
+		if got == nil || *got != tt.want {
+			t.Errorf("spreadConfidence(%v, 10) = %v, want %d", tt.spreads, got, tt.want)
+		}
+	}
+	if got := spreadConfidence(nil, 10); got != nil {
+		t.Errorf("spreadConfidence(nil, 10) = %d, want nil", *got)
+	}
+}
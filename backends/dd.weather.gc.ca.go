@@ -2,56 +2,77 @@ package backends
 
 import (
 	"bufio"
-	"bytes"
+	"context"
 	"encoding/csv"
 	"encoding/xml"
 	"flag"
 	"fmt"
 	"io"
-	"io/ioutil"
 	"log"
-	"math"
-	"net/http"
-	"regexp"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 
+	"github.com/nafiz1001/wego/cache"
+	"github.com/nafiz1001/wego/geocode"
+	"github.com/nafiz1001/wego/httpclient"
 	"github.com/nafiz1001/wego/iface"
 
 	"golang.org/x/net/html/charset"
 )
 
 type mscConfig struct {
-	lang string
+	lang            string
+	baseURL         string
+	proxy           string
+	stationsTTL     time.Duration
+	refreshStations bool
+
+	// stations is the parsed station list, kept for the following fetches
+	// until the list changes
+	stationsMu sync.Mutex
+	stations   *mscStationIndex
+
+	// codes is built by Init
+	codes map[string]iface.WeatherCode
+}
+
+// mscStationsKey is the cache key of the station list of the Datamart.
+const mscStationsKey = "msc-site_list_towns_en"
+
+// mscDateTime is a time in the citypage_weather documents, given in UTC and
+// in the local time of the site.
+type mscDateTime struct {
+	Text      string `xml:",chardata"`
+	Name      string `xml:"name,attr"`
+	Zone      string `xml:"zone,attr"`
+	UTCOffset string `xml:"UTCOffset,attr"`
+	Year      string `xml:"year"`
+	Month     struct {
+		Text string `xml:",chardata"`
+		Name string `xml:"name,attr"`
+	} `xml:"month"`
+	Day struct {
+		Text string `xml:",chardata"`
+		Name string `xml:"name,attr"`
+	} `xml:"day"`
+	Hour        string `xml:"hour"`
+	Minute      string `xml:"minute"`
+	TimeStamp   string `xml:"timeStamp"`
+	TextSummary string `xml:"textSummary"`
 }
 
 // generated with https://www.onlinetool.io/xmltogo/
 type siteData struct {
-	XMLName                   xml.Name `xml:"siteData"`
-	Text                      string   `xml:",chardata"`
-	Xsi                       string   `xml:"xsi,attr"`
-	NoNamespaceSchemaLocation string   `xml:"noNamespaceSchemaLocation,attr"`
-	License                   string   `xml:"license"`
-	DateTime                  []struct {
-		Text      string `xml:",chardata"`
-		Name      string `xml:"name,attr"`
-		Zone      string `xml:"zone,attr"`
-		UTCOffset string `xml:"UTCOffset,attr"`
-		Year      string `xml:"year"`
-		Month     struct {
-			Text string `xml:",chardata"`
-			Name string `xml:"name,attr"`
-		} `xml:"month"`
-		Day struct {
-			Text string `xml:",chardata"`
-			Name string `xml:"name,attr"`
-		} `xml:"day"`
-		Hour        string `xml:"hour"`
-		Minute      string `xml:"minute"`
-		TimeStamp   string `xml:"timeStamp"`
-		TextSummary string `xml:"textSummary"`
-	} `xml:"dateTime"`
-	Location struct {
+	XMLName                   xml.Name      `xml:"siteData"`
+	Text                      string        `xml:",chardata"`
+	Xsi                       string        `xml:"xsi,attr"`
+	NoNamespaceSchemaLocation string        `xml:"noNamespaceSchemaLocation,attr"`
+	License                   string        `xml:"license"`
+	DateTime                  []mscDateTime `xml:"dateTime"`
+	Location                  struct {
 		Text      string `xml:",chardata"`
 		Continent string `xml:"continent"`
 		Country   struct {
@@ -70,7 +91,15 @@ type siteData struct {
 		} `xml:"name"`
 		Region string `xml:"region"`
 	} `xml:"location"`
-	Warnings          string `xml:"warnings"`
+	Warnings struct {
+		URL   string `xml:"url,attr"`
+		Event []struct {
+			Type        string        `xml:"type,attr"`
+			Priority    string        `xml:"priority,attr"`
+			Description string        `xml:"description,attr"`
+			DateTime    []mscDateTime `xml:"dateTime"`
+		} `xml:"event"`
+	} `xml:"warnings"`
 	CurrentConditions struct {
 		Text    string `xml:",chardata"`
 		Station struct {
@@ -79,26 +108,8 @@ type siteData struct {
 			Lat  string `xml:"lat,attr"`
 			Lon  string `xml:"lon,attr"`
 		} `xml:"station"`
-		DateTime []struct {
-			Text      string `xml:",chardata"`
-			Name      string `xml:"name,attr"`
-			Zone      string `xml:"zone,attr"`
-			UTCOffset string `xml:"UTCOffset,attr"`
-			Year      string `xml:"year"`
-			Month     struct {
-				Text string `xml:",chardata"`
-				Name string `xml:"name,attr"`
-			} `xml:"month"`
-			Day struct {
-				Text string `xml:",chardata"`
-				Name string `xml:"name,attr"`
-			} `xml:"day"`
-			Hour        string `xml:"hour"`
-			Minute      string `xml:"minute"`
-			TimeStamp   string `xml:"timeStamp"`
-			TextSummary string `xml:"textSummary"`
-		} `xml:"dateTime"`
-		Condition string `xml:"condition"`
+		DateTime  []mscDateTime `xml:"dateTime"`
+		Condition string        `xml:"condition"`
 		IconCode  struct {
 			Text   string `xml:",chardata"`
 			Format string `xml:"format,attr"`
@@ -153,26 +164,8 @@ type siteData struct {
 		} `xml:"wind"`
 	} `xml:"currentConditions"`
 	ForecastGroup struct {
-		Text     string `xml:",chardata"`
-		DateTime []struct {
-			Text      string `xml:",chardata"`
-			Name      string `xml:"name,attr"`
-			Zone      string `xml:"zone,attr"`
-			UTCOffset string `xml:"UTCOffset,attr"`
-			Year      string `xml:"year"`
-			Month     struct {
-				Text string `xml:",chardata"`
-				Name string `xml:"name,attr"`
-			} `xml:"month"`
-			Day struct {
-				Text string `xml:",chardata"`
-				Name string `xml:"name,attr"`
-			} `xml:"day"`
-			Hour        string `xml:"hour"`
-			Minute      string `xml:"minute"`
-			TimeStamp   string `xml:"timeStamp"`
-			TextSummary string `xml:"textSummary"`
-		} `xml:"dateTime"`
+		Text            string        `xml:",chardata"`
+		DateTime        []mscDateTime `xml:"dateTime"`
 		RegionalNormals struct {
 			Text        string `xml:",chardata"`
 			TextSummary string `xml:"textSummary"`
@@ -284,26 +277,8 @@ type siteData struct {
 		} `xml:"forecast"`
 	} `xml:"forecastGroup"`
 	HourlyForecastGroup struct {
-		Text     string `xml:",chardata"`
-		DateTime []struct {
-			Text      string `xml:",chardata"`
-			Name      string `xml:"name,attr"`
-			Zone      string `xml:"zone,attr"`
-			UTCOffset string `xml:"UTCOffset,attr"`
-			Year      string `xml:"year"`
-			Month     struct {
-				Text string `xml:",chardata"`
-				Name string `xml:"name,attr"`
-			} `xml:"month"`
-			Day struct {
-				Text string `xml:",chardata"`
-				Name string `xml:"name,attr"`
-			} `xml:"day"`
-			Hour        string `xml:"hour"`
-			Minute      string `xml:"minute"`
-			TimeStamp   string `xml:"timeStamp"`
-			TextSummary string `xml:"textSummary"`
-		} `xml:"dateTime"`
+		Text           string        `xml:",chardata"`
+		DateTime       []mscDateTime `xml:"dateTime"`
 		HourlyForecast []struct {
 			Text        string `xml:",chardata"`
 			DateTimeUTC string `xml:"dateTimeUTC,attr"`
@@ -364,27 +339,9 @@ type siteData struct {
 		} `xml:"precip"`
 	} `xml:"yesterdayConditions"`
 	RiseSet struct {
-		Text       string `xml:",chardata"`
-		Disclaimer string `xml:"disclaimer"`
-		DateTime   []struct {
-			Text      string `xml:",chardata"`
-			Name      string `xml:"name,attr"`
-			Zone      string `xml:"zone,attr"`
-			UTCOffset string `xml:"UTCOffset,attr"`
-			Year      string `xml:"year"`
-			Month     struct {
-				Text string `xml:",chardata"`
-				Name string `xml:"name,attr"`
-			} `xml:"month"`
-			Day struct {
-				Text string `xml:",chardata"`
-				Name string `xml:"name,attr"`
-			} `xml:"day"`
-			Hour        string `xml:"hour"`
-			Minute      string `xml:"minute"`
-			TimeStamp   string `xml:"timeStamp"`
-			TextSummary string `xml:"textSummary"`
-		} `xml:"dateTime"`
+		Text       string        `xml:",chardata"`
+		Disclaimer string        `xml:"disclaimer"`
+		DateTime   []mscDateTime `xml:"dateTime"`
 	} `xml:"riseSet"`
 	Almanac struct {
 		Text        string `xml:",chardata"`
@@ -411,127 +368,745 @@ type siteData struct {
 	} `xml:"almanac"`
 }
 
+func (c *mscConfig) Capabilities() iface.Capabilities {
+	return iface.Capabilities{
+		Description: "Meteorological Service of Canada Datamart for locations in Canada, hourly for the first day",
+		Alerts:      true,
+		MaxDays:     7,
+	}
+}
+
 func (c *mscConfig) Setup() {
 	flag.StringVar(&c.lang, "msc-lang", "e", "dd.weather.gc.ca backend: the `LANGUAGE` to request from dd.weather.gc.ca (only e and f are supported")
+	flag.StringVar(&c.baseURL, "msc-url", "https://dd.weather.gc.ca", "dd.weather.gc.ca backend: the base `URL` of the Datamart, e.g. of a regional mirror or caching proxy")
+	flag.StringVar(&c.proxy, "msc-proxy", "", "dd.weather.gc.ca backend: the http or socks5 proxy `URL` to connect through, e.g. socks5://127.0.0.1:9050")
+	flag.DurationVar(&c.stationsTTL, "msc-stations-ttl", 7*24*time.Hour, "dd.weather.gc.ca backend: how long to reuse the cached station list before downloading it again")
+	flag.BoolVar(&c.refreshStations, "msc-refresh-stations", false, "dd.weather.gc.ca backend: download the station list even if the cached copy is still fresh")
 }
 
-func fetchLocation(location string) (lat float64, lon float64, err error) {
-	if matched, err := regexp.MatchString(`^[0-9]*(\.[0-9]+)?,-[0-9]*(\.[0-9]+)?$`, location); matched && err == nil {
-		s := strings.Split(location, ",")
-
-		if lat, err = strconv.ParseFloat(s[0], 64); err != nil {
-			return -1, -1, fmt.Errorf("latitude error: %v", err)
-		}
+// fetchLocation returns the coordinates of location, a latitude,longitude
+// pair or the name of a place.
+func fetchLocation(ctx context.Context, location string) (lat float64, lon float64, err error) {
+	place, err := geocode.Locate(ctx, location)
+	if err != nil {
+		return -1, -1, err
+	}
+	if place.Latitude < 0 || place.Longitude > 0 {
+		return -1, -1, fmt.Errorf("expected a location in Canada, %s is at %.4f,%.4f", place.Name, place.Latitude, place.Longitude)
+	}
+	return float64(place.Latitude), float64(place.Longitude), nil
+}
 
-		if lon, err = strconv.ParseFloat(s[1], 64); err != nil {
-			return -1, -1, fmt.Errorf("longitude error: %v", err)
-		}
-	} else {
-		return -1, -1, fmt.Errorf("expected location to be only positive latitude,longitude")
+// parseStationCoord parses a coordinate from the station list like "45.42N"
+// or "75.70W". The trailing hemisphere letter is stripped, so the result is
+// always positive.
+func parseStationCoord(s string) (float64, error) {
+	s = strings.TrimSpace(s)
+	if len(s) < 2 {
+		return 0, fmt.Errorf("malformed station coordinate %q", s)
 	}
+	return strconv.ParseFloat(s[:len(s)-1], 64)
+}
 
-	return lat, -lon, nil
+// mscStation is a site of the MSC site list.
+type mscStation struct {
+	code     string
+	province string
+	lat, lon float64
+	distKm   float64
 }
 
-func fetchNearestStation(lat float64, lon float64) (nearestStationCode string, province string, err error) {
-	const URI = "https://dd.meteo.gc.ca/citypage_weather/docs/site_list_towns_en.csv"
+// mscStationIndex is the parsed station list stored in the cache at stored,
+// indexed by location.
+type mscStationIndex struct {
+	stored   time.Time
+	stations []mscStation
+	grid     *geoGrid
+}
 
-	resp, err := http.Get(URI)
+func newMSCStationIndex(r io.Reader, stored time.Time) (*mscStationIndex, error) {
+	stations, err := parseStationList(r)
 	if err != nil {
-		return "", "", fmt.Errorf("unable to get (%s) %v", URI, err)
+		return nil, err
 	}
-	defer resp.Body.Close()
+	lat, lon := make([]float64, len(stations)), make([]float64, len(stations))
+	for i, s := range stations {
+		lat[i], lon[i] = s.lat, s.lon
+	}
+	return &mscStationIndex{stored, stations, newGeoGrid(lat, lon)}, nil
+}
 
-	body, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return "", "", fmt.Errorf("unable to read response body (%s): %v", URI, err)
+// nearest returns the n stations closest to the coordinates, nearest first.
+func (x *mscStationIndex) nearest(lat, lon float64, n int) []mscStation {
+	var ret []mscStation
+	for _, hit := range x.grid.nearest(lat, lon, n) {
+		s := x.stations[hit.index]
+		s.distKm = hit.distKm
+		ret = append(ret, s)
 	}
+	return ret
+}
 
-	bodyReader := bytes.NewReader(body)
+// parseStationList reads the MSC site list csv from r and returns its
+// stations. Malformed records are skipped, or fail it in strict mode.
+func parseStationList(r io.Reader) ([]mscStation, error) {
+	br := bufio.NewReader(r)
 
 	// skip first line
-	if firstLine, err := bufio.NewReader(bodyReader).ReadSlice('\n'); err != nil {
-		return "", "", err
-	} else {
-		bodyReader.Seek(int64(len(firstLine)), io.SeekStart)
+	if _, err := br.ReadSlice('\n'); err != nil {
+		return nil, fmt.Errorf("unable to read the station list: %v", err)
 	}
 
-	minDistance := math.MaxFloat64
-	csv := csv.NewReader(bodyReader)
+	var stations []mscStation
+	csv := csv.NewReader(br)
+	csv.FieldsPerRecord = -1
 	csv.Read() // skip header
 	for {
 		record, err := csv.Read()
 		if err == io.EOF {
 			break
 		} else if err != nil {
-			return "", "", fmt.Errorf("unable to process the csv at %s: %v", URI, err)
+			return nil, fmt.Errorf("unable to process the station list: %v", err)
 		}
 
-		stationLat, err := strconv.ParseFloat(record[3][:len(record[3])-1], 64)
-		if err != nil {
-			log.Print(err)
+		if len(record) < 5 {
+			if err := parseErrorf("skipping malformed station record: %q", record); err != nil {
+				return nil, err
+			}
 			continue
 		}
 
-		stationLon, err := strconv.ParseFloat(record[4][:len(record[4])-1], 64)
-		if err != nil {
-			log.Print(err)
+		coords := mscCoords(record[3], record[4])
+		if coords == nil {
+			if err := parseErrorf("skipping station %s: malformed coordinates %q, %q", record[0], record[3], record[4]); err != nil {
+				return nil, err
+			}
 			continue
 		}
+		stations = append(stations, mscStation{code: record[0], province: record[2], lat: float64(coords.Latitude), lon: float64(coords.Longitude)})
+	}
+
+	if len(stations) == 0 {
+		return nil, fmt.Errorf("no usable station found in the station list")
+	}
+	return stations, nil
+}
+
+// stationIndex returns the station list of the Datamart. The list rarely
+// changes, so it is cached for -msc-stations-ttl, and a stale copy is used if
+// the download fails. It is parsed while it is read, and kept parsed until the
+// cached copy changes.
+func (c *mscConfig) stationIndex(ctx context.Context) (*mscStationIndex, error) {
+	URI := strings.TrimSuffix(c.baseURL, "/") + "/citypage_weather/docs/site_list_towns_en.csv"
 
-		distance := math.Pow(lat-stationLat, 2) + math.Pow(lon-stationLon, 2)
-		if distance < minDistance {
-			minDistance = distance
-			nearestStationCode = record[0]
-			province = record[2]
+	c.stationsMu.Lock()
+	defer c.stationsMu.Unlock()
+	f, stored, cacheErr := cache.OpenFile(mscStationsKey, ".csv")
+	if cacheErr == nil {
+		defer f.Close()
+		if !c.refreshStations && time.Since(stored) < c.stationsTTL {
+			return c.cachedStationIndex(f, stored, URI)
 		}
 	}
 
-	return nearestStationCode, province, nil
+	index, err := c.downloadStationList(ctx, URI)
+	if err != nil {
+		if cacheErr != nil {
+			return nil, err
+		}
+		log.Printf("%v, using the station list cached at %s", err, stored.Format(time.RFC3339))
+		return c.cachedStationIndex(f, stored, URI)
+	}
+	c.stations = index
+	return index, nil
 }
 
-func fetchSiteData(stationCode string, province string, lang rune) (*siteData, error) {
-	URI := fmt.Sprintf("https://dd.weather.gc.ca/citypage_weather/xml/%s/%s_%c.xml", province, stationCode, lang)
+// cachedStationIndex returns the station list cached at stored, read from r
+// unless it is parsed already.
+func (c *mscConfig) cachedStationIndex(r io.Reader, stored time.Time, URI string) (*mscStationIndex, error) {
+	if c.stations != nil && c.stations.stored.Equal(stored) {
+		return c.stations, nil
+	}
+	index, err := newMSCStationIndex(r, stored)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", URI, err)
+	}
+	c.stations = index
+	return index, nil
+}
 
-	resp, err := http.Get(URI)
+// downloadStationList downloads the station list and parses it while it is
+// stored in the cache.
+func (c *mscConfig) downloadStationList(ctx context.Context, URI string) (*mscStationIndex, error) {
+	resp, err := httpclient.Get(ctx, c.proxy, URI, nil)
 	if err != nil {
 		return nil, fmt.Errorf("unable to get (%s) %v", URI, err)
 	}
 	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		return nil, fmt.Errorf("unable to get (%s): http status %d", URI, resp.StatusCode)
+	}
 
-	body, err := ioutil.ReadAll(resp.Body)
+	var r io.Reader = resp.Body
+	w, err := cache.CreateFile(mscStationsKey, ".csv")
 	if err != nil {
-		return nil, fmt.Errorf("unable to read response body (%s): %v", URI, err)
+		log.Println("Unable to cache the station list:", err)
+	} else {
+		defer w.Close()
+		r = io.TeeReader(r, w)
 	}
+	index, err := newMSCStationIndex(r, time.Time{})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", URI, err)
+	}
+	// the list is read to its end, so the cached copy is complete
+	if w != nil {
+		if index.stored, err = w.Commit(); err != nil {
+			log.Println("Unable to cache the station list:", err)
+		}
+	}
+	return index, nil
+}
 
-	reader := bytes.NewReader(body)
-	decoder := xml.NewDecoder(reader)
+func (c *mscConfig) fetchNearestStations(ctx context.Context, lat float64, lon float64, n int) ([]mscStation, error) {
+	index, err := c.stationIndex(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return index.nearest(lat, lon, n), nil
+}
+
+// parseSiteData decodes a citypage_weather xml document.
+func parseSiteData(r io.Reader) (*siteData, error) {
+	decoder := xml.NewDecoder(r)
 	decoder.CharsetReader = charset.NewReaderLabel
 
 	var data siteData
-	if err = decoder.Decode(&data); err != nil {
+	if err := decoder.Decode(&data); err != nil {
+		return nil, err
+	}
+	return &data, nil
+}
+
+func (c *mscConfig) fetchSiteData(ctx context.Context, stationCode string, province string, lang rune) (*siteData, error) {
+	URI := fmt.Sprintf("%s/citypage_weather/xml/%s/%s_%c.xml", strings.TrimSuffix(c.baseURL, "/"), province, stationCode, lang)
+
+	resp, err := httpclient.Get(ctx, c.proxy, URI, nil)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get (%s) %v", URI, err)
+	}
+	defer resp.Body.Close()
+
+	r, body, err := readBody(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read response body (%s): %v", URI, err)
+	}
+
+	data, err := parseSiteData(r)
+	if err != nil {
 		return nil, fmt.Errorf("unable to unmarshal response (%s): %v\nThe xml content is: %s", URI, err, string(body))
 	}
 
-	return &data, nil
+	return data, nil
 }
 
-func (c *mscConfig) Fetch(location string, numdays int) iface.Data {
-	var ret iface.Data
+// mscParser parses the values of a citypage_weather document. In strict mode it
+// keeps the first value it could not parse in err, which fails the fetch.
+type mscParser struct {
+	codes map[string]iface.WeatherCode
+	err   error
+}
 
-	if lat, lon, err := fetchLocation(location); err != nil {
-		log.Fatal(err)
-	} else if nearestStationCode, province, err := fetchNearestStation(lat, lon); err != nil {
-		log.Fatal(err)
-	} else if data, err := fetchSiteData(nearestStationCode, province, rune(c.lang[0])); err != nil {
-		log.Fatal(err)
-	} else {
-		log.Print(data)
+// errorf reports a value that could not be parsed with parseErrorf.
+func (p *mscParser) errorf(format string, v ...interface{}) {
+	if err := parseErrorf(format, v...); err != nil && p.err == nil {
+		p.err = err
 	}
+}
 
+// float parses a value of a citypage_weather document. It returns nil for
+// empty values, which the Datamart uses for missing observations.
+func (p *mscParser) float(name string, s string) *float32 {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil
+	}
+	if strings.EqualFold(s, "calm") {
+		s = "0"
+	}
+	f, err := strconv.ParseFloat(s, 32)
+	if err != nil {
+		p.errorf("dd.weather.gc.ca: unable to parse %s %q", name, s)
+		return nil
+	}
+	ret := float32(f)
+	return &ret
+}
+
+// mscCodes returns the map of the icon codes of the Datamart to weather codes.
+// Codes 00 to 09 are the day and 30 to 39 the night variants of the same
+// weather.
+func mscCodes() map[string]iface.WeatherCode {
+	return map[string]iface.WeatherCode{
+		"00": conditionCode("clear"),
+		"01": conditionCode("partly cloudy"), // mainly sunny
+		"02": conditionCode("partly cloudy"),
+		"03": conditionCode("cloudy"),        // mostly cloudy
+		"04": conditionCode("partly cloudy"), // increasing cloudiness
+		"05": conditionCode("partly cloudy"), // decreasing cloudiness
+		"06": conditionCode("light rain showers"),
+		"07": conditionCode("light sleet showers"), // showers or flurries
+		"08": conditionCode("light snow showers"),
+		"09": conditionCode("thunderstorm"),
+		"10": conditionCode("overcast"),
+		"11": conditionCode("rain"), // precipitation
+		"12": conditionCode("rain"),
+		"13": conditionCode("heavy rain"),
+		"14": conditionCode("freezing rain"),
+		"15": conditionCode("rain and snow"),
+		"16": conditionCode("light snow"),
+		"17": conditionCode("snow"),
+		"18": conditionCode("heavy snow"),
+		"19": conditionCode("thunderstorm"),
+		"23": conditionCode("haze"),
+		"24": conditionCode("fog"),
+		"25": conditionCode("blowing snow"), // drifting snow
+		"26": conditionCode("light snow"),   // ice crystals
+		"27": conditionCode("hail"),
+		"28": conditionCode("drizzle"),
+		"30": conditionCode("clear"),
+		"31": conditionCode("partly cloudy"), // mainly clear
+		"32": conditionCode("partly cloudy"),
+		"33": conditionCode("cloudy"),
+		"34": conditionCode("partly cloudy"),
+		"35": conditionCode("partly cloudy"),
+		"36": conditionCode("light rain showers"),
+		"37": conditionCode("light sleet showers"),
+		"38": conditionCode("light snow showers"),
+		"39": conditionCode("thunderstorm"),
+		"40": conditionCode("blowing snow"),
+		"41": conditionCode("tornado"), // funnel cloud
+		"42": conditionCode("tornado"),
+		"43": conditionCode("windy"),
+		"44": conditionCode("smoke"),
+		"45": conditionCode("dust"),
+		"46": conditionCode("severe thunderstorm"), // with hail
+		"47": conditionCode("thunderstorm"),        // with dust storm
+		"48": conditionCode("tornado"),             // waterspout
+	}
+}
+
+// mscWindDirs maps the wind directions of the forecasts to degrees.
+var mscWindDirs = map[string]int{
+	"N": 0, "NNE": 22, "NE": 45, "ENE": 67, "E": 90, "ESE": 112, "SE": 135, "SSE": 157,
+	"S": 180, "SSW": 202, "SW": 225, "WSW": 247, "W": 270, "WNW": 292, "NW": 315, "NNW": 337,
+	// French
+	"O": 270, "OSO": 247, "SO": 225, "SSO": 202, "ONO": 292, "NO": 315, "NNO": 337,
+}
+
+// setCode sets the weather code of cond from the icon code of the Datamart,
+// and whether it is day for the codes with a day and a night variant.
+func (p *mscParser) setCode(cond *iface.Cond, icon string) {
+	icon = strings.TrimSpace(icon)
+	cond.Code = iface.CodeUnknown
+	if icon == "" {
+		return
+	}
+	code, ok := p.codes[icon]
+	if !ok {
+		p.errorf("dd.weather.gc.ca: unknown icon code %q", icon)
+		return
+	}
+	cond.Code = code
+	if n, err := strconv.Atoi(icon); err == nil && (n <= 9 || (n >= 30 && n <= 39)) {
+		isDay := n <= 9
+		cond.IsDay = &isDay
+	}
+}
+
+// percent parses a percentage like the chance of precipitation.
+func (p *mscParser) percent(name string, s string) *int {
+	f := p.float(name, s)
+	if f == nil {
+		return nil
+	}
+	pct := int(*f + 0.5)
+	if pct < 0 || pct > 100 {
+		p.errorf("dd.weather.gc.ca: %s %d out of range", name, pct)
+		return nil
+	}
+	return &pct
+}
+
+// windDir returns the degrees of a wind direction like "NW", or nil for
+// variable and unknown directions.
+func (p *mscParser) windDir(s string) *int {
+	s = strings.ToUpper(strings.TrimSpace(s))
+	if s == "" || s == "VR" {
+		return nil
+	}
+	deg, ok := mscWindDirs[s]
+	if !ok {
+		p.errorf("dd.weather.gc.ca: unknown wind direction %q", s)
+		return nil
+	}
+	return &deg
+}
+
+// zone returns the time zone of the site from the local time of dts, or UTC
+// if there is none.
+func (p *mscParser) zone(dts []mscDateTime) *time.Location {
+	for _, dt := range dts {
+		if dt.Zone == "UTC" {
+			continue
+		}
+		hours, err := strconv.ParseFloat(dt.UTCOffset, 64)
+		if err != nil {
+			p.errorf("dd.weather.gc.ca: unable to parse UTC offset %q", dt.UTCOffset)
+			continue
+		}
+		return time.FixedZone(dt.Zone, int(hours*3600))
+	}
+	return time.UTC
+}
+
+// timestamp returns the time of the element of dts called name, or a zero
+// time if there is none.
+func (p *mscParser) timestamp(dts []mscDateTime, name string) time.Time {
+	for _, dt := range dts {
+		if dt.Zone != "UTC" || dt.Name != name {
+			continue
+		}
+		t, err := time.Parse("20060102150405", dt.TimeStamp)
+		if err != nil {
+			p.errorf("dd.weather.gc.ca: unable to parse time %q", dt.TimeStamp)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+// mscSeverities maps the priorities of the warnings of the Datamart to the CAP
+// severities of iface.Alert.
+var mscSeverities = map[string]string{"low": "Minor", "medium": "Moderate", "high": "Severe", "urgent": "Extreme"}
+
+// alerts returns the warnings, watches, advisories and statements in effect
+// for the site of data, leaving out the ended ones.
+func (p *mscParser) alerts(data *siteData, loc *time.Location) (ret []iface.Alert) {
+	for _, e := range data.Warnings.Event {
+		if e.Type == "ended" {
+			continue
+		}
+		a := iface.Alert{
+			Severity: mscSeverities[e.Priority],
+			Headline: strings.TrimSpace(e.Description),
+			URL:      data.Warnings.URL,
+		}
+		if a.Severity == "" {
+			a.Severity = "Unknown"
+		}
+		if t := p.timestamp(e.DateTime, "eventIssue"); !t.IsZero() {
+			a.Effective = t.In(loc)
+		}
+		ret = append(ret, a)
+	}
+	iface.SortAlerts(ret)
+	return ret
+}
+
+// mscTendencies maps the pressure tendencies of the English and French city
+// pages to the ones of iface.Cond.
+var mscTendencies = map[string]string{
+	"rising":      "rising",
+	"falling":     "falling",
+	"steady":      "steady",
+	"à la hausse": "rising",
+	"à la baisse": "falling",
+	"stable":      "steady",
+}
+
+// currentCond returns the observation of the current conditions of data. ok
+// is false if the site has no observation.
+func (p *mscParser) currentCond(data *siteData) (ret iface.Cond, ok bool) {
+	cc := data.CurrentConditions
+	if ret.Time = p.timestamp(cc.DateTime, "observation"); ret.Time.IsZero() {
+		return ret, false
+	}
+	ret.Time = ret.Time.In(p.zone(data.DateTime))
+
+	ret.Desc = strings.TrimSpace(cc.Condition)
+	p.setCode(&ret, cc.IconCode.Text)
+	ret.TempC = p.float("temperature", cc.Temperature.Text)
+	ret.FeelsLikeC = p.float("wind chill", cc.WindChill.Text)
+	if ret.FeelsLikeC == nil {
+		ret.FeelsLikeC = ret.TempC
+	}
+	ret.WindspeedKmph = p.float("wind speed", cc.Wind.Speed.Text)
+	ret.WindGustKmph = p.float("wind gust", cc.Wind.Gust.Text)
+	if b := p.float("wind bearing", cc.Wind.Bearing.Text); b != nil {
+		dir := (int(*b+0.5)%360 + 360) % 360
+		ret.WinddirDegree = &dir
+	}
+	ret.Humidity = p.percent("relative humidity", cc.RelativeHumidity.Text)
+	if kPa := p.float("pressure", cc.Pressure.Text); kPa != nil {
+		hPa := *kPa * 10
+		ret.PressureHPa = &hPa
+	}
+	ret.PressureTendency = mscTendencies[strings.ToLower(strings.TrimSpace(cc.Pressure.Tendency))]
+	if v := p.float("visibility", cc.Visibility.Text); v != nil {
+		visibility := *v * 1000
+		ret.VisibleDistM = &visibility
+	}
+	return ret, true
+}
+
+// hourly returns the conditions of the hourly forecast of data.
+func (p *mscParser) hourly(data *siteData, loc *time.Location) (ret []iface.Cond) {
+	for _, h := range data.HourlyForecastGroup.HourlyForecast {
+		t, err := time.Parse("200601021504", h.DateTimeUTC)
+		if err != nil {
+			p.errorf("dd.weather.gc.ca: unable to parse hourly forecast time %q", h.DateTimeUTC)
+			continue
+		}
+		cond := iface.Cond{Time: t.In(loc), Desc: strings.TrimSpace(h.Condition)}
+		p.setCode(&cond, h.IconCode.Text)
+		cond.TempC = p.float("temperature", h.Temperature.Text)
+		cond.FeelsLikeC = p.float("wind chill", h.WindChill.Text)
+		if cond.FeelsLikeC == nil {
+			cond.FeelsLikeC = p.float("humidex", h.Humidex.Text)
+		}
+		if cond.FeelsLikeC == nil {
+			cond.FeelsLikeC = cond.TempC
+		}
+		cond.ChanceOfRainPercent = p.percent("chance of precipitation", h.Lop.Text)
+		cond.WindspeedKmph = p.float("wind speed", h.Wind.Speed.Text)
+		cond.WindGustKmph = p.float("wind gust", h.Wind.Gust.Text)
+		cond.WinddirDegree = p.windDir(h.Wind.Direction.Text)
+		ret = append(ret, cond)
+	}
+	return
+}
+
+// mscPeriodHours are the local times of the conditions standing for the day
+// and the night periods of the forecast.
+const (
+	mscDayHour   = 12
+	mscNightHour = 22
+)
+
+// days returns the days of the forecast of data with one slot for each day and
+// night period. The periods are told apart by their temperature, which is the
+// high of the day or the low of the night.
+func (p *mscParser) days(data *siteData, loc *time.Location) (ret []iface.Day) {
+	fg := data.ForecastGroup
+	issued := p.timestamp(fg.DateTime, "forecastIssue")
+	if issued.IsZero() {
+		return nil
+	}
+	y, m, d := issued.In(loc).Date()
+	date := time.Date(y, m, d, 0, 0, 0, 0, loc)
+
+	var day *iface.Day
+	prevNight := false
+	for _, f := range fg.Forecast {
+		night := f.Temperatures.Temperature.Class == "low"
+		if day == nil || (!night && prevNight) {
+			if day != nil {
+				ret = append(ret, *day)
+				date = date.AddDate(0, 0, 1)
+			}
+			day = &iface.Day{Date: date}
+		}
+		prevNight = night
+
+		hour := mscDayHour
+		if night {
+			hour = mscNightHour
+		}
+		cond := iface.Cond{
+			Time: date.Add(time.Duration(hour) * time.Hour),
+			Desc: strings.TrimSpace(f.AbbreviatedForecast.TextSummary),
+		}
+		p.setCode(&cond, f.AbbreviatedForecast.IconCode.Text)
+		cond.TempC = p.float("temperature", f.Temperatures.Temperature.Text)
+		cond.FeelsLikeC = p.float("wind chill", f.WindChill.Calculated.Text)
+		if cond.FeelsLikeC == nil {
+			cond.FeelsLikeC = p.float("humidex", f.Humidex)
+		}
+		if cond.FeelsLikeC == nil {
+			cond.FeelsLikeC = cond.TempC
+		}
+		cond.ChanceOfRainPercent = p.percent("chance of precipitation", f.AbbreviatedForecast.Pop.Text)
+		cond.Humidity = p.percent("relative humidity", f.RelativeHumidity.Text)
+		if len(f.Winds.Wind) > 0 {
+			w := f.Winds.Wind[0]
+			cond.WindspeedKmph = p.float("wind speed", w.Speed.Text)
+			cond.WindGustKmph = p.float("wind gust", w.Gust.Text)
+			cond.WinddirDegree = p.windDir(w.Direction)
+		}
+
+		if cond.TempC != nil {
+			t := *cond.TempC
+			if night {
+				day.MinTempC = &t
+			} else {
+				day.MaxTempC = &t
+			}
+		}
+		day.Slots = append(day.Slots, cond)
+	}
+	if day != nil {
+		ret = append(ret, *day)
+	}
 	return ret
 }
 
+// mergeMSCHourly replaces the period slots of days covered by the hourly
+// forecast with the hourly conditions, which are more detailed.
+func mergeMSCHourly(days []iface.Day, hourly []iface.Cond) {
+	if len(hourly) == 0 {
+		return
+	}
+	first, last := hourly[0].Time, hourly[len(hourly)-1].Time
+	for i := range days {
+		d := &days[i]
+		var slots []iface.Cond
+		for _, s := range d.Slots {
+			if s.Time.Before(first) || s.Time.After(last) {
+				slots = append(slots, s)
+			}
+		}
+		for _, h := range hourly {
+			if y, m, dd := h.Time.Date(); y == d.Date.Year() && m == d.Date.Month() && dd == d.Date.Day() {
+				slots = append(slots, h)
+			}
+		}
+		sort.SliceStable(slots, func(i, j int) bool { return slots[i].Time.Before(slots[j].Time) })
+		d.Slots = slots
+	}
+}
+
+// setToday sets the sunrise, sunset and temperature normals of the first day
+// of the forecast.
+func (p *mscParser) setToday(day *iface.Day, data *siteData, loc *time.Location) {
+	if t := p.timestamp(data.RiseSet.DateTime, "sunrise"); !t.IsZero() {
+		day.Astronomy.Sunrise = t.In(loc)
+	}
+	if t := p.timestamp(data.RiseSet.DateTime, "sunset"); !t.IsZero() {
+		day.Astronomy.Sunset = t.In(loc)
+	}
+
+	for _, t := range data.ForecastGroup.RegionalNormals.Temperature {
+		switch t.Class {
+		case "high":
+			day.NormalMaxC = p.float("normal high", t.Text)
+		case "low":
+			day.NormalMinC = p.float("normal low", t.Text)
+		}
+	}
+}
+
+// mscGeoLoc returns the coordinates of the site of data, or nil if they are
+// malformed.
+func mscGeoLoc(data *siteData) *iface.LatLon {
+	return mscCoords(data.Location.Name.Lat, data.Location.Name.Lon)
+}
+
+// mscCoords returns the coordinates given like "43.67N" and "79.40W", or nil
+// if they are malformed.
+func mscCoords(latText, lonText string) *iface.LatLon {
+	lat, err := parseStationCoord(latText)
+	if err != nil {
+		return nil
+	}
+	lon, err := parseStationCoord(lonText)
+	if err != nil {
+		return nil
+	}
+	if strings.HasSuffix(latText, "S") {
+		lat = -lat
+	}
+	if strings.HasSuffix(lonText, "W") {
+		lon = -lon
+	}
+	return &iface.LatLon{Latitude: float32(lat), Longitude: float32(lon)}
+}
+
+// mscStationOf returns the station the current conditions of data were
+// observed at.
+func mscStationOf(data *siteData) iface.Station {
+	s := data.CurrentConditions.Station
+	return iface.Station{ID: s.Code, Name: strings.TrimSpace(s.Text), GeoLoc: mscCoords(s.Lat, s.Lon)}
+}
+
+// Init builds the table of the icon codes.
+func (c *mscConfig) Init() {
+	c.codes = mscCodes()
+}
+
+func (c *mscConfig) Fetch(ctx context.Context, location string, numdays int) (iface.Data, error) {
+	var ret iface.Data
+
+	if len(c.lang) == 0 {
+		return ret, fmt.Errorf("dd.weather.gc.ca backend: no language specified")
+	}
+
+	lat, lon, err := fetchLocation(ctx, location)
+	if err != nil {
+		return ret, err
+	}
+	stations, err := c.fetchNearestStations(ctx, lat, lon, iface.Stations)
+	if err != nil {
+		return ret, err
+	}
+
+	// the nearest station has to work, the others only help with the current
+	// conditions
+	var observations []iface.Cond
+	for i, station := range stations {
+		data, err := c.fetchSiteData(ctx, station.code, station.province, rune(c.lang[0]))
+		if err != nil {
+			if i == 0 {
+				return ret, err
+			}
+			log.Print(err)
+			continue
+		}
+		p := mscParser{codes: c.codes}
+		if i == 0 {
+			loc := p.zone(data.DateTime)
+			ret.Location = data.Location.Name.Text
+			ret.Province = data.Location.Province.Code
+			ret.GeoLoc = mscGeoLoc(data)
+			ret.Alerts = p.alerts(data, loc)
+			ret.Forecast = p.days(data, loc)
+			mergeMSCHourly(ret.Forecast, p.hourly(data, loc))
+			if len(ret.Forecast) > 0 {
+				p.setToday(&ret.Forecast[0], data, loc)
+			}
+		}
+		if cond, ok := p.currentCond(data); ok {
+			observations = append(observations, cond)
+			ret.Stations = append(ret.Stations, mscStationOf(data))
+		}
+		if p.err != nil {
+			return ret, p.err
+		}
+	}
+
+	if len(observations) > 1 {
+		ret.Current, ret.CurrentSpread = iface.CombineStations(observations)
+	} else if len(observations) == 1 {
+		ret.Current = observations[0]
+	}
+
+	if numdays < len(ret.Forecast) {
+		ret.Forecast = ret.Forecast[:numdays]
+	}
+	return ret, nil
+}
+
 func init() {
 	iface.AllBackends["dd.weather.gc.ca"] = &mscConfig{}
 }
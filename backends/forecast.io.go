@@ -1,23 +1,30 @@
 package backends
 
 import (
+	"context"
 	"encoding/json"
 	"flag"
 	"fmt"
-	"io/ioutil"
 	"log"
-	"net/http"
-	"regexp"
+	"math"
+	"strings"
 	"time"
 
+	"github.com/nafiz1001/wego/geocode"
+	"github.com/nafiz1001/wego/httpclient"
 	"github.com/nafiz1001/wego/iface"
 )
 
 type forecastConfig struct {
-	apiKey string
-	lang   string
-	debug  bool
-	tz     *time.Location
+	apiKey  string
+	lang    string
+	debug   bool
+	baseURL string
+	proxy   string
+	tz      *time.Location
+
+	// codes is built by Init
+	codes map[string]iface.WeatherCode
 }
 
 type forecastDataPoint struct {
@@ -26,14 +33,20 @@ type forecastDataPoint struct {
 	Icon                string   `json:"icon"`
 	SunriseTime         *int64   `json:"sunriseTime"`
 	SunsetTime          *int64   `json:"sunsetTime"`
+	MoonPhase           *float32 `json:"moonPhase"`
 	PrecipIntensity     *float32 `json:"precipIntensity"`
 	PrecipProb          *float32 `json:"precipProbability"`
 	Temperature         *float32 `json:"temperature"`
 	ApparentTemperature *float32 `json:"apparentTemperature"`
+	TemperatureMin      *float32 `json:"temperatureMin"`
+	TemperatureMax      *float32 `json:"temperatureMax"`
 	WindSpeed           *float32 `json:"windSpeed"`
 	WindBearing         *float32 `json:"windBearing"`
 	Visibility          *float32 `json:"visibility"`
 	Humidity            *float32 `json:"humidity"`
+	Pressure            *float32 `json:"pressure"`
+	CloudCover          *float32 `json:"cloudCover"`
+	UVIndex             *float32 `json:"uvIndex"`
 }
 
 type forecastDataBlock struct {
@@ -49,16 +62,31 @@ type forecastResponse struct {
 	Currently forecastDataPoint `json:"currently"`
 	Hourly    forecastDataBlock `json:"hourly"`
 	Daily     forecastDataBlock `json:"daily"`
+	Alerts    []forecastAlert   `json:"alerts"`
 }
 
+type forecastAlert struct {
+	Title    string `json:"title"`
+	Severity string `json:"severity"` // advisory, watch or warning
+	Time     int64  `json:"time"`
+	Expires  int64  `json:"expires"`
+	URI      string `json:"uri"`
+}
+
+// forecastSeverities maps the severities of forecast.io alerts to the CAP
+// severities of iface.Alert.
+var forecastSeverities = map[string]string{"advisory": "Minor", "watch": "Moderate", "warning": "Severe"}
+
 const (
 	// see https://developer.forecast.io/docs/v2
 	// see also https://github.com/mlbright/forecast
 	//https://api.forecast.io/forecast/APIKEY/LATITUDE,LONGITUDE
-	forecastWuri = "https://api.forecast.io/forecast/%s/%s?units=ca&lang=%s&exclude=minutely,alerts,flags&extend=hourly"
+	forecastWuri = "%s/forecast/%s/%s?units=ca&lang=%s&exclude=minutely,flags&extend=hourly"
 )
 
-func (c *forecastConfig) parseAstro(cur *iface.Day, days []forecastDataPoint) {
+// parseDayData sets the astronomical data and the temperature range of cur
+// from the matching data point of the daily block.
+func (c *forecastConfig) parseDayData(cur *iface.Day, days []forecastDataPoint) {
 	for _, day := range days {
 		if day.Time != nil && cur.Date.Day() == time.Unix(*day.Time, 0).In(c.tz).Day() {
 			if day.SunriseTime != nil {
@@ -67,19 +95,27 @@ func (c *forecastConfig) parseAstro(cur *iface.Day, days []forecastDataPoint) {
 			if day.SunsetTime != nil {
 				cur.Astronomy.Sunset = time.Unix(*day.SunsetTime, 0).In(c.tz)
 			}
+			if day.MoonPhase != nil && *day.MoonPhase >= 0 && *day.MoonPhase <= 1 {
+				p := float32(math.Mod(float64(*day.MoonPhase), 1))
+				cur.Astronomy.MoonPhase = &p
+			}
+			cur.MinTempC = day.TemperatureMin
+			cur.MaxTempC = day.TemperatureMax
 			return
 		}
 	}
 }
 
-func (c *forecastConfig) parseDaily(hours, days forecastDataBlock, numdays int) []iface.Day {
+func (c *forecastConfig) parseDaily(hours, days forecastDataBlock, numdays int) ([]iface.Day, error) {
 	var forecast []iface.Day
 	var day *iface.Day
 
 	for _, hourData := range hours.Data {
 		slot, err := c.parseCond(hourData)
 		if err != nil {
-			log.Println("Error parsing hourly weather condition:", err)
+			if err := parseErrorf("Error parsing hourly weather condition: %v", err); err != nil {
+				return nil, err
+			}
 			continue
 		}
 
@@ -93,40 +129,57 @@ func (c *forecastConfig) parseDaily(hours, days forecastDataBlock, numdays int)
 		if day == nil {
 			day = new(iface.Day)
 			day.Date = slot.Time
-			c.parseAstro(day, days.Data)
+			c.parseDayData(day, days.Data)
 		}
 
 		day.Slots = append(day.Slots, slot)
 	}
-	return append(forecast, *day)
+	if day == nil {
+		return forecast, nil
+	}
+	return append(forecast, *day), nil
 }
 
-func (c *forecastConfig) parseCond(dp forecastDataPoint) (ret iface.Cond, err error) {
-	codemap := map[string]iface.WeatherCode{
-		"clear-day":           iface.CodeSunny,
-		"clear-night":         iface.CodeSunny,
-		"rain":                iface.CodeLightRain,
-		"snow":                iface.CodeLightSnow,
-		"sleet":               iface.CodeLightSleet,
-		"wind":                iface.CodePartlyCloudy,
-		"fog":                 iface.CodeFog,
-		"cloudy":              iface.CodeCloudy,
-		"partly-cloudy-day":   iface.CodePartlyCloudy,
-		"partly-cloudy-night": iface.CodePartlyCloudy,
-		"thunderstorm":        iface.CodeThunderyShowers,
+// forecastCodes returns the map of the icons of forecast.io to weather codes.
+func forecastCodes() map[string]iface.WeatherCode {
+	return map[string]iface.WeatherCode{
+		"clear-day":           conditionCode("clear"),
+		"clear-night":         conditionCode("clear"),
+		"rain":                conditionCode("rain"),
+		"snow":                conditionCode("snow"),
+		"sleet":               conditionCode("light sleet"),
+		"wind":                conditionCode("windy"),
+		"fog":                 conditionCode("fog"),
+		"cloudy":              conditionCode("cloudy"),
+		"partly-cloudy-day":   conditionCode("partly cloudy"),
+		"partly-cloudy-night": conditionCode("partly cloudy"),
+		"thunderstorm":        conditionCode("thunderstorm"),
+		"hail":                conditionCode("hail"),
+		"tornado":             conditionCode("tornado"),
 	}
+}
 
+func (c *forecastConfig) parseCond(dp forecastDataPoint) (ret iface.Cond, err error) {
 	if dp.Time == nil {
 		return iface.Cond{}, fmt.Errorf("The forecast.io response did not provide a time for the weather condition")
 	}
 	ret.Time = time.Unix(*dp.Time, 0).In(c.tz)
 
 	ret.Code = iface.CodeUnknown
-	if val, ok := codemap[dp.Icon]; ok {
+	if val, ok := c.codes[dp.Icon]; ok {
 		ret.Code = val
+	} else if dp.Icon != "" {
+		if err := parseErrorf("Unknown forecast.io icon %q", dp.Icon); err != nil {
+			return iface.Cond{}, err
+		}
 	}
 	ret.Desc = dp.Summary
 
+	if strings.HasSuffix(dp.Icon, "-day") || strings.HasSuffix(dp.Icon, "-night") {
+		isDay := strings.HasSuffix(dp.Icon, "-day")
+		ret.IsDay = &isDay
+	}
+
 	ret.TempC = dp.Temperature
 	ret.FeelsLikeC = dp.ApparentTemperature
 
@@ -161,11 +214,41 @@ func (c *forecastConfig) parseCond(dp forecastDataPoint) (ret iface.Cond, err er
 		ret.Humidity = &p
 	}
 
+	ret.PressureHPa = dp.Pressure
+
+	if dp.CloudCover != nil && *dp.CloudCover >= 0 && *dp.CloudCover <= 1 {
+		p := int(*dp.CloudCover*100 + 0.5)
+		ret.CloudCoverPercent = &p
+	}
+	ret.UVIndex = dp.UVIndex
+
 	return ret, nil
 }
 
-func (c *forecastConfig) fetch(url string) (*forecastResponse, error) {
-	res, err := http.Get(url)
+func (c *forecastConfig) parseAlerts(alerts []forecastAlert) (ret []iface.Alert) {
+	for _, a := range alerts {
+		alert := iface.Alert{
+			Severity: forecastSeverities[a.Severity],
+			Headline: a.Title,
+			URL:      a.URI,
+		}
+		if alert.Severity == "" {
+			alert.Severity = "Unknown"
+		}
+		if a.Time > 0 {
+			alert.Effective = time.Unix(a.Time, 0).In(c.tz)
+		}
+		if a.Expires > 0 {
+			alert.Expires = time.Unix(a.Expires, 0).In(c.tz)
+		}
+		ret = append(ret, alert)
+	}
+	iface.SortAlerts(ret)
+	return ret
+}
+
+func (c *forecastConfig) fetch(ctx context.Context, url string) (*forecastResponse, error) {
+	res, err := httpclient.Get(ctx, c.proxy, url, nil)
 	if err != nil {
 		return nil, fmt.Errorf("Unable to get (%s): %v", url, err)
 	} else if res.StatusCode != 200 {
@@ -173,94 +256,142 @@ func (c *forecastConfig) fetch(url string) (*forecastResponse, error) {
 	}
 	defer res.Body.Close()
 
-	body, err := ioutil.ReadAll(res.Body)
+	r, body, err := readBody(res.Body)
 	if err != nil {
 		return nil, fmt.Errorf("Unable to read response body (%s): %v", url, err)
 	}
 
-	if c.debug {
+	if c.debug && body != nil {
 		log.Printf("Response (%s): %s\n", url, string(body))
 	}
 
 	var resp forecastResponse
-	if err = json.Unmarshal(body, &resp); err != nil {
+	if err = json.NewDecoder(r).Decode(&resp); err != nil {
 		return nil, fmt.Errorf("Unable to unmarshal response (%s): %v\nThe json body is: %s", url, err, string(body))
 	}
 
 	if resp.Timezone == nil {
-		log.Printf("No timezone set in response (%s)", url)
+		err = parseErrorf("No timezone set in response (%s)", url)
+	} else if tz, tzErr := time.LoadLocation(*resp.Timezone); tzErr != nil {
+		err = parseErrorf("Unknown Timezone used in response (%s)", url)
 	} else {
-		c.tz, err = time.LoadLocation(*resp.Timezone)
-		if err != nil {
-			log.Printf("Unknown Timezone used in response (%s)", url)
-		}
+		c.tz = tz
+	}
+	if err != nil {
+		return nil, err
 	}
 	return &resp, nil
 }
 
-func (c *forecastConfig) fetchToday(location string) ([]iface.Cond, error) {
+func (c *forecastConfig) fetchToday(ctx context.Context, location string) ([]iface.Cond, error) {
 	location = fmt.Sprintf("%s,%d", location, time.Now().Unix())
 
-	resp, err := c.fetch(fmt.Sprintf(forecastWuri, c.apiKey, location, c.lang))
+	resp, err := c.fetch(ctx, fmt.Sprintf(forecastWuri, strings.TrimSuffix(c.baseURL, "/"), c.apiKey, location, c.lang))
 	if err != nil {
 		return nil, fmt.Errorf("Failed to fetch todays weather data: %v\n", err)
 	}
 
-	days := c.parseDaily(resp.Hourly, resp.Daily, 1)
+	days, err := c.parseDaily(resp.Hourly, resp.Daily, 1)
+	if err != nil {
+		return nil, err
+	}
 	if len(days) < 1 {
 		return nil, fmt.Errorf("Failed to parse today\n")
 	}
 	return days[0].Slots, nil
 }
 
+func (c *forecastConfig) Capabilities() iface.Capabilities {
+	return iface.Capabilities{
+		Description: "Dark Sky forecast API",
+		APIKeyFlag:  "forecast-api-key",
+		Hourly:      true,
+		Alerts:      true,
+		MaxDays:     7,
+	}
+}
+
 func (c *forecastConfig) Setup() {
 	flag.StringVar(&c.apiKey, "forecast-api-key", "", "forecast backend: the api `KEY` to use")
 	flag.StringVar(&c.lang, "forecast-lang", "en", "forecast backend: the `LANGUAGE` to request from forecast.io")
 	flag.BoolVar(&c.debug, "forecast-debug", false, "forecast backend: print raw requests and responses")
+	flag.StringVar(&c.baseURL, "forecast-url", "https://api.forecast.io", "forecast backend: the base `URL` of the api, e.g. of a mirror or caching proxy")
+	flag.StringVar(&c.proxy, "forecast-proxy", "", "forecast backend: the http or socks5 proxy `URL` to connect through, e.g. socks5://127.0.0.1:9050")
+}
+
+// Init builds the table of the icons.
+func (c *forecastConfig) Init() {
+	c.codes = forecastCodes()
 }
 
-func (c *forecastConfig) Fetch(location string, numdays int) iface.Data {
+func (c *forecastConfig) Fetch(ctx context.Context, location string, numdays int) (iface.Data, error) {
+	// work on a copy, so concurrent fetches for several locations do not
+	// share the time zone
+	cc := *c
+	c = &cc
 	var ret iface.Data
-	todayChan := make(chan []iface.Cond)
+	// buffered, so the goroutine finishes when Fetch returns early
+	todayChan := make(chan []iface.Cond, 1)
+	// set before the slots are sent
+	var todayErr error
 
 	if len(c.apiKey) == 0 {
-		log.Fatal("No forecast.io API key specified.\nYou have to register for one at https://developer.forecast.io/register")
+		return ret, fmt.Errorf("no forecast.io API key specified.\nYou have to register for one at https://developer.forecast.io/register")
 	}
-	if matched, err := regexp.MatchString(`^-?[0-9]*(\.[0-9]+)?,-?[0-9]*(\.[0-9]+)?$`, location); !matched || err != nil {
-		log.Fatalf("Error: The forecast.io backend only supports latitude,longitude pairs as location.\nInstead of `%s` try `40.748,-73.985` for example to get a forecast for New York", location)
+	place, err := geocode.Locate(ctx, location)
+	if err != nil {
+		return ret, fmt.Errorf("%v\nThe forecast.io backend needs a latitude,longitude pair or the name of a place as location, e.g. `40.748,-73.985` or `New York`", err)
 	}
+	location = fmt.Sprintf("%.4f,%.4f", place.Latitude, place.Longitude)
 
 	c.tz = time.Local
 
 	go func() {
-		slots, err := c.fetchToday(location)
+		slots, err := c.fetchToday(ctx, location)
 		if err != nil {
-			log.Fatalf("Failed to fetch todays weather data: %v\n", err)
+			todayErr = parseErrorf("Failed to fetch todays weather data: %v", err)
 		}
 		todayChan <- slots
 	}()
 
-	resp, err := c.fetch(fmt.Sprintf(forecastWuri, c.apiKey, location, c.lang))
+	resp, err := c.fetch(ctx, fmt.Sprintf(forecastWuri, strings.TrimSuffix(c.baseURL, "/"), c.apiKey, location, c.lang))
 	if err != nil {
-		log.Fatalf("Failed to fetch weather data: %v\n", err)
+		return ret, fmt.Errorf("failed to fetch weather data: %v", err)
 	}
 
 	if resp.Latitude == nil || resp.Longitude == nil {
-		log.Println("nil response for latitude,longitude")
+		if err := parseErrorf("nil response for latitude,longitude"); err != nil {
+			return ret, err
+		}
 		ret.Location = location
 	} else {
 		ret.GeoLoc = &iface.LatLon{Latitude: *resp.Latitude, Longitude: *resp.Longitude}
 		ret.Location = fmt.Sprintf("%f,%f", *resp.Latitude, *resp.Longitude)
 	}
+	if _, ok := geocode.ParseLatLon(place.Name); !ok {
+		ret.Location = place.Name
+	}
+
+	ret.Alerts = c.parseAlerts(resp.Alerts)
 
 	if ret.Current, err = c.parseCond(resp.Currently); err != nil {
-		log.Fatalf("Could not parse current weather condition: %v", err)
+		if err := parseErrorf("Could not parse current weather condition: %v", err); err != nil {
+			return ret, err
+		}
 	}
 
 	if numdays >= 1 {
-		ret.Forecast = c.parseDaily(resp.Hourly, resp.Daily, numdays)
+		if ret.Forecast, err = c.parseDaily(resp.Hourly, resp.Daily, numdays); err != nil {
+			return ret, err
+		}
+		if len(ret.Forecast) < 1 {
+			return ret, fmt.Errorf("failed to parse the forecast: the forecast.io response contains no hourly data")
+		}
 
 		var tHistory, tFuture = <-todayChan, ret.Forecast[0].Slots
+		if todayErr != nil {
+			return ret, todayErr
+		}
 		var tRet []iface.Cond
 		h, f := 0, 0
 
@@ -283,7 +414,37 @@ func (c *forecastConfig) Fetch(location string, numdays int) iface.Data {
 		}
 		ret.Forecast[0].Slots = tRet
 	}
-	return ret
+	return ret, nil
+}
+
+// FetchHistory returns a single day per call with a Time Machine request for
+// the noon of the date of from at the location.
+func (c *forecastConfig) FetchHistory(ctx context.Context, location string, from, to time.Time) ([]iface.Day, error) {
+	cc := *c
+	c = &cc
+	if len(c.apiKey) == 0 {
+		return nil, fmt.Errorf("no forecast.io API key specified.\nYou have to register for one at https://developer.forecast.io/register")
+	}
+	place, err := geocode.Locate(ctx, location)
+	if err != nil {
+		return nil, err
+	}
+	// a time without offset is taken as local time of the location
+	location = fmt.Sprintf("%.4f,%.4f,%s", place.Latitude, place.Longitude, from.Format("2006-01-02T12:00:00"))
+
+	c.tz = time.Local
+	resp, err := c.fetch(ctx, fmt.Sprintf(forecastWuri, strings.TrimSuffix(c.baseURL, "/"), c.apiKey, location, c.lang))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch the weather of %s: %v", from.Format("2006-01-02"), err)
+	}
+	days, err := c.parseDaily(resp.Hourly, resp.Daily, 1)
+	if err != nil {
+		return nil, err
+	}
+	if len(days) < 1 {
+		return nil, fmt.Errorf("the forecast.io response of %s contains no hourly data", from.Format("2006-01-02"))
+	}
+	return days, nil
 }
 
 func init() {
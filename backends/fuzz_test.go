@@ -0,0 +1,192 @@
+package backends
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/nafiz1001/wego/iface"
+)
+
+func TestMain(m *testing.M) {
+	// the parse errors of malformed input are logged in the lenient mode
+	log.SetOutput(ioutil.Discard)
+	os.Exit(m.Run())
+}
+
+// seed returns the sample response testdata/name.
+func seed(f testing.TB, name string) []byte {
+	b, err := ioutil.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		f.Fatal(err)
+	}
+	return b
+}
+
+func FuzzParseStationList(f *testing.F) {
+	f.Add(seed(f, "msc_site_list.csv"))
+	f.Add([]byte("Site Names\nCodes,English Names,Province Codes,Latitude,Longitude\ns0000430,Ottawa,ON,,\n"))
+	f.Add([]byte("Site Names\nCodes\ns0000430,Ottawa,ON,N,W\n"))
+	f.Add([]byte("Site Names\n\"\n"))
+	f.Fuzz(func(t *testing.T, body []byte) {
+		stations, err := parseStationList(bytes.NewReader(body))
+		if err == nil && len(stations) == 0 {
+			t.Error("no error for a station list without stations")
+		}
+	})
+}
+
+func FuzzParseSiteData(f *testing.F) {
+	f.Add(seed(f, "msc_site.xml"))
+	f.Add([]byte(`<siteData><currentConditions><dateTime name="observation" zone="UTC"><timeStamp>2022</timeStamp></dateTime></currentConditions></siteData>`))
+	f.Add([]byte(`<siteData><forecastGroup><dateTime name="forecastIssue" zone="UTC"><timeStamp>20220115103000</timeStamp></dateTime><forecast><winds><wind><direction>XX</direction></wind></winds></forecast></forecastGroup></siteData>`))
+	f.Add([]byte(`<?xml version="1.0" encoding="ISO-8859-1"?><siteData><location><name lat="" lon="W"/></location></siteData>`))
+	c := &mscConfig{}
+	c.Init()
+	f.Fuzz(func(t *testing.T, body []byte) {
+		parseMSC(c, body)
+	})
+}
+
+func FuzzForecastResponse(f *testing.F) {
+	f.Add(seed(f, "forecast.io.json"))
+	f.Add([]byte(`{"timezone":"UTC","currently":{},"hourly":{"data":[]},"daily":{"data":[]}}`))
+	f.Add([]byte(`{"timezone":"UTC","hourly":{"data":[{"time":0,"icon":"nope","windBearing":-1}]},"daily":{"data":[{"time":0}]}}`))
+	c := &forecastConfig{}
+	c.Init()
+	f.Fuzz(func(t *testing.T, body []byte) {
+		parseForecast(c, body)
+	})
+}
+
+func FuzzOpenWeatherResponse(f *testing.F) {
+	f.Add(seed(f, "openweathermap.json"))
+	f.Add([]byte(`{"cod":"200","list":[{"dt":0,"weather":[]}]}`))
+	f.Add([]byte(`{"cod":"200","list":[{"dt":-1,"weather":[{"id":-1}]},{"dt":86400,"weather":[{"id":800,"icon":"n"}]}]}`))
+	c := &openWeatherConfig{}
+	c.Init()
+	f.Fuzz(func(t *testing.T, body []byte) {
+		parseOpenWeather(c, body)
+	})
+}
+
+func FuzzWWOResponse(f *testing.F) {
+	f.Add(seed(f, "worldweatheronline.json"), "")
+	f.Add(seed(f, "worldweatheronline.json"), "de")
+	f.Add([]byte(`{"data":{"current_condition":[{"weatherDesc":[],"lang_de":[{}]}],"weather":[{"hourly":[{"lang_de":[]}]}]}}`), "de")
+	f.Add([]byte(`{"data":{"weather":[{"date":"","astronomy":[{"sunrise":"25:99 XM"}],"hourly":[{"time":"-2400","winddirDegree":"-1"}]}]}}`), "")
+	c := &wwoConfig{}
+	c.Init()
+	f.Fuzz(func(t *testing.T, body []byte, lang string) {
+		parseWWO(c, body, lang)
+	})
+}
+
+func FuzzMetnoResponse(f *testing.F) {
+	f.Add(seed(f, "api.met.no.json"))
+	f.Add([]byte(`{"properties":{"timeseries":[{"time":"2022-01-15T09:00:00Z","data":{"instant":{"details":{}}}}]}}`))
+	f.Add([]byte(`{"geometry":{"coordinates":[10]},"properties":{"timeseries":[{"time":"2022-01-15T09:00:00Z","data":{"instant":{"details":{"wind_from_direction":-1e9}},"next_1_hours":{"summary":{"symbol_code":"_"}}}}]}}`))
+	c := &metnoConfig{}
+	f.Fuzz(func(t *testing.T, body []byte) {
+		parseMetno(c, body)
+	})
+}
+
+// parseMSC parses the citypage_weather document body like the Fetch of c.
+func parseMSC(c *mscConfig, body []byte) (ret iface.Data, err error) {
+	data, err := parseSiteData(bytes.NewReader(body))
+	if err != nil {
+		return ret, err
+	}
+	p := mscParser{codes: c.codes}
+	loc := p.zone(data.DateTime)
+	ret.GeoLoc = mscGeoLoc(data)
+	ret.Alerts = p.alerts(data, loc)
+	ret.Forecast = p.days(data, loc)
+	mergeMSCHourly(ret.Forecast, p.hourly(data, loc))
+	if len(ret.Forecast) > 0 {
+		p.setToday(&ret.Forecast[0], data, loc)
+	}
+	if cond, ok := p.currentCond(data); ok {
+		ret.Current = cond
+		ret.Stations = append(ret.Stations, mscStationOf(data))
+	}
+	return ret, p.err
+}
+
+// parseForecast parses the forecast.io response body like the Fetch of c.
+func parseForecast(c *forecastConfig, body []byte) (ret iface.Data, err error) {
+	var resp forecastResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return ret, err
+	}
+	cc := *c
+	cc.tz = time.UTC
+	ret.Alerts = cc.parseAlerts(resp.Alerts)
+	if ret.Current, err = cc.parseCond(resp.Currently); err != nil {
+		if err := parseErrorf("Could not parse current weather condition: %v", err); err != nil {
+			return ret, err
+		}
+	}
+	ret.Forecast, err = cc.parseDaily(resp.Hourly, resp.Daily, 7)
+	return ret, err
+}
+
+// parseOpenWeather parses the openweathermap response body like the Fetch
+// of c.
+func parseOpenWeather(c *openWeatherConfig, body []byte) (ret iface.Data, err error) {
+	var resp openWeatherResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return ret, err
+	}
+	if len(resp.List) > 0 {
+		if ret.Current, err = c.parseCond(resp.List[0]); err != nil {
+			if err := parseErrorf("Could not parse current weather condition: %v", err); err != nil {
+				return ret, err
+			}
+		}
+	}
+	ret.Forecast, err = c.parseDaily(resp.List, 7)
+	return ret, err
+}
+
+// parseWWO parses the worldweatheronline response body in lang like the
+// Fetch of c.
+func parseWWO(c *wwoConfig, body []byte, lang string) (ret iface.Data, err error) {
+	var resp wwoResponse
+	if lang == "" {
+		err = json.Unmarshal(body, &resp)
+	} else {
+		err = wwoUnmarshalLang(bytes.NewReader(body), &resp, lang)
+	}
+	if err != nil {
+		return ret, err
+	}
+	for _, cond := range resp.Data.CurCond {
+		if ret.Current, err = c.parseCond(cond, time.Now()); err != nil {
+			return ret, err
+		}
+	}
+	for i, day := range resp.Data.Days {
+		d, err := c.parseDay(day, i, time.UTC)
+		if err != nil {
+			return ret, err
+		}
+		ret.Forecast = append(ret.Forecast, d)
+	}
+	return ret, nil
+}
+
+// parseMetno parses the api.met.no response body like the Fetch of c.
+func parseMetno(c *metnoConfig, body []byte) (iface.Data, error) {
+	resp, err := c.decode("", bytes.NewReader(body))
+	if err != nil {
+		return iface.Data{}, err
+	}
+	return c.parse(resp, 7)
+}
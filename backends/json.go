@@ -1,9 +1,10 @@
 package backends
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
 	"io/ioutil"
-	"log"
 
 	"github.com/nafiz1001/wego/iface"
 )
@@ -11,28 +12,39 @@ import (
 type jsnConfig struct {
 }
 
+func (c *jsnConfig) Capabilities() iface.Capabilities {
+	return iface.Capabilities{
+		Description: "Reads the output of the json frontend from the file given as location",
+	}
+}
+
 func (c *jsnConfig) Setup() {
 }
 
 // Fetch will try to open the file specified in the location string argument and
-// read it as json content to fill the data. The numdays argument will only work
+// read it as json content to fill the data. Documents of all versions up to
+// iface.DocumentVersion are read. The numdays argument will only work
 // to further limit the amount of days in the output. It obviously cannot
 // produce more data than is available in the file.
-func (c *jsnConfig) Fetch(loc string, numdays int) (ret iface.Data) {
+func (c *jsnConfig) Fetch(ctx context.Context, loc string, numdays int) (ret iface.Data, err error) {
 	b, err := ioutil.ReadFile(loc)
 	if err != nil {
-		log.Fatal(err)
+		return ret, err
 	}
 
-	err = json.Unmarshal(b, &ret)
-	if err != nil {
-		log.Fatal(err)
+	var doc iface.Document
+	if err = json.Unmarshal(b, &doc); err != nil {
+		return ret, fmt.Errorf("%s: %v", loc, err)
+	}
+	if doc.Version > iface.DocumentVersion {
+		return ret, fmt.Errorf("%s: version %d of the json output is newer than this wego understands (%d)", loc, doc.Version, iface.DocumentVersion)
 	}
+	ret = doc.Data
 
 	if len(ret.Forecast) > numdays {
 		ret.Forecast = ret.Forecast[:numdays]
 	}
-	return
+	return ret, nil
 }
 
 func init() {
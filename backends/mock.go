@@ -0,0 +1,141 @@
+package backends
+
+import (
+	"context"
+	"time"
+
+	"github.com/nafiz1001/wego/iface"
+)
+
+type mockConfig struct {
+}
+
+// mockDays is the number of days the mock backend has a forecast for.
+const mockDays = 7
+
+func (c *mockConfig) Capabilities() iface.Capabilities {
+	return iface.Capabilities{
+		Description: "Made up forecast using every weather code, for testing frontends",
+		MaxDays:     mockDays,
+		Alerts:      true,
+	}
+}
+
+func (c *mockConfig) Setup() {
+}
+
+// mockCond returns a made up condition for slot i of day. The values are
+// derived from the indices only, so every call returns exactly the same data.
+// Every weather code is used and some optional fields are left out.
+func mockCond(t time.Time, day, i int) (ret iface.Cond) {
+	f := func(v float32) *float32 { return &v }
+	n := day*8 + i
+
+	ret.Time = t
+	ret.Code = iface.WeatherCode(n % (int(iface.CodeSmoke) + 1))
+	ret.Desc = ret.Code.String()
+	ret.TempC = f(float32(n%45) - 15)
+	if n%3 == 0 {
+		ret.FeelsLikeC = f(*ret.TempC - 4)
+	}
+	if n%4 != 1 {
+		p := (n * 13) % 101
+		ret.ChanceOfRainPercent = &p
+	}
+	if n%5 != 2 {
+		ret.PrecipM = f(float32(n%7) / 1000)
+	}
+	if n%6 != 3 {
+		ret.VisibleDistM = f(float32((n * 700) % 20000))
+	}
+	if n%7 != 4 {
+		ret.WindspeedKmph = f(float32((n * 3) % 60))
+		if n%2 == 0 {
+			ret.WindGustKmph = f(*ret.WindspeedKmph + 15)
+		}
+		dir := (n * 37) % 360
+		ret.WinddirDegree = &dir
+	}
+	h := (n * 7) % 101
+	ret.Humidity = &h
+	if n%8 != 3 {
+		ret.PressureHPa = f(990 + float32((n*11)%45))
+	}
+	if n%9 != 6 {
+		c := (n * 17) % 101
+		ret.CloudCoverPercent = &c
+	}
+	if n%10 != 7 {
+		ret.UVIndex = f(float32(n % 12))
+	}
+	return
+}
+
+// Fetch returns the same canonical data on every call regardless of the
+// location, so frontends can be compared against known output. The numdays
+// argument limits the number of days up to mockDays.
+func (c *mockConfig) Fetch(ctx context.Context, loc string, numdays int) (ret iface.Data, err error) {
+	tz := time.FixedZone("MOCK", -5*3600)
+	start := time.Date(2021, time.June, 1, 0, 0, 0, 0, tz)
+
+	ret.Location = "Mockville"
+	ret.GeoLoc = &iface.LatLon{Latitude: 45.42, Longitude: -75.69}
+	ret.Current = mockCond(start.Add(14*time.Hour), 0, 5)
+	ret.Alerts = []iface.Alert{{
+		Severity:  "Severe",
+		Headline:  "WIND WARNING IN EFFECT",
+		Effective: start.Add(30 * time.Hour),
+		Expires:   start.Add(42 * time.Hour),
+	}}
+
+	if numdays > mockDays {
+		numdays = mockDays
+	}
+	for d := 0; d < numdays; d++ {
+		date := start.AddDate(0, 0, d)
+		day := iface.Day{Date: date}
+		for i := 0; i < 8; i++ {
+			day.Slots = append(day.Slots, mockCond(date.Add(time.Duration(i*3)*time.Hour), d, i))
+		}
+		if d%2 == 0 {
+			conf := 90 - d*10
+			day.Confidence = &conf
+		}
+		ret.Forecast = append(ret.Forecast, day)
+	}
+	return ret, nil
+}
+
+// mockHistoryPage is the number of days FetchHistory of the mock backend
+// returns at most, so callers have to page through longer periods.
+const mockHistoryPage = 10
+
+// FetchHistory returns made up days like Fetch, derived from the number of
+// the day since the start of the mock forecast.
+func (c *mockConfig) FetchHistory(ctx context.Context, loc string, from, to time.Time) ([]iface.Day, error) {
+	tz := time.FixedZone("MOCK", -5*3600)
+	start := time.Date(2021, time.June, 1, 0, 0, 0, 0, tz)
+
+	var ret []iface.Day
+	y, m, d := from.Date()
+	date := time.Date(y, m, d, 0, 0, 0, 0, tz)
+	y, m, d = to.Date()
+	end := time.Date(y, m, d, 0, 0, 0, 0, tz)
+	for len(ret) < mockHistoryPage && !date.After(end) {
+		n := int(date.Sub(start).Hours()/24) % 1000
+		if n < 0 {
+			n += 1000
+		}
+		day := iface.Day{Date: date}
+		for i := 0; i < 8; i++ {
+			day.Slots = append(day.Slots, mockCond(date.Add(time.Duration(i*3)*time.Hour), n, i))
+		}
+		ret = append(ret, day)
+		date = date.AddDate(0, 0, 1)
+	}
+	return ret, nil
+}
+
+func init() {
+	iface.AllBackends["mock"] = &mockConfig{}
+}
@@ -0,0 +1,92 @@
+package backends
+
+import (
+	"math"
+	"sort"
+)
+
+// earthRadiusKm is the mean radius of the earth.
+const earthRadiusKm = 6371.0
+
+// haversineKm returns the great circle distance between two points given in
+// degrees.
+func haversineKm(lat1, lon1, lat2, lon2 float64) float64 {
+	const rad = math.Pi / 180
+	sinLat := math.Sin((lat2 - lat1) * rad / 2)
+	sinLon := math.Sin((lon2 - lon1) * rad / 2)
+	a := sinLat*sinLat + math.Cos(lat1*rad)*math.Cos(lat2*rad)*sinLon*sinLon
+	return 2 * earthRadiusKm * math.Asin(math.Min(1, math.Sqrt(a)))
+}
+
+// geoGrid indexes points by the cell of one degree latitude and longitude
+// they are in, to find the nearest ones without measuring the distance to
+// all of them.
+type geoGrid struct {
+	lat, lon []float64
+	cells    map[[2]int][]int
+}
+
+// geoHit is a point found in a geoGrid: its index in the slices the grid was
+// built from and its distance.
+type geoHit struct {
+	index  int
+	distKm float64
+}
+
+// gridCell returns the cell of the point, with longitudes wrapped around to
+// [-180, 180).
+func gridCell(lat, lon float64) [2]int {
+	return [2]int{int(math.Floor(lat)), int(math.Floor(lon - 360*math.Floor((lon+180)/360)))}
+}
+
+func newGeoGrid(lat, lon []float64) *geoGrid {
+	g := &geoGrid{lat: lat, lon: lon, cells: make(map[[2]int][]int)}
+	for i := range lat {
+		c := gridCell(lat[i], lon[i])
+		g.cells[c] = append(g.cells[c], i)
+	}
+	return g
+}
+
+// nearest returns the n points closest to the coordinates, nearest first. It
+// searches the cells within a radius, which is doubled until it holds n
+// points.
+func (g *geoGrid) nearest(lat, lon float64, n int) []geoHit {
+	if n > len(g.lat) {
+		n = len(g.lat)
+	}
+	if n <= 0 {
+		return nil
+	}
+	for radiusKm := 50.0; ; radiusKm *= 2 {
+		// the cells of the box around the circle, all longitudes if it
+		// includes a pole
+		dLat := radiusKm / earthRadiusKm * 180 / math.Pi
+		dLon := 180.0
+		if math.Abs(lat)+dLat < 90 {
+			if s := math.Sin(radiusKm/earthRadiusKm) / math.Cos(lat*math.Pi/180); s < 1 {
+				dLon = math.Asin(s) * 180 / math.Pi
+			}
+		}
+		first, last := int(math.Floor(lon-dLon)), int(math.Floor(lon+dLon))
+		if last-first >= 359 {
+			first, last = -180, 179
+		}
+
+		var hits []geoHit
+		for cLat := int(math.Floor(lat - dLat)); cLat <= int(math.Floor(lat+dLat)); cLat++ {
+			for cLon := first; cLon <= last; cLon++ {
+				for _, i := range g.cells[gridCell(float64(cLat), float64(cLon))] {
+					if d := haversineKm(lat, lon, g.lat[i], g.lon[i]); d <= radiusKm {
+						hits = append(hits, geoHit{i, d})
+					}
+				}
+			}
+		}
+		// beyond half the circumference the circle covers the earth
+		if len(hits) >= n || radiusKm >= math.Pi*earthRadiusKm {
+			sort.SliceStable(hits, func(i, j int) bool { return hits[i].distKm < hits[j].distKm })
+			return hits[:n]
+		}
+	}
+}
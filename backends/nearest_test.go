@@ -0,0 +1,76 @@
+package backends
+
+import (
+	"math"
+	"sort"
+	"testing"
+)
+
+func TestHaversineKm(t *testing.T) {
+	// a degree of latitude, or of longitude at the equator
+	degree := earthRadiusKm * math.Pi / 180
+	tests := []struct {
+		lat1, lon1, lat2, lon2 float64
+		want                   float64
+	}{
+		{0, 0, 0, 0, 0},
+		{0, 0, 1, 0, degree},
+		{0, 179.9, 0, -179.9, 0.2 * degree},
+		{0, -179.9, 0, 179.9, 0.2 * degree},
+		{89.9, 0, 89.9, 180, 0.2 * degree},
+		{-89.9, 45, -89.9, -135, 0.2 * degree},
+		{90, 0, -90, 0, 180 * degree},
+		{0, 0, 0, 180, 180 * degree},
+		// Montréal to Paris
+		{45.5, -73.57, 48.86, 2.35, 5505},
+	}
+	for _, tt := range tests {
+		if got := haversineKm(tt.lat1, tt.lon1, tt.lat2, tt.lon2); math.Abs(got-tt.want) > 1 {
+			t.Errorf("haversineKm(%v, %v, %v, %v) = %.1f, want %.1f", tt.lat1, tt.lon1, tt.lat2, tt.lon2, got, tt.want)
+		}
+	}
+}
+
+// TestGeoGridNearest compares geoGrid.nearest with measuring the distance to
+// all points, around the poles and the antimeridian.
+func TestGeoGridNearest(t *testing.T) {
+	var lat, lon []float64
+	for _, la := range []float64{-90, -89.9, -89.5, -88, -60, -1, 0, 0.5, 45, 88, 89.5, 89.9, 90} {
+		for _, lo := range []float64{-180, -179.9, -179, -90, -0.5, 0, 42, 179, 179.9} {
+			lat, lon = append(lat, la), append(lon, lo)
+		}
+	}
+	g := newGeoGrid(lat, lon)
+
+	for _, q := range [][2]float64{
+		{89.9, 179.9}, {89.9, -179.9}, {-89.9, 179.9}, {-89.9, -179.9},
+		{90, 0}, {-90, 0}, {0, 179.9}, {0, -179.9}, {0.2, 180}, {45, 100}, {-30, -179.95},
+	} {
+		for _, n := range []int{1, 5, 30, len(lat) + 1} {
+			got := g.nearest(q[0], q[1], n)
+
+			want := make([]float64, len(lat))
+			for i := range lat {
+				want[i] = haversineKm(q[0], q[1], lat[i], lon[i])
+			}
+			sort.Float64s(want)
+			if n > len(want) {
+				n = len(want)
+			}
+			if len(got) != n {
+				t.Errorf("nearest(%v, %v, %d) found %d points", q[0], q[1], n, len(got))
+				continue
+			}
+			for i, hit := range got {
+				if hit.distKm != want[i] || hit.distKm != haversineKm(q[0], q[1], lat[hit.index], lon[hit.index]) {
+					t.Errorf("nearest(%v, %v, %d)[%d] = %+v, want at %.3f km", q[0], q[1], n, i, hit, want[i])
+					break
+				}
+			}
+		}
+	}
+
+	if got := newGeoGrid(nil, nil).nearest(0, 0, 3); got != nil {
+		t.Errorf("nearest in an empty grid = %v, want nil", got)
+	}
+}
@@ -1,23 +1,27 @@
 package backends
 
 import (
+	"context"
 	"encoding/json"
 	"flag"
 	"fmt"
-	"io/ioutil"
-	"log"
-	"net/http"
 	"regexp"
 	"strings"
 	"time"
 
+	"github.com/nafiz1001/wego/httpclient"
 	"github.com/nafiz1001/wego/iface"
 )
 
 type openWeatherConfig struct {
-	apiKey string
-	lang   string
-	debug  bool
+	apiKey  string
+	lang    string
+	debug   bool
+	baseURL string
+	proxy   string
+
+	// codes is built by Init
+	codes map[int]iface.WeatherCode
 }
 
 type openWeatherResponse struct {
@@ -32,14 +36,17 @@ type openWeatherResponse struct {
 type dataBlock struct {
 	Dt   int64 `json:"dt"`
 	Main struct {
-		TempMin  float32 `json:"temp_min"`
-		TempMax  float32 `json:"temp_max"`
-		Humidity int     `json:"humidity"`
+		TempMin  float32  `json:"temp_min"`
+		TempMax  float32  `json:"temp_max"`
+		Humidity int      `json:"humidity"`
+		Pressure *float32 `json:"pressure"`
+		Ground   *float32 `json:"grnd_level"`
 	} `json:"main"`
 
 	Weather []struct {
 		Description string `json:"description"`
 		ID          int    `json:"id"`
+		Icon        string `json:"icon"`
 	} `json:"weather"`
 
 	Wind struct {
@@ -50,20 +57,34 @@ type dataBlock struct {
 	Rain struct {
 		MM3h float32 `json:"3h"`
 	} `json:"rain"`
+
+	Clouds struct {
+		All *int `json:"all"`
+	} `json:"clouds"`
 }
 
 const (
-	openweatherURI = "http://api.openweathermap.org/data/2.5/forecast?%s&appid=%s&units=metric&lang=%s"
+	openweatherURI = "%s/data/2.5/forecast?%s&appid=%s&units=metric&lang=%s"
 )
 
+func (c *openWeatherConfig) Capabilities() iface.Capabilities {
+	return iface.Capabilities{
+		Description: "OpenWeatherMap 5 day forecast in 3 hour steps",
+		APIKeyFlag:  "owm-api-key",
+		MaxDays:     5,
+	}
+}
+
 func (c *openWeatherConfig) Setup() {
 	flag.StringVar(&c.apiKey, "owm-api-key", "", "openweathermap backend: the api `KEY` to use")
 	flag.StringVar(&c.lang, "owm-lang", "en", "openweathermap backend: the `LANGUAGE` to request from openweathermap")
 	flag.BoolVar(&c.debug, "owm-debug", false, "openweathermap backend: print raw requests and responses")
+	flag.StringVar(&c.baseURL, "owm-url", "http://api.openweathermap.org", "openweathermap backend: the base `URL` of the api, e.g. of a mirror or caching proxy")
+	flag.StringVar(&c.proxy, "owm-proxy", "", "openweathermap backend: the http or socks5 proxy `URL` to connect through, e.g. socks5://127.0.0.1:9050")
 }
 
-func (c *openWeatherConfig) fetch(url string) (*openWeatherResponse, error) {
-	res, err := http.Get(url)
+func (c *openWeatherConfig) fetch(ctx context.Context, url string) (*openWeatherResponse, error) {
+	res, err := httpclient.Get(ctx, c.proxy, url, nil)
 	if c.debug {
 		fmt.Printf("Fetching %s\n", url)
 	}
@@ -71,17 +92,17 @@ func (c *openWeatherConfig) fetch(url string) (*openWeatherResponse, error) {
 		return nil, fmt.Errorf(" Unable to get (%s) %v", url, err)
 	}
 	defer res.Body.Close()
-	body, err := ioutil.ReadAll(res.Body)
+	r, body, err := readBody(res.Body)
 	if err != nil {
 		return nil, fmt.Errorf("Unable to read response body (%s): %v", url, err)
 	}
 
-	if c.debug {
+	if c.debug && body != nil {
 		fmt.Printf("Response (%s):\n%s\n", url, string(body))
 	}
 
 	var resp openWeatherResponse
-	if err = json.Unmarshal(body, &resp); err != nil {
+	if err = json.NewDecoder(r).Decode(&resp); err != nil {
 		return nil, fmt.Errorf("Unable to unmarshal response (%s): %v\nThe json body is: %s", url, err, string(body))
 	}
 	if resp.Cod != "200" {
@@ -90,14 +111,16 @@ func (c *openWeatherConfig) fetch(url string) (*openWeatherResponse, error) {
 	return &resp, nil
 }
 
-func (c *openWeatherConfig) parseDaily(dataInfo []dataBlock, numdays int) []iface.Day {
+func (c *openWeatherConfig) parseDaily(dataInfo []dataBlock, numdays int) ([]iface.Day, error) {
 	var forecast []iface.Day
 	var day *iface.Day
 
 	for _, data := range dataInfo {
 		slot, err := c.parseCond(data)
 		if err != nil {
-			log.Println("Error parsing hourly weather condition:", err)
+			if err := parseErrorf("Error parsing hourly weather condition: %v", err); err != nil {
+				return nil, err
+			}
 			continue
 		}
 		if day == nil {
@@ -118,90 +141,107 @@ func (c *openWeatherConfig) parseDaily(dataInfo []dataBlock, numdays int) []ifac
 		}
 
 	}
-	return forecast
+	return forecast, nil
+}
+
+// openWeatherCodes returns the map of the weather condition ids of
+// openweathermap to weather codes. See
+// https://openweathermap.org/weather-conditions
+func openWeatherCodes() map[int]iface.WeatherCode {
+	return map[int]iface.WeatherCode{
+		200: conditionCode("thunderstorm"), // thunderstorm with light rain
+		201: conditionCode("thunderstorm"), // thunderstorm with rain
+		202: conditionCode("thunderstorm with heavy rain"),
+		210: conditionCode("thunderstorm"), // light thunderstorm
+		211: conditionCode("thunderstorm"),
+		212: conditionCode("severe thunderstorm"),          // heavy thunderstorm
+		221: conditionCode("severe thunderstorm"),          // ragged thunderstorm
+		230: conditionCode("thunderstorm"),                 // thunderstorm with light drizzle
+		231: conditionCode("thunderstorm"),                 // thunderstorm with drizzle
+		232: conditionCode("thunderstorm with heavy rain"), // thunderstorm with heavy drizzle
+		300: conditionCode("light drizzle"),
+		301: conditionCode("drizzle"),
+		302: conditionCode("heavy drizzle"),
+		310: conditionCode("light drizzle"), // light intensity drizzle rain
+		311: conditionCode("drizzle"),       // drizzle rain
+		312: conditionCode("heavy drizzle"), // heavy intensity drizzle rain
+		313: conditionCode("drizzle"),       // shower rain and drizzle
+		314: conditionCode("heavy drizzle"), // heavy shower rain and drizzle
+		321: conditionCode("drizzle"),       // shower drizzle
+		500: conditionCode("light rain"),
+		501: conditionCode("moderate rain"),
+		502: conditionCode("heavy rain"), // heavy intensity rain
+		503: conditionCode("heavy rain"), // very heavy rain
+		504: conditionCode("heavy rain"), // extreme rain
+		511: conditionCode("freezing rain"),
+		520: conditionCode("light rain showers"), // light intensity shower rain
+		521: conditionCode("rain showers"),       // shower rain
+		522: conditionCode("heavy rain showers"), // heavy intensity shower rain
+		531: conditionCode("heavy rain showers"), // ragged shower rain
+		600: conditionCode("light snow"),
+		601: conditionCode("snow"),
+		602: conditionCode("heavy snow"),
+		611: conditionCode("light sleet"),         // sleet
+		612: conditionCode("light sleet showers"), // light shower sleet
+		613: conditionCode("light sleet showers"), // shower sleet
+		615: conditionCode("rain and snow"),       // light rain and snow
+		616: conditionCode("rain and snow"),
+		620: conditionCode("light snow showers"), // light shower snow
+		621: conditionCode("light snow showers"), // shower snow
+		622: conditionCode("heavy snow showers"), // heavy shower snow
+		701: conditionCode("mist"),
+		711: conditionCode("smoke"),
+		721: conditionCode("haze"),
+		731: conditionCode("sand"), // sand, dust whirls
+		741: conditionCode("fog"),
+		751: conditionCode("sand"),
+		761: conditionCode("dust"),
+		762: conditionCode("volcanic ash"),
+		771: conditionCode("squalls"),
+		781: conditionCode("tornado"),
+		800: conditionCode("clear"),         // clear sky
+		801: conditionCode("partly cloudy"), // few clouds
+		802: conditionCode("cloudy"),        // scattered clouds
+		803: conditionCode("overcast"),      // broken clouds
+		804: conditionCode("overcast"),      // overcast clouds
+		900: conditionCode("tornado"),
+		901: conditionCode("tropical storm"),
+		902: conditionCode("hurricane"),
+		903: conditionCode("cold"),
+		904: conditionCode("hot"),
+		905: conditionCode("windy"),
+		906: conditionCode("hail"),
+		951: conditionCode("calm"),
+		952: conditionCode("breeze"), // light breeze
+		953: conditionCode("breeze"), // gentle breeze
+		954: conditionCode("breeze"), // moderate breeze
+		955: conditionCode("breeze"), // fresh breeze
+		956: conditionCode("breeze"), // strong breeze
+		957: conditionCode("gale"),   // high wind, near gale
+		958: conditionCode("gale"),
+		959: conditionCode("gale"),           // severe gale
+		960: conditionCode("tropical storm"), // storm
+		961: conditionCode("tropical storm"), // violent storm
+		962: conditionCode("hurricane"),
+	}
 }
 
 func (c *openWeatherConfig) parseCond(dataInfo dataBlock) (iface.Cond, error) {
 	var ret iface.Cond
-	codemap := map[int]iface.WeatherCode{
-		200: iface.CodeThunderyShowers,
-		201: iface.CodeThunderyShowers,
-		210: iface.CodeThunderyShowers,
-		230: iface.CodeThunderyShowers,
-		231: iface.CodeThunderyShowers,
-		202: iface.CodeThunderyHeavyRain,
-		211: iface.CodeThunderyHeavyRain,
-		212: iface.CodeThunderyHeavyRain,
-		221: iface.CodeThunderyHeavyRain,
-		232: iface.CodeThunderyHeavyRain,
-		300: iface.CodeLightRain,
-		301: iface.CodeLightRain,
-		310: iface.CodeLightRain,
-		311: iface.CodeLightRain,
-		313: iface.CodeLightRain,
-		321: iface.CodeLightRain,
-		302: iface.CodeHeavyRain,
-		312: iface.CodeHeavyRain,
-		314: iface.CodeHeavyRain,
-		500: iface.CodeLightShowers,
-		501: iface.CodeLightShowers,
-		502: iface.CodeHeavyShowers,
-		503: iface.CodeHeavyShowers,
-		504: iface.CodeHeavyShowers,
-		511: iface.CodeLightSleet,
-		520: iface.CodeLightShowers,
-		521: iface.CodeLightShowers,
-		522: iface.CodeHeavyShowers,
-		531: iface.CodeHeavyShowers,
-		600: iface.CodeLightSnow,
-		601: iface.CodeLightSnow,
-		602: iface.CodeHeavySnow,
-		611: iface.CodeLightSleet,
-		612: iface.CodeLightSleetShowers,
-		615: iface.CodeLightSleet,
-		616: iface.CodeLightSleet,
-		620: iface.CodeLightSnowShowers,
-		621: iface.CodeLightSnowShowers,
-		622: iface.CodeHeavySnowShowers,
-		701: iface.CodeFog,
-		711: iface.CodeFog,
-		721: iface.CodeFog,
-		741: iface.CodeFog,
-		731: iface.CodeUnknown, // sand, dust whirls
-		751: iface.CodeUnknown, // sand
-		761: iface.CodeUnknown, // dust
-		762: iface.CodeUnknown, // volcanic ash
-		771: iface.CodeUnknown, // squalls
-		781: iface.CodeUnknown, // tornado
-		800: iface.CodeSunny,
-		801: iface.CodePartlyCloudy,
-		802: iface.CodeCloudy,
-		803: iface.CodeVeryCloudy,
-		804: iface.CodeVeryCloudy,
-		900: iface.CodeUnknown, // tornado
-		901: iface.CodeUnknown, // tropical storm
-		902: iface.CodeUnknown, // hurricane
-		903: iface.CodeUnknown, // cold
-		904: iface.CodeUnknown, // hot
-		905: iface.CodeUnknown, // windy
-		906: iface.CodeUnknown, // hail
-		951: iface.CodeUnknown, // calm
-		952: iface.CodeUnknown, // light breeze
-		953: iface.CodeUnknown, // gentle breeze
-		954: iface.CodeUnknown, // moderate breeze
-		955: iface.CodeUnknown, // fresh breeze
-		956: iface.CodeUnknown, // strong breeze
-		957: iface.CodeUnknown, // high wind, near gale
-		958: iface.CodeUnknown, // gale
-		959: iface.CodeUnknown, // severe gale
-		960: iface.CodeUnknown, // storm
-		961: iface.CodeUnknown, // violent storm
-		962: iface.CodeUnknown, // hurricane
+	if len(dataInfo.Weather) < 1 {
+		return ret, fmt.Errorf("no weather description in data block at %d", dataInfo.Dt)
 	}
 
 	ret.Code = iface.CodeUnknown
 	ret.Desc = dataInfo.Weather[0].Description
+	if icon := dataInfo.Weather[0].Icon; strings.HasSuffix(icon, "d") || strings.HasSuffix(icon, "n") {
+		isDay := strings.HasSuffix(icon, "d")
+		ret.IsDay = &isDay
+	}
 	ret.Humidity = &(dataInfo.Main.Humidity)
+	ret.PressureHPa = dataInfo.Main.Pressure
+	ret.StationPressureHPa = dataInfo.Main.Ground
+	ret.CloudCoverPercent = dataInfo.Clouds.All
 	ret.TempC = &(dataInfo.Main.TempMin)
 	ret.FeelsLikeC = &(dataInfo.Main.TempMax)
 	if &dataInfo.Wind.Deg != nil {
@@ -212,8 +252,10 @@ func (c *openWeatherConfig) parseCond(dataInfo dataBlock) (iface.Cond, error) {
 		windSpeed := (dataInfo.Wind.Speed * 3.6)
 		ret.WindspeedKmph = &(windSpeed)
 	}
-	if val, ok := codemap[dataInfo.Weather[0].ID]; ok {
+	if val, ok := c.codes[dataInfo.Weather[0].ID]; ok {
 		ret.Code = val
+	} else if err := parseErrorf("Unknown openweathermap weather id %d", dataInfo.Weather[0].ID); err != nil {
+		return ret, err
 	}
 
 	if &dataInfo.Rain.MM3h != nil {
@@ -226,12 +268,17 @@ func (c *openWeatherConfig) parseCond(dataInfo dataBlock) (iface.Cond, error) {
 	return ret, nil
 }
 
-func (c *openWeatherConfig) Fetch(location string, numdays int) iface.Data {
+// Init builds the table of the weather condition ids.
+func (c *openWeatherConfig) Init() {
+	c.codes = openWeatherCodes()
+}
+
+func (c *openWeatherConfig) Fetch(ctx context.Context, location string, numdays int) (iface.Data, error) {
 	var ret iface.Data
 	loc := ""
 
 	if len(c.apiKey) == 0 {
-		log.Fatal("No openweathermap.org API key specified.\nYou have to register for one at https://home.openweathermap.org/users/sign_up")
+		return ret, fmt.Errorf("no openweathermap.org API key specified.\nYou have to register for one at https://home.openweathermap.org/users/sign_up")
 	}
 	if matched, err := regexp.MatchString(`^-?[0-9]*(\.[0-9]+)?,-?[0-9]*(\.[0-9]+)?$`, location); matched && err == nil {
 		s := strings.Split(location, ",")
@@ -242,18 +289,25 @@ func (c *openWeatherConfig) Fetch(location string, numdays int) iface.Data {
 		loc = "q=" + location
 	}
 
-	resp, err := c.fetch(fmt.Sprintf(openweatherURI, loc, c.apiKey, c.lang))
+	resp, err := c.fetch(ctx, fmt.Sprintf(openweatherURI, strings.TrimSuffix(c.baseURL, "/"), loc, c.apiKey, c.lang))
 	if err != nil {
-		log.Fatalf("Failed to fetch weather data: %v\n", err)
+		return ret, fmt.Errorf("failed to fetch weather data: %v", err)
+	}
+	if len(resp.List) < 1 {
+		return ret, fmt.Errorf("failed to fetch weather data: the openweathermap response contains no forecast")
 	}
 	ret.Current, err = c.parseCond(resp.List[0])
 	ret.Location = fmt.Sprintf("%s, %s", resp.City.Name, resp.City.Country)
 
 	if err != nil {
-		log.Fatalf("Failed to fetch weather data: %v\n", err)
+		if err := parseErrorf("Could not parse current weather condition: %v", err); err != nil {
+			return ret, err
+		}
 	}
-	ret.Forecast = c.parseDaily(resp.List, numdays)
-	return ret
+	if ret.Forecast, err = c.parseDaily(resp.List, numdays); err != nil {
+		return ret, err
+	}
+	return ret, nil
 }
 
 func init() {
@@ -0,0 +1,41 @@
+package backends
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+
+	"github.com/nafiz1001/wego/iface"
+	"github.com/nafiz1001/wego/metrics"
+)
+
+var parseErrors = metrics.NewCounter("wego_parse_errors_total", "Fields or records of backend responses which could not be parsed.")
+
+// parseErrorf reports a field or record of a provider response which could not
+// be parsed. In strict mode it returns the error, which the caller passes up
+// to fail the fetch. Otherwise the message is logged, it returns nil and the
+// caller is expected to carry on without the offending value.
+func parseErrorf(format string, v ...interface{}) error {
+	parseErrors.Inc()
+	if iface.Strict {
+		return fmt.Errorf(format, v...)
+	}
+	log.Printf(format, v...)
+	return nil
+}
+
+// readBody returns a reader of the response body r for the decoder and the
+// body itself for debug and error messages. With -low-memory the body is
+// decoded while it is read instead, and the returned body is nil.
+func readBody(r io.Reader) (io.Reader, []byte, error) {
+	if iface.LowMemory {
+		return r, nil, nil
+	}
+	body, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	return bytes.NewReader(body), body, nil
+}
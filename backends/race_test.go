@@ -0,0 +1,8 @@
+//go:build race
+// +build race
+
+package backends
+
+// raceEnabled reports whether the tests run with the race detector, which
+// makes allocations of its own.
+const raceEnabled = true
@@ -2,11 +2,13 @@ package backends
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"flag"
+	"fmt"
+	"io"
 	"io/ioutil"
 	"log"
-	"net/http"
 	"net/url"
 	"strconv"
 	"strings"
@@ -16,6 +18,7 @@ import (
 	// v1.4.2 or later is in debian stable and the latest Ubuntu LTS release.
 	_ "crypto/sha512"
 
+	"github.com/nafiz1001/wego/httpclient"
 	"github.com/nafiz1001/wego/iface"
 )
 
@@ -25,6 +28,9 @@ type wwoCond struct {
 	TmpDesc       []struct{ Value string } `json:"weatherDesc"`
 	FeelsLikeC    *float32                 `json:",string"`
 	PrecipMM      *float32                 `json:"precipMM,string"`
+	Pressure      *float32                 `json:"pressure,string"`
+	Cloudcover    *int                     `json:"cloudcover,string"`
+	UVIndex       *float32                 `json:"uvIndex,string"`
 	TmpTempC      *float32                 `json:"tempC,string"`
 	TmpTempC2     *float32                 `json:"temp_C,string"`
 	TmpTime       *int                     `json:"time,string"`
@@ -41,8 +47,10 @@ type wwoDay struct {
 		Sunrise  string
 		Sunset   string
 	}
-	Date   string
-	Hourly []wwoCond
+	Date     string
+	Hourly   []wwoCond
+	MaxtempC *float32 `json:"maxtempC,string"`
+	MintempC *float32 `json:"mintempC,string"`
 }
 
 type wwoResponse struct {
@@ -53,7 +61,10 @@ type wwoResponse struct {
 			Query string `json:"query"`
 			Type  string `json:"type"`
 		} `json:"request"`
-		Days []wwoDay `json:"weather"`
+		Days     []wwoDay `json:"weather"`
+		TimeZone []struct {
+			UTCOffset string `json:"utcOffset"`
+		} `json:"time_zone"`
 	} `json:"data"`
 }
 
@@ -70,69 +81,82 @@ type wwoConfig struct {
 	apiKey   string
 	language string
 	debug    bool
+	baseURL  string
+	proxy    string
+
+	// codes is built by Init
+	codes map[int]iface.WeatherCode
 }
 
 const (
-	wwoSuri = "https://api.worldweatheronline.com/free/v2/search.ashx?"
-	wwoWuri = "https://api.worldweatheronline.com/free/v2/weather.ashx?"
+	wwoSuri = "/free/v2/search.ashx?"
+	wwoWuri = "/free/v2/weather.ashx?"
 )
 
-func wwoParseCond(cond wwoCond, date time.Time) (ret iface.Cond) {
+// wwoCodes returns the map of the weather codes of worldweatheronline to ours.
+func wwoCodes() map[int]iface.WeatherCode {
+	return map[int]iface.WeatherCode{
+		113: conditionCode("clear"), // sunny / clear
+		116: conditionCode("partly cloudy"),
+		119: conditionCode("cloudy"),
+		122: conditionCode("overcast"),
+		143: conditionCode("mist"),
+		176: conditionCode("light rain showers"), // patchy rain possible
+		179: conditionCode("light snow showers"), // patchy snow possible
+		182: conditionCode("light sleet"),        // patchy sleet possible
+		185: conditionCode("freezing drizzle"),   // patchy freezing drizzle possible
+		200: conditionCode("thunderstorm"),       // thundery outbreaks possible
+		227: conditionCode("blowing snow"),
+		230: conditionCode("blowing snow"), // blizzard
+		248: conditionCode("fog"),
+		260: conditionCode("fog"),           // freezing fog
+		263: conditionCode("light drizzle"), // patchy light drizzle
+		266: conditionCode("light drizzle"),
+		281: conditionCode("freezing drizzle"),
+		284: conditionCode("freezing drizzle"), // heavy freezing drizzle
+		293: conditionCode("light rain"),       // patchy light rain
+		296: conditionCode("light rain"),
+		299: conditionCode("heavy rain showers"), // moderate rain at times
+		302: conditionCode("moderate rain"),
+		305: conditionCode("heavy rain showers"), // heavy rain at times
+		308: conditionCode("heavy rain"),
+		311: conditionCode("freezing rain"), // light freezing rain
+		314: conditionCode("freezing rain"), // moderate or heavy freezing rain
+		317: conditionCode("light sleet"),
+		320: conditionCode("light sleet"),        // moderate or heavy sleet
+		323: conditionCode("light snow showers"), // patchy light snow
+		326: conditionCode("light snow"),
+		329: conditionCode("moderate snow"), // patchy moderate snow
+		332: conditionCode("moderate snow"),
+		335: conditionCode("heavy snow showers"), // patchy heavy snow
+		338: conditionCode("heavy snow"),
+		350: conditionCode("ice pellets"),
+		353: conditionCode("light rain showers"), // light rain shower
+		356: conditionCode("heavy rain showers"), // moderate or heavy rain shower
+		359: conditionCode("heavy rain"),         // torrential rain shower
+		362: conditionCode("light sleet showers"),
+		365: conditionCode("light sleet showers"), // moderate or heavy sleet showers
+		368: conditionCode("light snow showers"),
+		371: conditionCode("heavy snow showers"),           // moderate or heavy snow showers
+		374: conditionCode("ice pellets"),                  // light showers of ice pellets
+		377: conditionCode("ice pellets"),                  // moderate or heavy showers of ice pellets
+		386: conditionCode("thunderstorm"),                 // patchy light rain with thunder
+		389: conditionCode("thunderstorm with heavy rain"), // moderate or heavy rain with thunder
+		392: conditionCode("thunderstorm with snow"),       // patchy light snow with thunder
+		395: conditionCode("thunderstorm with snow"),       // moderate or heavy snow with thunder
+	}
+}
+
+// parseCond parses cond. The time of the condition is local to the
+// location of date.
+func (c *wwoConfig) parseCond(cond wwoCond, date time.Time) (ret iface.Cond, err error) {
 	ret.ChanceOfRainPercent = cond.TmpCor
 
-	codemap := map[int]iface.WeatherCode{
-		113: iface.CodeSunny,
-		116: iface.CodePartlyCloudy,
-		119: iface.CodeCloudy,
-		122: iface.CodeVeryCloudy,
-		143: iface.CodeFog,
-		176: iface.CodeLightShowers,
-		179: iface.CodeLightSleetShowers,
-		182: iface.CodeLightSleet,
-		185: iface.CodeLightSleet,
-		200: iface.CodeThunderyShowers,
-		227: iface.CodeLightSnow,
-		230: iface.CodeHeavySnow,
-		248: iface.CodeFog,
-		260: iface.CodeFog,
-		263: iface.CodeLightShowers,
-		266: iface.CodeLightRain,
-		281: iface.CodeLightSleet,
-		284: iface.CodeLightSleet,
-		293: iface.CodeLightRain,
-		296: iface.CodeLightRain,
-		299: iface.CodeHeavyShowers,
-		302: iface.CodeHeavyRain,
-		305: iface.CodeHeavyShowers,
-		308: iface.CodeHeavyRain,
-		311: iface.CodeLightSleet,
-		314: iface.CodeLightSleet,
-		317: iface.CodeLightSleet,
-		320: iface.CodeLightSnow,
-		323: iface.CodeLightSnowShowers,
-		326: iface.CodeLightSnowShowers,
-		329: iface.CodeHeavySnow,
-		332: iface.CodeHeavySnow,
-		335: iface.CodeHeavySnowShowers,
-		338: iface.CodeHeavySnow,
-		350: iface.CodeLightSleet,
-		353: iface.CodeLightShowers,
-		356: iface.CodeHeavyShowers,
-		359: iface.CodeHeavyRain,
-		362: iface.CodeLightSleetShowers,
-		365: iface.CodeLightSleetShowers,
-		368: iface.CodeLightSnowShowers,
-		371: iface.CodeHeavySnowShowers,
-		374: iface.CodeLightSleetShowers,
-		377: iface.CodeLightSleet,
-		386: iface.CodeThunderyShowers,
-		389: iface.CodeThunderyHeavyRain,
-		392: iface.CodeThunderySnowShowers,
-		395: iface.CodeHeavySnowShowers,
-	}
 	ret.Code = iface.CodeUnknown
-	if val, ok := codemap[cond.TmpCode]; ok {
+	if val, ok := c.codes[cond.TmpCode]; ok {
 		ret.Code = val
+	} else if err = parseErrorf("Unknown worldweatheronline weather code %d", cond.TmpCode); err != nil {
+		return
 	}
 
 	if cond.TmpDesc != nil && len(cond.TmpDesc) > 0 {
@@ -144,6 +168,9 @@ func wwoParseCond(cond wwoCond, date time.Time) (ret iface.Cond) {
 		ret.TempC = cond.TmpTempC
 	}
 	ret.FeelsLikeC = cond.FeelsLikeC
+	ret.PressureHPa = cond.Pressure
+	ret.CloudCoverPercent = cond.Cloudcover
+	ret.UVIndex = cond.UVIndex
 
 	if cond.PrecipMM != nil {
 		p := *cond.PrecipMM / 1000
@@ -154,7 +181,7 @@ func wwoParseCond(cond wwoCond, date time.Time) (ret iface.Cond) {
 	if cond.TmpTime != nil {
 		year, month, day := date.Date()
 		hour, min := *cond.TmpTime/100, *cond.TmpTime%100
-		ret.Time = time.Date(year, month, day, hour, min, 0, 0, time.UTC)
+		ret.Time = time.Date(year, month, day, hour, min, 0, 0, date.Location())
 	}
 
 	if cond.VisibleDistKM != nil {
@@ -173,27 +200,52 @@ func wwoParseCond(cond wwoCond, date time.Time) (ret iface.Cond) {
 	return
 }
 
-func wwoParseDay(day wwoDay, index int) (ret iface.Day) {
-	//TODO: Astronomy
+// wwoAstroTime returns the time like "07:15 AM" on date, or the zero time for
+// values like "No moonrise".
+func wwoAstroTime(date string, clock string, tz *time.Location) time.Time {
+	t, err := time.ParseInLocation("2006-01-02 03:04 PM", date+" "+clock, tz)
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
+
+func (c *wwoConfig) parseDay(day wwoDay, index int, tz *time.Location) (ret iface.Day, err error) {
+	if len(day.Astronomy) > 0 {
+		a := day.Astronomy[0]
+		ret.Astronomy.Sunrise = wwoAstroTime(day.Date, a.Sunrise, tz)
+		ret.Astronomy.Sunset = wwoAstroTime(day.Date, a.Sunset, tz)
+		ret.Astronomy.Moonrise = wwoAstroTime(day.Date, a.Moonrise, tz)
+		ret.Astronomy.Moonset = wwoAstroTime(day.Date, a.Moonset, tz)
+	}
 
-	ret.Date = time.Now().Add(time.Hour * 24 * time.Duration(index))
-	date, err := time.Parse("2006-01-02", day.Date)
+	ret.Date = time.Now().In(tz).Add(time.Hour * 24 * time.Duration(index))
+	date, err := time.ParseInLocation("2006-01-02", day.Date, tz)
 	if err == nil {
 		ret.Date = date
+	} else if err = parseErrorf("Unable to parse forecast date %q: %v", day.Date, err); err != nil {
+		return
 	}
 
+	ret.MaxTempC = day.MaxtempC
+	ret.MinTempC = day.MintempC
+
 	if day.Hourly != nil && len(day.Hourly) > 0 {
 		for _, slot := range day.Hourly {
-			ret.Slots = append(ret.Slots, wwoParseCond(slot, date))
+			cond, err := c.parseCond(slot, date)
+			if err != nil {
+				return ret, err
+			}
+			ret.Slots = append(ret.Slots, cond)
 		}
 	}
 
 	return
 }
 
-func wwoUnmarshalLang(body []byte, r *wwoResponse, lang string) error {
+func wwoUnmarshalLang(body io.Reader, r *wwoResponse, lang string) error {
 	var rv map[string]interface{}
-	if err := json.Unmarshal(body, &rv); err != nil {
+	if err := json.NewDecoder(body).Decode(&rv); err != nil {
 		return err
 	}
 	if data, ok := rv["data"].(map[string]interface{}); ok {
@@ -247,16 +299,26 @@ func wwoUnmarshalLang(body []byte, r *wwoResponse, lang string) error {
 	return json.NewDecoder(&buf).Decode(r)
 }
 
+func (c *wwoConfig) Capabilities() iface.Capabilities {
+	return iface.Capabilities{
+		Description: "World Weather Online forecast in 3 hour steps",
+		APIKeyFlag:  "wwo-api-key",
+		MaxDays:     14,
+	}
+}
+
 func (c *wwoConfig) Setup() {
 	flag.StringVar(&c.apiKey, "wwo-api-key", "", "worldweatheronline backend: the api `KEY` to use")
 	flag.StringVar(&c.language, "wwo-lang", "en", "worldweatheronline backend: the `LANGUAGE` to request from worldweatheronline")
 	flag.BoolVar(&c.debug, "wwo-debug", false, "worldweatheronline backend: print raw requests and responses")
+	flag.StringVar(&c.baseURL, "wwo-url", "https://api.worldweatheronline.com", "worldweatheronline backend: the base `URL` of the api, e.g. of a mirror or caching proxy")
+	flag.StringVar(&c.proxy, "wwo-proxy", "", "worldweatheronline backend: the http or socks5 proxy `URL` to connect through, e.g. socks5://127.0.0.1:9050")
 }
 
-func (c *wwoConfig) getCoordinatesFromAPI(queryParams []string, res chan *iface.LatLon) {
+func (c *wwoConfig) getCoordinatesFromAPI(ctx context.Context, queryParams []string, res chan *iface.LatLon) {
 	var coordResp wwoCoordinateResp
-	requri := wwoSuri + strings.Join(queryParams, "&")
-	hres, err := http.Get(requri)
+	requri := strings.TrimSuffix(c.baseURL, "/") + wwoSuri + strings.Join(queryParams, "&")
+	hres, err := httpclient.Get(ctx, c.proxy, requri, nil)
 	if err != nil {
 		log.Println("Unable to fetch geo location:", err)
 		res <- nil
@@ -296,14 +358,20 @@ func (c *wwoConfig) getCoordinatesFromAPI(queryParams []string, res chan *iface.
 	res <- &iface.LatLon{Latitude: *r[0].Latitude, Longitude: *r[0].Longitude}
 }
 
-func (c *wwoConfig) Fetch(loc string, numdays int) iface.Data {
+// Init builds the table of the weather codes.
+func (c *wwoConfig) Init() {
+	c.codes = wwoCodes()
+}
+
+func (c *wwoConfig) Fetch(ctx context.Context, loc string, numdays int) (iface.Data, error) {
 	var params []string
 	var resp wwoResponse
 	var ret iface.Data
-	coordChan := make(chan *iface.LatLon)
+	// buffered, so the goroutine finishes when Fetch returns early
+	coordChan := make(chan *iface.LatLon, 1)
 
 	if len(c.apiKey) == 0 {
-		log.Fatal("No API key specified. Setup instructions are in the README.")
+		return ret, fmt.Errorf("no API key specified. Setup instructions are in the README")
 	}
 	params = append(params, "key="+c.apiKey)
 
@@ -313,63 +381,84 @@ func (c *wwoConfig) Fetch(loc string, numdays int) iface.Data {
 	params = append(params, "format=json")
 	params = append(params, "num_of_days="+strconv.Itoa(numdays))
 	params = append(params, "tp=3")
+	params = append(params, "showlocaltime=yes")
 
-	go c.getCoordinatesFromAPI(params, coordChan)
+	go c.getCoordinatesFromAPI(ctx, params, coordChan)
 
 	if c.language != "" {
 		params = append(params, "lang="+c.language)
 	}
-	requri := wwoWuri + strings.Join(params, "&")
+	requri := strings.TrimSuffix(c.baseURL, "/") + wwoWuri + strings.Join(params, "&")
 
-	res, err := http.Get(requri)
+	res, err := httpclient.Get(ctx, c.proxy, requri, nil)
 	if err != nil {
-		log.Fatal("Unable to get weather data: ", err)
-	} else if res.StatusCode != 200 {
-		log.Fatal("Unable to get weather data: http status ", res.StatusCode)
+		return ret, fmt.Errorf("unable to get weather data: %v", err)
 	}
 	defer res.Body.Close()
+	if res.StatusCode != 200 {
+		return ret, fmt.Errorf("unable to get weather data: http status %d", res.StatusCode)
+	}
 
-	body, err := ioutil.ReadAll(res.Body)
+	r, body, err := readBody(res.Body)
 	if err != nil {
-		log.Fatal(err)
+		return ret, err
 	}
 
 	if c.debug {
 		log.Println("Weather request:", requri)
-		log.Println("Weather response:", string(body))
+		if body != nil {
+			log.Println("Weather response:", string(body))
+		}
 	}
 
 	if c.language == "" {
-		if err = json.Unmarshal(body, &resp); err != nil {
-			log.Println(err)
-		}
+		err = json.NewDecoder(r).Decode(&resp)
 	} else {
-		if err = wwoUnmarshalLang(body, &resp, c.language); err != nil {
-			log.Println(err)
+		err = wwoUnmarshalLang(r, &resp, c.language)
+	}
+	if err != nil {
+		if err := parseErrorf("Unable to unmarshal weather data: %v", err); err != nil {
+			return ret, err
 		}
 	}
 
 	if resp.Data.Req == nil || len(resp.Data.Req) < 1 {
 		if resp.Data.Err != nil && len(resp.Data.Err) >= 1 {
-			log.Fatal(resp.Data.Err[0].Msg)
+			return ret, fmt.Errorf("%s", resp.Data.Err[0].Msg)
 		}
-		log.Fatal("Malformed response.")
+		return ret, fmt.Errorf("malformed response")
 	}
 
 	ret.Location = resp.Data.Req[0].Type + ": " + resp.Data.Req[0].Query
 	ret.GeoLoc = <-coordChan
 
+	// times in the response are local to the location
+	tz := time.UTC
+	if len(resp.Data.TimeZone) > 0 {
+		if offset, err := strconv.ParseFloat(resp.Data.TimeZone[0].UTCOffset, 64); err == nil {
+			tz = time.FixedZone("", int(offset*3600))
+		} else if err := parseErrorf("Unable to parse utc offset %q: %v", resp.Data.TimeZone[0].UTCOffset, err); err != nil {
+			return ret, err
+		}
+	}
+
 	if resp.Data.CurCond != nil && len(resp.Data.CurCond) > 0 {
-		ret.Current = wwoParseCond(resp.Data.CurCond[0], time.Now())
+		if ret.Current, err = c.parseCond(resp.Data.CurCond[0], time.Now()); err != nil {
+			return ret, err
+		}
 	}
 
 	if resp.Data.Days != nil && numdays > 0 {
 		for i, day := range resp.Data.Days {
-			ret.Forecast = append(ret.Forecast, wwoParseDay(day, i))
+			d, err := c.parseDay(day, i, tz)
+			if err != nil {
+				return ret, err
+			}
+			ret.Forecast = append(ret.Forecast, d)
 		}
 	}
 
-	return ret
+	return ret, nil
 }
 
 func init() {
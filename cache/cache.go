@@ -0,0 +1,273 @@
+// Package cache keeps data fetched by wego on disk, so later invocations can
+// reuse or compare against it.
+package cache
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"time"
+
+	"github.com/nafiz1001/wego/metrics"
+)
+
+// MaxSize is the size in bytes the cache directory is kept below by removing
+// the least recently written files after every write, or 0 to let it grow.
+var MaxSize int64
+
+var unsafeChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+var lookups = metrics.NewCounter("wego_cache_lookups_total", "Lookups of stored values and files by result: hit or miss.", "result")
+
+// countLookup counts a lookup which failed with err as miss.
+func countLookup(err error) {
+	if err != nil {
+		lookups.Inc("miss")
+	} else {
+		lookups.Inc("hit")
+	}
+}
+
+// Dir returns the directory wego stores its cache files in.
+func Dir() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("unable to find cache directory: %v", err)
+	}
+	return filepath.Join(dir, "wego"), nil
+}
+
+// stateDir is the subdirectory of the cache directory with the values of
+// StoreState, which are not removed to keep the cache below MaxSize.
+const stateDir = "state"
+
+func path(key string, ext string) (string, error) {
+	return pathIn("", key, ext)
+}
+
+func pathIn(sub, key, ext string) (string, error) {
+	dir, err := Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, sub, unsafeChars.ReplaceAllString(key, "_")+ext), nil
+}
+
+// ForecastKey returns the cache key under which the forecast for location
+// fetched from backend is stored.
+func ForecastKey(backend, location string) string {
+	return "forecast-" + backend + "-" + location
+}
+
+// HistoryKey returns the cache key under which the daily summaries of past
+// days for location fetched from backend are stored.
+func HistoryKey(backend, location string) string {
+	return "history-" + backend + "-" + location
+}
+
+// Load decodes the value stored under key into v and returns the time it was
+// stored at.
+func Load(key string, v interface{}) (time.Time, error) {
+	p, err := path(key, ".json")
+	if err != nil {
+		return time.Time{}, err
+	}
+	return load(p, v)
+}
+
+// LoadState is Load for the values stored with StoreState.
+func LoadState(key string, v interface{}) (time.Time, error) {
+	p, err := pathIn(stateDir, key, ".json")
+	if err != nil {
+		return time.Time{}, err
+	}
+	return load(p, v)
+}
+
+func load(p string, v interface{}) (_ time.Time, err error) {
+	defer func() { countLookup(err) }()
+	f, err := os.Open(p)
+	if err != nil {
+		return time.Time{}, err
+	}
+	defer f.Close()
+
+	fi, err := f.Stat()
+	if err != nil {
+		return time.Time{}, err
+	}
+	if err = json.NewDecoder(f).Decode(v); err != nil {
+		return time.Time{}, fmt.Errorf("unable to decode %s: %v", p, err)
+	}
+	return fi.ModTime(), nil
+}
+
+// Store encodes v as json and saves it under key, replacing any previously
+// stored value.
+func Store(key string, v interface{}) error {
+	p, err := path(key, ".json")
+	if err != nil {
+		return err
+	}
+	return store(p, v)
+}
+
+// StoreState is Store for state which must be kept, like the alerts the daemon
+// notified of. It is never removed to keep the cache below MaxSize.
+func StoreState(key string, v interface{}) error {
+	p, err := pathIn(stateDir, key, ".json")
+	if err != nil {
+		return err
+	}
+	return store(p, v)
+}
+
+func store(p string, v interface{}) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return write(p, b)
+}
+
+// OpenFile opens the file stored under key with CreateFile and
+// returns the time it was stored at, to read it without loading it into
+// memory.
+func OpenFile(key string, ext string) (_ *os.File, _ time.Time, err error) {
+	defer func() { countLookup(err) }()
+	p, err := path(key, ext)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	f, err := os.Open(p)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, time.Time{}, err
+	}
+	return f, fi.ModTime(), nil
+}
+
+// FileWriter writes a file to store under a key while its content is
+// produced, e.g. read from a response, without keeping it in memory. Commit
+// replaces the stored file with it, Close discards it unless it is
+// committed.
+type FileWriter struct {
+	tmp  *os.File
+	p    string
+	done bool
+}
+
+// CreateFile returns a FileWriter for the file stored under key with the file
+// name extension ext.
+func CreateFile(key string, ext string) (*FileWriter, error) {
+	p, err := path(key, ext)
+	if err != nil {
+		return nil, err
+	}
+	return create(p)
+}
+
+func create(p string) (*FileWriter, error) {
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		return nil, fmt.Errorf("unable to create cache directory: %v", err)
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(p), filepath.Base(p))
+	if err != nil {
+		return nil, err
+	}
+	return &FileWriter{tmp: tmp, p: p}, nil
+}
+
+func (w *FileWriter) Write(b []byte) (int, error) {
+	return w.tmp.Write(b)
+}
+
+// Commit replaces the stored file atomically, so readers never see a
+// partially written file, and returns the time it was stored at.
+func (w *FileWriter) Commit() (time.Time, error) {
+	w.done = true
+	// sync before the rename, so after a crash or power loss the file is
+	// either the old or the new one, never a truncated one
+	err := w.tmp.Sync()
+	if cerr := w.tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(w.tmp.Name(), w.p)
+	}
+	var fi os.FileInfo
+	if err == nil {
+		fi, err = os.Stat(w.p)
+	}
+	if err != nil {
+		os.Remove(w.tmp.Name())
+		return time.Time{}, err
+	}
+	if MaxSize > 0 {
+		if dir, err := Dir(); err == nil {
+			prune(dir, w.p)
+		}
+	}
+	return fi.ModTime(), nil
+}
+
+// Close discards the file unless it is committed.
+func (w *FileWriter) Close() error {
+	if w.done {
+		return nil
+	}
+	w.done = true
+	w.tmp.Close()
+	return os.Remove(w.tmp.Name())
+}
+
+// write replaces the file at p with b.
+func write(p string, b []byte) error {
+	w, err := create(p)
+	if err != nil {
+		return err
+	}
+	defer w.Close()
+	if _, err := w.Write(b); err != nil {
+		return err
+	}
+	_, err = w.Commit()
+	return err
+}
+
+// prune removes the least recently written files of dir except keep until
+// the files take no more than MaxSize bytes. The values of StoreState are in a
+// subdirectory, which it leaves alone.
+func prune(dir, keep string) {
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return
+	}
+	var size int64
+	for _, fi := range entries {
+		if !fi.IsDir() {
+			size += fi.Size()
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].ModTime().Before(entries[j].ModTime()) })
+	for _, fi := range entries {
+		if size <= MaxSize {
+			return
+		}
+		p := filepath.Join(dir, fi.Name())
+		if fi.IsDir() || p == keep {
+			continue
+		}
+		if os.Remove(p) == nil {
+			size -= fi.Size()
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package cache
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestPruneKeepsState checks that keeping the cache below MaxSize removes the
+// oldest values but no state.
+func TestPruneKeepsState(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	defer func(size int64) { MaxSize = size }(MaxSize)
+	MaxSize = 0
+
+	big := strings.Repeat("x", 1000)
+	if err := StoreState("alerts-Berlin", map[string]string{"id": "Severe"}); err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{"a", "b", "c"} {
+		if err := Store(key, big); err != nil {
+			t.Fatal(err)
+		}
+	}
+	MaxSize = 2500
+	if err := Store("d", big); err != nil {
+		t.Fatal(err)
+	}
+
+	var v string
+	for key, kept := range map[string]bool{"a": false, "b": false, "c": true, "d": true} {
+		if _, err := Load(key, &v); (err == nil) != kept {
+			t.Errorf("Load(%s): %v, want it kept: %v", key, err, kept)
+		}
+	}
+	var seen map[string]string
+	if _, err := LoadState("alerts-Berlin", &seen); err != nil || seen["id"] != "Severe" {
+		t.Errorf("LoadState = %v, %v", seen, err)
+	}
+}
+
+func TestFileWriter(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	dir, err := Dir()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a file closed without a commit is discarded
+	w, err := CreateFile("list", ".csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Write([]byte("partial"))
+	w.Close()
+	if _, _, err := OpenFile("list", ".csv"); !os.IsNotExist(err) {
+		t.Errorf("OpenFile of a discarded file: %v", err)
+	}
+
+	w, err = CreateFile("list", ".csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	w.Write([]byte("a,b\n"))
+	stored, err := w.Commit()
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, got, err := OpenFile("list", ".csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	if !got.Equal(stored) {
+		t.Errorf("OpenFile returned the time %v, Commit %v", got, stored)
+	}
+	if files, _ := filepath.Glob(filepath.Join(dir, "*")); len(files) != 1 {
+		t.Errorf("cache directory holds %v, want only the committed file", files)
+	}
+}
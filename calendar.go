@@ -0,0 +1,176 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	colorable "github.com/mattn/go-colorable"
+	"github.com/nafiz1001/wego/cache"
+	"github.com/nafiz1001/wego/frontends"
+	"github.com/nafiz1001/wego/iface"
+)
+
+// calendarDays is the number of days shown before and after today.
+const calendarDays = 30
+
+// calendarMetric is set by the -calendar-metric flag and selects what the
+// calendar cells are colored by: "temp" or "precip".
+var calendarMetric string
+
+// historyDay is the summary of a single day kept in the history cache.
+type historyDay struct {
+	// MaxTempC and MinTempC are the highest and lowest temperature of the day
+	// in degrees celsius.
+	MaxTempC *float32
+	MinTempC *float32
+
+	// PrecipM is the total precipitation of the day in meters(!).
+	PrecipM *float32
+}
+
+// summarizeDay returns the summary of d, totaling the precipitation rates of
+// its slots weighted by their length like the totals of the frontends.
+func summarizeDay(d iface.Day) (ret historyDay) {
+	ret.MaxTempC, ret.MinTempC = d.MaxTempC, d.MinTempC
+	if len(d.Slots) == 0 {
+		return
+	}
+	for i, c := range d.Slots {
+		if c.PrecipM != nil {
+			if ret.PrecipM == nil {
+				ret.PrecipM = new(float32)
+			}
+			*ret.PrecipM += *c.PrecipM * iface.SlotHours(d.Slots, i)
+		}
+	}
+	return
+}
+
+// recordHistory remembers the summaries of today and earlier days of r, so the
+// calendar can show them once they are no longer part of the forecast.
+func recordHistory(backend, location string, r iface.Data) error {
+	key := cache.HistoryKey(backend, location)
+	history := make(map[string]historyDay)
+	if _, err := cache.Load(key, &history); err != nil {
+		history = make(map[string]historyDay)
+	}
+
+	today := iface.Now().Format("2006-01-02")
+	for _, d := range r.Forecast {
+		date := d.Date.Format("2006-01-02")
+		if date > today {
+			continue
+		}
+		if s := summarizeDay(d); s.MaxTempC != nil || s.PrecipM != nil {
+			history[date] = s
+		}
+	}
+
+	// keep a little more than a year
+	oldest := iface.Now().AddDate(-1, 0, -calendarDays).Format("2006-01-02")
+	for date := range history {
+		if date < oldest {
+			delete(history, date)
+		}
+	}
+	return cache.Store(key, history)
+}
+
+// precipColor returns the 256-color palette index for a daily precipitation
+// total.
+func precipColor(precipM float32) int {
+	colmap := []struct {
+		maxMM float32
+		color int
+	}{
+		{0.1, 252}, {1, 153}, {5, 117}, {10, 75}, {20, 33},
+	}
+	for _, candidate := range colmap {
+		if precipM*1000 < candidate.maxMM {
+			return candidate.color
+		}
+	}
+	return 21
+}
+
+func calendarCell(s historyDay, ok bool) (color int, known bool) {
+	if !ok {
+		return 0, false
+	}
+	if calendarMetric == "precip" {
+		if s.PrecipM == nil {
+			return 0, false
+		}
+		return precipColor(*s.PrecipM), true
+	}
+	if s.MaxTempC == nil {
+		return 0, false
+	}
+	return frontends.TempColor(*s.MaxTempC), true
+}
+
+func calendarLegend(unit iface.UnitSystem) string {
+	var parts []string
+	if calendarMetric == "precip" {
+		for _, mm := range []float32{0, 0.5, 2, 7, 15, 25} {
+			v, u := unit.Precip(mm / 1000)
+			parts = append(parts, fmt.Sprintf("\033[48;5;%dm  \033[0m %.1f %s", precipColor(mm/1000), v, u))
+		}
+	} else {
+		for _, t := range []float32{-15, -5, 0, 5, 10, 15, 20, 25, 30, 35} {
+			v, u := unit.Temp(t)
+			parts = append(parts, fmt.Sprintf("\033[48;5;%dm  \033[0m %d%s", frontends.TempColor(t), int(v), u))
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// runCalendar shows the days around today as a calendar colored by the daily
+// high or precipitation. Past days come from the history cache, today and
+// later days from a freshly fetched forecast.
+func runCalendar(backend string, location string, numdays int, unit iface.UnitSystem) {
+	if calendarMetric != "temp" && calendarMetric != "precip" {
+		log.Fatalf("Unknown calendar metric %q, choices are: temp, precip", calendarMetric)
+	}
+
+	r := fetch(backend, location, numdays)
+	days := make(map[string]historyDay)
+	if _, err := cache.Load(cache.HistoryKey(backend, location), &days); err != nil {
+		days = make(map[string]historyDay)
+	}
+	for _, d := range r.Forecast {
+		days[d.Date.Format("2006-01-02")] = summarizeDay(d)
+	}
+
+	now := iface.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+	first := today.AddDate(0, 0, -calendarDays)
+	first = first.AddDate(0, 0, -(int(first.Weekday())+6)%7) // monday
+	last := today.AddDate(0, 0, calendarDays)
+
+	stdout := colorable.NewColorableStdout()
+	what := "Daily high"
+	if calendarMetric == "precip" {
+		what = "Daily precipitation"
+	}
+	fmt.Fprintf(stdout, "%s for %s\n\n", what, r.Location)
+	fmt.Fprintln(stdout, "        Mo Tu We Th Fr Sa Su")
+	for week := first; !week.After(last); week = week.AddDate(0, 0, 7) {
+		line := week.Format("Jan 02") + " "
+		for d := week; d.Before(week.AddDate(0, 0, 7)); d = d.AddDate(0, 0, 1) {
+			s, ok := days[d.Format("2006-01-02")]
+			style := "\033[38;5;240m"
+			if col, known := calendarCell(s, ok); known {
+				style = fmt.Sprintf("\033[48;5;%dm\033[38;5;16m", col)
+			}
+			if d.Equal(today) {
+				style += "\033[1;4m"
+			}
+			line += fmt.Sprintf(" %s%2d\033[0m", style, d.Day())
+		}
+		fmt.Fprintln(stdout, line)
+	}
+	fmt.Fprintf(stdout, "\n%s\n", calendarLegend(unit))
+}
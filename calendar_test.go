@@ -0,0 +1,42 @@
+package main
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/nafiz1001/wego/iface"
+)
+
+func TestSummarizeDay(t *testing.T) {
+	day := time.Date(2026, 3, 14, 0, 0, 0, 0, time.UTC)
+	slot := func(hour int, mmPerHour float32) iface.Cond {
+		m := mmPerHour / 1000
+		return iface.Cond{Time: day.Add(time.Duration(hour) * time.Hour), PrecipM: &m}
+	}
+
+	tests := []struct {
+		slots []iface.Cond
+		want  float32 // mm
+	}{
+		// 1 mm/h from 6:00 to 12:00, 2 mm/h from 12:00 to 18:00 and as long
+		// after it
+		{[]iface.Cond{slot(6, 1), slot(12, 2), slot(18, 2)}, 6 + 12 + 12},
+		// hourly slots in the morning, then 6 hourly ones
+		{[]iface.Cond{slot(5, 1), slot(6, 1), slot(12, 0), slot(18, 0.5)}, 1 + 6 + 0 + 3},
+		{[]iface.Cond{slot(12, 3)}, 3},
+		{[]iface.Cond{{Time: day}}, -1},
+	}
+	for i, tt := range tests {
+		got := summarizeDay(iface.Day{Date: day, Slots: tt.slots})
+		if tt.want < 0 {
+			if got.PrecipM != nil {
+				t.Errorf("%d: precipitation %v, want none", i, *got.PrecipM)
+			}
+		} else if got.PrecipM == nil {
+			t.Errorf("%d: no precipitation, want %v mm", i, tt.want)
+		} else if mm := *got.PrecipM * 1000; math.Abs(float64(mm-tt.want)) > 1e-3 {
+			t.Errorf("%d: precipitation %v mm, want %v mm", i, mm, tt.want)
+		}
+	}
+}
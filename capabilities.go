@@ -0,0 +1,70 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/nafiz1001/wego/iface"
+)
+
+// capabilities returns the capabilities of a backend or frontend, or a zero
+// value for ones not describing themselves.
+func capabilities(v interface{}) iface.Capabilities {
+	if c, ok := v.(iface.Capable); ok {
+		return c.Capabilities()
+	}
+	return iface.Capabilities{}
+}
+
+// yesNo returns "yes" or "no" for the capability matrix.
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
+
+// runBackends prints the registered backends with their capabilities.
+func runBackends(backend string, location string, numdays int, unit iface.UnitSystem) {
+	var names []string
+	for name := range iface.AllBackends {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tAPI KEY\tHOURLY\tALERTS\tHISTORY\tDAYS\tDESCRIPTION")
+	for _, name := range names {
+		c := capabilities(iface.AllBackends[name])
+		key := "-"
+		if c.APIKeyFlag != "" {
+			key = "-" + c.APIKeyFlag
+		}
+		days := "any"
+		if c.MaxDays > 0 {
+			days = strconv.Itoa(c.MaxDays)
+		}
+		_, history := iface.AllBackends[name].(iface.HistoryBackend)
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", name, key, yesNo(c.Hourly), yesNo(c.Alerts), yesNo(history), days, c.Description)
+	}
+	w.Flush()
+}
+
+// runFrontends prints the registered frontends with their descriptions.
+func runFrontends(backend string, location string, numdays int, unit iface.UnitSystem) {
+	var names []string
+	for name := range iface.AllFrontends {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tDESCRIPTION")
+	for _, name := range names {
+		fmt.Fprintf(w, "%s\t%s\n", name, capabilities(iface.AllFrontends[name]).Description)
+	}
+	w.Flush()
+}
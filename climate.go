@@ -0,0 +1,273 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"net/url"
+	"strings"
+	"time"
+
+	colorable "github.com/mattn/go-colorable"
+	"github.com/nafiz1001/wego/cache"
+	"github.com/nafiz1001/wego/frontends"
+	"github.com/nafiz1001/wego/iface"
+)
+
+// climateSparkline is set by the -climate-sparkline flag.
+var climateSparkline bool
+
+// monthNormal is the climate normal of a month: the means of the daily highs
+// and lows and the mean total precipitation in mm.
+type monthNormal struct {
+	MaxC     float32
+	MinC     float32
+	PrecipMm float32
+}
+
+// monthActual is what the history cache knows about a month, nil if no day of
+// it is known.
+type monthActual struct {
+	Month    time.Time
+	Days     int
+	MaxC     *float32
+	MinC     *float32
+	PrecipMm *float32
+}
+
+// fetchMonthlyNormals computes the monthly normals at loc of normalsStart to
+// normalsEnd from the daily values of the Open-Meteo climate API.
+func fetchMonthlyNormals(loc iface.LatLon) (*[12]monthNormal, error) {
+	u, err := url.Parse(normalsURL)
+	if err != nil {
+		return nil, err
+	}
+	q := u.Query()
+	q.Set("latitude", fmt.Sprintf("%.2f", loc.Latitude))
+	q.Set("longitude", fmt.Sprintf("%.2f", loc.Longitude))
+	q.Set("start_date", normalsStart)
+	q.Set("end_date", normalsEnd)
+	q.Set("daily", "temperature_2m_max,temperature_2m_min,precipitation_sum")
+	u.RawQuery = q.Encode()
+
+	var resp struct {
+		Daily struct {
+			Time   []string   `json:"time"`
+			Max    []*float32 `json:"temperature_2m_max"`
+			Min    []*float32 `json:"temperature_2m_min"`
+			Precip []*float32 `json:"precipitation_sum"`
+		} `json:"daily"`
+	}
+	if err := fetchFeed(u.String(), func(r io.Reader) error {
+		return json.NewDecoder(r).Decode(&resp)
+	}); err != nil {
+		return nil, err
+	}
+	d := resp.Daily
+	if len(d.Max) != len(d.Time) || len(d.Min) != len(d.Time) || len(d.Precip) != len(d.Time) {
+		return nil, fmt.Errorf("%s: malformed daily data", normalsURL)
+	}
+
+	var sum [12][3]float64
+	var n [12][3]int
+	for i, date := range d.Time {
+		t, err := time.Parse("2006-01-02", date)
+		if err != nil {
+			continue
+		}
+		m := t.Month() - 1
+		for j, v := range []*float32{d.Max[i], d.Min[i], d.Precip[i]} {
+			if v != nil {
+				sum[m][j] += float64(*v)
+				n[m][j]++
+			}
+		}
+	}
+
+	var ret [12]monthNormal
+	for m := range ret {
+		if n[m][0] == 0 || n[m][1] == 0 || n[m][2] == 0 {
+			return nil, fmt.Errorf("%s: no data for %s", normalsURL, time.Month(m+1))
+		}
+		ret[m].MaxC = float32(sum[m][0] / float64(n[m][0]))
+		ret[m].MinC = float32(sum[m][1] / float64(n[m][1]))
+		// the mean daily precipitation times the days of the month, so
+		// missing days do not lower the total
+		days := time.Date(2001, time.Month(m+2), 0, 0, 0, 0, 0, time.UTC).Day()
+		ret[m].PrecipMm = float32(sum[m][2] / float64(n[m][2]) * float64(days))
+	}
+	return &ret, nil
+}
+
+// monthlyNormals returns the monthly normals at loc, from the cache if they
+// were fetched before, as they only depend on the location.
+func monthlyNormals(loc iface.LatLon) (*[12]monthNormal, error) {
+	key := fmt.Sprintf("climate-%.2f,%.2f", loc.Latitude, loc.Longitude)
+	var ret *[12]monthNormal
+	if _, err := cache.Load(key, &ret); err == nil && ret != nil {
+		return ret, nil
+	}
+	ret, err := fetchMonthlyNormals(loc)
+	if err != nil {
+		return nil, err
+	}
+	if err := cache.Store(key, ret); err != nil {
+		log.Printf("Unable to cache the climate normals: %v", err)
+	}
+	return ret, nil
+}
+
+// monthlyActuals sums up the days of the history by month for the 12 months
+// up to the one of now, oldest first.
+func monthlyActuals(history map[string]historyDay, now time.Time) []monthActual {
+	first := time.Date(now.Year(), now.Month()-11, 1, 0, 0, 0, 0, time.UTC)
+	ret := make([]monthActual, 12)
+	var sum [12][2]float32
+	var n [12][2]int
+	for i := range ret {
+		ret[i].Month = first.AddDate(0, i, 0)
+	}
+	for date, d := range history {
+		t, err := time.Parse("2006-01-02", date)
+		if err != nil || t.Before(first) {
+			continue
+		}
+		i := (t.Year()-first.Year())*12 + int(t.Month()-first.Month())
+		if i < 0 || i >= 12 {
+			continue
+		}
+		ret[i].Days++
+		if d.MaxTempC != nil && d.MinTempC != nil {
+			sum[i][0] += *d.MaxTempC
+			sum[i][1] += *d.MinTempC
+			n[i][0]++
+		}
+		if d.PrecipM != nil {
+			if ret[i].PrecipMm == nil {
+				ret[i].PrecipMm = new(float32)
+			}
+			*ret[i].PrecipMm += *d.PrecipM * 1000
+		}
+	}
+	for i := range ret {
+		if n[i][0] > 0 {
+			max, min := sum[i][0]/float32(n[i][0]), sum[i][1]/float32(n[i][0])
+			ret[i].MaxC, ret[i].MinC = &max, &min
+		}
+	}
+	return ret
+}
+
+// sparkline returns a bar of ▁ to █ for every value scaled between lo and
+// hi, and a space for unknown ones.
+func sparkline(values []*float32, lo, hi float32) string {
+	bars := []rune("▁▂▃▄▅▆▇█")
+	var b strings.Builder
+	for _, v := range values {
+		if v == nil {
+			b.WriteString("  ")
+			continue
+		}
+		i := 0
+		if hi > lo {
+			i = int((*v-lo)/(hi-lo)*float32(len(bars)-1) + 0.5)
+		}
+		b.WriteRune(bars[i])
+		b.WriteRune(bars[i])
+	}
+	return b.String()
+}
+
+// printClimateSparklines prints the normals and actuals of each quantity as
+// a pair of sparklines over the months, sharing their scale.
+func printClimateSparklines(w io.Writer, norm *[12]monthNormal, actual []monthActual) {
+	var months strings.Builder
+	for _, a := range actual {
+		months.WriteString(a.Month.Format("Jan")[:2])
+	}
+	fmt.Fprintf(w, "%-20s %s\n", "", months.String())
+	for _, q := range []struct {
+		name   string
+		normal func(monthNormal) float32
+		actual func(monthActual) *float32
+	}{
+		{"high", func(n monthNormal) float32 { return n.MaxC }, func(a monthActual) *float32 { return a.MaxC }},
+		{"low", func(n monthNormal) float32 { return n.MinC }, func(a monthActual) *float32 { return a.MinC }},
+		{"precipitation", func(n monthNormal) float32 { return n.PrecipMm }, func(a monthActual) *float32 { return a.PrecipMm }},
+	} {
+		var normals, actuals []*float32
+		lo, hi := float32(math.Inf(1)), float32(math.Inf(-1))
+		for _, a := range actual {
+			n := q.normal(norm[a.Month.Month()-1])
+			normals = append(normals, &n)
+			actuals = append(actuals, q.actual(a))
+			for _, v := range []*float32{&n, q.actual(a)} {
+				if v != nil && *v < lo {
+					lo = *v
+				}
+				if v != nil && *v > hi {
+					hi = *v
+				}
+			}
+		}
+		fmt.Fprintf(w, "%-20s %s\n", q.name+" normal", sparkline(normals, lo, hi))
+		fmt.Fprintf(w, "%-20s %s\n", q.name+" actual", sparkline(actuals, lo, hi))
+	}
+}
+
+// printClimateTable prints a row per month with the normals next to the
+// actuals, colored like the temperatures of the forecast.
+func printClimateTable(w io.Writer, norm *[12]monthNormal, actual []monthActual, unit iface.UnitSystem) {
+	temp := func(t *float32) string {
+		if t == nil {
+			return "     –"
+		}
+		v, _ := unit.Temp(*t)
+		return fmt.Sprintf("\033[38;5;%03dm%6.1f\033[0m", frontends.TempColor(*t), v)
+	}
+	precip := func(mm *float32) string {
+		if mm == nil {
+			return "      –"
+		}
+		v, _ := unit.Precip(*mm / 1000)
+		return fmt.Sprintf("%7.1f", v)
+	}
+	_, tu := unit.Temp(0)
+	_, pu := unit.Precip(0.001)
+
+	fmt.Fprintf(w, "%-8s  %-14s   %-14s   %s\n", "", "high "+tu, "low "+tu, "precipitation "+pu)
+	fmt.Fprintf(w, "%-8s  %6s  %6s   %6s  %6s   %7s  %7s  %4s\n", "month", "normal", "actual", "normal", "actual", "normal", "actual", "days")
+	for _, a := range actual {
+		n := norm[a.Month.Month()-1]
+		fmt.Fprintf(w, "%-8s  %s  %s   %s  %s   %s  %s  %4d\n", a.Month.Format("Jan 06"),
+			temp(&n.MaxC), temp(a.MaxC), temp(&n.MinC), temp(a.MinC), precip(&n.PrecipMm), precip(a.PrecipMm), a.Days)
+	}
+}
+
+// runClimate compares the monthly climate normals of the location with the
+// last 12 months as recorded in the history cache.
+func runClimate(backend string, location string, numdays int, unit iface.UnitSystem) {
+	r := fetch(backend, location, numdays)
+	if r.GeoLoc == nil {
+		log.Fatal("Unable to get the climate normals: the backend returned no coordinates")
+	}
+	norm, err := monthlyNormals(*r.GeoLoc)
+	if err != nil {
+		log.Fatal("Unable to get the climate normals: ", err)
+	}
+	history := make(map[string]historyDay)
+	if _, err := cache.Load(cache.HistoryKey(backend, location), &history); err != nil {
+		log.Println("No history recorded yet, run wego daily or the daemon to collect it")
+	}
+	actual := monthlyActuals(history, iface.Now())
+
+	stdout := colorable.NewColorableStdout()
+	fmt.Fprintf(stdout, "Climate of %s, normals of %s to %s\n\n", r.Location, normalsStart[:4], normalsEnd[:4])
+	if climateSparkline {
+		printClimateSparklines(stdout, norm, actual)
+		return
+	}
+	printClimateTable(stdout, norm, actual, unit)
+}
@@ -0,0 +1,196 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	colorable "github.com/mattn/go-colorable"
+	"github.com/nafiz1001/wego/iface"
+)
+
+// commuteTimes is set by the -at flag. The config file holds a comma separated
+// list, which is replaced by the times given on the command line.
+type commuteTimes struct {
+	times    []string
+	fromArgs bool
+}
+
+func (c *commuteTimes) String() string {
+	if c == nil {
+		return ""
+	}
+	return strings.Join(c.times, ",")
+}
+
+func (c *commuteTimes) Set(s string) error {
+	// ingo sets the value of the config file before parsing the command line,
+	// both replace the previous times
+	if !flag.Parsed() {
+		c.times = nil
+	} else if !c.fromArgs {
+		c.times, c.fromArgs = nil, true
+	}
+	for _, t := range strings.Split(s, ",") {
+		if t = strings.TrimSpace(t); t == "" {
+			continue
+		}
+		var hour, minute int
+		if _, err := fmt.Sscanf(t, "%d:%d", &hour, &minute); err != nil || hour < 0 || hour > 23 || minute < 0 || minute > 59 {
+			return fmt.Errorf("invalid time %q, use HH:MM", t)
+		}
+		c.times = append(c.times, fmt.Sprintf("%02d:%02d", hour, minute))
+	}
+	return nil
+}
+
+var commuteAt = commuteTimes{times: []string{"08:00", "17:30"}}
+
+// traffic light levels of the commute command, from good to bad
+const (
+	commuteGreen = iota
+	commuteYellow
+	commuteRed
+)
+
+// thresholds of the commute command. Wind chill follows the frostbite risk
+// levels of Environment and Climate Change Canada.
+const (
+	commuteHeavyPrecipM  = 0.004 // per hour
+	commuteLightPrecipM  = 0.0002
+	commuteRainPercent   = 40
+	commuteColdC         = -10
+	commuteFrostbiteC    = -27
+	commutePoorVisibleM  = 1000
+	commuteDenseVisibleM = 200
+	slotToleranceH       = 3
+)
+
+var commuteLights = []struct{ name, color string }{
+	{"green", "\033[38;5;46m"},
+	{"yellow", "\033[38;5;226m"},
+	{"red", "\033[38;5;196m"},
+}
+
+// closestSlot returns the slot of r closest to t or false if no slot is within
+// slotToleranceH hours of it.
+func closestSlot(r iface.Data, t time.Time) (ret iface.Cond, ok bool) {
+	best := slotToleranceH * time.Hour
+	for _, d := range r.Forecast {
+		for _, s := range d.Slots {
+			diff := s.Time.Sub(t)
+			if diff < 0 {
+				diff = -diff
+			}
+			if diff <= best {
+				ret, ok, best = s, true, diff
+			}
+		}
+	}
+	return
+}
+
+// commuteRate rates the precipitation, wind chill and visibility of s. It
+// returns the worst level and a short note for each of them.
+func commuteRate(s iface.Cond, unit iface.UnitSystem) (level int, notes []string) {
+	rate := func(l int, note string) {
+		if l > level {
+			level = l
+		}
+		notes = append(notes, note)
+	}
+
+	precip := commuteGreen
+	switch s.Code {
+	case iface.CodeThunderyHeavyRain, iface.CodeThunderyShowers, iface.CodeThunderySnowShowers,
+		iface.CodeSevereThunderstorm, iface.CodeFreezingRain, iface.CodeHeavySnow, iface.CodeBlowingSnow, iface.CodeHail:
+		precip = commuteRed
+	case iface.CodeHeavyRain, iface.CodeHeavyShowers, iface.CodeLightRain, iface.CodeLightShowers,
+		iface.CodeLightSleet, iface.CodeLightSleetShowers, iface.CodeLightSnow, iface.CodeLightSnowShowers,
+		iface.CodeHeavySnowShowers, iface.CodeIcePellets, iface.CodeRainSnowMix:
+		precip = commuteYellow
+	}
+	desc := strings.ToLower(s.Desc)
+	if desc == "" && precip > commuteGreen {
+		desc = "precipitation"
+	} else if desc == "" {
+		desc = "dry"
+	}
+	if s.PrecipM != nil && *s.PrecipM >= commuteHeavyPrecipM {
+		precip = commuteRed
+	} else if s.PrecipM != nil && *s.PrecipM >= commuteLightPrecipM && precip < commuteYellow {
+		precip = commuteYellow
+	}
+	if s.ChanceOfRainPercent != nil && *s.ChanceOfRainPercent < commuteRainPercent && precip == commuteYellow {
+		precip = commuteGreen
+	}
+	if s.ChanceOfRainPercent != nil {
+		desc += fmt.Sprintf(" %d%%", *s.ChanceOfRainPercent)
+	}
+	rate(precip, desc)
+
+	if s.FeelsLikeC != nil {
+		l := commuteGreen
+		if *s.FeelsLikeC <= commuteFrostbiteC {
+			l = commuteRed
+		} else if *s.FeelsLikeC <= commuteColdC {
+			l = commuteYellow
+		}
+		t, u := unit.Temp(*s.FeelsLikeC)
+		rate(l, fmt.Sprintf("feels %d %s", int(t), u))
+	}
+
+	if s.VisibleDistM != nil {
+		l := commuteGreen
+		if *s.VisibleDistM < commuteDenseVisibleM {
+			l = commuteRed
+		} else if *s.VisibleDistM < commutePoorVisibleM {
+			l = commuteYellow
+		}
+		v, u := unit.Distance(*s.VisibleDistM)
+		rate(l, fmt.Sprintf("visibility %d %s", int(v), u))
+	}
+	return
+}
+
+// runCommute prints a green, yellow or red line for each commute time given
+// with -at, rating precipitation, wind chill and visibility. Times which
+// already passed today refer to tomorrow.
+func runCommute(backend string, location string, numdays int, unit iface.UnitSystem) {
+	if len(commuteAt.times) == 0 {
+		log.Fatal("No commute times given, use -at HH:MM")
+	}
+	if numdays < 2 {
+		numdays = 2
+	}
+	r := fetch(backend, location, numdays)
+
+	now := iface.Now()
+	for _, d := range r.Forecast {
+		if len(d.Slots) > 0 {
+			now = now.In(d.Slots[0].Time.Location())
+			break
+		}
+	}
+
+	stdout := colorable.NewColorableStdout()
+	for _, at := range commuteAt.times {
+		var hour, minute int
+		fmt.Sscanf(at, "%d:%d", &hour, &minute)
+		t := time.Date(now.Year(), now.Month(), now.Day(), hour, minute, 0, 0, now.Location())
+		if t.Before(now) {
+			t = t.AddDate(0, 0, 1)
+		}
+
+		s, ok := closestSlot(r, t)
+		if !ok {
+			fmt.Fprintf(stdout, "%s  \033[38;5;240m●\033[0m no forecast\n", t.Format("Mon 15:04"))
+			continue
+		}
+		level, notes := commuteRate(s, unit)
+		light := commuteLights[level]
+		fmt.Fprintf(stdout, "%s  %s● %-6s\033[0m %s\n", t.Format("Mon 15:04"), light.color, light.name, strings.Join(notes, ", "))
+	}
+}
@@ -0,0 +1,239 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/nafiz1001/wego/iface"
+)
+
+// configFlag is the command line flag selecting the config file. It is not
+// registered with the flag package, since ingo would persist it in the config
+// file it selects.
+const configFlag = "config"
+
+// takeConfigFlag removes -config FILE (or --config=FILE) from os.Args and
+// returns FILE, or an empty string if it is not given.
+func takeConfigFlag() (ret string) {
+	args := []string{os.Args[0]}
+	for i := 1; i < len(os.Args); i++ {
+		a := os.Args[i]
+		name := strings.TrimLeft(a, "-")
+		switch {
+		case a == "--" || !strings.HasPrefix(a, "-"):
+			args = append(args, a)
+		case name == configFlag && i+1 < len(os.Args):
+			ret = os.Args[i+1]
+			i++
+		case strings.HasPrefix(name, configFlag+"="):
+			ret = strings.TrimPrefix(name, configFlag+"=")
+		default:
+			args = append(args, a)
+		}
+	}
+	os.Args = args
+	return
+}
+
+// helpRequested tells whether the usage is asked for on the command line.
+func helpRequested() bool {
+	for _, a := range os.Args[1:] {
+		if a == "-h" || a == "-help" || a == "--help" {
+			return true
+		}
+	}
+	return false
+}
+
+// configPath returns the absolute path of the config file, as ingo looks for
+// it.
+func configPath() (string, error) {
+	if p := os.Getenv("WEGORC"); p != "" {
+		return filepath.Abs(p)
+	}
+	dir, err := iface.ConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "config.toml"), nil
+}
+
+// profileDir returns the directory of the profiles, next to the config file.
+func profileDir() (string, error) {
+	p, err := configPath()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(filepath.Dir(p), "profiles"), nil
+}
+
+// cmdlineFlags returns the values of the flags given on the command line.
+// Flags sharing a value, like -l and -location, count as the same flag.
+func cmdlineFlags() map[flag.Value]bool {
+	given := make(map[flag.Value]bool)
+	for _, a := range os.Args[1:] {
+		if !strings.HasPrefix(a, "-") {
+			continue
+		}
+		if fl := flag.Lookup(strings.SplitN(strings.TrimLeft(a, "-"), "=", 2)[0]); fl != nil {
+			given[fl.Value] = true
+		}
+	}
+	return given
+}
+
+// setCmdlineFlags sets the flags of fs called names which are given in the
+// command line args, before ingo parses all flags.
+func setCmdlineFlags(fs *flag.FlagSet, args []string, names ...string) error {
+	want := make(map[string]bool)
+	for _, name := range names {
+		want[name] = true
+	}
+	for i := 0; i < len(args); i++ {
+		if args[i] == "--" {
+			break
+		}
+		kv := strings.SplitN(strings.TrimLeft(args[i], "-"), "=", 2)
+		fl := fs.Lookup(kv[0])
+		if !strings.HasPrefix(args[i], "-") || !want[kv[0]] || fl == nil {
+			continue
+		}
+		if len(kv) == 1 {
+			if b, ok := fl.Value.(interface{ IsBoolFlag() bool }); ok && b.IsBoolFlag() {
+				kv = append(kv, "true")
+			} else if i+1 < len(args) {
+				i++
+				kv = append(kv, args[i])
+			} else {
+				continue // ingo reports the missing value
+			}
+		}
+		if err := fl.Value.Set(kv[1]); err != nil {
+			return fmt.Errorf("invalid value %q for flag -%s: %v", kv[1], kv[0], err)
+		}
+	}
+	return nil
+}
+
+// applyProfile sets the flags listed in the profile file called name, like
+// the config file one KEY=VALUE per line. Flags given on the command line take
+// precedence.
+func applyProfile(name string) error {
+	dir, err := profileDir()
+	if err != nil {
+		return err
+	}
+	f, err := os.Open(filepath.Join(dir, name))
+	if err != nil {
+		return fmt.Errorf("unable to read profile %s: %v", name, err)
+	}
+	defer f.Close()
+
+	given := cmdlineFlags()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		kv := strings.SplitN(line, "=", 2)
+		key := strings.TrimSpace(kv[0])
+		fl := flag.Lookup(key)
+		if len(kv) != 2 || fl == nil || key == "profile" {
+			return fmt.Errorf("profile %s: invalid line %q", name, line)
+		}
+		if given[fl.Value] {
+			continue
+		}
+		if err := fl.Value.Set(strings.TrimSpace(kv[1])); err != nil {
+			return fmt.Errorf("profile %s: %s: %v", name, key, err)
+		}
+	}
+	return scanner.Err()
+}
+
+// legacyConfigPath returns the path of the config file of older versions.
+func legacyConfigPath() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".wegorc"), nil
+}
+
+// setupConfig selects the config file for ingo: the one given with -config,
+// $WEGORC or the one in the XDG config directory. ~/.wegorc is moved to the
+// latter once if it does not exist yet. If there is no config file yet, the
+// setup wizard creates it when running in a terminal.
+func setupConfig() error {
+	if p := takeConfigFlag(); p != "" {
+		os.Setenv("WEGORC", p)
+	}
+	p, err := configPath()
+	if err != nil {
+		return fmt.Errorf("unable to find the config directory: %v\nYou can set the environment variable WEGORC to point to your config file as a workaround", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		return err
+	}
+	if os.Getenv("WEGORC") == "" {
+		if err := migrateConfig(p); err != nil {
+			return err
+		}
+	}
+	if _, err := os.Stat(p); os.IsNotExist(err) && interactive() && !helpRequested() {
+		// the wizard may look up the location, over the network set up
+		// by the flags on the command line
+		if err := setCmdlineFlags(flag.CommandLine, os.Args[1:], netFlags...); err != nil {
+			return err
+		}
+		if err := setupNetwork(); err != nil {
+			return err
+		}
+		if err := runWizard(os.Stdin, os.Stdout, p); err != nil && err != errWizardSkipped {
+			return err
+		}
+	}
+	return os.Setenv("WEGORC", p)
+}
+
+// migrateConfig moves ~/.wegorc to p unless p exists already, keeping its
+// permissions since it may hold API keys.
+func migrateConfig(p string) error {
+	if _, err := os.Stat(p); err == nil {
+		return nil
+	}
+	old, err := legacyConfigPath()
+	if err != nil {
+		return nil // nothing to migrate without a home directory
+	}
+	fi, err := os.Stat(old)
+	if err != nil {
+		return nil
+	}
+
+	if err := os.Rename(old, p); err != nil {
+		// e.g. the config directory is on another file system
+		b, err := ioutil.ReadFile(old)
+		if err != nil {
+			return err
+		}
+		if err := ioutil.WriteFile(p, b, 0600); err != nil {
+			return err
+		}
+		if err := os.Chmod(p, fi.Mode().Perm()); err != nil {
+			return err
+		}
+		if err := os.Remove(old); err != nil {
+			return err
+		}
+	}
+	log.Printf("Moved the config file from %s to %s", old, p)
+	return nil
+}
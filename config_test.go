@@ -0,0 +1,72 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestConfigPath(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("WEGORC", "")
+
+	tests := []struct {
+		xdg  string
+		want string
+	}{
+		{"", filepath.Join(home, ".config", "wego", "config.toml")},
+		{"relative", filepath.Join(home, ".config", "wego", "config.toml")},
+		{"/xdg", filepath.Join("/xdg", "wego", "config.toml")},
+	}
+	for _, tt := range tests {
+		t.Setenv("XDG_CONFIG_HOME", tt.xdg)
+		if got, err := configPath(); err != nil || got != tt.want {
+			t.Errorf("configPath() with XDG_CONFIG_HOME=%q = %q, %v, want %q", tt.xdg, got, err, tt.want)
+		}
+	}
+
+	t.Setenv("WEGORC", "/etc/wegorc")
+	if got, err := configPath(); err != nil || got != "/etc/wegorc" {
+		t.Errorf("configPath() with WEGORC = %q, %v", got, err)
+	}
+}
+
+func TestMigrateConfig(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	old := filepath.Join(home, ".wegorc")
+	if err := ioutil.WriteFile(old, []byte("owm-api-key=secret\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	p := filepath.Join(home, ".config", "wego", "config.toml")
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := migrateConfig(p); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(old); !os.IsNotExist(err) {
+		t.Errorf("%s still exists: %v", old, err)
+	}
+	fi, err := os.Stat(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fi.Mode().Perm() != 0600 {
+		t.Errorf("the moved config file has mode %v, want 0600", fi.Mode().Perm())
+	}
+
+	// an existing config file is kept
+	if err := ioutil.WriteFile(old, []byte("old\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := migrateConfig(p); err != nil {
+		t.Fatal(err)
+	}
+	if b, _ := ioutil.ReadFile(p); string(b) != "owm-api-key=secret\n" {
+		t.Errorf("the config file was overwritten with %q", b)
+	}
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/nafiz1001/wego/cache"
+	"github.com/nafiz1001/wego/frontends"
+	"github.com/nafiz1001/wego/iface"
+)
+
+// set by the -daemon-interval and -digest-lang flags
+var (
+	daemonInterval time.Duration
+	digestLang     string
+)
+
+// plainText removes the highlighting of diffForecast for logs and mails.
+var plainText = strings.NewReplacer("\033[1m", "", "\033[0m", "")
+
+// gustWarnings returns one line per slot of r with wind or gusts reaching
+// the -gust-limit.
+func gustWarnings(r iface.Data, unit iface.UnitSystem) (ret []string) {
+	if iface.GustLimitKmph <= 0 {
+		return nil
+	}
+	for _, d := range r.Forecast {
+		for _, s := range d.Slots {
+			max := s.WindspeedKmph
+			if s.WindGustKmph != nil && (max == nil || *s.WindGustKmph > *max) {
+				max = s.WindGustKmph
+			}
+			if max != nil && *max >= iface.GustLimitKmph {
+				v, u := unit.Speed(*max)
+				ret = append(ret, fmt.Sprintf("%s: wind up to %d %s reaches the gust limit", s.Time.Format("Mon 15:04"), int(v), u))
+			}
+		}
+	}
+	return
+}
+
+// windChillWarnings returns one line per slot of r with a wind chill at or
+// below the warning criterion of the province of the location, whether or not
+// Environment Canada issued a warning.
+func windChillWarnings(r iface.Data, unit iface.UnitSystem) (ret []string) {
+	limit, province, ok := iface.WindChillLimit(r)
+	if !ok {
+		return nil
+	}
+	l, u := unit.Temp(limit)
+	for _, d := range r.Forecast {
+		for _, s := range d.Slots {
+			if wc, ok := iface.WindChillC(s); ok && wc <= limit {
+				v, _ := unit.Temp(wc)
+				ret = append(ret, fmt.Sprintf("%s: wind chill %d %s reaches the warning criterion of %s (%d %s)", s.Time.Format("Mon 15:04"), int(math.Round(float64(v))), u, iface.ProvinceNames[province], int(l), u))
+			}
+		}
+	}
+	return
+}
+
+// runDaemon keeps fetching the forecast every daemonInterval, so the cache and
+// the history of the calendar stay up to date. Changes to the previous
+// forecast are logged, failed fetches are retried at the next interval. With
+// -notify, notifications are sent for new and upgraded weather alerts. With
+// -metrics, the metrics of wego itself are served for Prometheus.
+//
+// The config file is reloaded on SIGHUP, which also triggers a fetch, and
+// before every fetch if it changed. On SIGTERM or SIGINT the requests in
+// flight are aborted and the daemon exits once the fetch returned, so it never
+// stops halfway through writing the cache or the history.
+func runDaemon(backend string, location string, numdays int, unit iface.UnitSystem) {
+	if daemonInterval < time.Minute {
+		log.Fatal("The daemon interval must be at least one minute")
+	}
+	serveMetrics()
+	config, err := newConfigWatch()
+	if err != nil {
+		log.Println("Unable to watch the config file, changes need a restart:", err)
+	}
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, syscall.SIGTERM, os.Interrupt)
+	go func() {
+		<-stop
+		log.Println("Stopping")
+		cancelNet()
+	}()
+
+	for first := true; ; first = false {
+		if !first {
+			select {
+			case <-netCtx.Done():
+				return
+			case <-time.After(daemonInterval):
+			case <-hup:
+				log.Println("Reloading the config file")
+				if config != nil {
+					config.modTime = time.Time{}
+				}
+			}
+		}
+		if config != nil && config.modified() {
+			backend, location, numdays, unit = reloadDaemonConfig(config, backend, location, numdays, unit)
+		}
+
+		var prev iface.Data
+		_, err := cache.Load(cache.ForecastKey(backend, location), &prev)
+		cur, fetchErr := fetchData(backend, location, numdays)
+		if netCtx.Err() != nil {
+			return
+		}
+		if fetchErr != nil {
+			log.Printf("Unable to fetch the forecast, retrying in %v: %v", daemonInterval, fetchErr)
+			continue
+		}
+		log.Printf("Fetched forecast for %s", cur.Location)
+		for _, w := range append(gustWarnings(cur, unit), windChillWarnings(cur, unit)...) {
+			log.Println(w)
+		}
+		if len(notifyList()) > 0 {
+			if err := checkAlerts(location, cur); err != nil {
+				log.Println("Unable to check for alerts:", err)
+			}
+		}
+		if err == nil {
+			for _, c := range diffForecast(prev, cur, unit) {
+				log.Println(plainText.Replace(c))
+			}
+		}
+	}
+}
+
+// reloadDaemonConfig applies the edits of the config file and returns the
+// arguments of the daemon updated from them. On errors the arguments stay as
+// they are.
+func reloadDaemonConfig(config *configWatch, backend string, location string, numdays int, unit iface.UnitSystem) (string, string, int, iface.UnitSystem) {
+	changed, err := config.reload()
+	if err != nil {
+		log.Println("Unable to reload the config file:", err)
+		return backend, location, numdays, unit
+	}
+	if len(changed) == 0 {
+		return backend, location, numdays, unit
+	}
+	log.Println("Applied changed settings of the config file:", strings.Join(changed, ", "))
+	for _, name := range changed {
+		switch name {
+		case "backend", "b":
+			backend = flagValue("backend")
+		case "location", "l":
+			location = flagValue("location")
+		case "days", "d":
+			if n, err := strconv.Atoi(flagValue("days")); err == nil {
+				numdays = n
+			}
+		case "units", "u", "temp-unit", "wind-unit", "pressure-unit", "precip-unit", "distance-unit":
+			unit = parseUnits(flagValue("units"))
+		}
+	}
+	return backend, location, numdays, unit
+}
+
+// runDigest prints a short plain text digest of the forecast, meant to be run
+// once a day by a timer and read in the journal or a mail: the summary and
+// the changes since the previous fetch.
+func runDigest(backend string, location string, numdays int, unit iface.UnitSystem) {
+	var prev iface.Data
+	fetched, err := cache.Load(cache.ForecastKey(backend, location), &prev)
+	cur := fetch(backend, location, numdays)
+
+	fmt.Printf("Weather for %s\n\n", cur.Location)
+	if s := frontends.Summary(cur, digestLang, iface.Now()); s != "" {
+		fmt.Println(s)
+	}
+	if warnings := append(gustWarnings(cur, unit), windChillWarnings(cur, unit)...); len(warnings) > 0 {
+		fmt.Println()
+		for _, w := range warnings {
+			fmt.Println(w)
+		}
+	}
+	if err != nil {
+		return
+	}
+	if changes := diffForecast(prev, cur, unit); len(changes) > 0 {
+		fmt.Printf("\nChanges since %s:\n", fetched.Format(time.RFC1123))
+		for _, c := range changes {
+			fmt.Println(plainText.Replace(c))
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	colorable "github.com/mattn/go-colorable"
+	"github.com/nafiz1001/wego/cache"
+	"github.com/nafiz1001/wego/iface"
+)
+
+// minimum changes between two forecasts of the same slot to be reported
+const (
+	diffMinTempC       = 1
+	diffMinRainPercent = 10
+	diffMinWindKmph    = 5
+)
+
+func diffSlot(prev, cur iface.Cond, unit iface.UnitSystem) (changes []string) {
+	bold := func(s string) string {
+		return "\033[1m" + s + "\033[0m"
+	}
+
+	if prev.Code != cur.Code {
+		changes = append(changes, fmt.Sprintf("%s→%s", prev.Desc, bold(cur.Desc)))
+	}
+	if prev.TempC != nil && cur.TempC != nil && math.Abs(float64(*cur.TempC-*prev.TempC)) >= diffMinTempC {
+		p, u := unit.Temp(*prev.TempC)
+		c, _ := unit.Temp(*cur.TempC)
+		changes = append(changes, fmt.Sprintf("temperature %d→%s %s", int(p), bold(fmt.Sprint(int(c))), u))
+	}
+	if prev.ChanceOfRainPercent != nil && cur.ChanceOfRainPercent != nil {
+		p, c := *prev.ChanceOfRainPercent, *cur.ChanceOfRainPercent
+		if p-c >= diffMinRainPercent || c-p >= diffMinRainPercent {
+			changes = append(changes, fmt.Sprintf("precip %d%%→%s", p, bold(fmt.Sprintf("%d%%", c))))
+		}
+	}
+	if prev.WindspeedKmph != nil && cur.WindspeedKmph != nil && math.Abs(float64(*cur.WindspeedKmph-*prev.WindspeedKmph)) >= diffMinWindKmph {
+		p, u := unit.Speed(*prev.WindspeedKmph)
+		c, _ := unit.Speed(*cur.WindspeedKmph)
+		changes = append(changes, fmt.Sprintf("wind %d→%s %s", int(p), bold(fmt.Sprint(int(c))), u))
+	}
+	return
+}
+
+// diffForecast compares all slots contained in both forecasts and returns one
+// line per slot which changed noticeably.
+func diffForecast(prev, cur iface.Data, unit iface.UnitSystem) (ret []string) {
+	prevSlots := make(map[int64]iface.Cond)
+	for _, day := range prev.Forecast {
+		for _, slot := range day.Slots {
+			prevSlots[slot.Time.Unix()] = slot
+		}
+	}
+
+	for _, day := range cur.Forecast {
+		for _, slot := range day.Slots {
+			p, ok := prevSlots[slot.Time.Unix()]
+			if !ok {
+				continue
+			}
+			if changes := diffSlot(p, slot, unit); len(changes) > 0 {
+				ret = append(ret, slot.Time.Format("Mon 15:04")+": "+strings.Join(changes, ", "))
+			}
+		}
+	}
+	return
+}
+
+// runDiff fetches a fresh forecast and prints what changed compared to the
+// forecast stored by the previous invocation.
+func runDiff(backend string, location string, numdays int, unit iface.UnitSystem) {
+	var prev iface.Data
+	fetched, err := cache.Load(cache.ForecastKey(backend, location), &prev)
+	cur := fetch(backend, location, numdays)
+	if err != nil {
+		fmt.Printf("No previous forecast for %s to compare with. The current one has been stored.\n", cur.Location)
+		return
+	}
+
+	changes := diffForecast(prev, cur, unit)
+	if len(changes) == 0 {
+		fmt.Printf("No changes for %s since %s.\n", cur.Location, fetched.Format(time.RFC1123))
+		return
+	}
+
+	stdout := colorable.NewColorableStdout()
+	fmt.Fprintf(stdout, "Changes for %s since %s:\n", cur.Location, fetched.Format(time.RFC1123))
+	for _, c := range changes {
+		fmt.Fprintln(stdout, c)
+	}
+}
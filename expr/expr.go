@@ -0,0 +1,584 @@
+// Package expr evaluates the small expressions users can put in the config
+// file to derive their own values from the forecast, like
+//
+//	feels < -25 ? 'FROSTBITE' : ''
+//
+// Values are numbers, strings, booleans and nil, which stands for unknown
+// data. Operators are, from lowest to highest precedence: ?:, ||, &&, == !=,
+// < <= > >=, + -, * / %, and the unary - and !. + also concatenates strings.
+// Operations on nil yield nil, comparisons with nil are false and nil is false
+// as condition. The functions abs, round, min and max work on numbers.
+package expr
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Vars are the values of the variables an expression is evaluated against.
+// Missing variables are an error, unknown values should be nil.
+type Vars map[string]interface{}
+
+// Expr is a parsed expression.
+type Expr struct {
+	src  string
+	root node
+}
+
+func (e *Expr) String() string { return e.src }
+
+type node interface {
+	eval(v Vars) (interface{}, error)
+	variables(add func(string))
+}
+
+// Parse parses src into an expression.
+func Parse(src string) (*Expr, error) {
+	p := &parser{src: src}
+	p.next()
+	root, err := p.ternary()
+	if err != nil {
+		return nil, err
+	}
+	if p.tok.kind != tokEOF {
+		return nil, p.errorf("unexpected %q", p.tok.text)
+	}
+	return &Expr{src, root}, nil
+}
+
+// Eval evaluates the expression with the given variables.
+func (e *Expr) Eval(v Vars) (interface{}, error) {
+	return e.root.eval(v)
+}
+
+// Variables returns the names of the variables the expression uses.
+func (e *Expr) Variables() (ret []string) {
+	seen := make(map[string]bool)
+	e.root.variables(func(name string) {
+		if !seen[name] {
+			seen[name] = true
+			ret = append(ret, name)
+		}
+	})
+	return
+}
+
+// Assignment is a NAME=EXPRESSION pair, e.g. a row defined by the user or a
+// rule of -mark.
+type Assignment struct {
+	Name string
+	Expr *Expr
+}
+
+func (a Assignment) String() string { return a.Name + "=" + a.Expr.String() }
+
+var assignmentName = regexp.MustCompile(`^[\w-]+$`)
+
+// ParseAssignments parses a list of assignments separated by semicolons like
+// "temp = temp - 1.5; frost = feels < -25".
+func ParseAssignments(s string) (ret []Assignment, err error) {
+	for _, def := range split(s) {
+		if def = strings.TrimSpace(def); def == "" {
+			continue
+		}
+		kv := strings.SplitN(def, "=", 2)
+		name := strings.TrimSpace(kv[0])
+		if len(kv) != 2 || !assignmentName.MatchString(name) {
+			return nil, fmt.Errorf("invalid assignment %q, use NAME=EXPRESSION", def)
+		}
+		e, err := Parse(strings.TrimSpace(kv[1]))
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, Assignment{name, e})
+	}
+	return ret, nil
+}
+
+// split splits s at the semicolons outside of quoted strings.
+func split(s string) (ret []string) {
+	var quote rune
+	start := 0
+	for i, r := range s {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '\'' || r == '"':
+			quote = r
+		case r == ';':
+			ret = append(ret, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(ret, s[start:])
+}
+
+// Format returns the result of an evaluation as text: numbers are rounded to
+// one decimal and nil and false are empty.
+func Format(val interface{}) string {
+	switch x := val.(type) {
+	case nil:
+		return ""
+	case bool:
+		if x {
+			return "true"
+		}
+		return ""
+	case float64:
+		return strconv.FormatFloat(math.Round(x*10)/10, 'f', -1, 64)
+	}
+	return fmt.Sprint(val)
+}
+
+// tokens
+
+const (
+	tokEOF = iota
+	tokNum
+	tokStr
+	tokIdent
+	tokOp
+)
+
+type token struct {
+	kind int
+	text string
+	pos  int
+}
+
+type parser struct {
+	src string
+	pos int
+	tok token
+}
+
+func (p *parser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("expression %q at %d: %s", p.src, p.tok.pos+1, fmt.Sprintf(format, args...))
+}
+
+var operators = []string{"||", "&&", "==", "!=", "<=", ">=", "<", ">", "+", "-", "*", "/", "%", "!", "?", ":", "(", ")", ","}
+
+func (p *parser) next() {
+	for p.pos < len(p.src) && unicode.IsSpace(rune(p.src[p.pos])) {
+		p.pos++
+	}
+	start := p.pos
+	if p.pos >= len(p.src) {
+		p.tok = token{tokEOF, "end", start}
+		return
+	}
+
+	c := p.src[p.pos]
+	switch {
+	case c >= '0' && c <= '9' || c == '.':
+		for p.pos < len(p.src) && (p.src[p.pos] >= '0' && p.src[p.pos] <= '9' || p.src[p.pos] == '.') {
+			p.pos++
+		}
+		p.tok = token{tokNum, p.src[start:p.pos], start}
+		return
+	case c == '\'' || c == '"':
+		end := strings.IndexByte(p.src[p.pos+1:], c)
+		if end < 0 {
+			p.tok = token{tokOp, p.src[start:], start} // reported as unexpected
+			p.pos = len(p.src)
+			return
+		}
+		p.pos += end + 2
+		p.tok = token{tokStr, p.src[start+1 : p.pos-1], start}
+		return
+	case c == '_' || unicode.IsLetter(rune(c)):
+		for p.pos < len(p.src) && (p.src[p.pos] == '_' || unicode.IsLetter(rune(p.src[p.pos])) || unicode.IsDigit(rune(p.src[p.pos]))) {
+			p.pos++
+		}
+		p.tok = token{tokIdent, p.src[start:p.pos], start}
+		return
+	}
+	for _, op := range operators {
+		if strings.HasPrefix(p.src[p.pos:], op) {
+			p.pos += len(op)
+			p.tok = token{tokOp, op, start}
+			return
+		}
+	}
+	p.pos++
+	p.tok = token{tokOp, p.src[start:p.pos], start}
+}
+
+func (p *parser) accept(op string) bool {
+	if p.tok.kind == tokOp && p.tok.text == op {
+		p.next()
+		return true
+	}
+	return false
+}
+
+// grammar, from lowest to highest precedence
+
+var binaryLevels = [][]string{
+	{"||"},
+	{"&&"},
+	{"==", "!="},
+	{"<", "<=", ">", ">="},
+	{"+", "-"},
+	{"*", "/", "%"},
+}
+
+func (p *parser) ternary() (node, error) {
+	cond, err := p.binary(0)
+	if err != nil || !p.accept("?") {
+		return cond, err
+	}
+	then, err := p.ternary()
+	if err != nil {
+		return nil, err
+	}
+	if !p.accept(":") {
+		return nil, p.errorf("expected : instead of %q", p.tok.text)
+	}
+	otherwise, err := p.ternary()
+	if err != nil {
+		return nil, err
+	}
+	return ternaryNode{cond, then, otherwise}, nil
+}
+
+func (p *parser) binary(level int) (node, error) {
+	if level == len(binaryLevels) {
+		return p.unary()
+	}
+	left, err := p.binary(level + 1)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op := ""
+		for _, candidate := range binaryLevels[level] {
+			if p.tok.kind == tokOp && p.tok.text == candidate {
+				op = candidate
+			}
+		}
+		if op == "" {
+			return left, nil
+		}
+		p.next()
+		right, err := p.binary(level + 1)
+		if err != nil {
+			return nil, err
+		}
+		left = binaryNode{op, left, right}
+	}
+}
+
+func (p *parser) unary() (node, error) {
+	for _, op := range []string{"-", "!"} {
+		if p.accept(op) {
+			x, err := p.unary()
+			if err != nil {
+				return nil, err
+			}
+			return unaryNode{op, x}, nil
+		}
+	}
+	return p.primary()
+}
+
+func (p *parser) primary() (node, error) {
+	tok := p.tok
+	switch tok.kind {
+	case tokNum:
+		p.next()
+		f, err := strconv.ParseFloat(tok.text, 64)
+		if err != nil {
+			return nil, p.errorf("invalid number %q", tok.text)
+		}
+		return constNode{f}, nil
+	case tokStr:
+		p.next()
+		return constNode{tok.text}, nil
+	case tokIdent:
+		p.next()
+		switch tok.text {
+		case "true":
+			return constNode{true}, nil
+		case "false":
+			return constNode{false}, nil
+		case "nil":
+			return constNode{nil}, nil
+		}
+		if !p.accept("(") {
+			return varNode(tok.text), nil
+		}
+		fn, ok := functions[tok.text]
+		if !ok {
+			return nil, p.errorf("unknown function %q", tok.text)
+		}
+		var args []node
+		for !p.accept(")") {
+			if len(args) > 0 && !p.accept(",") {
+				return nil, p.errorf("expected , or ) instead of %q", p.tok.text)
+			}
+			arg, err := p.ternary()
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, arg)
+		}
+		if fn.args >= 0 && len(args) != fn.args {
+			return nil, p.errorf("%s takes %d arguments", tok.text, fn.args)
+		}
+		return callNode{tok.text, fn.f, args}, nil
+	}
+	if p.accept("(") {
+		x, err := p.ternary()
+		if err != nil {
+			return nil, err
+		}
+		if !p.accept(")") {
+			return nil, p.errorf("expected ) instead of %q", p.tok.text)
+		}
+		return x, nil
+	}
+	return nil, p.errorf("unexpected %q", tok.text)
+}
+
+// evaluation
+
+type constNode struct{ val interface{} }
+
+func (n constNode) eval(Vars) (interface{}, error) { return n.val, nil }
+
+func (n constNode) variables(func(string)) {}
+
+type varNode string
+
+func (n varNode) variables(add func(string)) { add(string(n)) }
+
+func (n varNode) eval(v Vars) (interface{}, error) {
+	val, ok := v[string(n)]
+	if !ok {
+		return nil, fmt.Errorf("unknown variable %q", string(n))
+	}
+	return val, nil
+}
+
+// Truthy tells whether val counts as true in a condition: nil, false, 0 and
+// the empty string do not.
+func Truthy(val interface{}) bool {
+	switch x := val.(type) {
+	case nil:
+		return false
+	case bool:
+		return x
+	case float64:
+		return x != 0
+	case string:
+		return x != ""
+	}
+	return true
+}
+
+type ternaryNode struct{ cond, then, otherwise node }
+
+func (n ternaryNode) variables(add func(string)) {
+	n.cond.variables(add)
+	n.then.variables(add)
+	n.otherwise.variables(add)
+}
+
+func (n ternaryNode) eval(v Vars) (interface{}, error) {
+	c, err := n.cond.eval(v)
+	if err != nil {
+		return nil, err
+	}
+	if Truthy(c) {
+		return n.then.eval(v)
+	}
+	return n.otherwise.eval(v)
+}
+
+type unaryNode struct {
+	op string
+	x  node
+}
+
+func (n unaryNode) variables(add func(string)) { n.x.variables(add) }
+
+func (n unaryNode) eval(v Vars) (interface{}, error) {
+	x, err := n.x.eval(v)
+	if err != nil || x == nil {
+		return nil, err
+	}
+	if n.op == "!" {
+		return !Truthy(x), nil
+	}
+	f, ok := x.(float64)
+	if !ok {
+		return nil, fmt.Errorf("cannot negate %v", x)
+	}
+	return -f, nil
+}
+
+type binaryNode struct {
+	op          string
+	left, right node
+}
+
+func (n binaryNode) variables(add func(string)) {
+	n.left.variables(add)
+	n.right.variables(add)
+}
+
+func (n binaryNode) eval(v Vars) (interface{}, error) {
+	l, err := n.left.eval(v)
+	if err != nil {
+		return nil, err
+	}
+	switch n.op {
+	case "&&":
+		if !Truthy(l) {
+			return false, nil
+		}
+		r, err := n.right.eval(v)
+		return Truthy(r), err
+	case "||":
+		if Truthy(l) {
+			return true, nil
+		}
+		r, err := n.right.eval(v)
+		return Truthy(r), err
+	}
+
+	r, err := n.right.eval(v)
+	if err != nil {
+		return nil, err
+	}
+	switch n.op {
+	case "==":
+		return l != nil && r != nil && l == r, nil
+	case "!=":
+		return l != nil && r != nil && l != r, nil
+	}
+	if l == nil || r == nil {
+		if n.op == "<" || n.op == "<=" || n.op == ">" || n.op == ">=" {
+			return false, nil
+		}
+		return nil, nil
+	}
+
+	if ls, ok := l.(string); ok {
+		rs, ok := r.(string)
+		if !ok && n.op == "+" {
+			return ls + Format(r), nil
+		} else if !ok {
+			return nil, fmt.Errorf("cannot compare %q with %v", ls, r)
+		}
+		switch n.op {
+		case "+":
+			return ls + rs, nil
+		case "<":
+			return ls < rs, nil
+		case "<=":
+			return ls <= rs, nil
+		case ">":
+			return ls > rs, nil
+		case ">=":
+			return ls >= rs, nil
+		}
+		return nil, fmt.Errorf("operator %s does not work on strings", n.op)
+	}
+
+	lf, lok := l.(float64)
+	rf, rok := r.(float64)
+	if !lok || !rok {
+		if rs, ok := r.(string); ok && n.op == "+" {
+			return Format(l) + rs, nil
+		}
+		return nil, fmt.Errorf("operator %s needs numbers, got %v and %v", n.op, l, r)
+	}
+	switch n.op {
+	case "<":
+		return lf < rf, nil
+	case "<=":
+		return lf <= rf, nil
+	case ">":
+		return lf > rf, nil
+	case ">=":
+		return lf >= rf, nil
+	case "+":
+		return lf + rf, nil
+	case "-":
+		return lf - rf, nil
+	case "*":
+		return lf * rf, nil
+	case "/":
+		if rf == 0 {
+			return nil, nil
+		}
+		return lf / rf, nil
+	case "%":
+		if rf == 0 {
+			return nil, nil
+		}
+		return math.Mod(lf, rf), nil
+	}
+	return nil, fmt.Errorf("unknown operator %s", n.op)
+}
+
+var functions = map[string]struct {
+	args int // -1 for any number
+	f    func(args []float64) float64
+}{
+	"abs":   {1, func(a []float64) float64 { return math.Abs(a[0]) }},
+	"round": {1, func(a []float64) float64 { return math.Round(a[0]) }},
+	"min": {-1, func(a []float64) float64 {
+		ret := math.Inf(1)
+		for _, x := range a {
+			ret = math.Min(ret, x)
+		}
+		return ret
+	}},
+	"max": {-1, func(a []float64) float64 {
+		ret := math.Inf(-1)
+		for _, x := range a {
+			ret = math.Max(ret, x)
+		}
+		return ret
+	}},
+}
+
+type callNode struct {
+	name string
+	f    func([]float64) float64
+	args []node
+}
+
+func (n callNode) variables(add func(string)) {
+	for _, a := range n.args {
+		a.variables(add)
+	}
+}
+
+func (n callNode) eval(v Vars) (interface{}, error) {
+	if len(n.args) == 0 {
+		return nil, nil
+	}
+	args := make([]float64, len(n.args))
+	for i, a := range n.args {
+		x, err := a.eval(v)
+		if err != nil || x == nil {
+			return nil, err
+		}
+		f, ok := x.(float64)
+		if !ok {
+			return nil, fmt.Errorf("%s needs numbers, got %v", n.name, x)
+		}
+		args[i] = f
+	}
+	return n.f(args), nil
+}
@@ -0,0 +1,190 @@
+package expr
+
+import (
+	"strings"
+	"testing"
+)
+
+// vars are the variables of the tests, with wind unknown.
+var vars = Vars{
+	"temp":  -12.0,
+	"feels": -27.0,
+	"wind":  nil,
+	"code":  "LightSnow",
+	"day":   true,
+}
+
+func TestEval(t *testing.T) {
+	tests := []struct {
+		src  string
+		want interface{}
+	}{
+		// precedence
+		{"1 + 2 * 3", 7.0},
+		{"(1 + 2) * 3", 9.0},
+		{"10 - 4 - 3", 3.0},
+		{"2 * 3 % 4", 2.0},
+		{"-2 * 3", -6.0},
+		{"!false && false", false},
+		{"1 < 2 == 2 < 3", true},
+		{"true || false && false", true},
+		{"1 + 1 == 2 ? 'yes' : 'no'", "yes"},
+		{"false ? 1 : true ? 2 : 3", 2.0},
+		{"feels < -25 ? 'FROSTBITE' : ''", "FROSTBITE"},
+
+		// nil stands for unknown values
+		{"wind", nil},
+		{"wind + 1", nil},
+		{"-wind", nil},
+		{"!wind", nil},
+		{"wind * 2 > 10", false},
+		{"wind < 10", false},
+		{"wind == nil", false},
+		{"wind != 3", false},
+		{"wind ? 1 : 2", 2.0},
+		{"wind || temp", true},
+		{"abs(wind)", nil},
+		{"min()", nil},
+		{"1 / 0", nil},
+		{"1 % 0", nil},
+
+		// strings and functions
+		{"'gusts ' + 60", "gusts 60"},
+		{"temp + ' °C'", "-12 °C"},
+		{"code == 'LightSnow'", true},
+		{"'a' < 'b'", true},
+		{`"it's"`, "it's"},
+		{"abs(feels)", 27.0},
+		{"round(2.5)", 3.0},
+		{"min(3, temp, 7)", -12.0},
+		{"max(3, temp, 7)", 7.0},
+		{"day && temp < 0", true},
+	}
+	for _, tt := range tests {
+		e, err := Parse(tt.src)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", tt.src, err)
+			continue
+		}
+		got, err := e.Eval(vars)
+		if err != nil {
+			t.Errorf("%q: %v", tt.src, err)
+		} else if got != tt.want {
+			t.Errorf("%q = %#v, want %#v", tt.src, got, tt.want)
+		}
+	}
+}
+
+func TestEvalErrors(t *testing.T) {
+	for _, src := range []string{
+		"code - 1",
+		"code * 'x'",
+		"code < 1",
+		"-code",
+		"true + 1",
+		"abs(code)",
+		"pressure > 1000",
+	} {
+		e, err := Parse(src)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", src, err)
+			continue
+		}
+		if got, err := e.Eval(vars); err == nil {
+			t.Errorf("%q = %#v, want an error", src, got)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, src := range []string{
+		"",
+		"1 +",
+		"(1 + 2",
+		"1 + 2)",
+		"temp ? 1",
+		"'unterminated",
+		"1.2.3",
+		"foo(1)",
+		"abs(1, 2)",
+		"min(1 2)",
+		"temp @ 2",
+		"1 2",
+	} {
+		if _, err := Parse(src); err == nil {
+			t.Errorf("Parse(%q) succeeded, want an error", src)
+		}
+	}
+}
+
+func TestVariables(t *testing.T) {
+	e, err := Parse("temp < 0 ? max(feels, temp) : wind + temp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(e.Variables(), ","); got != "temp,feels,wind" {
+		t.Errorf("Variables() = %s, want temp,feels,wind", got)
+	}
+}
+
+func TestParseAssignments(t *testing.T) {
+	got, err := ParseAssignments("frost = feels < -25 ? 'a;b' : ''; ;gusts=wind > 60")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got[0].String() != "frost=feels < -25 ? 'a;b' : ''" || got[1].String() != "gusts=wind > 60" {
+		t.Errorf("ParseAssignments = %v", got)
+	}
+
+	for _, s := range []string{"frost", "= 1", "a b = 1", "x = (1"} {
+		if _, err := ParseAssignments(s); err == nil {
+			t.Errorf("ParseAssignments(%q) succeeded, want an error", s)
+		}
+	}
+}
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		val  interface{}
+		want string
+	}{
+		{nil, ""},
+		{false, ""},
+		{true, "true"},
+		{2.0, "2"},
+		{-1.25, "-1.3"},
+		{"x", "x"},
+	}
+	for _, tt := range tests {
+		if got := Format(tt.val); got != tt.want {
+			t.Errorf("Format(%#v) = %q, want %q", tt.val, got, tt.want)
+		}
+	}
+}
+
+// FuzzParse checks that no expression makes the parser or the evaluation
+// panic, and that parsed expressions use only the variables they report.
+func FuzzParse(f *testing.F) {
+	for _, src := range []string{
+		"feels < -25 ? 'FROSTBITE' : ''",
+		"gust > 60 ? 'GUSTS ' + gust : ''",
+		"max(temp, feels, 3) % 2 == 1 || !day",
+		"-(-wind) / 0",
+		"'a;b' + \"c\"",
+	} {
+		f.Add(src)
+	}
+	f.Fuzz(func(t *testing.T, src string) {
+		e, err := Parse(src)
+		if err != nil {
+			return
+		}
+		v := Vars{}
+		for _, name := range e.Variables() {
+			v[name] = vars[name]
+		}
+		if _, err := e.Eval(v); err != nil && strings.Contains(err.Error(), "unknown variable") {
+			t.Errorf("%q: %v, but Variables() = %v", src, err, e.Variables())
+		}
+	})
+}
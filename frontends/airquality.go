@@ -0,0 +1,64 @@
+package frontends
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/nafiz1001/wego/iface"
+)
+
+// aqiColors are the colors of the levels of iface.AQICategories, as used by
+// the US EPA: green, yellow, orange, red, purple and maroon.
+var aqiColors = []int{46, 226, 208, 196, 129, 88}
+
+// aqiHorizon is how far ahead haze and smoke slots are annotated with the AQI,
+// as only the current air quality is known.
+const aqiHorizon = 6 * time.Hour
+
+// annotateAQI returns cond with the AQI of aq appended to its description if
+// it is haze or smoke, like "Smoke, AQI 162", unless it is more than
+// aqiHorizon away from now or the AQI is unknown.
+func annotateAQI(cond iface.Cond, aq *iface.AirQuality) iface.Cond {
+	if aq == nil || aq.AQI == nil || cond.Code != iface.CodeHaze && cond.Code != iface.CodeSmoke {
+		return cond
+	}
+	if d := cond.Time.Sub(iface.Now()); !cond.Time.IsZero() && (d > aqiHorizon || d < -aqiHorizon) {
+		return cond
+	}
+	cond.Desc = fmt.Sprintf("%s, AQI %d", cond.Desc, *aq.AQI)
+	return cond
+}
+
+// formatAirQuality returns a line with the air quality of r, like "AQI 42
+// Good (good for outdoor exercise), PM2.5 5.1, PM10 8.3, O₃ 61, NO₂ 12 µg/m³",
+// the AQI and its advice in the color of its level. It is empty unless -aqi
+// is set or if the air quality is unknown.
+func formatAirQuality(r iface.Data) string {
+	aq := r.AirQuality
+	if !iface.ShowAirQuality || aq == nil {
+		return ""
+	}
+	var parts []string
+	if aq.AQI != nil {
+		i := iface.AQICategory(*aq.AQI)
+		c := iface.AQICategories[i]
+		parts = append(parts, fmt.Sprintf("\033[38;5;%03dmAQI %d %s (%s)\033[0m", aqiColors[i], *aq.AQI, c.Name, c.Advice))
+	}
+	for _, p := range []struct {
+		name string
+		v    *float32
+	}{{"PM2.5", aq.PM25Ugm3}, {"PM10", aq.PM10Ugm3}, {"O₃", aq.O3Ugm3}, {"NO₂", aq.NO2Ugm3}} {
+		if p.v != nil {
+			parts = append(parts, p.name+" "+iface.FormatFloat(*p.v, 1))
+		}
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	line := strings.Join(parts, ", ")
+	if len(parts) > 1 || aq.AQI == nil {
+		line += " µg/m³"
+	}
+	return line
+}
@@ -0,0 +1,35 @@
+package frontends
+
+import (
+	"testing"
+	"time"
+
+	"github.com/nafiz1001/wego/iface"
+)
+
+func TestAnnotateAQI(t *testing.T) {
+	now := time.Date(2023, 6, 7, 14, 0, 0, 0, time.UTC)
+	defer func(f func() time.Time) { iface.Now = f }(iface.Now)
+	iface.Now = func() time.Time { return now }
+	aqi := 162
+	aq := &iface.AirQuality{AQI: &aqi}
+
+	tests := []struct {
+		name string
+		cond iface.Cond
+		aq   *iface.AirQuality
+		want string
+	}{
+		{"current", iface.Cond{Code: iface.CodeSmoke, Desc: "Smoke"}, aq, "Smoke, AQI 162"},
+		{"soon", iface.Cond{Time: now.Add(3 * time.Hour), Code: iface.CodeHaze, Desc: "Haze"}, aq, "Haze, AQI 162"},
+		{"tomorrow", iface.Cond{Time: now.Add(24 * time.Hour), Code: iface.CodeSmoke, Desc: "Smoke"}, aq, "Smoke"},
+		{"fog", iface.Cond{Code: iface.CodeFog, Desc: "Fog"}, aq, "Fog"},
+		{"unknown", iface.Cond{Code: iface.CodeSmoke, Desc: "Smoke"}, &iface.AirQuality{}, "Smoke"},
+		{"no -aqi", iface.Cond{Code: iface.CodeSmoke, Desc: "Smoke"}, nil, "Smoke"},
+	}
+	for _, tt := range tests {
+		if got := annotateAQI(tt.cond, tt.aq).Desc; got != tt.want {
+			t.Errorf("%s: description %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
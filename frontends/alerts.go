@@ -0,0 +1,78 @@
+package frontends
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/nafiz1001/wego/iface"
+)
+
+// alertColors are the colors of the alerts by severity, red for severe and
+// extreme ones and orange for moderate ones.
+var alertColors = map[string]string{
+	"Extreme":  "\033[48;5;196;38;5;231;1m",
+	"Severe":   "\033[38;5;196;1m",
+	"Moderate": "\033[38;5;214;1m",
+}
+
+// alertDuration is how long an alert without an end time is assumed to last
+// from the time it takes effect.
+const alertDuration = 24 * time.Hour
+
+// alertActive tells whether a is in effect at t. Alerts without a start time
+// are in effect from now.
+func alertActive(a iface.Alert, t time.Time) bool {
+	start, end := a.Effective, a.Expires
+	if start.IsZero() {
+		start = iface.Now()
+	}
+	if end.IsZero() {
+		end = start.Add(alertDuration)
+	}
+	return !t.Before(start) && t.Before(end)
+}
+
+// slotColor returns the color to highlight the description of cond with: the
+// one of the most severe alert in effect at its time, red for severe weather,
+// or an empty string. alerts are sorted like Data.Alerts.
+func slotColor(cond iface.Cond, alerts []iface.Alert) string {
+	t := cond.Time
+	if t.IsZero() {
+		t = iface.Now()
+	}
+	for _, a := range alerts {
+		if color, ok := alertColors[a.Severity]; ok && alertActive(a, t) {
+			return color
+		}
+	}
+	if cond.Code.Severe() {
+		return alertColors["Severe"]
+	}
+	return ""
+}
+
+// formatAlerts returns one highlighted line per alert of r, like "⚠ SNOWFALL
+// WARNING IN EFFECT (Mon 14:00 – Tue 06:00) https://…", to be shown above the
+// forecast.
+func formatAlerts(r iface.Data) (ret []string) {
+	for _, a := range r.Alerts {
+		line := "⚠ " + a.Headline
+		if !a.Effective.IsZero() && !a.Expires.IsZero() {
+			line += fmt.Sprintf(" (%s – %s)", a.Effective.Format("Mon 15:04"), a.Expires.Format("Mon 15:04"))
+		} else if !a.Effective.IsZero() {
+			line += fmt.Sprintf(" (since %s)", a.Effective.Format("Mon 15:04"))
+		} else if !a.Expires.IsZero() {
+			line += fmt.Sprintf(" (until %s)", a.Expires.Format("Mon 15:04"))
+		}
+		color, ok := alertColors[a.Severity]
+		if !ok {
+			color = "\033[1m"
+		}
+		line = color + line + "\033[0m"
+		if a.URL != "" {
+			line += " " + a.URL
+		}
+		ret = append(ret, line)
+	}
+	return
+}
@@ -0,0 +1,33 @@
+package frontends
+
+import (
+	"testing"
+	"time"
+
+	"github.com/nafiz1001/wego/iface"
+)
+
+func TestSlotColor(t *testing.T) {
+	start := time.Date(2023, 1, 10, 6, 0, 0, 0, time.UTC)
+	alerts := []iface.Alert{
+		{Severity: "Extreme", Headline: "BLIZZARD", Effective: start, Expires: start.Add(6 * time.Hour)},
+		{Severity: "Moderate", Headline: "SNOWFALL", Effective: start},
+	}
+	tests := []struct {
+		name string
+		cond iface.Cond
+		want string
+	}{
+		{"before", iface.Cond{Time: start.Add(-time.Hour)}, ""},
+		{"start", iface.Cond{Time: start}, alertColors["Extreme"]},
+		{"most severe", iface.Cond{Time: start.Add(5 * time.Hour)}, alertColors["Extreme"]},
+		{"no end", iface.Cond{Time: start.Add(6 * time.Hour)}, alertColors["Moderate"]},
+		{"after a day", iface.Cond{Time: start.Add(alertDuration)}, ""},
+		{"severe weather", iface.Cond{Time: start.Add(-time.Hour), Code: iface.CodeHail}, alertColors["Severe"]},
+	}
+	for _, tt := range tests {
+		if got := slotColor(tt.cond, alerts); got != tt.want {
+			t.Errorf("%s: slotColor = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
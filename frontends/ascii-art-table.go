@@ -3,27 +3,50 @@ package frontends
 import (
 	"flag"
 	"fmt"
+	"io"
 	"log"
 	"math"
-	"os"
 	"regexp"
 	"strings"
 	"time"
 
 	"github.com/mattn/go-colorable"
 	"github.com/mattn/go-runewidth"
+	"github.com/nafiz1001/wego/astro"
 	"github.com/nafiz1001/wego/iface"
 )
 
 type aatConfig struct {
-	coords     bool
-	monochrome bool
-	unit       iface.UnitSystem
+	coords       bool
+	monochrome   bool
+	solarSlots   bool
+	banner       bool
+	precipBar    bool
+	totals       bool
+	drying       bool
+	solar        bool
+	rows         customRows
+	windPoints   int
+	windColor    bool
+	windUnit2    string
+	highlightNow bool
+	summaryLang  string
+	themeName    string
+	theme        *aatTheme
+	unit         iface.UnitSystem
+	geo          *iface.LatLon
+	alerts       []iface.Alert
+	airQuality   *iface.AirQuality
+
+	// windChillLimit is the wind chill warning criterion of the province of
+	// the location, or nil if it is unknown.
+	windChillLimit *float32
 }
 
+var ansiEsc = regexp.MustCompile("\033.*?m")
+
 //TODO: replace s parameter with printf interface?
 func aatPad(s string, mustLen int) (ret string) {
-	ansiEsc := regexp.MustCompile("\033.*?m")
 	ret = s
 	realLen := runewidth.StringWidth(ansiEsc.ReplaceAllLiteralString(s, ""))
 	delta := mustLen - realLen
@@ -42,27 +65,125 @@ func aatPad(s string, mustLen int) (ret string) {
 	return
 }
 
-func (c *aatConfig) formatTemp(cond iface.Cond) string {
-	color := func(temp float32) string {
-		colmap := []struct {
-			maxtemp float32
-			color   int
-		}{
-			{-15, 21}, {-12, 27}, {-9, 33}, {-6, 39}, {-3, 45},
-			{0, 51}, {2, 50}, {4, 49}, {6, 48}, {8, 47},
-			{10, 46}, {13, 82}, {16, 118}, {19, 154}, {22, 190},
-			{25, 226}, {28, 220}, {31, 214}, {34, 208}, {37, 202},
+// formatConfidence returns a marker like "●●○" for the given forecast
+// confidence percentage or an empty string if it is unknown.
+func formatConfidence(conf *int) string {
+	if conf == nil {
+		return ""
+	}
+	filled := (*conf + 16) / 33
+	if filled < 0 {
+		filled = 0
+	} else if filled > 3 {
+		filled = 3
+	}
+	return "confidence " + strings.Repeat("●", filled) + strings.Repeat("○", 3-filled)
+}
+
+// formatSources names the backends of the current conditions and the forecast
+// if they are not the same, e.g. " (now: json, forecast: mock)", and the ones
+// of a fallback chain which failed, e.g. " (from met.no, forecast.io failed)".
+func formatSources(r iface.Data) string {
+	var ret string
+	if r.CurrentSource != r.ForecastSource {
+		ret = fmt.Sprintf("now: %s, forecast: %s", r.CurrentSource, r.ForecastSource)
+	} else if len(r.FailedSources) > 0 {
+		ret = "from " + r.ForecastSource
+	}
+	if len(r.FailedSources) > 0 {
+		ret += ", " + strings.Join(r.FailedSources, ", ") + " failed"
+	}
+	if ret == "" {
+		return ""
+	}
+	return " (" + ret + ")"
+}
+
+// formatDayRange formats the high and low temperature of day, e.g. "↑ 24 °C
+// ↓ -4 °C". It is empty if they are unknown.
+func formatDayRange(day iface.Day, unit iface.UnitSystem) string {
+	if day.MaxTempC == nil || day.MinTempC == nil {
+		return ""
+	}
+	high, u := unit.Temp(*day.MaxTempC)
+	low, _ := unit.Temp(*day.MinTempC)
+	return fmt.Sprintf("↑ \033[38;5;%03dm%d\033[0m %s  ↓ \033[38;5;%03dm%d\033[0m %s",
+		tempColor(*day.MaxTempC, day.NormalMaxC), int(high), u, tempColor(*day.MinTempC, day.NormalMinC), int(low), u)
+}
+
+// alignRight pads s with spaces on the left to a width of mustLen.
+func alignRight(s string, mustLen int) string {
+	if w := runewidth.StringWidth(ansiEsc.ReplaceAllLiteralString(s, "")); w < mustLen {
+		return strings.Repeat(" ", mustLen-w) + s
+	}
+	return s
+}
+
+// isNight reports whether cond applies to a time when the sun is down. The
+// backend's opinion is preferred, otherwise it is computed from the location.
+func isNight(cond iface.Cond, geo *iface.LatLon) bool {
+	if cond.IsDay != nil {
+		return !*cond.IsDay
+	}
+	if geo == nil || cond.Time.IsZero() {
+		return false
+	}
+	return !astro.IsDay(cond.Time, float64(geo.Latitude), float64(geo.Longitude))
+}
+
+// TempColor returns the 256-color palette index the frontends use for tempC.
+func TempColor(tempC float32) int {
+	colmap := []struct {
+		maxtemp float32
+		color   int
+	}{
+		{-15, 21}, {-12, 27}, {-9, 33}, {-6, 39}, {-3, 45},
+		{0, 51}, {2, 50}, {4, 49}, {6, 48}, {8, 47},
+		{10, 46}, {13, 82}, {16, 118}, {19, 154}, {22, 190},
+		{25, 226}, {28, 220}, {31, 214}, {34, 208}, {37, 202},
+	}
+
+	for _, candidate := range colmap {
+		if tempC < candidate.maxtemp {
+			return candidate.color
 		}
+	}
+	return 196
+}
 
-		col := 196
-		for _, candidate := range colmap {
-			if temp < candidate.maxtemp {
-				col = candidate.color
-				break
-			}
+// AnomalyColor returns the 256-color palette index used for a temperature
+// diffC degrees warmer (or colder if negative) than normal. Temperatures
+// within a degree of normal are grey.
+func AnomalyColor(diffC float32) int {
+	colmap := []struct {
+		maxdiff float32
+		color   int
+	}{
+		{-10, 21}, {-8, 27}, {-6, 33}, {-4, 39}, {-2, 45}, {-1, 117},
+		{1, 250}, {2, 229}, {4, 226}, {6, 220}, {8, 214}, {10, 208},
+	}
+
+	for _, candidate := range colmap {
+		if diffC < candidate.maxdiff {
+			return candidate.color
 		}
+	}
+	return 196
+}
+
+// tempColor returns the palette index for tempC on the scale selected with
+// -temp-scale. It falls back to the absolute scale if normalC is nil.
+func tempColor(tempC float32, normalC *float32) int {
+	if iface.TempScale == "anomaly" && normalC != nil {
+		return AnomalyColor(tempC - *normalC)
+	}
+	return TempColor(tempC)
+}
+
+func (c *aatConfig) formatTemp(cond iface.Cond) string {
+	color := func(temp float32) string {
 		t, _ := c.unit.Temp(temp)
-		return fmt.Sprintf("\033[38;5;%03dm%d\033[0m", col, int(t))
+		return fmt.Sprintf("\033[38;5;%03dm%d\033[0m", tempColor(temp, cond.NormalTempC), int(t))
 	}
 
 	_, u := c.unit.Temp(0.0)
@@ -73,39 +194,65 @@ func (c *aatConfig) formatTemp(cond iface.Cond) string {
 
 	t := *cond.TempC
 	if cond.FeelsLikeC != nil {
-		fl := *cond.FeelsLikeC
-		return aatPad(fmt.Sprintf("%s (%s) %s", color(t), color(fl), u), 15)
+		fl := color(*cond.FeelsLikeC)
+		if wc, ok := iface.WindChillC(cond); ok && c.windChillLimit != nil && wc <= *c.windChillLimit {
+			v, _ := c.unit.Temp(*cond.FeelsLikeC)
+			fl = fmt.Sprintf("\033[48;5;196;38;5;231;1m%d\033[0m", int(v))
+		}
+		return aatPad(fmt.Sprintf("%s (%s) %s", color(t), fl, u), 15)
 	}
 	return aatPad(fmt.Sprintf("%s %s", color(t), u), 15)
 }
 
+// windColor returns the 256-color palette index used for a wind speed.
+func windColor(spdKmph float32) int {
+	colmap := []struct {
+		maxtemp float32
+		color   int
+	}{
+		{0, 46}, {4, 82}, {7, 118}, {10, 154}, {13, 190},
+		{16, 226}, {20, 220}, {24, 214}, {28, 208}, {32, 202},
+	}
+
+	for _, candidate := range colmap {
+		if spdKmph < candidate.maxtemp {
+			return candidate.color
+		}
+	}
+	return 196
+}
+
+// windArrow returns the arrow pointing where the wind blows to for the
+// direction deg it is blowing from. With 16 points the directions between
+// the 8 main arrows are shown as both neighbouring arrows, e.g. "↓↙" for NNE.
+func windArrow(deg int, points int) string {
+	arrows := []string{"↓", "↙", "←", "↖", "↑", "↗", "→", "↘"}
+	if points != 16 {
+		return arrows[((deg+22)%360)/45]
+	}
+	i := ((deg*2 + 22) % 720) / 45 // index of the 16 points, rounded
+	if i%2 == 0 {
+		return arrows[i/2]
+	}
+	return arrows[i/2] + arrows[(i/2+1)%8]
+}
+
 func (c *aatConfig) formatWind(cond iface.Cond) string {
 	windDir := func(deg *int) string {
 		if deg == nil {
 			return "?"
 		}
-		arrows := []string{"↓", "↙", "←", "↖", "↑", "↗", "→", "↘"}
-		return "\033[1m" + arrows[((*deg+22)%360)/45] + "\033[0m"
+		if c.windColor && cond.WindspeedKmph != nil {
+			return fmt.Sprintf("\033[38;5;%03d;1m%s\033[0m", windColor(*cond.WindspeedKmph), windArrow(*deg, c.windPoints))
+		}
+		return "\033[1m" + windArrow(*deg, c.windPoints) + "\033[0m"
 	}
 	color := func(spdKmph float32) string {
-		colmap := []struct {
-			maxtemp float32
-			color   int
-		}{
-			{0, 46}, {4, 82}, {7, 118}, {10, 154}, {13, 190},
-			{16, 226}, {20, 220}, {24, 214}, {28, 208}, {32, 202},
-		}
-
-		col := 196
-		for _, candidate := range colmap {
-			if spdKmph < candidate.maxtemp {
-				col = candidate.color
-				break
-			}
-		}
-
 		s, _ := c.unit.Speed(spdKmph)
-		return fmt.Sprintf("\033[38;5;%03dm%d\033[0m", col, int(s))
+		if iface.GustLimitKmph > 0 && spdKmph >= iface.GustLimitKmph {
+			return fmt.Sprintf("\033[48;5;196;38;5;231;1m%d\033[0m", int(s))
+		}
+		return fmt.Sprintf("\033[38;5;%03dm%d\033[0m", windColor(spdKmph), int(s))
 	}
 
 	_, u := c.unit.Speed(0.0)
@@ -115,13 +262,40 @@ func (c *aatConfig) formatWind(cond iface.Cond) string {
 	}
 	s := *cond.WindspeedKmph
 
+	speeds := color(s)
+	second, hasSecond := iface.ConvertSpeed(s, c.windUnit2)
+	secondText := fmt.Sprintf("%d %s", int(second), c.windUnit2)
 	if cond.WindGustKmph != nil {
 		if g := *cond.WindGustKmph; g > s {
-			return aatPad(fmt.Sprintf("%s %s – %s %s", windDir(cond.WinddirDegree), color(s), color(g), u), 15)
+			speeds = fmt.Sprintf("%s – %s", color(s), color(g))
+			g2, _ := iface.ConvertSpeed(g, c.windUnit2)
+			secondText = fmt.Sprintf("%d–%d %s", int(second), int(g2), c.windUnit2)
+		}
+	}
+
+	// the second unit is only shown if it fits
+	candidates := []string{fmt.Sprintf("%s %s %s", windDir(cond.WinddirDegree), speeds, u)}
+	if hasSecond {
+		candidates = append([]string{
+			fmt.Sprintf("%s %s %s (%s)", windDir(cond.WinddirDegree), speeds, u, secondText),
+			fmt.Sprintf("%s %s (%s)", windDir(cond.WinddirDegree), speeds, secondText),
+		}, candidates...)
+	}
+	for _, s := range candidates[:len(candidates)-1] {
+		if runewidth.StringWidth(ansiEsc.ReplaceAllLiteralString(s, "")) <= 15 {
+			return aatPad(s, 15)
 		}
 	}
+	return aatPad(candidates[len(candidates)-1], 15)
+}
 
-	return aatPad(fmt.Sprintf("%s %s %s", windDir(cond.WinddirDegree), color(s), u), 15)
+// shortSnowHazard abbreviates the hazards of iface.Cond.SnowHazard to fit in
+// a cell next to a visibility of up to 875 yd.
+func shortSnowHazard(hazard string) string {
+	if hazard == "blowing snow" {
+		return "bl. snow"
+	}
+	return hazard
 }
 
 func (c *aatConfig) formatVisibility(cond iface.Cond) string {
@@ -129,168 +303,287 @@ func (c *aatConfig) formatVisibility(cond iface.Cond) string {
 		return aatPad("", 15)
 	}
 	v, u := c.unit.Distance(*cond.VisibleDistM)
-	return aatPad(fmt.Sprintf("%d %s", int(v), u), 15)
+	if hazard := cond.SnowHazard(); hazard != "" {
+		return aatPad(fmt.Sprintf("\033[38;5;196;1m%s %s %s\033[0m", iface.FormatInt(int(v)), u, shortSnowHazard(hazard)), 15)
+	}
+	return aatPad(fmt.Sprintf("%s %s", iface.FormatInt(int(v)), u), 15)
+}
+
+func (c *aatConfig) formatDrying(cond iface.Cond) string {
+	score, ok := dryingScore(cond)
+	if !ok {
+		return aatPad("", 15)
+	}
+	col := 196
+	switch {
+	case score >= 8:
+		col = 46
+	case score >= 6:
+		col = 118
+	case score >= 3:
+		col = 226
+	}
+	return aatPad(fmt.Sprintf("drying \033[38;5;%03dm%d\033[0m/10", col, score), 15)
 }
 
 func (c *aatConfig) formatRain(cond iface.Cond) string {
 	if cond.PrecipM != nil {
-		v, u := c.unit.Distance(*cond.PrecipM)
+		v, u := c.unit.Precip(*cond.PrecipM)
 		u += "/h" // it's the same in all unit systems
 		if cond.ChanceOfRainPercent != nil {
-			return aatPad(fmt.Sprintf("%.1f %s | %d%%", v, u, *cond.ChanceOfRainPercent), 15)
+			return aatPad(fmt.Sprintf("%s %s | %d%%", iface.FormatFloat(v, 1), u, *cond.ChanceOfRainPercent), 15)
 		}
-		return aatPad(fmt.Sprintf("%.1f %s", v, u), 15)
+		return aatPad(fmt.Sprintf("%s %s", iface.FormatFloat(v, 1), u), 15)
 	} else if cond.ChanceOfRainPercent != nil {
 		return aatPad(fmt.Sprintf("%d%%", *cond.ChanceOfRainPercent), 15)
 	}
 	return aatPad("", 15)
 }
 
+// aatCodes holds the art for every weather code.
+var aatCodes = map[iface.WeatherCode][]string{
+	iface.CodeUnknown: {
+		"    .-.      ",
+		"     __)     ",
+		"    (        ",
+		"     `-᾿     ",
+		"      •      ",
+	},
+	iface.CodeCloudy: {
+		"             ",
+		"\033[38;5;250m     .--.    \033[0m",
+		"\033[38;5;250m  .-(    ).  \033[0m",
+		"\033[38;5;250m (___.__)__) \033[0m",
+		"             ",
+	},
+	iface.CodeFog: {
+		"             ",
+		"\033[38;5;251m _ - _ - _ - \033[0m",
+		"\033[38;5;251m  _ - _ - _  \033[0m",
+		"\033[38;5;251m _ - _ - _ - \033[0m",
+		"             ",
+	},
+	iface.CodeHeavyRain: {
+		"\033[38;5;240;1m     .-.     \033[0m",
+		"\033[38;5;240;1m    (   ).   \033[0m",
+		"\033[38;5;240;1m   (___(__)  \033[0m",
+		"\033[38;5;21;1m  ‚ʻ‚ʻ‚ʻ‚ʻ   \033[0m",
+		"\033[38;5;21;1m  ‚ʻ‚ʻ‚ʻ‚ʻ   \033[0m",
+	},
+	iface.CodeHeavyShowers: {
+		"\033[38;5;226m _`/\"\"\033[38;5;240;1m.-.    \033[0m",
+		"\033[38;5;226m  ,\\_\033[38;5;240;1m(   ).  \033[0m",
+		"\033[38;5;226m   /\033[38;5;240;1m(___(__) \033[0m",
+		"\033[38;5;21;1m   ‚ʻ‚ʻ‚ʻ‚ʻ  \033[0m",
+		"\033[38;5;21;1m   ‚ʻ‚ʻ‚ʻ‚ʻ  \033[0m",
+	},
+	iface.CodeHeavySnow: {
+		"\033[38;5;240;1m     .-.     \033[0m",
+		"\033[38;5;240;1m    (   ).   \033[0m",
+		"\033[38;5;240;1m   (___(__)  \033[0m",
+		"\033[38;5;255;1m   * * * *   \033[0m",
+		"\033[38;5;255;1m  * * * *    \033[0m",
+	},
+	iface.CodeHeavySnowShowers: {
+		"\033[38;5;226m _`/\"\"\033[38;5;240;1m.-.    \033[0m",
+		"\033[38;5;226m  ,\\_\033[38;5;240;1m(   ).  \033[0m",
+		"\033[38;5;226m   /\033[38;5;240;1m(___(__) \033[0m",
+		"\033[38;5;255;1m    * * * *  \033[0m",
+		"\033[38;5;255;1m   * * * *   \033[0m",
+	},
+	iface.CodeLightRain: {
+		"\033[38;5;250m     .-.     \033[0m",
+		"\033[38;5;250m    (   ).   \033[0m",
+		"\033[38;5;250m   (___(__)  \033[0m",
+		"\033[38;5;111m    ʻ ʻ ʻ ʻ  \033[0m",
+		"\033[38;5;111m   ʻ ʻ ʻ ʻ   \033[0m",
+	},
+	iface.CodeLightShowers: {
+		"\033[38;5;226m _`/\"\"\033[38;5;250m.-.    \033[0m",
+		"\033[38;5;226m  ,\\_\033[38;5;250m(   ).  \033[0m",
+		"\033[38;5;226m   /\033[38;5;250m(___(__) \033[0m",
+		"\033[38;5;111m     ʻ ʻ ʻ ʻ \033[0m",
+		"\033[38;5;111m    ʻ ʻ ʻ ʻ  \033[0m",
+	},
+	iface.CodeLightSleet: {
+		"\033[38;5;250m     .-.     \033[0m",
+		"\033[38;5;250m    (   ).   \033[0m",
+		"\033[38;5;250m   (___(__)  \033[0m",
+		"\033[38;5;111m    ʻ \033[38;5;255m*\033[38;5;111m ʻ \033[38;5;255m*  \033[0m",
+		"\033[38;5;255m   *\033[38;5;111m ʻ \033[38;5;255m*\033[38;5;111m ʻ   \033[0m",
+	},
+	iface.CodeLightSleetShowers: {
+		"\033[38;5;226m _`/\"\"\033[38;5;250m.-.    \033[0m",
+		"\033[38;5;226m  ,\\_\033[38;5;250m(   ).  \033[0m",
+		"\033[38;5;226m   /\033[38;5;250m(___(__) \033[0m",
+		"\033[38;5;111m     ʻ \033[38;5;255m*\033[38;5;111m ʻ \033[38;5;255m* \033[0m",
+		"\033[38;5;255m    *\033[38;5;111m ʻ \033[38;5;255m*\033[38;5;111m ʻ  \033[0m",
+	},
+	iface.CodeLightSnow: {
+		"\033[38;5;250m     .-.     \033[0m",
+		"\033[38;5;250m    (   ).   \033[0m",
+		"\033[38;5;250m   (___(__)  \033[0m",
+		"\033[38;5;255m    *  *  *  \033[0m",
+		"\033[38;5;255m   *  *  *   \033[0m",
+	},
+	iface.CodeLightSnowShowers: {
+		"\033[38;5;226m _`/\"\"\033[38;5;250m.-.    \033[0m",
+		"\033[38;5;226m  ,\\_\033[38;5;250m(   ).  \033[0m",
+		"\033[38;5;226m   /\033[38;5;250m(___(__) \033[0m",
+		"\033[38;5;255m     *  *  * \033[0m",
+		"\033[38;5;255m    *  *  *  \033[0m",
+	},
+	iface.CodePartlyCloudy: {
+		"\033[38;5;226m   \\  /\033[0m      ",
+		"\033[38;5;226m _ /\"\"\033[38;5;250m.-.    \033[0m",
+		"\033[38;5;226m   \\_\033[38;5;250m(   ).  \033[0m",
+		"\033[38;5;226m   /\033[38;5;250m(___(__) \033[0m",
+		"             ",
+	},
+	iface.CodeSunny: {
+		"\033[38;5;226m    \\   /    \033[0m",
+		"\033[38;5;226m     .-.     \033[0m",
+		"\033[38;5;226m  ‒ (   ) ‒  \033[0m",
+		"\033[38;5;226m     `-᾿     \033[0m",
+		"\033[38;5;226m    /   \\    \033[0m",
+	},
+	iface.CodeThunderyHeavyRain: {
+		"\033[38;5;240;1m     .-.     \033[0m",
+		"\033[38;5;240;1m    (   ).   \033[0m",
+		"\033[38;5;240;1m   (___(__)  \033[0m",
+		"\033[38;5;21;1m  ‚ʻ\033[38;5;228;5m⚡\033[38;5;21;25mʻ‚\033[38;5;228;5m⚡\033[38;5;21;25m‚ʻ   \033[0m",
+		"\033[38;5;21;1m  ‚ʻ‚ʻ\033[38;5;228;5m⚡\033[38;5;21;25mʻ‚ʻ   \033[0m",
+	},
+	iface.CodeThunderyShowers: {
+		"\033[38;5;226m _`/\"\"\033[38;5;250m.-.    \033[0m",
+		"\033[38;5;226m  ,\\_\033[38;5;250m(   ).  \033[0m",
+		"\033[38;5;226m   /\033[38;5;250m(___(__) \033[0m",
+		"\033[38;5;228;5m    ⚡\033[38;5;111;25mʻ ʻ\033[38;5;228;5m⚡\033[38;5;111;25mʻ ʻ \033[0m",
+		"\033[38;5;111m    ʻ ʻ ʻ ʻ  \033[0m",
+	},
+	iface.CodeThunderySnowShowers: {
+		"\033[38;5;226m _`/\"\"\033[38;5;250m.-.    \033[0m",
+		"\033[38;5;226m  ,\\_\033[38;5;250m(   ).  \033[0m",
+		"\033[38;5;226m   /\033[38;5;250m(___(__) \033[0m",
+		"\033[38;5;255m     *\033[38;5;228;5m⚡\033[38;5;255;25m *\033[38;5;228;5m⚡\033[38;5;255;25m * \033[0m",
+		"\033[38;5;255m    *  *  *  \033[0m",
+	},
+	iface.CodeVeryCloudy: {
+		"             ",
+		"\033[38;5;240;1m     .--.    \033[0m",
+		"\033[38;5;240;1m  .-(    ).  \033[0m",
+		"\033[38;5;240;1m (___.__)__) \033[0m",
+		"             ",
+	},
+	iface.CodeFreezingRain: {
+		"\033[38;5;250m     .-.     \033[0m",
+		"\033[38;5;250m    (   ).   \033[0m",
+		"\033[38;5;250m   (___(__)  \033[0m",
+		"\033[38;5;117m    ʻ ʻ ʻ ʻ  \033[0m",
+		"\033[38;5;159m   ‾‾‾‾‾‾‾   \033[0m",
+	},
+	iface.CodeIcePellets: {
+		"\033[38;5;250m     .-.     \033[0m",
+		"\033[38;5;250m    (   ).   \033[0m",
+		"\033[38;5;250m   (___(__)  \033[0m",
+		"\033[38;5;159m    o  o  o  \033[0m",
+		"\033[38;5;159m   o  o  o   \033[0m",
+	},
+	iface.CodeRainSnowMix: {
+		"\033[38;5;250m     .-.     \033[0m",
+		"\033[38;5;250m    (   ).   \033[0m",
+		"\033[38;5;250m   (___(__)  \033[0m",
+		"\033[38;5;111m    ʻ ʻ ʻ ʻ  \033[0m",
+		"\033[38;5;255m   *  *  *   \033[0m",
+	},
+	iface.CodeBlowingSnow: {
+		"\033[38;5;250m     .-.     \033[0m",
+		"\033[38;5;250m    (   ).   \033[0m",
+		"\033[38;5;250m   (___(__)  \033[0m",
+		"\033[38;5;255m  ~* ~* ~*   \033[0m",
+		"\033[38;5;255m ~* ~* ~*    \033[0m",
+	},
+	iface.CodeHail: {
+		"\033[38;5;196;1m     .-.     \033[0m",
+		"\033[38;5;196;1m    (   ).   \033[0m",
+		"\033[38;5;196;1m   (___(__)  \033[0m",
+		"\033[38;5;255;1m   O  O  O   \033[0m",
+		"\033[38;5;255;1m  O  O  O    \033[0m",
+	},
+	iface.CodeFunnelCloud: {
+		"\033[38;5;196;1m  .-(    ).  \033[0m",
+		"\033[38;5;196;1m (___.__)__) \033[0m",
+		"\033[38;5;240;1m   \\    /    \033[0m",
+		"\033[38;5;240;1m    \\  /     \033[0m",
+		"\033[38;5;240;1m     )(      \033[0m",
+	},
+	iface.CodeSevereThunderstorm: {
+		"\033[38;5;196;1m     .-.     \033[0m",
+		"\033[38;5;196;1m    (   ).   \033[0m",
+		"\033[38;5;196;1m   (___(__)  \033[0m",
+		"\033[38;5;21;1m  ‚ʻ\033[38;5;228;5m⚡\033[38;5;21;25mʻ‚\033[38;5;228;5m⚡\033[38;5;21;25m‚ʻ   \033[0m",
+		"\033[38;5;21;1m  ‚ʻ‚ʻ\033[38;5;228;5m⚡\033[38;5;21;25mʻ‚ʻ   \033[0m",
+	},
+	iface.CodeHaze: {
+		"\033[38;5;226m    \\   /    \033[0m",
+		"\033[38;5;226m     .-.     \033[0m",
+		"\033[38;5;180m  ~ ~ ~ ~ ~  \033[0m",
+		"\033[38;5;180m ~ ~ ~ ~ ~ ~ \033[0m",
+		"\033[38;5;180m  ~ ~ ~ ~ ~  \033[0m",
+	},
+	iface.CodeSmoke: {
+		"\033[38;5;244m    )  )  )  \033[0m",
+		"\033[38;5;244m   (  (  (   \033[0m",
+		"\033[38;5;244m    )  )  )  \033[0m",
+		"\033[38;5;244m   (  (  (   \033[0m",
+		"\033[38;5;130m _/\\_/\\_/\\_  \033[0m",
+	},
+}
+
+// aatNightCodes holds the art replacing aatCodes when the sun is down.
+var aatNightCodes = map[iface.WeatherCode][]string{
+	iface.CodePartlyCloudy: {
+		"\033[38;5;228m   _.-.      \033[0m",
+		"\033[38;5;228m .' ,'\033[38;5;250m.-.    \033[0m",
+		"\033[38;5;228m |  |\033[38;5;250m(   ).  \033[0m",
+		"\033[38;5;228m '. \033[38;5;250m(___(__) \033[0m",
+		"\033[38;5;228m  `-'        \033[0m",
+	},
+	iface.CodeSunny: {
+		"\033[38;5;228m    _.-.     \033[0m",
+		"\033[38;5;228m  .' ,'   \033[38;5;250m*  \033[0m",
+		"\033[38;5;228m  |  |       \033[0m",
+		"\033[38;5;228m  '. '.   \033[38;5;250m*  \033[0m",
+		"\033[38;5;228m    `-'      \033[0m",
+	},
+}
+
 func (c *aatConfig) formatCond(cur []string, cond iface.Cond, current bool) (ret []string) {
-	codes := map[iface.WeatherCode][]string{
-		iface.CodeUnknown: {
-			"    .-.      ",
-			"     __)     ",
-			"    (        ",
-			"     `-᾿     ",
-			"      •      ",
-		},
-		iface.CodeCloudy: {
-			"             ",
-			"\033[38;5;250m     .--.    \033[0m",
-			"\033[38;5;250m  .-(    ).  \033[0m",
-			"\033[38;5;250m (___.__)__) \033[0m",
-			"             ",
-		},
-		iface.CodeFog: {
-			"             ",
-			"\033[38;5;251m _ - _ - _ - \033[0m",
-			"\033[38;5;251m  _ - _ - _  \033[0m",
-			"\033[38;5;251m _ - _ - _ - \033[0m",
-			"             ",
-		},
-		iface.CodeHeavyRain: {
-			"\033[38;5;240;1m     .-.     \033[0m",
-			"\033[38;5;240;1m    (   ).   \033[0m",
-			"\033[38;5;240;1m   (___(__)  \033[0m",
-			"\033[38;5;21;1m  ‚ʻ‚ʻ‚ʻ‚ʻ   \033[0m",
-			"\033[38;5;21;1m  ‚ʻ‚ʻ‚ʻ‚ʻ   \033[0m",
-		},
-		iface.CodeHeavyShowers: {
-			"\033[38;5;226m _`/\"\"\033[38;5;240;1m.-.    \033[0m",
-			"\033[38;5;226m  ,\\_\033[38;5;240;1m(   ).  \033[0m",
-			"\033[38;5;226m   /\033[38;5;240;1m(___(__) \033[0m",
-			"\033[38;5;21;1m   ‚ʻ‚ʻ‚ʻ‚ʻ  \033[0m",
-			"\033[38;5;21;1m   ‚ʻ‚ʻ‚ʻ‚ʻ  \033[0m",
-		},
-		iface.CodeHeavySnow: {
-			"\033[38;5;240;1m     .-.     \033[0m",
-			"\033[38;5;240;1m    (   ).   \033[0m",
-			"\033[38;5;240;1m   (___(__)  \033[0m",
-			"\033[38;5;255;1m   * * * *   \033[0m",
-			"\033[38;5;255;1m  * * * *    \033[0m",
-		},
-		iface.CodeHeavySnowShowers: {
-			"\033[38;5;226m _`/\"\"\033[38;5;240;1m.-.    \033[0m",
-			"\033[38;5;226m  ,\\_\033[38;5;240;1m(   ).  \033[0m",
-			"\033[38;5;226m   /\033[38;5;240;1m(___(__) \033[0m",
-			"\033[38;5;255;1m    * * * *  \033[0m",
-			"\033[38;5;255;1m   * * * *   \033[0m",
-		},
-		iface.CodeLightRain: {
-			"\033[38;5;250m     .-.     \033[0m",
-			"\033[38;5;250m    (   ).   \033[0m",
-			"\033[38;5;250m   (___(__)  \033[0m",
-			"\033[38;5;111m    ʻ ʻ ʻ ʻ  \033[0m",
-			"\033[38;5;111m   ʻ ʻ ʻ ʻ   \033[0m",
-		},
-		iface.CodeLightShowers: {
-			"\033[38;5;226m _`/\"\"\033[38;5;250m.-.    \033[0m",
-			"\033[38;5;226m  ,\\_\033[38;5;250m(   ).  \033[0m",
-			"\033[38;5;226m   /\033[38;5;250m(___(__) \033[0m",
-			"\033[38;5;111m     ʻ ʻ ʻ ʻ \033[0m",
-			"\033[38;5;111m    ʻ ʻ ʻ ʻ  \033[0m",
-		},
-		iface.CodeLightSleet: {
-			"\033[38;5;250m     .-.     \033[0m",
-			"\033[38;5;250m    (   ).   \033[0m",
-			"\033[38;5;250m   (___(__)  \033[0m",
-			"\033[38;5;111m    ʻ \033[38;5;255m*\033[38;5;111m ʻ \033[38;5;255m*  \033[0m",
-			"\033[38;5;255m   *\033[38;5;111m ʻ \033[38;5;255m*\033[38;5;111m ʻ   \033[0m",
-		},
-		iface.CodeLightSleetShowers: {
-			"\033[38;5;226m _`/\"\"\033[38;5;250m.-.    \033[0m",
-			"\033[38;5;226m  ,\\_\033[38;5;250m(   ).  \033[0m",
-			"\033[38;5;226m   /\033[38;5;250m(___(__) \033[0m",
-			"\033[38;5;111m     ʻ \033[38;5;255m*\033[38;5;111m ʻ \033[38;5;255m* \033[0m",
-			"\033[38;5;255m    *\033[38;5;111m ʻ \033[38;5;255m*\033[38;5;111m ʻ  \033[0m",
-		},
-		iface.CodeLightSnow: {
-			"\033[38;5;250m     .-.     \033[0m",
-			"\033[38;5;250m    (   ).   \033[0m",
-			"\033[38;5;250m   (___(__)  \033[0m",
-			"\033[38;5;255m    *  *  *  \033[0m",
-			"\033[38;5;255m   *  *  *   \033[0m",
-		},
-		iface.CodeLightSnowShowers: {
-			"\033[38;5;226m _`/\"\"\033[38;5;250m.-.    \033[0m",
-			"\033[38;5;226m  ,\\_\033[38;5;250m(   ).  \033[0m",
-			"\033[38;5;226m   /\033[38;5;250m(___(__) \033[0m",
-			"\033[38;5;255m     *  *  * \033[0m",
-			"\033[38;5;255m    *  *  *  \033[0m",
-		},
-		iface.CodePartlyCloudy: {
-			"\033[38;5;226m   \\  /\033[0m      ",
-			"\033[38;5;226m _ /\"\"\033[38;5;250m.-.    \033[0m",
-			"\033[38;5;226m   \\_\033[38;5;250m(   ).  \033[0m",
-			"\033[38;5;226m   /\033[38;5;250m(___(__) \033[0m",
-			"             ",
-		},
-		iface.CodeSunny: {
-			"\033[38;5;226m    \\   /    \033[0m",
-			"\033[38;5;226m     .-.     \033[0m",
-			"\033[38;5;226m  ‒ (   ) ‒  \033[0m",
-			"\033[38;5;226m     `-᾿     \033[0m",
-			"\033[38;5;226m    /   \\    \033[0m",
-		},
-		iface.CodeThunderyHeavyRain: {
-			"\033[38;5;240;1m     .-.     \033[0m",
-			"\033[38;5;240;1m    (   ).   \033[0m",
-			"\033[38;5;240;1m   (___(__)  \033[0m",
-			"\033[38;5;21;1m  ‚ʻ\033[38;5;228;5m⚡\033[38;5;21;25mʻ‚\033[38;5;228;5m⚡\033[38;5;21;25m‚ʻ   \033[0m",
-			"\033[38;5;21;1m  ‚ʻ‚ʻ\033[38;5;228;5m⚡\033[38;5;21;25mʻ‚ʻ   \033[0m",
-		},
-		iface.CodeThunderyShowers: {
-			"\033[38;5;226m _`/\"\"\033[38;5;250m.-.    \033[0m",
-			"\033[38;5;226m  ,\\_\033[38;5;250m(   ).  \033[0m",
-			"\033[38;5;226m   /\033[38;5;250m(___(__) \033[0m",
-			"\033[38;5;228;5m    ⚡\033[38;5;111;25mʻ ʻ\033[38;5;228;5m⚡\033[38;5;111;25mʻ ʻ \033[0m",
-			"\033[38;5;111m    ʻ ʻ ʻ ʻ  \033[0m",
-		},
-		iface.CodeThunderySnowShowers: {
-			"\033[38;5;226m _`/\"\"\033[38;5;250m.-.    \033[0m",
-			"\033[38;5;226m  ,\\_\033[38;5;250m(   ).  \033[0m",
-			"\033[38;5;226m   /\033[38;5;250m(___(__) \033[0m",
-			"\033[38;5;255m     *\033[38;5;228;5m⚡\033[38;5;255;25m *\033[38;5;228;5m⚡\033[38;5;255;25m * \033[0m",
-			"\033[38;5;255m    *  *  *  \033[0m",
-		},
-		iface.CodeVeryCloudy: {
-			"             ",
-			"\033[38;5;240;1m     .--.    \033[0m",
-			"\033[38;5;240;1m  .-(    ).  \033[0m",
-			"\033[38;5;240;1m (___.__)__) \033[0m",
-			"             ",
-		},
-	}
-
-	icon, ok := codes[cond.Code]
+	icon, ok := aatCodes[cond.Code]
 	if !ok {
-		log.Fatalln("aat-frontend: The following weather code has no icon:", cond.Code)
+		log.Println("aat-frontend: The following weather code has no icon:", cond.Code)
+		icon = aatCodes[iface.CodeUnknown]
+	}
+	night, hasNight := aatNightCodes[cond.Code]
+	if c.theme != nil {
+		if themed, ok := c.theme.icons[cond.Code]; ok {
+			icon = themed
+		}
+		if themed, ok := c.theme.nightIcons[cond.Code]; ok {
+			night, hasNight = themed, true
+		}
+	}
+	if hasNight && isNight(cond, c.geo) {
+		icon = night
 	}
 
-	desc := cond.Desc
-	if !current {
-		desc = runewidth.Truncate(runewidth.FillRight(desc, 15), 15, "…")
+	cond = annotateAQI(cond, c.airQuality)
+	desc := markedDesc(cond, 15)
+	if current {
+		desc = markedDesc(cond, 0)
+	}
+	if color := slotColor(cond, c.alerts); color != "" {
+		desc = color + desc + "\033[0m"
 	}
 
 	ret = append(ret, fmt.Sprintf("%v %v %v", cur[0], icon[0], desc))
@@ -298,9 +591,44 @@ func (c *aatConfig) formatCond(cur []string, cond iface.Cond, current bool) (ret
 	ret = append(ret, fmt.Sprintf("%v %v %v", cur[2], icon[2], c.formatWind(cond)))
 	ret = append(ret, fmt.Sprintf("%v %v %v", cur[3], icon[3], c.formatVisibility(cond)))
 	ret = append(ret, fmt.Sprintf("%v %v %v", cur[4], icon[4], c.formatRain(cond)))
+	var extra []string
+	if c.drying {
+		extra = append(extra, aatPad("", 13)+" "+c.formatDrying(cond))
+	}
+	for _, row := range c.rows.rows {
+		extra = append(extra, aatPad(fmt.Sprintf("\033[38;5;245m%12.12s\033[0m", row.Name), 13)+" "+aatPad(evalRow(row, cond), 15))
+	}
+	for i, line := range extra {
+		if 5+i < len(cur) {
+			ret = append(ret, fmt.Sprintf("%v %v", cur[5+i], line))
+		}
+	}
 	return
 }
 
+// cellLines returns the number of lines of a forecast cell.
+func (c *aatConfig) cellLines() int {
+	ret := 5 + len(c.rows.rows)
+	if c.drying {
+		ret++
+	}
+	return ret
+}
+
+// formatBanner renders the temperature of cond in large letters, colored the
+// same way as in the table.
+func (c *aatConfig) formatBanner(cond iface.Cond) (ret []string) {
+	if cond.TempC == nil {
+		return nil
+	}
+	t, u := c.unit.Temp(*cond.TempC)
+	col := tempColor(*cond.TempC, cond.NormalTempC)
+	for _, line := range bannerText(fmt.Sprintf("%d %s", int(t), u)) {
+		ret = append(ret, fmt.Sprintf(" \033[38;5;%03dm%s\033[0m", col, line))
+	}
+	return append(ret, "")
+}
+
 func (c *aatConfig) formatGeo(coords *iface.LatLon) (ret string) {
 	if !c.coords || coords == nil {
 		return ""
@@ -314,35 +642,146 @@ func (c *aatConfig) formatGeo(coords *iface.LatLon) (ret string) {
 		lon = "W"
 	}
 	ret = " "
-	ret += fmt.Sprintf("(%.1f°%s", math.Abs(float64(coords.Latitude)), lat)
-	ret += fmt.Sprintf(" %.1f°%s)", math.Abs(float64(coords.Longitude)), lon)
+	ret += fmt.Sprintf("(%s°%s", iface.FormatFloat(float32(math.Abs(float64(coords.Latitude))), 1), lat)
+	ret += fmt.Sprintf(" %s°%s)", iface.FormatFloat(float32(math.Abs(float64(coords.Longitude))), 1), lon)
 	return
 }
 
-func (c *aatConfig) printDay(day iface.Day) (ret []string) {
+// solarTimes returns the times of sunrise, solar noon, sunset and solar
+// midnight of day or nil if they are unknown. During polar day or night, the
+// times six hours before and after solar noon stand in for sunrise and sunset.
+func (c *aatConfig) solarTimes(day iface.Day) []time.Time {
+	rise, set := day.Astronomy.Sunrise, day.Astronomy.Sunset
+	var noon time.Time
+	if c.geo != nil {
+		lat, lon := float64(c.geo.Latitude), float64(c.geo.Longitude)
+		noon = astro.SolarNoon(day.Date, lat, lon)
+		if rise.IsZero() || set.IsZero() {
+			rise, set = astro.SunriseSunset(day.Date, lat, lon)
+		}
+		if rise.IsZero() || set.IsZero() {
+			rise, set = noon.Add(-6*time.Hour), noon.Add(6*time.Hour)
+		}
+	}
+	if rise.IsZero() || set.IsZero() {
+		return nil
+	}
+	if noon.IsZero() {
+		noon = rise.Add(set.Sub(rise) / 2)
+	}
+	return []time.Time{rise, noon, set, noon.Add(12 * time.Hour)}
+}
+
+// timeOfDay returns the wall clock time of t in its own location as the
+// duration since midnight.
+func timeOfDay(t time.Time) time.Duration {
+	h, m, s := t.Clock()
+	return time.Duration(h)*time.Hour + time.Duration(m)*time.Minute + time.Duration(s)*time.Second
+}
+
+// nowColumn returns the index of the column closest to now if the columns
+// are for the current day at their location and -1 otherwise. The location
+// is taken from the time zone of the slot times.
+func nowColumn(cols []iface.Cond, now time.Time) (ret int) {
+	ret = -1
+	dist := func(t time.Time) float64 {
+		return math.Abs(float64(t.Sub(now)))
+	}
+	for i, col := range cols {
+		if col.Time.IsZero() {
+			continue
+		}
+		y, m, d := now.In(col.Time.Location()).Date()
+		if cy, cm, cd := col.Time.Date(); cy != y || cm != m || cd != d {
+			continue
+		}
+		if ret == -1 || dist(col.Time) < dist(cols[ret].Time) {
+			ret = i
+		}
+	}
+	return
+}
+
+// highlightLabel shows the n-th of the given column labels in the header line
+// in inverse video.
+func highlightLabel(line string, labels []string, n int) string {
+	if n < 0 || n >= len(labels) {
+		return line
+	}
+	return strings.Replace(line, labels[n], "\033[7m"+labels[n]+"\033[27m", 1)
+}
+
+// selectSlots picks the slots of day shown in the four columns of the table.
+// The solar result tells whether they were picked relative to solar events.
+func (c *aatConfig) selectSlots(day iface.Day) (cols []iface.Cond, solar bool) {
 	desiredTimesOfDay := []time.Duration{
 		8 * time.Hour,
 		12 * time.Hour,
 		19 * time.Hour,
 		23 * time.Hour,
 	}
-	ret = make([]string, 5)
-	for i := range ret {
-		ret[i] = "│"
-	}
 
 	// save our selected elements from day.Slots in this array
-	cols := make([]iface.Cond, len(desiredTimesOfDay))
+	cols = make([]iface.Cond, len(desiredTimesOfDay))
+	var solarTimes []time.Time
+	if c.solarSlots {
+		solarTimes = c.solarTimes(day)
+	}
+	if solarTimes != nil {
+		// find hourly data closest to the solar events
+		for _, candidate := range day.Slots {
+			for i, col := range cols {
+				if col.Time.IsZero() || math.Abs(float64(candidate.Time.Sub(solarTimes[i]))) < math.Abs(float64(col.Time.Sub(solarTimes[i]))) {
+					cols[i] = candidate
+				}
+			}
+		}
+		return cols, true
+	}
+
 	// find hourly data which fits the desired times of day best
 	for _, candidate := range day.Slots {
-		cand := candidate.Time.UTC().Sub(candidate.Time.Truncate(24 * time.Hour))
+		cand := timeOfDay(candidate.Time)
 		for i, col := range cols {
-			cur := col.Time.Sub(col.Time.Truncate(24 * time.Hour))
+			cur := timeOfDay(col.Time)
 			if col.Time.IsZero() || math.Abs(float64(cand-desiredTimesOfDay[i])) < math.Abs(float64(cur-desiredTimesOfDay[i])) {
 				cols[i] = candidate
 			}
 		}
 	}
+	return cols, false
+}
+
+func (c *aatConfig) printDay(day iface.Day) (ret []string) {
+	ret = make([]string, c.cellLines())
+	for i := range ret {
+		ret[i] = "│"
+	}
+
+	cols, solar := c.selectSlots(day)
+	labels := "│           Morning            │             Noon      └──────┬──────┘    Evening            │            Night             │"
+	names := []string{"Morning", "Noon", "Evening", "Night"}
+	if solar {
+		labels = "│            Dawn              │            Midday     └──────┬──────┘    Dusk               │            Night             │"
+		names = []string{"Dawn", "Midday", "Dusk", "Night"}
+	}
+	now := -1
+	if c.highlightNow {
+		now = nowColumn(cols, iface.Now())
+	}
+
+	if w := outputWidth(); w > 0 && w < 1+31*len(cols) {
+		// rows of equal length look better than a single column left over
+		perRow := (w - 1) / 31
+		for perRow > 1 && len(cols)%perRow != 0 {
+			perRow--
+		}
+		if perRow < 1 {
+			perRow = 1
+		}
+		return c.printNarrowDay(day, cols, names, now, perRow)
+	}
+	labels = highlightLabel(labels, names, now)
 
 	for _, s := range cols {
 		ret = c.formatCond(ret, s, false)
@@ -353,45 +792,382 @@ func (c *aatConfig) printDay(day iface.Day) (ret []string) {
 
 	dateFmt := "┤ " + day.Date.Format("Mon 02. Jan") + " ├"
 	ret = append([]string{
-		"                                                       ┌─────────────┐                                                       ",
+		aatPad(" "+strings.TrimSpace(formatConfidence(day.Confidence)+"  "+formatAstro(day)), 55) + "┌─────────────┐" + alignRight(c.formatDayInfo(day)+" ", 55),
 		"┌──────────────────────────────┬───────────────────────" + dateFmt + "───────────────────────┬──────────────────────────────┐",
-		"│           Morning            │             Noon      └──────┬──────┘    Evening            │            Night             │",
+		labels,
 		"├──────────────────────────────┼──────────────────────────────┼──────────────────────────────┼──────────────────────────────┤"},
 		ret...)
+	if c.precipBar {
+		ret = append(ret,
+			"├──────────────────────────────┴──────────────────────────────┴──────────────────────────────┴──────────────────────────────┤")
+		return append(ret, c.formatPrecipBox(day, 125)...)
+	}
 	return append(ret,
 		"└──────────────────────────────┴──────────────────────────────┴──────────────────────────────┴──────────────────────────────┘")
 }
 
+// formatDayInfo formats the daily values shown in the header row of day: the
+// high and low temperature and the onset of precipitation.
+func (c *aatConfig) formatDayInfo(day iface.Day) string {
+	info := formatDayRange(day, c.unit)
+	if day.PrecipOnset != nil {
+		if info != "" {
+			info += "  "
+		}
+		info += "precip from " + day.PrecipOnset.Format("15:04")
+	}
+	return info
+}
+
+// printNarrowDay lays out the columns of day in rows of perRow columns, for
+// outputs too narrow to show all of them next to each other.
+func (c *aatConfig) printNarrowDay(day iface.Day, cols []iface.Cond, names []string, now, perRow int) (ret []string) {
+	border := func(n int, left, mid, right string) string {
+		return left + strings.Repeat(strings.Repeat("─", 30)+mid, n-1) + strings.Repeat("─", 30) + right
+	}
+
+	if info := strings.TrimSpace(formatConfidence(day.Confidence) + "  " + c.formatDayInfo(day)); info != "" {
+		ret = append(ret, " "+info)
+	}
+	if astro := formatAstro(day); astro != "" {
+		ret = append(ret, " "+astro)
+	}
+	for start := 0; start < len(cols); start += perRow {
+		end := start + perRow
+		if end > len(cols) {
+			end = len(cols)
+		}
+		n := end - start
+
+		top := border(n, "┌", "┬", "┐")
+		if start == 0 {
+			// put the date on the top border like a tab
+			dateFmt := []rune("┤ " + day.Date.Format("Mon 02. Jan") + " ├")
+			line := []rune(top)
+			if at := (len(line) - len(dateFmt)) / 2; at > 0 {
+				copy(line[at:], dateFmt)
+			}
+			top = string(line)
+		}
+
+		labels := "│"
+		for _, name := range names[start:end] {
+			labels += aatPad(fmt.Sprintf("%*s", 15+(len(name)+1)/2, name), 30) + "│"
+		}
+		labels = highlightLabel(labels, names[start:end], now-start)
+
+		lines := make([]string, c.cellLines())
+		for i := range lines {
+			lines[i] = "│"
+		}
+		for _, s := range cols[start:end] {
+			lines = c.formatCond(lines, s, false)
+			for i := range lines {
+				lines[i] = lines[i] + "│"
+			}
+		}
+
+		ret = append(ret, top, labels, border(n, "├", "┼", "┤"))
+		ret = append(ret, lines...)
+		if end < len(cols) {
+			ret = append(ret, border(n, "└", "┴", "┘"))
+		} else if c.precipBar {
+			ret = append(ret, border(n, "├", "┴", "┤"))
+			ret = append(ret, c.formatPrecipBox(day, 1+31*n)...)
+		} else {
+			ret = append(ret, border(n, "└", "┴", "┘"))
+		}
+	}
+	return
+}
+
+// formatPrecipBox returns the lines of the precipitation bar of day and the
+// bottom of the box around it for a table of the given width.
+func (c *aatConfig) formatPrecipBox(day iface.Day, width int) (ret []string) {
+	inner := width - 5
+	for _, line := range c.formatPrecipBar(day, inner/24) {
+		ret = append(ret, "│  "+aatPad(line, inner)+"  │")
+	}
+	return append(ret, "└"+strings.Repeat("─", width-2)+"┘")
+}
+
+// formatPrecipBar returns an hour scale and a bar with one cell of cellWidth
+// columns per hour of day. The shade of a cell tells the chance of
+// precipitation, its color the intensity (or white for snow). Each hour uses
+// the closest slot up to three hours away, hours without one are left blank.
+func (c *aatConfig) formatPrecipBar(day iface.Day, cellWidth int) []string {
+	if cellWidth < 1 {
+		cellWidth = 1
+	}
+	intensityColor := func(cond iface.Cond) int {
+		if cond.Code.Snow() {
+			return 255
+		}
+		colmap := []struct {
+			maxMM float32
+			color int
+		}{
+			{0.1, 153}, {1, 117}, {4, 75}, {8, 33},
+		}
+		if cond.PrecipM == nil {
+			return 75
+		}
+		for _, candidate := range colmap {
+			if *cond.PrecipM*1000 < candidate.maxMM {
+				return candidate.color
+			}
+		}
+		return 21
+	}
+	shade := func(cond iface.Cond) string {
+		if cond.ChanceOfRainPercent == nil {
+			if cond.PrecipM != nil && *cond.PrecipM > 0 {
+				return "█"
+			}
+			return ""
+		}
+		shades := []struct {
+			maxPercent int
+			glyph      string
+		}{
+			{10, ""}, {30, "░"}, {60, "▒"}, {90, "▓"},
+		}
+		for _, candidate := range shades {
+			if *cond.ChanceOfRainPercent < candidate.maxPercent {
+				return candidate.glyph
+			}
+		}
+		return "█"
+	}
+
+	var scale, bar string
+	y, m, d := day.Date.Date()
+	for h := 0; h < 24; h++ {
+		if h%3 == 0 {
+			scale += fmt.Sprintf("%-*s", 3*cellWidth, fmt.Sprintf("%02d", h))
+		}
+
+		t := time.Date(y, m, d, h, 0, 0, 0, day.Date.Location())
+		var slot *iface.Cond
+		for i, candidate := range day.Slots {
+			dist := math.Abs(float64(candidate.Time.Sub(t)))
+			if dist <= float64(3*time.Hour) && (slot == nil || dist < math.Abs(float64(slot.Time.Sub(t)))) {
+				slot = &day.Slots[i]
+			}
+		}
+
+		if slot == nil {
+			bar += strings.Repeat(" ", cellWidth)
+		} else if g := shade(*slot); g == "" {
+			bar += "\033[38;5;240m" + strings.Repeat("·", cellWidth) + "\033[0m"
+		} else {
+			bar += fmt.Sprintf("\033[38;5;%03dm%s\033[0m", intensityColor(*slot), strings.Repeat(g, cellWidth))
+		}
+	}
+	return []string{scale, bar}
+}
+
+func (c *aatConfig) Capabilities() iface.Capabilities {
+	return iface.Capabilities{
+		Description: "Table of four slots per day with ASCII art icons",
+	}
+}
+
 func (c *aatConfig) Setup() {
 	flag.BoolVar(&c.coords, "aat-coords", false, "aat-frontend: Show geo coordinates")
 	flag.BoolVar(&c.monochrome, "aat-monochrome", false, "aat-frontend: Monochrome output")
+	flag.BoolVar(&c.solarSlots, "aat-solar-slots", false, "aat-frontend: Show the forecast at dawn, midday, dusk and night instead of fixed hours")
+	flag.BoolVar(&c.banner, "aat-banner", false, "aat-frontend: Show the current temperature as a large banner above the table")
+	flag.BoolVar(&c.solar, "aat-solar", false, "aat-frontend: Show the hours of usable sunshine and the rough PV yield of each day below it, see -solar-kwp")
+	flag.BoolVar(&c.drying, "aat-drying", false, "aat-frontend: Show a row with the drying score (0 to 10) of each slot, how fast laundry dries outside")
+	flag.Var(&c.rows, "aat-row", "aat-frontend: Show a row of values derived from each slot, `NAME=EXPRESSION` like frost=feels < -25 ? 'FROSTBITE' : '' (separate several rows with ;)")
+	flag.BoolVar(&c.totals, "aat-totals", false, "aat-frontend: Show the total rain and snow of the forecast below the table")
+	flag.BoolVar(&c.precipBar, "aat-precip-bar", false, "aat-frontend: Show the hourly chance and intensity of precipitation as a bar below each day")
+	flag.IntVar(&c.windPoints, "aat-wind-points", 8, "aat-frontend: `NUMBER` of compass points (8 or 16) the wind direction arrows distinguish")
+	flag.BoolVar(&c.windColor, "aat-wind-color", false, "aat-frontend: Color the wind direction arrows by wind speed")
+	flag.StringVar(&c.windUnit2, "aat-wind-unit2", "", "aat-frontend: Second `UNIT` (km/h, mph, m/s, kn or Bft) to show wind speeds in, if there is room")
+	flag.BoolVar(&c.highlightNow, "aat-highlight-now", false, "aat-frontend: Highlight the column closest to the current time")
+	flag.StringVar(&c.summaryLang, "aat-summary", "", "aat-frontend: Show a one sentence summary of the forecast in `LANGUAGE` (en, de, fr)")
+	flag.StringVar(&c.themeName, "aat-theme", "", "aat-frontend: `THEME` to load from the themes directory or a path to a theme file")
 }
 
-func (c *aatConfig) Render(r iface.Data, unitSystem iface.UnitSystem) {
+// prepare sets up c for rendering r and returns the writer for the output to
+// w.
+func (c *aatConfig) prepare(w io.Writer, r iface.Data, unitSystem iface.UnitSystem) io.Writer {
 	c.unit = unitSystem
+	c.geo = r.GeoLoc
+	c.alerts = r.Alerts
+	c.airQuality = r.AirQuality
+	c.windChillLimit = nil
+	if limit, _, ok := iface.WindChillLimit(r); ok {
+		c.windChillLimit = &limit
+	}
+	c.theme = nil
+	if c.themeName != "" {
+		t, err := loadTheme(c.themeName)
+		if err != nil {
+			log.Println("aat-frontend: Unable to load theme, using the default colors:", err)
+		}
+		c.theme = t
+	}
 
-	fmt.Printf("Weather for %s%s\n\n", r.Location, c.formatGeo(r.GeoLoc))
-	stdout := colorable.NewColorableStdout()
 	if c.monochrome {
-		stdout = colorable.NewNonColorable(os.Stdout)
+		return colorable.NewNonColorable(w)
+	}
+	// the palette of the theme applies to all lines
+	if c.theme != nil && len(c.theme.palette) > 0 {
+		return themeWriter{colorWriter(w), c.theme}
 	}
+	return colorWriter(w)
+}
 
-	out := c.formatCond(make([]string, 5), r.Current, true)
-	for _, val := range out {
-		fmt.Fprintln(stdout, val)
+// printHeader prints the location of r followed by the stale data banner, the
+// alerts and the summary.
+func (c *aatConfig) printHeader(stdout io.Writer, r iface.Data) {
+	fmt.Fprintf(stdout, "Weather for %s%s%s\n\n", r.Location, c.formatGeo(r.GeoLoc), formatSources(r))
+	if s := formatStale(r); s != "" {
+		fmt.Fprintln(stdout, s)
+		fmt.Fprintln(stdout)
+	}
+	if alerts := formatAlerts(r); len(alerts) > 0 {
+		for _, line := range alerts {
+			fmt.Fprintln(stdout, line)
+		}
+		fmt.Fprintln(stdout)
+	}
+
+	if c.summaryLang != "" {
+		if _, ok := summaryPhrases[c.summaryLang]; !ok {
+			log.Println("aat-frontend: No summary available in language", c.summaryLang)
+		} else if s := Summary(r, c.summaryLang, iface.Now()); s != "" {
+			for _, line := range wrapText(s, outputWidth()) {
+				fmt.Fprintln(stdout, line)
+			}
+			fmt.Fprintln(stdout)
+		}
 	}
+}
+
+// printCurrentExtras prints the lines shown below the current conditions.
+func (c *aatConfig) printCurrentExtras(stdout io.Writer, r iface.Data) {
+	if s := formatSpread(r, c.unit); s != "" {
+		fmt.Fprintln(stdout, s)
+	}
+	if s := formatQNH(r); s != "" {
+		fmt.Fprintln(stdout, s)
+	}
+	if s := formatAirQuality(r); s != "" {
+		fmt.Fprintln(stdout, s)
+	}
+}
 
+// printForecast prints the days, or the -hourly slots, of r.
+func (c *aatConfig) printForecast(stdout io.Writer, r iface.Data) {
 	if len(r.Forecast) == 0 {
 		return
 	}
-	if r.Forecast == nil {
-		log.Fatal("No detailed weather forecast available.")
+	if len(r.Hourly) > 0 {
+		fmt.Fprintln(stdout)
+		for _, val := range c.printHourly(r) {
+			fmt.Fprintln(stdout, val)
+		}
+		first, last := r.Hourly[0].Time, r.Hourly[len(r.Hourly)-1].Time
+		for _, d := range r.Forecast {
+			if at, _, _, ok := iface.ClockChange(d.Date); ok && !at.Before(first) && !at.After(last) {
+				fmt.Fprintln(stdout, " "+formatClockChange(d))
+			}
+		}
+		r.Forecast = nil
 	}
 	for _, d := range r.Forecast {
 		for _, val := range c.printDay(d) {
 			fmt.Fprintln(stdout, val)
 		}
+		if s := formatClockChange(d); s != "" {
+			fmt.Fprintln(stdout, " "+s)
+		}
+		if c.solar {
+			if s := formatSolar(d, r.GeoLoc); s != "" {
+				fmt.Fprintln(stdout, " "+s)
+			}
+		}
+	}
+	if c.totals {
+		if t := formatTotals(r, c.unit); t != "" {
+			fmt.Fprintln(stdout, t)
+		}
+	}
+	if v := formatVentilation(r, c.unit); v != "" {
+		fmt.Fprintln(stdout, v)
+	}
+}
+
+func (c *aatConfig) Render(w io.Writer, r iface.Data, unitSystem iface.UnitSystem) error {
+	stdout := c.prepare(w, r, unitSystem)
+	c.printHeader(stdout, r)
+
+	if c.banner {
+		for _, val := range c.formatBanner(r.Current) {
+			fmt.Fprintln(stdout, val)
+		}
+	}
+	out := c.formatCond(make([]string, 5), r.Current, true)
+	for _, val := range out {
+		fmt.Fprintln(stdout, val)
+	}
+	c.printCurrentExtras(stdout, r)
+	c.printForecast(stdout, r)
+	return nil
+}
+
+// aatOverviewWidth is the width of the current conditions of a location in
+// the overview of RenderAll.
+const aatOverviewWidth = 34
+
+// RenderAll shows the current conditions of all locations side by side, as
+// many per row as fit the terminal, followed by the forecast of each one.
+func (c *aatConfig) RenderAll(w io.Writer, rs []iface.Data, unitSystem iface.UnitSystem) error {
+	perRow := len(rs)
+	if w := outputWidth(); w > 0 {
+		perRow = w / aatOverviewWidth
+		if perRow < 1 {
+			perRow = 1
+		}
+	}
+	var stdout io.Writer
+	for start := 0; start < len(rs); start += perRow {
+		row := rs[start:]
+		if len(row) > perRow {
+			row = row[:perRow]
+		}
+		var lines []string
+		for i, r := range row {
+			stdout = c.prepare(w, r, unitSystem)
+			block := append([]string{" \033[1m" + r.Location + "\033[0m"}, c.formatCond(make([]string, 5), r.Current, false)...)
+			for j, line := range block {
+				if j >= len(lines) {
+					lines = append(lines, strings.Repeat(" ", i*aatOverviewWidth))
+				}
+				lines[j] += aatPad(line, aatOverviewWidth)
+			}
+			for j := len(block); j < len(lines); j++ {
+				lines[j] += strings.Repeat(" ", aatOverviewWidth)
+			}
+		}
+		for _, line := range lines {
+			fmt.Fprintln(stdout, strings.TrimRight(line, " "))
+		}
+		fmt.Fprintln(stdout)
+	}
+
+	for _, r := range rs {
+		stdout = c.prepare(w, r, unitSystem)
+		c.printHeader(stdout, r)
+		c.printCurrentExtras(stdout, r)
+		c.printForecast(stdout, r)
+		fmt.Fprintln(stdout)
 	}
+	return nil
 }
 
 func init() {
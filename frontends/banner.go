@@ -0,0 +1,50 @@
+package frontends
+
+import (
+	"strings"
+)
+
+// bannerHeight is the number of lines each glyph of bannerFont spans.
+const bannerHeight = 5
+
+// bannerFont is a small figlet-like block font covering the characters needed
+// to print a temperature. Every row of a glyph has the same width.
+var bannerFont = map[rune][bannerHeight]string{
+	'0': {"█▀▀█", "█  █", "█  █", "█  █", "▀▀▀▀"},
+	'1': {" ▄█ ", "  █ ", "  █ ", "  █ ", " ▀▀▀"},
+	'2': {"▀▀▀█", "   █", "█▀▀▀", "█   ", "▀▀▀▀"},
+	'3': {"▀▀▀█", "   █", " ▀▀█", "   █", "▀▀▀▀"},
+	'4': {"█  █", "█  █", "▀▀▀█", "   █", "   ▀"},
+	'5': {"█▀▀▀", "█   ", "▀▀▀█", "   █", "▀▀▀▀"},
+	'6': {"█▀▀▀", "█   ", "█▀▀█", "█  █", "▀▀▀▀"},
+	'7': {"▀▀▀█", "   █", "  █ ", " █  ", " ▀  "},
+	'8': {"█▀▀█", "█  █", "█▀▀█", "█  █", "▀▀▀▀"},
+	'9': {"█▀▀█", "█  █", "▀▀▀█", "   █", "▀▀▀▀"},
+	'-': {"    ", "    ", "▀▀▀▀", "    ", "    "},
+	'°': {"█▀█", "▀▀▀", "   ", "   ", "   "},
+	'C': {"█▀▀▀", "█   ", "█   ", "█   ", "▀▀▀▀"},
+	'F': {"█▀▀▀", "█   ", "█▀▀ ", "█   ", "▀   "},
+	'K': {"█  █", "█ █ ", "██  ", "█ █ ", "▀  ▀"},
+	' ': {"  ", "  ", "  ", "  ", "  "},
+}
+
+// bannerText renders s in bannerFont. Characters without a glyph are skipped.
+func bannerText(s string) (ret []string) {
+	ret = make([]string, bannerHeight)
+	for _, r := range s {
+		glyph, ok := bannerFont[r]
+		if !ok {
+			continue
+		}
+		for i := range ret {
+			if len(ret[i]) > 0 {
+				ret[i] += " "
+			}
+			ret[i] += glyph[i]
+		}
+	}
+	for i := range ret {
+		ret[i] = strings.TrimRight(ret[i], " ")
+	}
+	return
+}
@@ -0,0 +1,60 @@
+package frontends
+
+import (
+	"io"
+	"testing"
+
+	"github.com/nafiz1001/wego/iface"
+)
+
+func BenchmarkRender(b *testing.B) {
+	r := mockData(b)
+	defer func(width int) { iface.Width = width }(iface.Width)
+	iface.Width = 80
+	for _, name := range frontendNames() {
+		fe := iface.AllFrontends[name]
+		b.Run(name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if err := fe.Render(io.Discard, r, iface.UnitsMetric); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// renderAllocs are the allocations the frontends may make to render the mock
+// forecast, with some headroom over the current numbers.
+var renderAllocs = map[string]float64{
+	"ascii-art-table": 4000,
+	"emoji":           1400,
+	"image":           10000,
+	"json":            8,
+	"oneline":         20,
+	"prometheus":      240,
+}
+
+func TestRenderAllocs(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping allocation budgets in short mode")
+	}
+	if raceEnabled {
+		t.Skip("skipping allocation budgets with the race detector")
+	}
+	r := mockData(t)
+	defer func(width int) { iface.Width = width }(iface.Width)
+	iface.Width = 80
+	for _, name := range frontendNames() {
+		var err error
+		allocs := testing.AllocsPerRun(5, func() { err = iface.AllFrontends[name].Render(io.Discard, r, iface.UnitsMetric) })
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if budget, ok := renderAllocs[name]; !ok {
+			t.Errorf("%s: no allocation budget", name)
+		} else if allocs > budget {
+			t.Errorf("%s: %.0f allocations, the budget is %.0f", name, allocs, budget)
+		}
+	}
+}
@@ -0,0 +1,24 @@
+package frontends
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/nafiz1001/wego/iface"
+)
+
+// formatClockChange returns a line telling that the clocks change on day,
+// like "Clocks go back Sunday 02:00 → 01:00", as the times of the slots
+// after it shift. It is empty if they do not change.
+func formatClockChange(day iface.Day) string {
+	at, before, after, ok := iface.ClockChange(day.Date)
+	if !ok {
+		return ""
+	}
+	dir := "back"
+	if after > before {
+		dir = "forward"
+	}
+	return fmt.Sprintf("Clocks go %s %s %s → %s", dir, at.In(day.Date.Location()).Format("Monday"),
+		at.In(time.FixedZone("", before)).Format("15:04"), at.In(day.Date.Location()).Format("15:04"))
+}
@@ -0,0 +1,33 @@
+package frontends
+
+import (
+	"math"
+
+	"github.com/nafiz1001/wego/iface"
+)
+
+// dryingScore rates how fast laundry dries outside during cond from 0 (not at
+// all) to 10, like the laundry indices of some weather services. It grows with
+// the vapour pressure deficit (warm and dry air) and the wind, and drops with
+// the chance of precipitation. It is false if temperature or humidity are
+// unknown.
+func dryingScore(cond iface.Cond) (int, bool) {
+	if cond.TempC == nil || cond.Humidity == nil {
+		return 0, false
+	}
+	if summaryPrecipKind(cond) != "" {
+		return 0, true
+	}
+
+	// saturation vapour pressure in kPa (Tetens)
+	t := float64(*cond.TempC)
+	es := 0.6108 * math.Exp(17.27*t/(t+237.3))
+	score := 4 * es * (1 - float64(*cond.Humidity)/100)
+	if cond.WindspeedKmph != nil {
+		score += math.Min(float64(*cond.WindspeedKmph), 40) / 10
+	}
+	if cond.ChanceOfRainPercent != nil {
+		score *= 1 - float64(*cond.ChanceOfRainPercent)/100
+	}
+	return int(math.Min(math.Max(score, 0), 10) + 0.5), true
+}
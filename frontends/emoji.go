@@ -1,39 +1,57 @@
 package frontends
 
 import (
+	"flag"
 	"fmt"
+	"io"
 	"log"
 	"math"
+	"strings"
 	"time"
 
-	colorable "github.com/mattn/go-colorable"
 	runewidth "github.com/mattn/go-runewidth"
 	"github.com/nafiz1001/wego/iface"
 )
 
 type emojiConfig struct {
-	unit iface.UnitSystem
+	zwj          bool
+	highlightNow bool
+	totals       bool
+	summaryLang  string
+	unit         iface.UnitSystem
+	geo          *iface.LatLon
+	alerts       []iface.Alert
+	airQuality   *iface.AirQuality
+}
+
+const (
+	emojiVS16 = "\ufe0f" // variation selector requesting emoji presentation
+	emojiZWJ  = "\u200d" // zero width joiner
+)
+
+// emojiPresentation appends the emoji presentation selector to icons whose
+// base character defaults to text presentation, so terminals draw all icons
+// as two cell wide emoji.
+func emojiPresentation(icon string) string {
+	if strings.HasSuffix(icon, emojiVS16) || strings.Contains(icon, emojiZWJ) {
+		return icon
+	}
+	return icon + emojiVS16
+}
+
+// emojiWidth returns the number of terminal cells the icon occupies. Emoji
+// presentation sequences are always two cells wide, even if runewidth only
+// knows the width of their text presentation.
+func emojiWidth(icon string) int {
+	if strings.Contains(icon, emojiVS16) {
+		return 2
+	}
+	return runewidth.StringWidth(icon)
 }
 
 func (c *emojiConfig) formatTemp(cond iface.Cond) string {
 	color := func(temp float32) string {
-		colmap := []struct {
-			maxtemp float32
-			color   int
-		}{
-			{-15, 21}, {-12, 27}, {-9, 33}, {-6, 39}, {-3, 45},
-			{0, 51}, {2, 50}, {4, 49}, {6, 48}, {8, 47},
-			{10, 46}, {13, 82}, {16, 118}, {19, 154}, {22, 190},
-			{25, 226}, {28, 220}, {31, 214}, {34, 208}, {37, 202},
-		}
-
-		col := 196
-		for _, candidate := range colmap {
-			if temp < candidate.maxtemp {
-				col = candidate.color
-				break
-			}
-		}
+		col := tempColor(temp, cond.NormalTempC)
 		t, _ := c.unit.Temp(temp)
 		return fmt.Sprintf("\033[38;5;%03dm%d\033[0m", col, int(t))
 	}
@@ -52,40 +70,92 @@ func (c *emojiConfig) formatTemp(cond iface.Cond) string {
 	return aatPad(fmt.Sprintf("%s %s", color(t), u), 12)
 }
 
-func (c *emojiConfig) formatCond(cur []string, cond iface.Cond, current bool) (ret []string) {
-	codes := map[iface.WeatherCode]string{
-		iface.CodeUnknown:             "✨",
-		iface.CodeCloudy:              "☁️",
-		iface.CodeFog:                 "🌫",
-		iface.CodeHeavyRain:           "🌧",
-		iface.CodeHeavyShowers:        "🌧",
-		iface.CodeHeavySnow:           "❄️",
-		iface.CodeHeavySnowShowers:    "❄️",
-		iface.CodeLightRain:           "🌦",
-		iface.CodeLightShowers:        "🌦",
-		iface.CodeLightSleet:          "🌧",
-		iface.CodeLightSleetShowers:   "🌧",
-		iface.CodeLightSnow:           "🌨",
-		iface.CodeLightSnowShowers:    "🌨",
-		iface.CodePartlyCloudy:        "⛅️",
-		iface.CodeSunny:               "☀️",
-		iface.CodeThunderyHeavyRain:   "🌩",
-		iface.CodeThunderyShowers:     "⛈",
-		iface.CodeThunderySnowShowers: "⛈",
-		iface.CodeVeryCloudy:          "☁️",
-	}
-
-	icon, ok := codes[cond.Code]
+// emojiCodes holds the icon for every weather code.
+var emojiCodes = map[iface.WeatherCode]string{
+	iface.CodeUnknown:             "✨",
+	iface.CodeCloudy:              "☁️",
+	iface.CodeFog:                 "🌫",
+	iface.CodeHeavyRain:           "🌧",
+	iface.CodeHeavyShowers:        "🌧",
+	iface.CodeHeavySnow:           "❄️",
+	iface.CodeHeavySnowShowers:    "❄️",
+	iface.CodeLightRain:           "🌦",
+	iface.CodeLightShowers:        "🌦",
+	iface.CodeLightSleet:          "🌧",
+	iface.CodeLightSleetShowers:   "🌧",
+	iface.CodeLightSnow:           "🌨",
+	iface.CodeLightSnowShowers:    "🌨",
+	iface.CodePartlyCloudy:        "⛅️",
+	iface.CodeSunny:               "☀️",
+	iface.CodeThunderyHeavyRain:   "🌩",
+	iface.CodeThunderyShowers:     "⛈",
+	iface.CodeThunderySnowShowers: "⛈",
+	iface.CodeVeryCloudy:          "☁️",
+	iface.CodeFreezingRain:        "🧊",
+	iface.CodeIcePellets:          "🧊",
+	iface.CodeRainSnowMix:         "🌨",
+	iface.CodeBlowingSnow:         "🌬",
+	iface.CodeHail:                "⛈",
+	iface.CodeFunnelCloud:         "🌪",
+	iface.CodeSevereThunderstorm:  "⛈",
+	iface.CodeHaze:                "🌫",
+	iface.CodeSmoke:               "💨",
+}
+
+// emojiNightCodes replace the icons of some codes at night.
+var emojiNightCodes = map[iface.WeatherCode]string{
+	iface.CodeSunny: "🌙",
+}
+
+// emojiZWJCodes replace the icons of some codes with RGI zwj sequences, which
+// are not supported by all terminals and fonts, so they are only used if
+// requested.
+var emojiZWJCodes = map[iface.WeatherCode]string{
+	iface.CodeFog:  "😶‍🌫️",
+	iface.CodeHaze: "😶‍🌫️",
+}
+
+// emojiIcon returns the icon of cond in emoji presentation. geo is needed to
+// tell night from day if the backend does not, useZWJ selects RGI zwj
+// sequences.
+func emojiIcon(cond iface.Cond, geo *iface.LatLon, useZWJ bool) string {
+	icon, ok := emojiCodes[cond.Code]
 	if !ok {
-		log.Fatalln("emoji-frontend: The following weather code has no icon:", cond.Code)
+		log.Println("emoji-frontend: The following weather code has no icon:", cond.Code)
+		icon = emojiCodes[iface.CodeUnknown]
+	}
+	if night, ok := emojiNightCodes[cond.Code]; ok && isNight(cond, geo) {
+		icon = night
 	}
-	if runewidth.StringWidth(icon) == 1 {
-		icon += " "
+	if zwj, ok := emojiZWJCodes[cond.Code]; ok && useZWJ {
+		icon = zwj
+	}
+	return emojiPresentation(icon)
+}
+
+func (c *emojiConfig) formatCond(cur []string, cond iface.Cond, current bool) (ret []string) {
+	icon := emojiIcon(cond, c.geo, c.zwj)
+	if w := emojiWidth(icon); w < 2 {
+		icon += strings.Repeat(" ", 2-w)
 	}
 
-	desc := cond.Desc
-	if !current {
-		desc = runewidth.Truncate(runewidth.FillRight(desc, 13), 13, "…")
+	// the emoji table has no visibility, so the snow hazard takes the place
+	// of the description
+	hazard := cond.SnowHazard()
+	if hazard != "" && current {
+		cond.Desc += ", " + hazard
+	} else if hazard != "" {
+		cond.Desc = hazard
+	}
+	cond = annotateAQI(cond, c.airQuality)
+	desc := markedDesc(cond, 13)
+	if current {
+		desc = markedDesc(cond, 0)
+	}
+	if color := slotColor(cond, c.alerts); color != "" {
+		desc = color + desc + "\033[0m"
+	} else if hazard != "" {
+		desc = alertColors["Severe"] + desc + "\033[0m"
 	}
 
 	ret = append(ret, fmt.Sprintf("%v %v %v", cur[0], "", desc))
@@ -109,9 +179,9 @@ func (c *emojiConfig) printDay(day iface.Day) (ret []string) {
 	cols := make([]iface.Cond, len(desiredTimesOfDay))
 	// find hourly data which fits the desired times of day best
 	for _, candidate := range day.Slots {
-		cand := candidate.Time.UTC().Sub(candidate.Time.Truncate(24 * time.Hour))
+		cand := timeOfDay(candidate.Time)
 		for i, col := range cols {
-			cur := col.Time.Sub(col.Time.Truncate(24 * time.Hour))
+			cur := timeOfDay(col.Time)
 			if math.Abs(float64(cand-desiredTimesOfDay[i])) < math.Abs(float64(cur-desiredTimesOfDay[i])) {
 				cols[i] = candidate
 			}
@@ -125,43 +195,93 @@ func (c *emojiConfig) printDay(day iface.Day) (ret []string) {
 		}
 	}
 
+	labels := "│    Morning    │    Noon   └───┬───┘ Evening   │     Night     │"
+	if c.highlightNow {
+		labels = highlightLabel(labels, []string{"Morning", "Noon", "Evening", "Night"}, nowColumn(cols, iface.Now()))
+	}
+
 	dateFmt := "┤  " + day.Date.Format("Mon") + "  ├"
 	ret = append([]string{
-		"                            ┌───────┐ ",
+		aatPad(" "+formatConfidence(day.Confidence), 28) + "┌───────┐" + alignRight(formatDayRange(day, c.unit)+" ", 28),
 		"┌───────────────┬───────────" + dateFmt + "───────────┬───────────────┐",
-		"│    Morning    │    Noon   └───┬───┘ Evening   │     Night     │",
+		labels,
 		"├───────────────┼───────────────┼───────────────┼───────────────┤"},
 		ret...)
-	return append(ret,
-		"└───────────────┴───────────────┴───────────────┴───────────────┘",
-		" ")
+	ret = append(ret, "└───────────────┴───────────────┴───────────────┴───────────────┘")
+	if s := formatClockChange(day); s != "" {
+		ret = append(ret, " "+s)
+	}
+	return append(ret, " ")
+}
+
+func (c *emojiConfig) Capabilities() iface.Capabilities {
+	return iface.Capabilities{
+		Description: "Compact table with emoji icons",
+	}
 }
 
 func (c *emojiConfig) Setup() {
+	flag.BoolVar(&c.zwj, "emoji-zwj", false, "emoji frontend: use RGI zwj sequences for some icons (not supported by all terminals)")
+	flag.StringVar(&c.summaryLang, "emoji-summary", "", "emoji frontend: show a one sentence summary of the forecast in `LANGUAGE` (en, de, fr)")
+	flag.BoolVar(&c.highlightNow, "emoji-highlight-now", false, "emoji frontend: highlight the column closest to the current time")
+	flag.BoolVar(&c.totals, "emoji-totals", false, "emoji frontend: show the total rain and snow of the forecast below the table")
 }
 
-func (c *emojiConfig) Render(r iface.Data, unitSystem iface.UnitSystem) {
+func (c *emojiConfig) Render(w io.Writer, r iface.Data, unitSystem iface.UnitSystem) error {
 	c.unit = unitSystem
+	c.geo = r.GeoLoc
+	c.alerts = r.Alerts
+	c.airQuality = r.AirQuality
+
+	stdout := colorWriter(w)
+	fmt.Fprintf(stdout, "Weather for %s%s\n\n", r.Location, formatSources(r))
+	if s := formatStale(r); s != "" {
+		fmt.Fprintln(stdout, s)
+		fmt.Fprintln(stdout)
+	}
 
-	fmt.Printf("Weather for %s\n\n", r.Location)
-	stdout := colorable.NewColorableStdout()
+	if c.summaryLang != "" {
+		if _, ok := summaryPhrases[c.summaryLang]; !ok {
+			log.Println("emoji frontend: No summary available in language", c.summaryLang)
+		} else if s := Summary(r, c.summaryLang, iface.Now()); s != "" {
+			for _, line := range wrapText(s, outputWidth()) {
+				fmt.Fprintln(stdout, line)
+			}
+			fmt.Fprintln(stdout)
+		}
+	}
 
 	out := c.formatCond(make([]string, 5), r.Current, true)
 	for _, val := range out {
 		fmt.Fprintln(stdout, val)
 	}
+	if s := formatSpread(r, c.unit); s != "" {
+		fmt.Fprintln(stdout, s)
+	}
+	if s := formatQNH(r); s != "" {
+		fmt.Fprintln(stdout, s)
+	}
+	if s := formatAirQuality(r); s != "" {
+		fmt.Fprintln(stdout, s)
+	}
 
 	if len(r.Forecast) == 0 {
-		return
-	}
-	if r.Forecast == nil {
-		log.Fatal("No detailed weather forecast available.")
+		return nil
 	}
 	for _, d := range r.Forecast {
 		for _, val := range c.printDay(d) {
 			fmt.Fprintln(stdout, val)
 		}
 	}
+	if c.totals {
+		if t := formatTotals(r, c.unit); t != "" {
+			fmt.Fprintln(stdout, t)
+		}
+	}
+	if v := formatVentilation(r, c.unit); v != "" {
+		fmt.Fprintln(stdout, v)
+	}
+	return nil
 }
 
 func init() {
@@ -0,0 +1,274 @@
+package frontends
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/nafiz1001/wego/iface"
+	"github.com/nafiz1001/wego/units"
+)
+
+// formatToken is a token of the format strings of FormatLine, like %t for the
+// temperature.
+type formatToken struct {
+	char byte
+	desc string
+	fn   func(r iface.Data, unit iface.UnitSystem) string
+}
+
+// unknownValue is shown for values the backend does not provide.
+const unknownValue = "?"
+
+// formatTempValue returns the temperature t like "12 °C", or unknownValue if t
+// is nil.
+func formatTempValue(t *float32, unit iface.UnitSystem) string {
+	if t == nil {
+		return unknownValue
+	}
+	v, u := unit.Temp(*t)
+	return iface.FormatInt(int(v)) + " " + u
+}
+
+// formatSpeedValue returns the speed s like "12 km/h", or unknownValue if s is
+// nil.
+func formatSpeedValue(s *float32, unit iface.UnitSystem) string {
+	if s == nil {
+		return unknownValue
+	}
+	v, u := unit.Speed(*s)
+	return iface.FormatInt(int(v)) + " " + u
+}
+
+// formatPercentValue returns p like "30%", or unknownValue if p is nil.
+func formatPercentValue(p *int) string {
+	if p == nil {
+		return unknownValue
+	}
+	return iface.FormatInt(*p) + "%"
+}
+
+// formatTimeValue returns t like "15:04", or unknownValue if it is zero.
+func formatTimeValue(t time.Time) string {
+	if t.IsZero() {
+		return unknownValue
+	}
+	return t.Format("15:04")
+}
+
+// today returns the first forecast day, or an empty day if there is none.
+func today(r iface.Data) iface.Day {
+	if len(r.Forecast) == 0 {
+		return iface.Day{}
+	}
+	return r.Forecast[0]
+}
+
+// formatTokens are the tokens of FormatLine. Values without a day refer to the
+// current conditions.
+var formatTokens = []formatToken{
+	{'l', "location", func(r iface.Data, unit iface.UnitSystem) string {
+		return r.Location
+	}},
+	{'L', "coordinates of the location", func(r iface.Data, unit iface.UnitSystem) string {
+		if r.GeoLoc == nil {
+			return unknownValue
+		}
+		return fmt.Sprintf("%.2f,%.2f", r.GeoLoc.Latitude, r.GeoLoc.Longitude)
+	}},
+	{'B', "backend of the current conditions", func(r iface.Data, unit iface.UnitSystem) string {
+		return r.CurrentSource
+	}},
+	{'T', "time of the current conditions", func(r iface.Data, unit iface.UnitSystem) string {
+		return formatTimeValue(r.Current.Time)
+	}},
+	{'c', "weather icon (emoji)", func(r iface.Data, unit iface.UnitSystem) string {
+		return emojiIcon(r.Current, r.GeoLoc, false)
+	}},
+	{'C', "description of the weather", func(r iface.Data, unit iface.UnitSystem) string {
+		return r.Current.Desc
+	}},
+	{'x', "weather code, e.g. LightRain", func(r iface.Data, unit iface.UnitSystem) string {
+		return r.Current.Code.String()
+	}},
+	{'t', "temperature", func(r iface.Data, unit iface.UnitSystem) string {
+		return formatTempValue(r.Current.TempC, unit)
+	}},
+	{'f', "felt temperature", func(r iface.Data, unit iface.UnitSystem) string {
+		return formatTempValue(r.Current.FeelsLikeC, unit)
+	}},
+	{'N', "normal temperature (with -temp-scale=anomaly)", func(r iface.Data, unit iface.UnitSystem) string {
+		return formatTempValue(r.Current.NormalTempC, unit)
+	}},
+	{'D', "dew point", func(r iface.Data, unit iface.UnitSystem) string {
+		dp, ok := r.Current.DewPointC()
+		if !ok {
+			return formatTempValue(nil, unit)
+		}
+		return formatTempValue(&dp, unit)
+	}},
+	{'w', "wind direction arrow and speed", func(r iface.Data, unit iface.UnitSystem) string {
+		if r.Current.WinddirDegree == nil {
+			return formatSpeedValue(r.Current.WindspeedKmph, unit)
+		}
+		return windArrow(*r.Current.WinddirDegree, 8) + " " + formatSpeedValue(r.Current.WindspeedKmph, unit)
+	}},
+	{'d', "wind direction, e.g. NW", func(r iface.Data, unit iface.UnitSystem) string {
+		if r.Current.WinddirDegree == nil {
+			return unknownValue
+		}
+		return iface.CompassPoint(float64(*r.Current.WinddirDegree))
+	}},
+	{'g', "wind gusts", func(r iface.Data, unit iface.UnitSystem) string {
+		return formatSpeedValue(r.Current.WindGustKmph, unit)
+	}},
+	{'p', "precipitation per hour", func(r iface.Data, unit iface.UnitSystem) string {
+		if r.Current.PrecipM == nil {
+			return unknownValue
+		}
+		v, u := unit.Precip(*r.Current.PrecipM)
+		return iface.FormatFloat(v, 1) + " " + u + "/h"
+	}},
+	{'r', "chance of rain", func(r iface.Data, unit iface.UnitSystem) string {
+		return formatPercentValue(r.Current.ChanceOfRainPercent)
+	}},
+	{'U', "UV index", func(r iface.Data, unit iface.UnitSystem) string {
+		if r.Current.UVIndex == nil {
+			return unknownValue
+		}
+		return iface.FormatFloat(*r.Current.UVIndex, 0)
+	}},
+	{'W', "-mark-symbol and the -mark rules of the current conditions, empty if none", func(r iface.Data, unit iface.UnitSystem) string {
+		return formatMarks(r.Current)
+	}},
+	{'h', "relative humidity", func(r iface.Data, unit iface.UnitSystem) string {
+		return formatPercentValue(r.Current.Humidity)
+	}},
+	{'Q', "QNH (altimeter setting) in hPa", func(r iface.Data, unit iface.UnitSystem) string {
+		qnh, ok := iface.QNH(r.Current)
+		if !ok {
+			return unknownValue
+		}
+		return iface.FormatInt(int(qnh+0.5)) + " hPa"
+	}},
+	{'I', "QNH (altimeter setting) in inHg", func(r iface.Data, unit iface.UnitSystem) string {
+		qnh, ok := iface.QNH(r.Current)
+		if !ok {
+			return unknownValue
+		}
+		return iface.FormatFloat(iface.HPaToInHg(qnh), 2) + " inHg"
+	}},
+	{'P', "air pressure at sea level in -pressure-unit", func(r iface.Data, unit iface.UnitSystem) string {
+		if r.Current.PressureHPa == nil {
+			return unknownValue
+		}
+		v, u := unit.Pressure(*r.Current.PressureHPa)
+		if unit.PressureUnit == units.InHg {
+			return iface.FormatFloat(v, 2) + " " + u
+		}
+		return iface.FormatInt(int(v+0.5)) + " " + u
+	}},
+	{'A', "pressure altitude at -elevation, in m or ft", func(r iface.Data, unit iface.UnitSystem) string {
+		qnh, ok := iface.QNH(r.Current)
+		if !ok {
+			return unknownValue
+		}
+		alt, ok := iface.PressureAltitudeM(qnh)
+		if !ok {
+			return unknownValue
+		}
+		v, u := unit.DistanceUnit.FromMShort(alt)
+		return iface.FormatInt(int(v+0.5)) + " " + iface.NumberLocale.Unit(u)
+	}},
+	{'v', "visibility", func(r iface.Data, unit iface.UnitSystem) string {
+		if r.Current.VisibleDistM == nil {
+			return unknownValue
+		}
+		v, u := unit.Distance(*r.Current.VisibleDistM)
+		return iface.FormatInt(int(v)) + " " + u
+	}},
+	{'H', "highest temperature of the day", func(r iface.Data, unit iface.UnitSystem) string {
+		return formatTempValue(today(r).MaxTempC, unit)
+	}},
+	{'M', "lowest temperature of the day", func(r iface.Data, unit iface.UnitSystem) string {
+		return formatTempValue(today(r).MinTempC, unit)
+	}},
+	{'u', "hours of usable sunshine of the day", func(r iface.Data, unit iface.UnitSystem) string {
+		sunHours, _, ok := solarDay(today(r), r.GeoLoc)
+		if !ok {
+			return unknownValue
+		}
+		return iface.FormatFloat(float32(sunHours), 1) + " h"
+	}},
+	{'y', "rough PV yield of the day for -solar-kwp", func(r iface.Data, unit iface.UnitSystem) string {
+		_, kWhm2, ok := solarDay(today(r), r.GeoLoc)
+		if !ok {
+			return unknownValue
+		}
+		return iface.FormatFloat(float32(solarYield(kWhm2)), 1) + " kWh"
+	}},
+	{'k', "confidence of the forecast of the day", func(r iface.Data, unit iface.UnitSystem) string {
+		return formatPercentValue(today(r).Confidence)
+	}},
+	{'o', "onset of rain or snow of the day", func(r iface.Data, unit iface.UnitSystem) string {
+		if onset := today(r).PrecipOnset; onset != nil {
+			return formatTimeValue(*onset)
+		}
+		return "-"
+	}},
+	{'S', "sunrise", func(r iface.Data, unit iface.UnitSystem) string {
+		return formatTimeValue(today(r).Astronomy.Sunrise)
+	}},
+	{'s', "sunset", func(r iface.Data, unit iface.UnitSystem) string {
+		return formatTimeValue(today(r).Astronomy.Sunset)
+	}},
+	{'R', "moonrise", func(r iface.Data, unit iface.UnitSystem) string {
+		return formatTimeValue(today(r).Astronomy.Moonrise)
+	}},
+	{'E', "moonset", func(r iface.Data, unit iface.UnitSystem) string {
+		return formatTimeValue(today(r).Astronomy.Moonset)
+	}},
+	{'n', "line break", func(r iface.Data, unit iface.UnitSystem) string {
+		return "\n"
+	}},
+	{'%', "a percent sign", func(r iface.Data, unit iface.UnitSystem) string {
+		return "%"
+	}},
+}
+
+// formatTokenHelp returns the table of the tokens of FormatLine for the flag
+// usage, one "%t  temperature" per line.
+func formatTokenHelp() string {
+	var lines []string
+	for _, t := range formatTokens {
+		lines = append(lines, fmt.Sprintf("  %%%c  %s", t.char, t.desc))
+	}
+	// ingo comments out the lines of the usage starting like this
+	return strings.Join(lines, "\n    \t")
+}
+
+// FormatLine returns format with its tokens like %t replaced by the values of
+// r, see formatTokens. Unknown tokens are kept as they are.
+func FormatLine(format string, r iface.Data, unit iface.UnitSystem) string {
+	var b strings.Builder
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' || i+1 == len(format) {
+			b.WriteByte(format[i])
+			continue
+		}
+		i++
+		found := false
+		for _, t := range formatTokens {
+			if t.char == format[i] {
+				b.WriteString(t.fn(r, unit))
+				found = true
+				break
+			}
+		}
+		if !found {
+			b.WriteByte('%')
+			b.WriteByte(format[i])
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,109 @@
+package frontends
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	_ "github.com/nafiz1001/wego/backends"
+	"github.com/nafiz1001/wego/iface"
+)
+
+var update = flag.Bool("update", false, "write the output of the frontends to the golden files in testdata")
+
+func TestMain(m *testing.M) {
+	// register the flags to get the default settings
+	for _, fe := range iface.AllFrontends {
+		fe.Setup()
+	}
+	// stdout is no terminal, so the image frontend would fall back to the
+	// table
+	iface.AllFrontends["image"].(*imgConfig).protocol = "sixel"
+	iface.Deterministic = true
+	os.Exit(m.Run())
+}
+
+// mockData returns the forecast of the mock backend, with iface.Now fixed to
+// the time of its current conditions like -deterministic does.
+func mockData(tb testing.TB) iface.Data {
+	be, ok := iface.SelectBackend("mock")
+	if !ok {
+		tb.Fatal("mock backend not registered")
+	}
+	r, err := be.Fetch(context.Background(), "", 7)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	now := r.Current.Time
+	iface.Now = func() time.Time { return now }
+	return r
+}
+
+// frontendNames returns the names of all frontends in a fixed order.
+func frontendNames() []string {
+	var names []string
+	for name := range iface.AllFrontends {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// TestGolden compares the output of every frontend for the mock forecast
+// with the golden files in testdata, at the terminal width and at 80
+// columns.
+func TestGolden(t *testing.T) {
+	aat := iface.AllFrontends["ascii-art-table"].(*aatConfig)
+	defer func(monochrome bool) { aat.monochrome = monochrome }(aat.monochrome)
+	defer func(width int) { iface.Width = width }(iface.Width)
+
+	type golden struct {
+		frontend   string
+		monochrome bool
+	}
+	var tests []golden
+	for _, name := range frontendNames() {
+		tests = append(tests, golden{frontend: name})
+	}
+	tests = append(tests, golden{"ascii-art-table", true})
+
+	r := mockData(t)
+	for _, tt := range tests {
+		for _, width := range []int{0, 80} {
+			name := fmt.Sprintf("%s-w%d", tt.frontend, width)
+			if tt.monochrome {
+				name = fmt.Sprintf("%s-monochrome-w%d", tt.frontend, width)
+			}
+			t.Run(name, func(t *testing.T) {
+				aat.monochrome = tt.monochrome
+				iface.Width = width
+				var out bytes.Buffer
+				if err := iface.AllFrontends[tt.frontend].Render(&out, r, iface.UnitsMetric); err != nil {
+					t.Fatal(err)
+				}
+				got := out.Bytes()
+
+				golden := filepath.Join("testdata", name+".golden")
+				if *update {
+					if err := ioutil.WriteFile(golden, got, 0644); err != nil {
+						t.Fatal(err)
+					}
+				}
+				want, err := ioutil.ReadFile(golden)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !bytes.Equal(got, want) {
+					t.Errorf("output differs from %s, run go test -update to accept it:\n%s", golden, got)
+				}
+			})
+		}
+	}
+}
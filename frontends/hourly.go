@@ -0,0 +1,24 @@
+package frontends
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/nafiz1001/wego/iface"
+)
+
+// printHourly returns the table of the -hourly slots of r, one row per slot
+// with the time, the weather, the temperature with the felt one in
+// parentheses, the precipitation with its probability and the wind.
+func (c *aatConfig) printHourly(r iface.Data) (ret []string) {
+	ret = append(ret, fmt.Sprintf("\033[1m %-10s%-19s%-16s%-16sWind\033[0m", "Time", "Weather", "Temp (feels)", "Precipitation"))
+	for _, s := range r.Hourly {
+		icon := emojiIcon(s, r.GeoLoc, false)
+		if w := emojiWidth(icon); w < 2 {
+			icon += strings.Repeat(" ", 2-w)
+		}
+		ret = append(ret, " "+aatPad(s.Time.Format("Mon 15:04"), 10)+icon+" "+markedDesc(s, 15)+" "+
+			c.formatTemp(s)+" "+c.formatRain(s)+" "+c.formatWind(s))
+	}
+	return
+}
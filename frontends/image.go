@@ -0,0 +1,453 @@
+package frontends
+
+import (
+	"bytes"
+	"encoding/base64"
+	"flag"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"io"
+	"log"
+	"math"
+	"os"
+	"strings"
+
+	isatty "github.com/mattn/go-isatty"
+	"github.com/nafiz1001/wego/iface"
+)
+
+type imgConfig struct {
+	protocol    string
+	size        int
+	solarSlots  bool
+	summaryLang string
+	totals      bool
+	unit        iface.UnitSystem
+	geo         *iface.LatLon
+	alerts      []iface.Alert
+	airQuality  *iface.AirQuality
+}
+
+// number of terminal cells a single icon column occupies
+const (
+	imgColCells  = 16
+	imgIconCells = 8
+	imgIconRows  = 4
+)
+
+var (
+	imgSun       = color.RGBA{0xff, 0xd7, 0x00, 0xff}
+	imgMoon      = color.RGBA{0xff, 0xf5, 0xb0, 0xff}
+	imgCloud     = color.RGBA{0xbc, 0xbc, 0xbc, 0xff}
+	imgDarkCloud = color.RGBA{0x58, 0x58, 0x58, 0xff}
+	imgRedCloud  = color.RGBA{0xd7, 0x00, 0x00, 0xff}
+	imgRain      = color.RGBA{0x5f, 0x87, 0xff, 0xff}
+	imgSnow      = color.RGBA{0xff, 0xff, 0xff, 0xff}
+	imgIce       = color.RGBA{0xaf, 0xff, 0xff, 0xff}
+	imgFog       = color.RGBA{0xc6, 0xc6, 0xc6, 0xff}
+	imgHaze      = color.RGBA{0xd7, 0xaf, 0x87, 0xff}
+	imgSmoke     = color.RGBA{0x80, 0x80, 0x80, 0xff}
+
+	// imgPalette holds all colors used for drawing icons, the first entry is
+	// the transparent background.
+	imgPalette = color.Palette{
+		color.RGBA{}, imgSun, imgMoon, imgCloud, imgDarkCloud, imgRedCloud,
+		imgRain, imgSnow, imgIce, imgFog, imgHaze, imgSmoke,
+	}
+)
+
+func imgDisc(img draw.Image, cx, cy, r float64, col color.Color) {
+	for y := int(cy - r); y <= int(cy+r); y++ {
+		for x := int(cx - r); x <= int(cx+r); x++ {
+			if dx, dy := float64(x)-cx, float64(y)-cy; dx*dx+dy*dy <= r*r {
+				img.Set(x, y, col)
+			}
+		}
+	}
+}
+
+func imgLine(img draw.Image, x0, y0, x1, y1, width float64, col color.Color) {
+	steps := int(math.Max(math.Abs(x1-x0), math.Abs(y1-y0))) + 1
+	for i := 0; i <= steps; i++ {
+		t := float64(i) / float64(steps)
+		imgDisc(img, x0+(x1-x0)*t, y0+(y1-y0)*t, width/2, col)
+	}
+}
+
+func imgSunShape(img draw.Image, cx, cy, s float64) {
+	imgDisc(img, cx, cy, s*0.18, imgSun)
+	for i := 0; i < 8; i++ {
+		a := float64(i) * math.Pi / 4
+		imgLine(img, cx+math.Cos(a)*s*0.25, cy+math.Sin(a)*s*0.25, cx+math.Cos(a)*s*0.34, cy+math.Sin(a)*s*0.34, s*0.04, imgSun)
+	}
+}
+
+func imgMoonShape(img *image.RGBA, cx, cy, s float64) {
+	imgDisc(img, cx, cy, s*0.22, imgMoon)
+	imgDisc(img, cx+s*0.1, cy-s*0.06, s*0.19, color.RGBA{})
+}
+
+func imgCloudShape(img draw.Image, cx, cy, s float64, col color.Color) {
+	imgDisc(img, cx-s*0.17, cy+s*0.02, s*0.13, col)
+	imgDisc(img, cx, cy-s*0.06, s*0.18, col)
+	imgDisc(img, cx+s*0.19, cy+s*0.03, s*0.12, col)
+	draw.Draw(img, image.Rect(int(cx-s*0.17), int(cy+s*0.02), int(cx+s*0.19), int(cy+s*0.15)), image.NewUniform(col), image.Point{}, draw.Src)
+}
+
+// imgPrecip draws three columns of particles below a cloud centered at cx.
+func imgPrecip(img draw.Image, cx, top, s float64, particle func(x, y float64)) {
+	for i := -1; i <= 1; i++ {
+		particle(cx+float64(i)*s*0.15, top)
+		particle(cx+float64(i)*s*0.15-s*0.05, top+s*0.14)
+	}
+}
+
+// imgIcon draws the icon for cond on a transparent square of s pixels.
+func (c *imgConfig) imgIcon(cond iface.Cond, s int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, s, s))
+	f := float64(s)
+	cx, cy := f/2, f*0.42
+	night := isNight(cond, c.geo)
+
+	rain := func(x, y float64) { imgLine(img, x, y, x-f*0.04, y+f*0.08, f*0.03, imgRain) }
+	snow := func(x, y float64) { imgDisc(img, x, y+f*0.04, f*0.035, imgSnow) }
+	ice := func(x, y float64) { imgDisc(img, x, y+f*0.04, f*0.03, imgIce) }
+	hail := func(x, y float64) { imgDisc(img, x, y+f*0.04, f*0.05, imgSnow) }
+	flash := func() {
+		imgLine(img, cx+f*0.02, cy+f*0.1, cx-f*0.05, cy+f*0.26, f*0.04, imgSun)
+		imgLine(img, cx-f*0.05, cy+f*0.26, cx+f*0.04, cy+f*0.26, f*0.04, imgSun)
+		imgLine(img, cx+f*0.04, cy+f*0.26, cx-f*0.03, cy+f*0.42, f*0.04, imgSun)
+	}
+	celestial := func(x, y, scale float64) {
+		if night {
+			imgMoonShape(img, x, y, f*scale)
+		} else {
+			imgSunShape(img, x, y, f*scale)
+		}
+	}
+	lines := func(col color.Color) {
+		for i := 0; i < 4; i++ {
+			y := f*0.3 + float64(i)*f*0.13
+			imgLine(img, f*0.15+float64(i%2)*f*0.06, y, f*0.8+float64(i%2)*f*0.06, y, f*0.05, col)
+		}
+	}
+
+	switch cond.Code {
+	case iface.CodeSunny:
+		celestial(cx, f/2, 1)
+	case iface.CodePartlyCloudy:
+		celestial(cx-f*0.15, cy-f*0.1, 0.8)
+		imgCloudShape(img, cx+f*0.05, cy+f*0.1, f, imgCloud)
+	case iface.CodeCloudy:
+		imgCloudShape(img, cx, f/2, f, imgCloud)
+	case iface.CodeVeryCloudy:
+		imgCloudShape(img, cx, f/2, f, imgDarkCloud)
+	case iface.CodeFog:
+		lines(imgFog)
+	case iface.CodeHaze:
+		imgSunShape(img, cx, f*0.3, f*0.7)
+		lines(imgHaze)
+	case iface.CodeSmoke:
+		lines(imgSmoke)
+	case iface.CodeLightRain, iface.CodeLightShowers:
+		if cond.Code == iface.CodeLightShowers {
+			celestial(cx-f*0.15, cy-f*0.12, 0.7)
+		}
+		imgCloudShape(img, cx, cy, f, imgCloud)
+		imgPrecip(img, cx, cy+f*0.2, f, rain)
+	case iface.CodeHeavyRain, iface.CodeHeavyShowers:
+		if cond.Code == iface.CodeHeavyShowers {
+			celestial(cx-f*0.15, cy-f*0.12, 0.7)
+		}
+		imgCloudShape(img, cx, cy, f, imgDarkCloud)
+		imgPrecip(img, cx, cy+f*0.2, f, rain)
+		imgPrecip(img, cx+f*0.07, cy+f*0.27, f, rain)
+	case iface.CodeLightSnow, iface.CodeLightSnowShowers, iface.CodeBlowingSnow:
+		if cond.Code == iface.CodeLightSnowShowers {
+			celestial(cx-f*0.15, cy-f*0.12, 0.7)
+		}
+		imgCloudShape(img, cx, cy, f, imgCloud)
+		imgPrecip(img, cx, cy+f*0.2, f, snow)
+	case iface.CodeHeavySnow, iface.CodeHeavySnowShowers:
+		if cond.Code == iface.CodeHeavySnowShowers {
+			celestial(cx-f*0.15, cy-f*0.12, 0.7)
+		}
+		imgCloudShape(img, cx, cy, f, imgDarkCloud)
+		imgPrecip(img, cx, cy+f*0.2, f, snow)
+		imgPrecip(img, cx+f*0.07, cy+f*0.27, f, snow)
+	case iface.CodeLightSleet, iface.CodeLightSleetShowers, iface.CodeRainSnowMix:
+		imgCloudShape(img, cx, cy, f, imgCloud)
+		imgPrecip(img, cx, cy+f*0.2, f, func(x, y float64) {
+			rain(x, y)
+			snow(x+f*0.07, y)
+		})
+	case iface.CodeFreezingRain, iface.CodeIcePellets:
+		imgCloudShape(img, cx, cy, f, imgCloud)
+		imgPrecip(img, cx, cy+f*0.2, f, ice)
+	case iface.CodeHail:
+		imgCloudShape(img, cx, cy, f, imgRedCloud)
+		imgPrecip(img, cx, cy+f*0.2, f, hail)
+	case iface.CodeThunderyShowers, iface.CodeThunderyHeavyRain, iface.CodeThunderySnowShowers:
+		imgCloudShape(img, cx, cy, f, imgDarkCloud)
+		flash()
+	case iface.CodeSevereThunderstorm:
+		imgCloudShape(img, cx, cy, f, imgRedCloud)
+		flash()
+	case iface.CodeFunnelCloud:
+		imgCloudShape(img, cx, f*0.3, f, imgRedCloud)
+		for i := 0; i < 5; i++ {
+			y := f*0.45 + float64(i)*f*0.08
+			w := f * (0.22 - float64(i)*0.04)
+			imgLine(img, cx-w, y, cx+w, y, f*0.04, imgDarkCloud)
+		}
+	default:
+		imgCloudShape(img, cx, f/2, f, imgFog)
+	}
+	return img
+}
+
+// imgStrip draws the icons for all conds next to each other, each one centered
+// in a column of colWidth pixels.
+func (c *imgConfig) imgStrip(conds []iface.Cond, colWidth int) *image.RGBA {
+	strip := image.NewRGBA(image.Rect(0, 0, colWidth*len(conds), c.size))
+	for i, cond := range conds {
+		off := image.Pt(i*colWidth+(colWidth-c.size)/2, 0)
+		icon := c.imgIcon(cond, c.size)
+		draw.Draw(strip, icon.Bounds().Add(off), icon, image.Point{}, draw.Over)
+	}
+	return strip
+}
+
+// writeKitty transmits img as png using the kitty graphics protocol and lets
+// the terminal scale it to cols x rows cells.
+func writeKitty(w io.Writer, img image.Image, cols, rows int) error {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return err
+	}
+	data := base64.StdEncoding.EncodeToString(buf.Bytes())
+
+	const chunkSize = 4096
+	for first := true; len(data) > 0; first = false {
+		chunk := data
+		if len(chunk) > chunkSize {
+			chunk = chunk[:chunkSize]
+		}
+		data = data[len(chunk):]
+
+		more := 0
+		if len(data) > 0 {
+			more = 1
+		}
+		if first {
+			fmt.Fprintf(w, "\033_Gf=100,a=T,c=%d,r=%d,m=%d;%s\033\\", cols, rows, more, chunk)
+		} else {
+			fmt.Fprintf(w, "\033_Gm=%d;%s\033\\", more, chunk)
+		}
+	}
+	_, err := fmt.Fprintln(w)
+	return err
+}
+
+// writeSixel encodes img with the colors of imgPalette as sixel graphics. The
+// first palette entry is left transparent.
+func writeSixel(w io.Writer, img image.Image) error {
+	b := img.Bounds()
+	pal := image.NewPaletted(b, imgPalette)
+	draw.Draw(pal, b, img, b.Min, draw.Src)
+
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "\033P0;1;0q\"1;1;%d;%d", b.Dx(), b.Dy())
+	for i, col := range imgPalette[1:] {
+		r, g, bl, _ := col.RGBA()
+		fmt.Fprintf(&out, "#%d;2;%d;%d;%d", i+1, r*100/0xffff, g*100/0xffff, bl*100/0xffff)
+	}
+
+	for y := b.Min.Y; y < b.Max.Y; y += 6 {
+		for idx := 1; idx < len(imgPalette); idx++ {
+			var band []byte
+			used := false
+			for x := b.Min.X; x < b.Max.X; x++ {
+				var bits byte
+				for dy := 0; dy < 6 && y+dy < b.Max.Y; dy++ {
+					if int(pal.ColorIndexAt(x, y+dy)) == idx {
+						bits |= 1 << uint(dy)
+						used = true
+					}
+				}
+				band = append(band, '?'+bits)
+			}
+			if !used {
+				continue
+			}
+			fmt.Fprintf(&out, "#%d", idx)
+			for i := 0; i < len(band); {
+				j := i
+				for j < len(band) && band[j] == band[i] {
+					j++
+				}
+				if j-i > 3 {
+					fmt.Fprintf(&out, "!%d%c", j-i, band[i])
+				} else {
+					out.Write(band[i:j])
+				}
+				i = j
+			}
+			out.WriteByte('$')
+		}
+		out.WriteByte('-')
+	}
+	out.WriteString("\033\\\n")
+	_, err := out.WriteTo(w)
+	return err
+}
+
+// detectProtocol guesses the graphics protocol supported by the terminal from
+// the environment, if w is the terminal. It returns an empty string if
+// images cannot be shown.
+func (c *imgConfig) detectProtocol(w io.Writer) string {
+	if c.protocol != "auto" {
+		return c.protocol
+	}
+	if f, ok := w.(*os.File); !ok || !isatty.IsTerminal(f.Fd()) {
+		return ""
+	}
+
+	term, prog := os.Getenv("TERM"), os.Getenv("TERM_PROGRAM")
+	if os.Getenv("KITTY_WINDOW_ID") != "" || term == "xterm-kitty" || prog == "WezTerm" || prog == "ghostty" {
+		return "kitty"
+	}
+	if strings.Contains(term, "sixel") || strings.HasPrefix(term, "mlterm") || strings.HasPrefix(term, "foot") || prog == "iTerm.app" {
+		return "sixel"
+	}
+	return ""
+}
+
+func (c *imgConfig) writeImage(w io.Writer, protocol string, img image.Image, cols int) error {
+	var err error
+	if protocol == "kitty" {
+		err = writeKitty(w, img, cols, imgIconRows)
+	} else {
+		err = writeSixel(w, img)
+	}
+	if err != nil {
+		return fmt.Errorf("image frontend: %v", err)
+	}
+	return nil
+}
+
+// printCols prints the description, temperature and precipitation of conds
+// below their icons, highlighted like in the table.
+func (c *imgConfig) printCols(w io.Writer, conds []iface.Cond) {
+	aat := aatConfig{unit: c.unit}
+	var desc, temp, rain string
+	for _, cond := range conds {
+		d := markedDesc(annotateAQI(cond, c.airQuality), imgColCells-1)
+		if color := slotColor(cond, c.alerts); color != "" {
+			d = color + d + "\033[0m"
+		}
+		desc += " " + d
+		temp += " " + aatPad(aat.formatTemp(cond), imgColCells-1)
+		rain += " " + aatPad(aat.formatRain(cond), imgColCells-1)
+	}
+	fmt.Fprintln(w, desc)
+	fmt.Fprintln(w, temp)
+	fmt.Fprintln(w, rain)
+}
+
+func (c *imgConfig) Capabilities() iface.Capabilities {
+	return iface.Capabilities{
+		Description: "The table as an image, shown with the terminal's graphics protocol",
+	}
+}
+
+func (c *imgConfig) Setup() {
+	flag.StringVar(&c.protocol, "img-protocol", "auto", "image frontend: graphics `PROTOCOL` to use (auto, kitty or sixel)")
+	flag.IntVar(&c.size, "img-size", 64, "image frontend: icon size in `PIXELS`")
+	flag.BoolVar(&c.solarSlots, "img-solar-slots", false, "image frontend: show the forecast at dawn, midday, dusk and night instead of fixed hours")
+	flag.StringVar(&c.summaryLang, "img-summary", "", "image frontend: show a one sentence summary of the forecast in `LANGUAGE` (en, de, fr)")
+	flag.BoolVar(&c.totals, "img-totals", false, "image frontend: show the total rain and snow of the forecast below the images")
+}
+
+func (c *imgConfig) Render(w io.Writer, r iface.Data, unitSystem iface.UnitSystem) error {
+	if c.size <= 0 {
+		return fmt.Errorf("image frontend: -img-size must be positive, not %d", c.size)
+	}
+	c.unit = unitSystem
+	c.geo = r.GeoLoc
+	c.alerts = r.Alerts
+	c.airQuality = r.AirQuality
+
+	protocol := c.detectProtocol(w)
+	if protocol != "kitty" && protocol != "sixel" {
+		if protocol != "" {
+			log.Printf("image frontend: unknown protocol %q, falling back to ascii-art-table", protocol)
+		}
+		return iface.AllFrontends["ascii-art-table"].Render(w, r, unitSystem)
+	}
+
+	colWidth := c.size * imgColCells / imgIconCells
+	stdout := colorWriter(w)
+	fmt.Fprintf(stdout, "Weather for %s%s\n\n", r.Location, formatSources(r))
+	if s := formatStale(r); s != "" {
+		fmt.Fprintln(stdout, s)
+		fmt.Fprintln(stdout)
+	}
+	if alerts := formatAlerts(r); len(alerts) > 0 {
+		for _, line := range alerts {
+			fmt.Fprintln(stdout, line)
+		}
+		fmt.Fprintln(stdout)
+	}
+	if c.summaryLang != "" {
+		if _, ok := summaryPhrases[c.summaryLang]; !ok {
+			log.Println("image frontend: No summary available in language", c.summaryLang)
+		} else if s := Summary(r, c.summaryLang, iface.Now()); s != "" {
+			for _, line := range wrapText(s, outputWidth()) {
+				fmt.Fprintln(stdout, line)
+			}
+			fmt.Fprintln(stdout)
+		}
+	}
+
+	if err := c.writeImage(stdout, protocol, c.imgStrip([]iface.Cond{r.Current}, colWidth), imgColCells); err != nil {
+		return err
+	}
+	c.printCols(stdout, []iface.Cond{r.Current})
+	if s := formatSpread(r, c.unit); s != "" {
+		fmt.Fprintln(stdout, s)
+	}
+	if s := formatQNH(r); s != "" {
+		fmt.Fprintln(stdout, s)
+	}
+	if s := formatAirQuality(r); s != "" {
+		fmt.Fprintln(stdout, s)
+	}
+
+	// pick the slots like the table does
+	aat := aatConfig{geo: r.GeoLoc, solarSlots: c.solarSlots}
+	for _, d := range r.Forecast {
+		fmt.Fprintf(stdout, "\n\033[1m%s\033[0m\n", d.Date.Format("Mon 02. Jan"))
+		cols, _ := aat.selectSlots(d)
+		if err := c.writeImage(stdout, protocol, c.imgStrip(cols, colWidth), imgColCells*len(cols)); err != nil {
+			return err
+		}
+		c.printCols(stdout, cols)
+	}
+	if c.totals {
+		if t := formatTotals(r, c.unit); t != "" {
+			fmt.Fprintln(stdout, t)
+		}
+	}
+	if v := formatVentilation(r, c.unit); v != "" {
+		fmt.Fprintln(stdout, v)
+	}
+	return nil
+}
+
+func init() {
+	iface.AllFrontends["image"] = &imgConfig{}
+}
@@ -0,0 +1,41 @@
+package frontends
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/nafiz1001/wego/iface"
+)
+
+// TestImageSettings checks that the image frontend shows the summary and the
+// totals and picks the slots with its own settings.
+func TestImageSettings(t *testing.T) {
+	c := iface.AllFrontends["image"].(*imgConfig)
+	defer func(saved imgConfig) { *c = saved }(*c)
+	r := mockData(t)
+
+	var plain bytes.Buffer
+	if err := c.Render(&plain, r, iface.UnitsMetric); err != nil {
+		t.Fatal(err)
+	}
+
+	c.summaryLang, c.totals, c.solarSlots = "en", true, true
+	var out bytes.Buffer
+	if err := c.Render(&out, r, iface.UnitsMetric); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{Summary(r, "en", iface.Now()), formatTotals(r, iface.UnitsMetric)} {
+		if want == "" || !strings.Contains(out.String(), want) {
+			t.Errorf("output lacks %q", want)
+		}
+	}
+
+	aat := aatConfig{geo: r.GeoLoc, solarSlots: true}
+	if solar, _ := aat.selectSlots(r.Forecast[0]); len(solar) > 0 && !strings.Contains(out.String(), markedDesc(solar[0], imgColCells-1)) {
+		t.Errorf("output lacks the first solar slot %s", solar[0].Time)
+	}
+	if bytes.Equal(plain.Bytes(), out.Bytes()) {
+		t.Error("the settings did not change the output")
+	}
+}
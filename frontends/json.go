@@ -3,8 +3,7 @@ package frontends
 import (
 	"encoding/json"
 	"flag"
-	"log"
-	"os"
+	"io"
 
 	"github.com/nafiz1001/wego/iface"
 )
@@ -13,22 +12,42 @@ type jsnConfig struct {
 	noIndent bool
 }
 
+func (c *jsnConfig) Capabilities() iface.Capabilities {
+	return iface.Capabilities{
+		Description: "The data as versioned JSON, for scripts and the json backend, see the schema command",
+	}
+}
+
 func (c *jsnConfig) Setup() {
 	flag.BoolVar(&c.noIndent, "jsn-no-indent", false, "json frontend: do not indent the output")
 }
 
-func (c *jsnConfig) Render(r iface.Data, unitSystem iface.UnitSystem) {
+func (c *jsnConfig) write(w io.Writer, v interface{}) error {
 	var b []byte
 	var err error
 	if c.noIndent {
-		b, err = json.Marshal(r)
+		b, err = json.Marshal(v)
 	} else {
-		b, err = json.MarshalIndent(r, "", "\t")
+		b, err = json.MarshalIndent(v, "", "\t")
 	}
 	if err != nil {
-		log.Fatal(err)
+		return err
+	}
+	_, err = w.Write(b)
+	return err
+}
+
+func (c *jsnConfig) Render(w io.Writer, r iface.Data, unitSystem iface.UnitSystem) error {
+	return c.write(w, iface.Document{Version: iface.DocumentVersion, Data: r})
+}
+
+// RenderAll writes an array with a document per location.
+func (c *jsnConfig) RenderAll(w io.Writer, rs []iface.Data, unitSystem iface.UnitSystem) error {
+	docs := make([]iface.Document, len(rs))
+	for i, r := range rs {
+		docs[i] = iface.Document{Version: iface.DocumentVersion, Data: r}
 	}
-	os.Stdout.Write(b)
+	return c.write(w, docs)
 }
 
 func init() {
@@ -0,0 +1,31 @@
+package frontends
+
+import (
+	"strings"
+
+	"github.com/mattn/go-runewidth"
+	"github.com/nafiz1001/wego/iface"
+)
+
+// markedDesc returns the description of cond prefixed with the -mark-symbol if
+// it crosses a -mark rule, fit to width cells unless width is 0.
+func markedDesc(cond iface.Cond, width int) string {
+	desc, mark := cond.Desc, ""
+	if len(cond.Marks) > 0 {
+		mark = iface.MarkSymbol + " "
+	}
+	if width > 0 {
+		w := width - runewidth.StringWidth(mark)
+		desc = runewidth.Truncate(runewidth.FillRight(desc, w), w, "…")
+	}
+	return mark + desc
+}
+
+// formatMarks returns the -mark-symbol followed by the names of the -mark rules
+// cond crosses, like "⚠ sun, heat", or an empty string if there are none.
+func formatMarks(cond iface.Cond) string {
+	if len(cond.Marks) == 0 {
+		return ""
+	}
+	return iface.MarkSymbol + " " + strings.Join(cond.Marks, ", ")
+}
@@ -0,0 +1,32 @@
+package frontends
+
+import (
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/nafiz1001/wego/iface"
+)
+
+type onelineConfig struct {
+	format string
+}
+
+func (c *onelineConfig) Capabilities() iface.Capabilities {
+	return iface.Capabilities{
+		Description: "The current conditions in one or two lines of -format, for status bars and prompts",
+	}
+}
+
+func (c *onelineConfig) Setup() {
+	flag.StringVar(&c.format, "format", "%l: %c %t (%f) %w %p", "oneline frontend: printf-like `FORMAT` of the output with the tokens\n    \t"+formatTokenHelp())
+}
+
+func (c *onelineConfig) Render(w io.Writer, r iface.Data, unitSystem iface.UnitSystem) error {
+	_, err := fmt.Fprintln(w, FormatLine(c.format, r, unitSystem))
+	return err
+}
+
+func init() {
+	iface.AllFrontends["oneline"] = &onelineConfig{}
+}
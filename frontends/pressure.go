@@ -0,0 +1,26 @@
+package frontends
+
+import (
+	"fmt"
+
+	"github.com/nafiz1001/wego/iface"
+)
+
+// formatQNH returns a line with the QNH of the current conditions in hPa and
+// inHg, and the pressure altitude if the elevation is known, like "QNH 1008
+// hPa / 29.77 inHg, pressure altitude 1265 m / 4150 ft". It is empty unless
+// -qnh is set or if the backend reports no pressure.
+func formatQNH(r iface.Data) string {
+	if !iface.ShowQNH {
+		return ""
+	}
+	qnh, ok := iface.QNH(r.Current)
+	if !ok {
+		return ""
+	}
+	line := fmt.Sprintf("QNH %s hPa / %s inHg", iface.FormatInt(int(qnh+0.5)), iface.FormatFloat(iface.HPaToInHg(qnh), 2))
+	if alt, ok := iface.PressureAltitudeM(qnh); ok {
+		line += fmt.Sprintf(", pressure altitude %s m / %s ft", iface.FormatInt(int(alt+0.5)), iface.FormatInt(int(alt/0.3048+0.5)))
+	}
+	return line
+}
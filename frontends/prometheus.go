@@ -0,0 +1,96 @@
+package frontends
+
+import (
+	"io"
+	"strconv"
+
+	"github.com/nafiz1001/wego/iface"
+	"github.com/nafiz1001/wego/metrics"
+)
+
+type promConfig struct{}
+
+func (c *promConfig) Capabilities() iface.Capabilities {
+	return iface.Capabilities{
+		Description: "The current conditions as Prometheus gauges, e.g. for the textfile collector of node_exporter",
+	}
+}
+
+func (c *promConfig) Setup() {
+}
+
+// weatherGauges are the gauges of the current conditions, in the units of
+// the data regardless of -units, as Prometheus expects fixed units.
+var weatherGauges = []struct {
+	name, help string
+	value      func(iface.Data) *float64
+}{
+	{"wego_temperature_celsius", "Current air temperature.", func(r iface.Data) *float64 { return f32(r.Current.TempC, 1) }},
+	{"wego_feels_like_celsius", "Current felt temperature.", func(r iface.Data) *float64 { return f32(r.Current.FeelsLikeC, 1) }},
+	{"wego_humidity_percent", "Current relative humidity.", func(r iface.Data) *float64 { return integer(r.Current.Humidity) }},
+	{"wego_wind_speed_kmh", "Current average wind speed.", func(r iface.Data) *float64 { return f32(r.Current.WindspeedKmph, 1) }},
+	{"wego_wind_gust_kmh", "Current wind gusts.", func(r iface.Data) *float64 { return f32(r.Current.WindGustKmph, 1) }},
+	{"wego_wind_direction_degrees", "Direction the wind blows from, clockwise from north.", func(r iface.Data) *float64 { return integer(r.Current.WinddirDegree) }},
+	{"wego_pressure_hpa", "Current air pressure reduced to sea level.", func(r iface.Data) *float64 { return f32(r.Current.PressureHPa, 1) }},
+	{"wego_precipitation_mm_per_hour", "Current precipitation.", func(r iface.Data) *float64 { return f32(r.Current.PrecipM, 1000) }},
+	{"wego_precipitation_chance_percent", "Current chance of precipitation.", func(r iface.Data) *float64 { return integer(r.Current.ChanceOfRainPercent) }},
+	{"wego_cloud_cover_percent", "Current share of the sky covered by clouds.", func(r iface.Data) *float64 { return integer(r.Current.CloudCoverPercent) }},
+	{"wego_visibility_meters", "Current visibility.", func(r iface.Data) *float64 { return f32(r.Current.VisibleDistM, 1) }},
+	{"wego_uv_index", "Current UV index.", func(r iface.Data) *float64 { return f32(r.Current.UVIndex, 1) }},
+	{"wego_fetched_timestamp_seconds", "Time the data was fetched from the backend.", func(r iface.Data) *float64 {
+		if r.FetchedAt.IsZero() {
+			return nil
+		}
+		v := float64(r.FetchedAt.Unix())
+		return &v
+	}},
+}
+
+// f32 returns v times scale with the digits of a float32, so 0.3 does not
+// become 0.30000001192092896.
+func f32(v *float32, scale float32) *float64 {
+	if v == nil {
+		return nil
+	}
+	ret, _ := strconv.ParseFloat(strconv.FormatFloat(float64(*v*scale), 'g', -1, 32), 64)
+	return &ret
+}
+
+func integer(v *int) *float64 {
+	if v == nil {
+		return nil
+	}
+	ret := float64(*v)
+	return &ret
+}
+
+// WritePrometheus writes the current conditions of rs to w as Prometheus
+// gauges labeled by location and backend.
+func WritePrometheus(w io.Writer, rs []iface.Data) error {
+	for _, g := range weatherGauges {
+		var samples []metrics.Sample
+		for _, r := range rs {
+			if v := g.value(r); v != nil {
+				samples = append(samples, metrics.Sample{Labels: []string{"location", r.Location, "backend", r.CurrentSource}, Value: *v})
+			}
+		}
+		if err := metrics.WriteGauge(w, g.name, g.help, samples); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *promConfig) Render(w io.Writer, r iface.Data, unitSystem iface.UnitSystem) error {
+	return c.RenderAll(w, []iface.Data{r}, unitSystem)
+}
+
+// RenderAll writes the gauges of all locations together, as each metric may
+// only appear once.
+func (c *promConfig) RenderAll(w io.Writer, rs []iface.Data, unitSystem iface.UnitSystem) error {
+	return WritePrometheus(w, rs)
+}
+
+func init() {
+	iface.AllFrontends["prometheus"] = &promConfig{}
+}
@@ -0,0 +1,8 @@
+//go:build race
+// +build race
+
+package frontends
+
+// raceEnabled reports whether the tests run with the race detector, which
+// makes allocations of its own.
+const raceEnabled = true
@@ -0,0 +1,62 @@
+package frontends
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/nafiz1001/wego/expr"
+	"github.com/nafiz1001/wego/iface"
+)
+
+// customRows is set by the -aat-row flag, a semicolon separated list of
+// NAME=EXPRESSION rows of values the user derives from each slot. The config
+// file holds all rows in one line, which is replaced by the rows given on the
+// command line.
+type customRows struct {
+	rows     []expr.Assignment
+	fromArgs bool
+}
+
+func (c *customRows) String() string {
+	if c == nil {
+		return ""
+	}
+	var ret []string
+	for _, r := range c.rows {
+		ret = append(ret, r.String())
+	}
+	return strings.Join(ret, "; ")
+}
+
+func (c *customRows) Set(s string) error {
+	// ingo sets the value of the config file before parsing the command line
+	if !flag.Parsed() {
+		c.rows = nil
+	} else if !c.fromArgs {
+		c.rows, c.fromArgs = nil, true
+	}
+	rows, err := expr.ParseAssignments(s)
+	if err != nil {
+		return err
+	}
+	known := iface.Cond{}.Vars()
+	for _, row := range rows {
+		for _, v := range row.Expr.Variables() {
+			if _, ok := known[v]; !ok {
+				return fmt.Errorf("row %s: unknown variable %q", row.Name, v)
+			}
+		}
+	}
+	c.rows = append(c.rows, rows...)
+	return nil
+}
+
+// evalRow returns the value of row for cond as text, or the error in red.
+func evalRow(row expr.Assignment, cond iface.Cond) string {
+	val, err := row.Expr.Eval(cond.Vars())
+	if err != nil {
+		return "\033[38;5;196merror\033[0m"
+	}
+	return expr.Format(val)
+}
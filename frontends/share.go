@@ -0,0 +1,117 @@
+package frontends
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+
+	"github.com/nafiz1001/wego/iface"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/math/fixed"
+)
+
+// size of the share card, the usual size of link previews on social media
+const (
+	shareWidth  = 1200
+	shareHeight = 630
+	shareMargin = 60
+)
+
+// ShareDays is the number of days on a share card.
+const ShareDays = 5
+
+var (
+	shareBgTop    = color.RGBA{0x1c, 0x24, 0x3a, 0xff}
+	shareBgBottom = color.RGBA{0x3a, 0x55, 0x7a, 0xff}
+	shareText     = color.RGBA{0xff, 0xff, 0xff, 0xff}
+	shareDimText  = color.RGBA{0xb0, 0xc4, 0xde, 0xff}
+)
+
+func shareFace(ttf []byte, size float64) (font.Face, error) {
+	f, err := opentype.Parse(ttf)
+	if err != nil {
+		return nil, err
+	}
+	return opentype.NewFace(f, &opentype.FaceOptions{Size: size, DPI: 72, Hinting: font.HintingFull})
+}
+
+// shareDrawText draws s with its baseline at y. If center is true, x is the
+// horizontal center of the text, otherwise its left edge.
+func shareDrawText(img draw.Image, face font.Face, x, y int, s string, col color.Color, center bool) {
+	d := font.Drawer{Dst: img, Src: image.NewUniform(col), Face: face}
+	if center {
+		x -= d.MeasureString(s).Round() / 2
+	}
+	d.Dot = fixed.P(x, y)
+	d.DrawString(s)
+}
+
+// ShareCard draws a picture for sharing the forecast with the location, the
+// current conditions and a strip with the next days.
+func ShareCard(r iface.Data, unit iface.UnitSystem) (image.Image, error) {
+	if shareRegular == nil {
+		return nil, fmt.Errorf("share cards are not included in builds with the minimal tag")
+	}
+	img := image.NewRGBA(image.Rect(0, 0, shareWidth, shareHeight))
+	for y := 0; y < shareHeight; y++ {
+		f := float64(y) / shareHeight
+		mix := func(a, b uint8) uint8 { return uint8(float64(a)*(1-f) + float64(b)*f) }
+		row := color.RGBA{mix(shareBgTop.R, shareBgBottom.R), mix(shareBgTop.G, shareBgBottom.G), mix(shareBgTop.B, shareBgBottom.B), 0xff}
+		draw.Draw(img, image.Rect(0, y, shareWidth, y+1), image.NewUniform(row), image.Point{}, draw.Src)
+	}
+
+	var faces [4]font.Face
+	for i, spec := range []struct {
+		ttf  []byte
+		size float64
+	}{{shareBold, 44}, {shareRegular, 28}, {shareBold, 150}, {shareRegular, 36}} {
+		face, err := shareFace(spec.ttf, spec.size)
+		if err != nil {
+			return nil, fmt.Errorf("unable to load font: %v", err)
+		}
+		defer face.Close()
+		faces[i] = face
+	}
+	title, small, huge, normal := faces[0], faces[1], faces[2], faces[3]
+
+	shareDrawText(img, title, shareMargin, 90, r.Location, shareText, false)
+	if !r.Current.Time.IsZero() {
+		shareDrawText(img, small, shareMargin, 130, r.Current.Time.Format("Mon 02. Jan 15:04"), shareDimText, false)
+	}
+
+	c := imgConfig{unit: unit, geo: r.GeoLoc}
+	icon := c.imgIcon(r.Current, 220)
+	draw.Draw(img, icon.Bounds().Add(image.Pt(shareMargin, 150)), icon, image.Point{}, draw.Over)
+	if r.Current.TempC != nil {
+		t, u := unit.Temp(*r.Current.TempC)
+		shareDrawText(img, huge, 320, 310, fmt.Sprintf("%d%s", int(t), u), shareText, false)
+	}
+	shareDrawText(img, normal, 330, 365, r.Current.Desc, shareDimText, false)
+
+	// the days are drawn with daytime icons of the slot closest to noon
+	days := r.Forecast
+	if len(days) > ShareDays {
+		days = days[:ShareDays]
+	}
+	colWidth := (shareWidth - 2*shareMargin) / ShareDays
+	day := imgConfig{unit: unit}
+	aat := aatConfig{}
+	for i, d := range days {
+		x := shareMargin + i*colWidth + colWidth/2
+		shareDrawText(img, small, x, 450, d.Date.Format("Mon"), shareText, true)
+		if cols, _ := aat.selectSlots(d); len(cols) > 1 && !cols[1].Time.IsZero() {
+			noon := cols[1]
+			noon.IsDay = nil
+			icon := day.imgIcon(noon, 96)
+			draw.Draw(img, icon.Bounds().Add(image.Pt(x-48, 460)), icon, image.Point{}, draw.Over)
+		}
+		if d.MinTempC != nil && d.MaxTempC != nil {
+			l, _ := unit.Temp(*d.MinTempC)
+			h, _ := unit.Temp(*d.MaxTempC)
+			shareDrawText(img, small, x, 595, fmt.Sprintf("%d° / %d°", int(h), int(l)), shareText, true)
+		}
+	}
+	return img, nil
+}
@@ -0,0 +1,12 @@
+//go:build !minimal
+// +build !minimal
+
+package frontends
+
+import (
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/gofont/goregular"
+)
+
+// the fonts of the share card, left out of builds with the minimal tag
+var shareRegular, shareBold = goregular.TTF, gobold.TTF
@@ -0,0 +1,7 @@
+//go:build minimal
+// +build minimal
+
+package frontends
+
+// builds with the minimal tag have no fonts for the share card
+var shareRegular, shareBold []byte
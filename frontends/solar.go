@@ -0,0 +1,149 @@
+package frontends
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/nafiz1001/wego/astro"
+	"github.com/nafiz1001/wego/iface"
+)
+
+const (
+	// solarStep is the time step the sun is followed over the day with.
+	solarStep = 10 * time.Minute
+
+	// solarUsableDeg is the elevation of the sun above which its light counts
+	// as usable sunshine. Lower, the light is too weak and mostly blocked by
+	// the surroundings.
+	solarUsableDeg = 5
+
+	// solarPerformanceRatio is the share of the irradiation on the panels a
+	// typical PV system turns into electricity, after losses from heat,
+	// wiring and the inverter.
+	solarPerformanceRatio = 0.8
+)
+
+// slotAt returns the slot of day covering t: the last one starting at or
+// before t, or the first one.
+func slotAt(day iface.Day, t time.Time) iface.Cond {
+	ret := day.Slots[0]
+	for _, s := range day.Slots {
+		if s.Time.After(t) {
+			break
+		}
+		ret = s
+	}
+	return ret
+}
+
+// clearSkyWm2 returns the global horizontal irradiance under a clear sky for
+// the sun at elevation elev in degrees, after the model of Haurwitz.
+func clearSkyWm2(elev float64) float64 {
+	if elev <= 0 {
+		return 0
+	}
+	sin := math.Sin(elev * math.Pi / 180)
+	return 1098 * sin * math.Exp(-0.057/sin)
+}
+
+// solarDay estimates the hours of usable sunshine of day and the irradiation
+// on a horizontal surface in kWh/m². The sun is followed through the day and
+// dimmed by the cloud cover of each slot (Kasten and Czeplak), unless the
+// backend provides the irradiance itself. It is false without coordinates or
+// if the slots have neither cloud cover nor irradiance.
+func solarDay(day iface.Day, geo *iface.LatLon) (sunHours, kWhm2 float64, ok bool) {
+	if geo == nil || len(day.Slots) == 0 {
+		return 0, 0, false
+	}
+	for _, s := range day.Slots {
+		if s.CloudCoverPercent != nil || s.ShortwaveWm2 != nil {
+			ok = true
+		}
+	}
+	if !ok {
+		return 0, 0, false
+	}
+
+	lat, lon := float64(geo.Latitude), float64(geo.Longitude)
+	y, m, d := day.Date.Date()
+	start := time.Date(y, m, d, 0, 0, 0, 0, day.Date.Location())
+	hours := solarStep.Hours()
+	for t := start.Add(solarStep / 2); t.Before(start.AddDate(0, 0, 1)); t = t.Add(solarStep) {
+		elev := astro.Elevation(t, lat, lon)
+		if elev <= 0 {
+			continue
+		}
+		s := slotAt(day, t)
+		clear := 1.0
+		if s.CloudCoverPercent != nil {
+			clear = 1 - float64(*s.CloudCoverPercent)/100
+		}
+		if s.ShortwaveWm2 != nil {
+			kWhm2 += float64(*s.ShortwaveWm2) * hours / 1000
+		} else {
+			kWhm2 += clearSkyWm2(elev) * (1 - 0.75*math.Pow(1-clear, 3.4)) * hours / 1000
+		}
+		if elev >= solarUsableDeg {
+			sunHours += clear * hours
+		}
+	}
+	return sunHours, kWhm2, true
+}
+
+// formatSolar returns the solar line of day, like "Solar: 6.5 h of usable
+// sunshine, about 21 kWh from 5 kWp", or an empty string if it cannot be
+// estimated. The yield assumes panels lying flat, tilted ones facing the sun
+// yield more in winter and less in summer.
+func formatSolar(day iface.Day, geo *iface.LatLon) string {
+	sunHours, kWhm2, ok := solarDay(day, geo)
+	if !ok {
+		return ""
+	}
+	return fmt.Sprintf("Solar: %s h of usable sunshine, about %s kWh from %s kWp", iface.FormatFloat(float32(sunHours), 1), iface.FormatFloat(float32(solarYield(kWhm2)), 1), iface.FormatFloat(float32(iface.SolarKWp), 1))
+}
+
+// solarYield returns the kWh a PV system of iface.SolarKWp makes from kWhm2 of
+// irradiation. Panels are rated at 1 kW/m², so 1 kWh/m² makes 1 kWh per kWp
+// before losses.
+func solarYield(kWhm2 float64) float64 {
+	return kWhm2 * iface.SolarKWp * solarPerformanceRatio
+}
+
+// moonPhaseIcons are the phases of the moon from new moon on, as seen from
+// the northern hemisphere.
+var moonPhaseIcons = []string{"🌑", "🌒", "🌓", "🌔", "🌕", "🌖", "🌗", "🌘"}
+
+// formatAstro returns the solar and lunar events of day for its header, like
+// "☀ 07:15–18:32  🌔 14:20–01:05" with the icon of the phase of the moon.
+// Events not happening on the day are left out, like "–01:05".
+func formatAstro(day iface.Day) string {
+	a := day.Astronomy
+	span := func(from, to time.Time) string {
+		if from.IsZero() && to.IsZero() {
+			return ""
+		}
+		ret := "–"
+		if !from.IsZero() {
+			ret = from.Format("15:04") + ret
+		}
+		if !to.IsZero() {
+			ret += to.Format("15:04")
+		}
+		return ret
+	}
+
+	var parts []string
+	if s := span(a.Sunrise, a.Sunset); s != "" {
+		parts = append(parts, "☀ "+s)
+	}
+	if a.MoonPhase != nil {
+		moon := moonPhaseIcons[int(*a.MoonPhase*8+0.5)%8]
+		if s := span(a.Moonrise, a.Moonset); s != "" {
+			moon += " " + s
+		}
+		parts = append(parts, moon)
+	}
+	return strings.Join(parts, "  ")
+}
@@ -0,0 +1,45 @@
+package frontends
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/nafiz1001/wego/iface"
+)
+
+// formatRange returns r like "11.5–13.2 °C", or only one number if all values
+// are the same. conv converts to the unit system and returns the unit label.
+func formatRange(r iface.Range, prec int, conv func(float32) (float32, string)) string {
+	min, unit := conv(r.Min)
+	max, _ := conv(r.Max)
+	if iface.FormatFloat(min, prec) == iface.FormatFloat(max, prec) {
+		return iface.FormatFloat(min, prec) + " " + unit
+	}
+	return iface.FormatFloat(min, prec) + "–" + iface.FormatFloat(max, prec) + " " + unit
+}
+
+// formatSpread returns a line telling how many stations the current conditions
+// are combined from and how far their observations differ, like "Median of 3
+// stations: 11.5–13.2 °C, wind 8–15 km/h, humidity 60–72 %", or an empty
+// string if they are observed at one station only.
+func formatSpread(r iface.Data, unit iface.UnitSystem) string {
+	s := r.CurrentSpread
+	if s == nil {
+		return ""
+	}
+	var parts []string
+	if s.TempC != nil {
+		parts = append(parts, formatRange(*s.TempC, 1, unit.Temp))
+	}
+	if s.WindspeedKmph != nil {
+		parts = append(parts, "wind "+formatRange(*s.WindspeedKmph, 0, unit.Speed))
+	}
+	if s.Humidity != nil {
+		parts = append(parts, "humidity "+formatRange(*s.Humidity, 0, func(h float32) (float32, string) { return h, "%" }))
+	}
+	method := strings.ToUpper(s.Method[:1]) + s.Method[1:]
+	if len(parts) == 0 {
+		return fmt.Sprintf("%s of %d stations", method, s.Stations)
+	}
+	return fmt.Sprintf("%s of %d stations: %s", method, s.Stations, strings.Join(parts, ", "))
+}
@@ -0,0 +1,31 @@
+package frontends
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/nafiz1001/wego/iface"
+)
+
+// formatStale returns a highlighted line telling that the data of r is taken
+// from the cache, like "⚠ Offline, showing the weather fetched 42 minutes
+// ago", or an empty string if it is current.
+func formatStale(r iface.Data) string {
+	if !r.Stale {
+		return ""
+	}
+	var age string
+	switch d := iface.Now().Sub(r.FetchedAt); {
+	case iface.Deterministic:
+		age = "on " + r.FetchedAt.Format("Mon 02. Jan 15:04")
+	case d < time.Minute:
+		age = "just now"
+	case d < 90*time.Minute:
+		age = fmt.Sprintf("%d minutes ago", int(d.Minutes()+0.5))
+	case d < 36*time.Hour:
+		age = fmt.Sprintf("%d hours ago", int(d.Hours()+0.5))
+	default:
+		age = fmt.Sprintf("%d days ago", int(d.Hours()/24+0.5))
+	}
+	return "\033[38;5;214;1m⚠ Offline, showing the weather fetched " + age + "\033[0m"
+}
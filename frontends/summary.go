@@ -0,0 +1,312 @@
+package frontends
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/nafiz1001/wego/iface"
+)
+
+// summaryPhrases holds the phrases a summary is built from for every
+// supported language.
+var summaryPhrases = map[string]map[string]string{
+	"en": {
+		"today": "%s today", "and": " and ",
+		"cold": "cold", "cool": "cool", "mild": "mild", "warm": "warm", "hot": "hot",
+		"breezy": "breezy", "windy": "windy",
+		"rain": "rain", "snow": "snow", "thunderstorms": "thunderstorms",
+		"starting": "%s starting %s", "ending": "%s ending %s", "clearing": "clearing by %s",
+		"in minutes": "in %d minutes", "in hours": "in %d hours",
+		"morning": "this morning", "afternoon": "this afternoon", "evening": "this evening", "night": "tonight",
+		"tomorrow morning": "tomorrow morning", "tomorrow afternoon": "tomorrow afternoon",
+		"tomorrow evening": "tomorrow evening", "tomorrow night": "tomorrow night",
+		"day morning": "%s morning", "day afternoon": "%s afternoon", "day evening": "%s evening", "day night": "%s night",
+		"milder": "milder by %s", "colder": "colder by %s",
+		"Sunday": "Sunday", "Monday": "Monday", "Tuesday": "Tuesday", "Wednesday": "Wednesday",
+		"Thursday": "Thursday", "Friday": "Friday", "Saturday": "Saturday",
+	},
+	"de": {
+		"today": "heute %s", "and": " und ",
+		"cold": "kalt", "cool": "kühl", "mild": "mild", "warm": "warm", "hot": "heiß",
+		"breezy": "windig", "windy": "stürmisch",
+		"rain": "Regen", "snow": "Schnee", "thunderstorms": "Gewitter",
+		"starting": "%s %s", "ending": "%s endet %s", "clearing": "trocken ab %s",
+		"in minutes": "in %d Minuten", "in hours": "in %d Stunden",
+		"morning": "heute Morgen", "afternoon": "heute Nachmittag", "evening": "heute Abend", "night": "heute Nacht",
+		"tomorrow morning": "morgen früh", "tomorrow afternoon": "morgen Nachmittag",
+		"tomorrow evening": "morgen Abend", "tomorrow night": "morgen Nacht",
+		"day morning": "%smorgen", "day afternoon": "%snachmittag", "day evening": "%sabend", "day night": "%snacht",
+		"milder": "milder bis %s", "colder": "kälter bis %s",
+		"Sunday": "Sonntag", "Monday": "Montag", "Tuesday": "Dienstag", "Wednesday": "Mittwoch",
+		"Thursday": "Donnerstag", "Friday": "Freitag", "Saturday": "Samstag",
+	},
+	"fr": {
+		"today": "%s aujourd'hui", "and": " et ",
+		"cold": "froid", "cool": "frais", "mild": "doux", "warm": "chaud", "hot": "très chaud",
+		"breezy": "venteux", "windy": "très venteux",
+		"rain": "pluie", "snow": "neige", "thunderstorms": "orages",
+		"starting": "%s %s", "ending": "%s cessant %s", "clearing": "retour au sec %s",
+		"in minutes": "dans %d minutes", "in hours": "dans %d heures",
+		"morning": "ce matin", "afternoon": "cet après-midi", "evening": "ce soir", "night": "cette nuit",
+		"tomorrow morning": "demain matin", "tomorrow afternoon": "demain après-midi",
+		"tomorrow evening": "demain soir", "tomorrow night": "dans la nuit de demain",
+		"day morning": "%s matin", "day afternoon": "%s après-midi", "day evening": "%s soir", "day night": "dans la nuit de %s",
+		"milder": "plus doux d'ici %s", "colder": "plus froid d'ici %s",
+		"Sunday": "dimanche", "Monday": "lundi", "Tuesday": "mardi", "Wednesday": "mercredi",
+		"Thursday": "jeudi", "Friday": "vendredi", "Saturday": "samedi",
+	},
+}
+
+// thresholds used by the summary rules
+const (
+	summaryBreezyKmph  = 20
+	summaryWindyKmph   = 40
+	summaryRainPercent = 50
+	summaryTrendC      = 5
+)
+
+// summaryRule returns a clause of the summary or an empty string if it has
+// nothing to say. today is the index of the current day in r.Forecast.
+type summaryRule func(r iface.Data, today int, now time.Time, tr func(string) string) string
+
+var summaryRules = []summaryRule{summaryToday, summaryPrecip, summaryTrend}
+
+// summaryTr returns a function translating phrase keys to lang, falling back
+// to English for missing phrases, or nil if the language is unsupported.
+func summaryTr(lang string) func(string) string {
+	phrases, ok := summaryPhrases[lang]
+	if !ok {
+		return nil
+	}
+	return func(key string) string {
+		if p, ok := phrases[key]; ok {
+			return p
+		}
+		return summaryPhrases["en"][key]
+	}
+}
+
+// Summary returns a sentence summarizing r in the given language like
+// "Cold and breezy today, snow starting tonight, milder by Thursday". It is
+// empty if the language is unsupported or there is too little data.
+func Summary(r iface.Data, lang string, now time.Time) string {
+	tr := summaryTr(lang)
+	if tr == nil || len(r.Forecast) == 0 {
+		return ""
+	}
+
+	today := 0
+	for i, d := range r.Forecast {
+		if len(d.Slots) == 0 {
+			continue
+		}
+		y, m, dd := now.In(d.Slots[0].Time.Location()).Date()
+		if dy, dm, ddd := d.Date.Date(); dy == y && dm == m && ddd == dd {
+			today = i
+			break
+		}
+	}
+
+	var clauses []string
+	for _, rule := range summaryRules {
+		if clause := rule(r, today, now, tr); clause != "" {
+			clauses = append(clauses, clause)
+		}
+	}
+	if len(clauses) == 0 {
+		return ""
+	}
+	s := strings.Join(clauses, ", ")
+	first, size := utf8.DecodeRuneInString(s)
+	return string(unicode.ToUpper(first)) + s[size:]
+}
+
+// summaryToday describes the temperature and wind of the current day.
+func summaryToday(r iface.Data, today int, now time.Time, tr func(string) string) string {
+	var adjectives []string
+	if high := r.Forecast[today].MaxTempC; high != nil {
+		switch {
+		case *high < 0:
+			adjectives = append(adjectives, tr("cold"))
+		case *high < 10:
+			adjectives = append(adjectives, tr("cool"))
+		case *high < 20:
+			adjectives = append(adjectives, tr("mild"))
+		case *high < 28:
+			adjectives = append(adjectives, tr("warm"))
+		default:
+			adjectives = append(adjectives, tr("hot"))
+		}
+	}
+
+	var wind float32
+	for _, s := range r.Forecast[today].Slots {
+		if s.WindspeedKmph != nil && *s.WindspeedKmph > wind {
+			wind = *s.WindspeedKmph
+		}
+	}
+	if wind >= summaryWindyKmph {
+		adjectives = append(adjectives, tr("windy"))
+	} else if wind >= summaryBreezyKmph {
+		adjectives = append(adjectives, tr("breezy"))
+	}
+
+	if len(adjectives) == 0 {
+		return ""
+	}
+	return fmt.Sprintf(tr("today"), strings.Join(adjectives, tr("and")))
+}
+
+// summaryPrecipKind returns the phrase key for the precipitation of cond or an
+// empty string if it is dry.
+func summaryPrecipKind(cond iface.Cond) string {
+	if cond.ChanceOfRainPercent != nil && *cond.ChanceOfRainPercent < summaryRainPercent {
+		return ""
+	}
+	switch cond.Code {
+	case iface.CodeThunderyHeavyRain, iface.CodeThunderyShowers, iface.CodeThunderySnowShowers, iface.CodeSevereThunderstorm:
+		return "thunderstorms"
+	case iface.CodeBlowingSnow:
+		return ""
+	}
+	if cond.Code.Snow() {
+		return "snow"
+	}
+	switch cond.Code {
+	case iface.CodeHeavyRain, iface.CodeHeavyShowers, iface.CodeLightRain, iface.CodeLightShowers,
+		iface.CodeLightSleet, iface.CodeLightSleetShowers, iface.CodeFreezingRain, iface.CodeIcePellets, iface.CodeHail:
+		return "rain"
+	}
+	return ""
+}
+
+// summaryWhen returns when t is relative to now, e.g. "in 40 minutes", "this
+// evening" or "Tuesday evening". Times before 5 a.m. belong to the night of the
+// previous day. In deterministic mode it is never counted in minutes or hours.
+func summaryWhen(t, now time.Time, tr func(string) string) string {
+	if d := t.Sub(now); d < 90*time.Minute && !iface.Deterministic {
+		m := int(d.Minutes()+2.5) / 5 * 5
+		if m < 5 {
+			m = 5
+		}
+		return fmt.Sprintf(tr("in minutes"), m)
+	} else if d < 6*time.Hour && !iface.Deterministic {
+		return fmt.Sprintf(tr("in hours"), int(d.Hours()+0.5))
+	}
+
+	day, part := t, "night"
+	switch h := t.Hour(); {
+	case h < 5:
+		day = t.AddDate(0, 0, -1)
+	case h < 12:
+		part = "morning"
+	case h < 17:
+		part = "afternoon"
+	case h < 21:
+		part = "evening"
+	}
+	local := now.In(t.Location())
+	y, m, d := day.Date()
+	if ny, nm, nd := local.Date(); ny == y && nm == m && nd == d {
+		return tr(part)
+	}
+	if ty, tm, td := local.AddDate(0, 0, 1).Date(); ty == y && tm == m && td == d {
+		return tr("tomorrow " + part)
+	}
+	return fmt.Sprintf(tr("day "+part), tr(day.Weekday().String()))
+}
+
+// RelativeTime returns t relative to now in lang like the summary does, e.g.
+// "in 40 minutes" or "Tuesday evening". Unsupported languages fall back to
+// English.
+func RelativeTime(t, now time.Time, lang string) string {
+	tr := summaryTr(lang)
+	if tr == nil {
+		tr = summaryTr("en")
+	}
+	return summaryWhen(t, now, tr)
+}
+
+// summaryMatches tells whether cond has precipitation of kind, which is a
+// phrase key of summaryPrecipKind or "dry". Thunderstorms count as rain.
+func summaryMatches(cond iface.Cond, kind string) bool {
+	switch k := summaryPrecipKind(cond); kind {
+	case "dry":
+		return k == ""
+	case "rain":
+		return k == "rain" || k == "thunderstorms"
+	default:
+		return k == kind
+	}
+}
+
+// NextOnset returns when precipitation of kind (rain, snow, thunderstorms or
+// dry for none at all) is expected next in r, which is now if the current
+// conditions already match. ok is false if it is not part of the forecast.
+func NextOnset(r iface.Data, kind string, now time.Time) (t time.Time, ok bool) {
+	if summaryMatches(r.Current, kind) {
+		return now, true
+	}
+	for _, d := range r.Forecast {
+		for _, s := range d.Slots {
+			if s.Time.After(now) && summaryMatches(s, kind) {
+				return s.Time, true
+			}
+		}
+	}
+	return time.Time{}, false
+}
+
+// summaryPrecip tells when precipitation starts or ends within the forecast.
+func summaryPrecip(r iface.Data, today int, now time.Time, tr func(string) string) string {
+	current := summaryPrecipKind(r.Current)
+	for _, d := range r.Forecast[today:] {
+		for _, s := range d.Slots {
+			if !s.Time.After(now) {
+				continue
+			}
+			kind := summaryPrecipKind(s)
+			if current == "" && kind != "" {
+				return fmt.Sprintf(tr("starting"), tr(kind), summaryWhen(s.Time, now, tr))
+			} else if current != "" && kind == "" {
+				// as in summaryWhen, times before 5 a.m. belong to the night before
+				if s.Time.Sub(now) >= 6*time.Hour && !sameDay(s.Time.Add(-5*time.Hour), now.Add(-5*time.Hour)) {
+					return fmt.Sprintf(tr("clearing"), summaryWhen(s.Time, now, tr))
+				}
+				return fmt.Sprintf(tr("ending"), tr(current), summaryWhen(s.Time, now, tr))
+			}
+		}
+	}
+	return ""
+}
+
+// sameDay tells whether t is on the day of now in the time zone of t.
+func sameDay(t, now time.Time) bool {
+	y, m, d := now.In(t.Location()).Date()
+	ty, tm, td := t.Date()
+	return ty == y && tm == m && td == d
+}
+
+// summaryTrend names the first later day noticeably warmer or colder than
+// today.
+func summaryTrend(r iface.Data, today int, now time.Time, tr func(string) string) string {
+	high := r.Forecast[today].MaxTempC
+	if high == nil {
+		return ""
+	}
+	for _, d := range r.Forecast[today+1:] {
+		other := d.MaxTempC
+		if other == nil {
+			continue
+		}
+		if *other-*high >= summaryTrendC {
+			return fmt.Sprintf(tr("milder"), tr(d.Date.Weekday().String()))
+		} else if *high-*other >= summaryTrendC {
+			return fmt.Sprintf(tr("colder"), tr(d.Date.Weekday().String()))
+		}
+	}
+	return ""
+}
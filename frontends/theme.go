@@ -0,0 +1,161 @@
+package frontends
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/nafiz1001/wego/iface"
+)
+
+// aatTheme changes the look of the ascii-art-table frontend without
+// recompiling. Themes are TOML files like this:
+//
+//	# replace colors of the 256 color palette, e.g. a darker sun
+//	[palette]
+//	226 = 220
+//
+//	# replace the art of a weather code (five lines, 13 columns each)
+//	[icons]
+//	Fog = ["             ", " - - - - - - ", "  - - - - -  ", " - - - - - - ", "             "]
+//
+//	# like icons, but used when the sun is down
+//	[night-icons]
+//	Sunny = ["...", "...", "...", "...", "..."]
+//
+// Weather codes are named as returned by iface.WeatherCode.String.
+type aatTheme struct {
+	palette    map[int]int
+	icons      map[iface.WeatherCode][]string
+	nightIcons map[iface.WeatherCode][]string
+}
+
+var themeColorEsc = regexp.MustCompile("\033\\[38;5;([0-9]+)")
+
+// ThemeDir returns the directory where named themes are looked up, the themes
+// directory next to the config file.
+func ThemeDir() (string, error) {
+	dir, err := iface.ConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "themes"), nil
+}
+
+// ThemeNames returns the names of all themes in ThemeDir sorted
+// alphabetically.
+func ThemeNames() ([]string, error) {
+	dir, err := ThemeDir()
+	if err != nil {
+		return nil, err
+	}
+	files, err := ioutil.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, f := range files {
+		if !f.IsDir() && strings.HasSuffix(f.Name(), ".toml") {
+			names = append(names, strings.TrimSuffix(f.Name(), ".toml"))
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// loadTheme reads the theme with the given name from ThemeDir. If name looks
+// like a path to a file, that file is read instead.
+func loadTheme(name string) (*aatTheme, error) {
+	path := name
+	if !strings.ContainsRune(name, os.PathSeparator) && !strings.HasSuffix(name, ".toml") {
+		dir, err := ThemeDir()
+		if err != nil {
+			return nil, err
+		}
+		path = filepath.Join(dir, name+".toml")
+	}
+
+	var raw struct {
+		Palette    map[string]int      `toml:"palette"`
+		Icons      map[string][]string `toml:"icons"`
+		NightIcons map[string][]string `toml:"night-icons"`
+	}
+	if _, err := toml.DecodeFile(path, &raw); err != nil {
+		return nil, err
+	}
+
+	t := &aatTheme{palette: make(map[int]int)}
+	for from, to := range raw.Palette {
+		f, err := strconv.Atoi(from)
+		if err != nil || f < 0 || f > 255 || to < 0 || to > 255 {
+			return nil, fmt.Errorf("%s: invalid palette entry %s = %d", path, from, to)
+		}
+		t.palette[f] = to
+	}
+
+	var err error
+	if t.icons, err = parseThemeIcons(raw.Icons); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	if t.nightIcons, err = parseThemeIcons(raw.NightIcons); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return t, nil
+}
+
+func parseThemeIcons(raw map[string][]string) (map[iface.WeatherCode][]string, error) {
+	ret := make(map[iface.WeatherCode][]string)
+	for name, lines := range raw {
+		code, ok := iface.ParseWeatherCode(name)
+		if !ok {
+			return nil, fmt.Errorf("unknown weather code %q", name)
+		}
+		if len(lines) != 5 {
+			return nil, fmt.Errorf("icon %s must have 5 lines, not %d", name, len(lines))
+		}
+		icon := make([]string, len(lines))
+		for i, l := range lines {
+			icon[i] = aatPad(l, 13)
+		}
+		ret[code] = icon
+	}
+	return ret, nil
+}
+
+// themeWriter applies the palette of theme to all output written to w.
+type themeWriter struct {
+	w     io.Writer
+	theme *aatTheme
+}
+
+func (t themeWriter) Write(b []byte) (int, error) {
+	if _, err := io.WriteString(t.w, t.theme.apply(string(b))); err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
+
+// apply replaces the palette colors in an already formatted line. A nil theme
+// leaves the line untouched.
+func (t *aatTheme) apply(line string) string {
+	if t == nil || len(t.palette) == 0 {
+		return line
+	}
+	return themeColorEsc.ReplaceAllStringFunc(line, func(esc string) string {
+		col, _ := strconv.Atoi(esc[len("\033[38;5;"):])
+		if to, ok := t.palette[col]; ok {
+			return fmt.Sprintf("\033[38;5;%03d", to)
+		}
+		return esc
+	})
+}
@@ -0,0 +1,54 @@
+package frontends
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/nafiz1001/wego/iface"
+)
+
+// TestThemePalette checks that the palette of a theme applies to all lines of
+// the table, the alerts and the single and multi location layouts included.
+func TestThemePalette(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "red.toml")
+	if err := ioutil.WriteFile(path, []byte("[palette]\n196 = 201\n226 = 220\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	aat := iface.AllFrontends["ascii-art-table"].(*aatConfig)
+	defer func(name string) { aat.themeName = name }(aat.themeName)
+	r := mockData(t)
+
+	aat.themeName = ""
+	var plain bytes.Buffer
+	if err := aat.Render(&plain, r, iface.UnitsMetric); err != nil {
+		t.Fatal(err)
+	}
+	for _, code := range []string{"\033[38;5;196", "\033[38;5;226"} {
+		if !bytes.Contains(plain.Bytes(), []byte(code)) {
+			t.Fatalf("the table does not use %q without a theme", code)
+		}
+	}
+
+	aat.themeName = path
+	var out bytes.Buffer
+	if err := aat.Render(&out, r, iface.UnitsMetric); err != nil {
+		t.Fatal(err)
+	}
+	if err := aat.RenderAll(&out, []iface.Data{r, r}, iface.UnitsMetric); err != nil {
+		t.Fatal(err)
+	}
+	for _, code := range []string{"\033[38;5;196", "\033[38;5;226"} {
+		if bytes.Contains(out.Bytes(), []byte(code)) {
+			t.Errorf("the theme did not replace %q everywhere", code)
+		}
+	}
+}
+
+func TestThemeDir(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", "/xdg")
+	if dir, err := ThemeDir(); err != nil || dir != filepath.Join("/xdg", "wego", "themes") {
+		t.Errorf("ThemeDir() = %q, %v", dir, err)
+	}
+}
@@ -0,0 +1,71 @@
+package frontends
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/nafiz1001/wego/iface"
+	"github.com/nafiz1001/wego/units"
+)
+
+// snowRatio is the depth of fresh snow per depth of its water equivalent. 10:1
+// is the usual rule of thumb, actual snow ranges from 5:1 to over 20:1.
+const snowRatio = 10
+
+// precipTotals returns the amounts of rain and snow (as water equivalent) in m
+// expected over the days of r, and whether any slot had an amount at all.
+// Slots of snow or a rain and snow mix count as snow. Each slot lasts as long
+// as iface.SlotHours tells.
+func precipTotals(r iface.Data) (rainM, snowM float32, ok bool) {
+	var slots []iface.Cond
+	for _, d := range r.Forecast {
+		slots = append(slots, d.Slots...)
+	}
+	for i, s := range slots {
+		if s.PrecipM == nil {
+			continue
+		}
+		ok = true
+		hours := iface.SlotHours(slots, i)
+		if s.Code.Snow() {
+			snowM += *s.PrecipM * hours
+		} else {
+			rainM += *s.PrecipM * hours
+		}
+	}
+	return
+}
+
+// formatTotals returns the totals footer, like "Next 5 days: 23 mm rain, 11 cm
+// snow", or an empty string if the backend has no precipitation amounts.
+func formatTotals(r iface.Data, unit iface.UnitSystem) string {
+	rainM, snowM, ok := precipTotals(r)
+	if !ok {
+		return ""
+	}
+
+	days := "Next day"
+	if len(r.Forecast) != 1 {
+		days = fmt.Sprintf("Next %d days", len(r.Forecast))
+	}
+	var parts []string
+	if unit.PrecipUnit == units.In {
+		if in := rainM / 0.0254; in >= 0.05 {
+			parts = append(parts, fmt.Sprintf("%s %s rain", iface.FormatFloat(in, 1), iface.NumberLocale.Unit("in")))
+		}
+		if in := snowM * snowRatio / 0.0254; in >= 0.5 {
+			parts = append(parts, fmt.Sprintf("%s %s snow", iface.FormatFloat(in, 0), iface.NumberLocale.Unit("in")))
+		}
+	} else {
+		if mm := rainM * 1000; mm >= 0.5 {
+			parts = append(parts, fmt.Sprintf("%s mm rain", iface.FormatFloat(mm, 0)))
+		}
+		if cm := snowM * snowRatio * 100; cm >= 0.5 {
+			parts = append(parts, fmt.Sprintf("%s cm snow", iface.FormatFloat(cm, 0)))
+		}
+	}
+	if len(parts) == 0 {
+		return days + ": no rain or snow"
+	}
+	return days + ": " + strings.Join(parts, ", ")
+}
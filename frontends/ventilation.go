@@ -0,0 +1,64 @@
+package frontends
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/nafiz1001/wego/iface"
+)
+
+// Mold grows above about 70% relative humidity, 60% leaves a margin for cold
+// walls and corners.
+const (
+	ventilateDryPercent  = 60
+	ventilateMoldPercent = 70
+)
+
+// ventilationAdvice returns what airing out does to air at iface.IndoorTempC
+// when it ends up at rh percent relative humidity.
+func ventilationAdvice(rh float32) string {
+	switch {
+	case rh < ventilateDryPercent:
+		return "good time to air out"
+	case rh < ventilateMoldPercent:
+		return "air out briefly only"
+	}
+	return "airing out adds moisture, mold risk"
+}
+
+// formatVentilation returns a line telling how humid outdoor air gets at the
+// indoor temperature set with -indoor-temp and when the air is driest for the
+// rest of today, or an empty string if it is not set or the humidity is
+// unknown.
+func formatVentilation(r iface.Data, unit iface.UnitSystem) string {
+	if iface.IndoorTempC == nil {
+		return ""
+	}
+	indoor := *iface.IndoorTempC
+	dp, ok := r.Current.DewPointC()
+	if !ok {
+		return ""
+	}
+	rh := iface.RelativeHumidity(indoor, dp)
+	t, u := unit.Temp(indoor)
+	ret := fmt.Sprintf("Outdoor air at %s %s indoors: %d%% humidity, %s", iface.FormatFloat(t, 0), u, int(rh+0.5), ventilationAdvice(rh))
+
+	now := iface.Now()
+	best, bestRH := time.Time{}, rh
+	for _, d := range r.Forecast {
+		for _, s := range d.Slots {
+			if !s.Time.After(now) || !sameDay(s.Time, now) {
+				continue
+			}
+			if dp, ok := s.DewPointC(); ok {
+				if srh := iface.RelativeHumidity(indoor, dp); srh < bestRH-5 {
+					best, bestRH = s.Time, srh
+				}
+			}
+		}
+	}
+	if !best.IsZero() {
+		ret += fmt.Sprintf(", driest at %s (%d%%)", best.Format("15:04"), int(bestRH+0.5))
+	}
+	return ret
+}
@@ -0,0 +1,55 @@
+package frontends
+
+import (
+	"io"
+	"os"
+	"strings"
+
+	colorable "github.com/mattn/go-colorable"
+	runewidth "github.com/mattn/go-runewidth"
+	"github.com/nafiz1001/wego/iface"
+	"golang.org/x/term"
+)
+
+// outputWidth returns the number of columns the output should fit in: the
+// -width flag if set, otherwise the width of the terminal on stdout. It is 0
+// if neither is known, e.g. when piping the output.
+func outputWidth() int {
+	if iface.Width > 0 {
+		return iface.Width
+	}
+	if w, _, err := term.GetSize(int(os.Stdout.Fd())); err == nil && w > 0 {
+		return w
+	}
+	return 0
+}
+
+// colorWriter returns w, translating the ANSI escape sequences for the
+// Windows console if w is one.
+func colorWriter(w io.Writer) io.Writer {
+	if f, ok := w.(*os.File); ok {
+		return colorable.NewColorable(f)
+	}
+	return w
+}
+
+// wrapText breaks s into lines of at most width columns at spaces. Words
+// longer than width get a line of their own. A width <= 0 leaves s as is.
+func wrapText(s string, width int) []string {
+	if width <= 0 {
+		return []string{s}
+	}
+	var lines []string
+	var line string
+	for _, word := range strings.Fields(s) {
+		if line != "" && runewidth.StringWidth(line)+1+runewidth.StringWidth(word) > width {
+			lines = append(lines, line)
+			line = ""
+		}
+		if line != "" {
+			line += " "
+		}
+		line += word
+	}
+	return append(lines, line)
+}
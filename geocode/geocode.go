@@ -0,0 +1,209 @@
+// Package geocode looks up the coordinates of places given by name, like
+// "Toronto" or "Paris, FR", for the backends which only take coordinates.
+package geocode
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/nafiz1001/wego/cache"
+	"github.com/nafiz1001/wego/httpclient"
+	"github.com/nafiz1001/wego/iface"
+)
+
+// Place is the result of a lookup.
+type Place struct {
+	iface.LatLon
+
+	// Name is the full name of the place, like "Paris, Île-de-France,
+	// France".
+	Name string
+}
+
+// provider looks up name at the base URL of the service.
+type provider struct {
+	defaultURL string
+	lookup     func(ctx context.Context, baseURL, name string) (Place, error)
+}
+
+// Providers are the geocoding services to choose from with the -geocoder flag.
+var Providers = map[string]provider{
+	"nominatim":  {"https://nominatim.openstreetmap.org/search", nominatim},
+	"open-meteo": {"https://geocoding-api.open-meteo.com/v1/search", openMeteo},
+}
+
+var (
+	// Provider is set by the -geocoder flag.
+	Provider = "open-meteo"
+
+	// URL is set by the -geocoder-url flag, the base URL of the service of
+	// Provider or empty for the default one.
+	URL string
+)
+
+var coordinates = regexp.MustCompile(`^\s*(-?[0-9]+(?:\.[0-9]+)?)\s*,\s*(-?[0-9]+(?:\.[0-9]+)?)\s*$`)
+
+// ParseLatLon parses a location like "43.65,-79.38" and returns false if it
+// is not a latitude,longitude pair.
+func ParseLatLon(location string) (iface.LatLon, bool) {
+	m := coordinates.FindStringSubmatch(location)
+	if m == nil {
+		return iface.LatLon{}, false
+	}
+	lat, err1 := strconv.ParseFloat(m[1], 32)
+	lon, err2 := strconv.ParseFloat(m[2], 32)
+	if err1 != nil || err2 != nil || lat < -90 || lat > 90 || lon < -180 || lon > 180 {
+		return iface.LatLon{}, false
+	}
+	return iface.LatLon{Latitude: float32(lat), Longitude: float32(lon)}, true
+}
+
+// CheckProvider returns an error if name is not one of Providers.
+func CheckProvider(name string) error {
+	if _, ok := Providers[name]; ok {
+		return nil
+	}
+	var names []string
+	for n := range Providers {
+		names = append(names, n)
+	}
+	return fmt.Errorf("unknown geocoder %q, expected one of %v", name, names)
+}
+
+// Locate returns the place of location, which is either a latitude,longitude
+// pair or the name of a place, optionally followed by the region or country
+// like "Paris, FR". Names are looked up with Provider once and then taken
+// from the cache.
+func Locate(ctx context.Context, location string) (Place, error) {
+	if loc, ok := ParseLatLon(location); ok {
+		return Place{LatLon: loc, Name: strings.TrimSpace(location)}, nil
+	}
+	name := strings.Join(strings.Fields(location), " ")
+	if name == "" {
+		return Place{}, fmt.Errorf("empty location")
+	}
+	p, ok := Providers[Provider]
+	if !ok {
+		return Place{}, CheckProvider(Provider)
+	}
+
+	key := "geocode-" + Provider + "-" + strings.ToLower(name)
+	var ret Place
+	if _, err := cache.Load(key, &ret); err == nil {
+		return ret, nil
+	}
+	base := URL
+	if base == "" {
+		base = p.defaultURL
+	}
+	ret, err := p.lookup(ctx, base, name)
+	if err != nil {
+		return Place{}, fmt.Errorf("unable to find %q with %s: %v", name, Provider, err)
+	}
+	// a failure to cache only costs another lookup next time
+	cache.Store(key, ret)
+	return ret, nil
+}
+
+// get fetches u and decodes the json response into v.
+func get(ctx context.Context, u string, v interface{}) error {
+	// the usage policy of Nominatim requires the User-Agent of httpclient
+	res, err := httpclient.Get(ctx, "", u, nil)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s: %s", u, res.Status)
+	}
+	return json.NewDecoder(res.Body).Decode(v)
+}
+
+// nominatim looks up name with the search API of Nominatim, the geocoder of
+// OpenStreetMap. See https://nominatim.org/release-docs/latest/api/Search/
+func nominatim(ctx context.Context, baseURL, name string) (Place, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return Place{}, err
+	}
+	q := u.Query()
+	q.Set("q", name)
+	q.Set("format", "jsonv2")
+	q.Set("limit", "1")
+	u.RawQuery = q.Encode()
+
+	var resp []struct {
+		Lat         string `json:"lat"`
+		Lon         string `json:"lon"`
+		DisplayName string `json:"display_name"`
+	}
+	if err := get(ctx, u.String(), &resp); err != nil {
+		return Place{}, err
+	}
+	if len(resp) == 0 {
+		return Place{}, fmt.Errorf("no such place")
+	}
+	loc, ok := ParseLatLon(resp[0].Lat + "," + resp[0].Lon)
+	if !ok {
+		return Place{}, fmt.Errorf("malformed coordinates %s,%s", resp[0].Lat, resp[0].Lon)
+	}
+	return Place{LatLon: loc, Name: resp[0].DisplayName}, nil
+}
+
+// openMeteo looks up name with the geocoding API of Open-Meteo. It only
+// searches by the name of the place, so anything after the first comma, like
+// the country code in "Paris, FR", picks among the results by their country,
+// country code or region. See https://open-meteo.com/en/docs/geocoding-api
+func openMeteo(ctx context.Context, baseURL, name string) (Place, error) {
+	parts := strings.Split(name, ",")
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return Place{}, err
+	}
+	q := u.Query()
+	q.Set("name", strings.TrimSpace(parts[0]))
+	q.Set("count", "10")
+	q.Set("format", "json")
+	u.RawQuery = q.Encode()
+
+	var resp struct {
+		Results []struct {
+			Name        string  `json:"name"`
+			Latitude    float32 `json:"latitude"`
+			Longitude   float32 `json:"longitude"`
+			CountryCode string  `json:"country_code"`
+			Country     string  `json:"country"`
+			Admin1      string  `json:"admin1"`
+		} `json:"results"`
+	}
+	if err := get(ctx, u.String(), &resp); err != nil {
+		return Place{}, err
+	}
+
+	for _, r := range resp.Results {
+		matches := true
+		for _, part := range parts[1:] {
+			part = strings.TrimSpace(part)
+			if !strings.EqualFold(part, r.CountryCode) && !strings.EqualFold(part, r.Country) && !strings.EqualFold(part, r.Admin1) {
+				matches = false
+			}
+		}
+		if !matches {
+			continue
+		}
+		var full []string
+		for _, n := range []string{r.Name, r.Admin1, r.Country} {
+			if n != "" {
+				full = append(full, n)
+			}
+		}
+		return Place{LatLon: iface.LatLon{Latitude: r.Latitude, Longitude: r.Longitude}, Name: strings.Join(full, ", ")}, nil
+	}
+	return Place{}, fmt.Errorf("no such place")
+}
@@ -1,15 +1,19 @@
 module github.com/nafiz1001/wego
 
-go 1.17
+go 1.18
 
 require (
+	github.com/BurntSushi/toml v1.2.1
 	github.com/mattn/go-colorable v0.1.12
+	github.com/mattn/go-isatty v0.0.14
 	github.com/mattn/go-runewidth v0.0.13
 	github.com/schachmat/ingo v0.0.0-20170403011506-a4bdc0729a3f
+	go.starlark.net v0.0.0-20220302181546-5411bad688d1
+	golang.org/x/image v0.0.0-20211028202545-6944b10bf410
+	golang.org/x/term v0.0.0-20210927222741-03fcf44c2211
 )
 
 require (
-	github.com/mattn/go-isatty v0.0.14 // indirect
 	github.com/rivo/uniseg v0.2.0 // indirect
 	golang.org/x/net v0.0.0-20211216030914-fe4d6282115f // indirect
 	golang.org/x/sys v0.0.0-20211216021012-1d35b9e2eb4e // indirect
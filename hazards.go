@@ -0,0 +1,190 @@
+package main
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/nafiz1001/wego/httpclient"
+	"github.com/nafiz1001/wego/iface"
+)
+
+// set by the -quakes… and -tsunamis… flags
+var (
+	quakesEnabled    bool
+	quakesURL        string
+	quakesRadiusKm   float64
+	tsunamisEnabled  bool
+	tsunamisURLs     string
+	tsunamisRadiusKm float64
+)
+
+// hazardsMaxAge is how old an earthquake or tsunami message may be to be shown.
+const hazardsMaxAge = 24 * time.Hour
+
+// fetchFeed gets url and passes the body to decode.
+func fetchFeed(url string, decode func(io.Reader) error) error {
+	res, err := httpclient.Get(netCtx, "", url, nil)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s: %s", url, res.Status)
+	}
+	if err := decode(res.Body); err != nil {
+		return fmt.Errorf("unable to decode %s: %v", url, err)
+	}
+	return nil
+}
+
+// usgsQuakes is the GeoJSON summary feed of the USGS earthquake hazards
+// program.
+type usgsQuakes struct {
+	Features []struct {
+		Properties struct {
+			Mag     float64 `json:"mag"`
+			Place   string  `json:"place"`
+			Time    int64   `json:"time"` // ms since the epoch
+			Tsunami int     `json:"tsunami"`
+		} `json:"properties"`
+		Geometry struct {
+			Coordinates []float64 `json:"coordinates"` // lon, lat, depth
+		} `json:"geometry"`
+	} `json:"features"`
+}
+
+// quakeWarnings returns one line for every earthquake of the last day within
+// quakesRadiusKm of loc, e.g. "M6.1 earthquake 45 km SW of Port Hardy, Canada
+// (210 km NW of the location), 3 hours ago".
+func quakeWarnings(loc iface.LatLon, unit iface.UnitSystem) ([]string, error) {
+	var feed usgsQuakes
+	if err := fetchFeed(quakesURL, func(r io.Reader) error {
+		return json.NewDecoder(r).Decode(&feed)
+	}); err != nil {
+		return nil, err
+	}
+
+	var ret []string
+	for _, f := range feed.Features {
+		p, c := f.Properties, f.Geometry.Coordinates
+		when := time.Unix(0, p.Time*int64(time.Millisecond))
+		if len(c) < 2 || iface.Now().Sub(when) > hazardsMaxAge {
+			continue
+		}
+		dist, bearing := greatCircle(float64(loc.Latitude), float64(loc.Longitude), c[1], c[0])
+		if dist > quakesRadiusKm {
+			continue
+		}
+		d, u := unit.Distance(float32(dist * 1000))
+		line := fmt.Sprintf("M%.1f earthquake %s (%d %s %s of the location), %s", p.Mag, p.Place, int(d), u, iface.CompassPoint(bearing), hazardAge(when))
+		if p.Tsunami != 0 {
+			line += ", check tsunami.gov for tsunami messages"
+		}
+		ret = append(ret, line)
+	}
+	return ret, nil
+}
+
+// tsunamiFeed is an Atom feed of a NOAA tsunami warning center.
+type tsunamiFeed struct {
+	Entries []struct {
+		Title   string    `xml:"title"`
+		Updated time.Time `xml:"updated"`
+		Lat     float64   `xml:"http://www.w3.org/2003/01/geo/wgs84_pos# lat"`
+		Long    float64   `xml:"http://www.w3.org/2003/01/geo/wgs84_pos# long"`
+		Summary struct {
+			Content string `xml:",innerxml"`
+		} `xml:"summary"`
+	} `xml:"entry"`
+}
+
+var (
+	htmlTag         = regexp.MustCompile("<[^>]*>")
+	tsunamiCategory = regexp.MustCompile(`Category:\s*(\w+)`)
+)
+
+// tsunamiLevels are the message categories of the warning centers which are
+// shown, informational statements are not.
+var tsunamiLevels = map[string]bool{"Warning": true, "Advisory": true, "Watch": true, "Threat": true}
+
+// tsunamiWarnings returns one line for every tsunami warning, advisory, watch
+// or threat message of the last day with its source earthquake within
+// tsunamisRadiusKm of loc.
+func tsunamiWarnings(loc iface.LatLon) ([]string, error) {
+	var ret []string
+	for _, url := range strings.Split(tsunamisURLs, ",") {
+		var feed tsunamiFeed
+		if err := fetchFeed(strings.TrimSpace(url), func(r io.Reader) error {
+			return xml.NewDecoder(r).Decode(&feed)
+		}); err != nil {
+			return nil, err
+		}
+		for _, e := range feed.Entries {
+			if iface.Now().Sub(e.Updated) > hazardsMaxAge {
+				continue
+			}
+			if dist, _ := greatCircle(float64(loc.Latitude), float64(loc.Longitude), e.Lat, e.Long); dist > tsunamisRadiusKm {
+				continue
+			}
+			m := tsunamiCategory.FindStringSubmatch(htmlTag.ReplaceAllString(e.Summary.Content, " "))
+			if m == nil || !tsunamiLevels[m[1]] {
+				continue
+			}
+			ret = append(ret, fmt.Sprintf("Tsunami %s: %s, %s", strings.ToLower(m[1]), strings.TrimSpace(e.Title), hazardAge(e.Updated)))
+		}
+	}
+	return ret, nil
+}
+
+// hazardAge returns how long ago t was, e.g. "3 hours ago", or the time like
+// "at Mon 15:04 UTC" in deterministic mode.
+func hazardAge(t time.Time) string {
+	if iface.Deterministic {
+		return "at " + t.UTC().Format("Mon 15:04") + " UTC"
+	}
+	d := iface.Now().Sub(t)
+	if d < 90*time.Minute {
+		return fmt.Sprintf("%d minutes ago", int(d.Minutes()+0.5))
+	}
+	return fmt.Sprintf("%d hours ago", int(d.Hours()+0.5))
+}
+
+// printHazards prints the warnings of the enabled hazard checks (storms,
+// earthquakes and tsunamis) for the location of r to stderr, so they do not
+// end up in the output of the json frontend.
+func printHazards(r iface.Data, unit iface.UnitSystem) {
+	if r.GeoLoc == nil {
+		log.Println("Unable to check for hazards: the backend returned no coordinates")
+		return
+	}
+	checks := []struct {
+		enabled bool
+		name    string
+		check   func() ([]string, error)
+	}{
+		{stormsEnabled, "storms", func() ([]string, error) { return stormWarnings(*r.GeoLoc, unit) }},
+		{quakesEnabled, "earthquakes", func() ([]string, error) { return quakeWarnings(*r.GeoLoc, unit) }},
+		{tsunamisEnabled, "tsunamis", func() ([]string, error) { return tsunamiWarnings(*r.GeoLoc) }},
+	}
+	for _, c := range checks {
+		if !c.enabled {
+			continue
+		}
+		warnings, err := c.check()
+		if err != nil {
+			log.Printf("Unable to check for %s: %v", c.name, err)
+			continue
+		}
+		for _, w := range warnings {
+			fmt.Fprintln(os.Stderr, w)
+		}
+	}
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/nafiz1001/wego/iface"
+)
+
+// set by the -from, -to, -out and -history-delay flags of the history command
+var (
+	historyFrom  string
+	historyTo    string
+	historyOut   string
+	historyDelay time.Duration
+)
+
+// historyColumns are the columns of the csv file written by history fetch.
+var historyColumns = []string{"date", "max_temp_c", "min_temp_c", "mean_temp_c", "precip_mm", "max_wind_kmph", "max_gust_kmph"}
+
+// historyRow returns the csv row of day with empty cells for unknown values.
+func historyRow(day iface.Day) []string {
+	cell := func(v *float32, prec int) string {
+		if v == nil {
+			return ""
+		}
+		return fmt.Sprintf("%.*f", prec, *v)
+	}
+	var mean, wind, gust *float32
+	var sum float32
+	var n int
+	for _, s := range day.Slots {
+		if s.TempC != nil {
+			sum += *s.TempC
+			n++
+		}
+		if s.WindspeedKmph != nil && (wind == nil || *s.WindspeedKmph > *wind) {
+			wind = s.WindspeedKmph
+		}
+		if s.WindGustKmph != nil && (gust == nil || *s.WindGustKmph > *gust) {
+			gust = s.WindGustKmph
+		}
+	}
+	if n > 0 {
+		m := sum / float32(n)
+		mean = &m
+	}
+	s := summarizeDay(day)
+	var precipMm *float32
+	if s.PrecipM != nil {
+		p := *s.PrecipM * 1000
+		precipMm = &p
+	}
+	return []string{day.Date.Format("2006-01-02"), cell(s.MaxTempC, 1), cell(s.MinTempC, 1), cell(mean, 1), cell(precipMm, 1), cell(wind, 0), cell(gust, 0)}
+}
+
+// historyBackends returns the names of the backends which can fetch past
+// days.
+func historyBackends() (ret []string) {
+	for name, be := range iface.AllBackends {
+		if _, ok := be.(iface.HistoryBackend); ok {
+			ret = append(ret, name)
+		}
+	}
+	sort.Strings(ret)
+	return
+}
+
+// runHistory downloads the weather of the days from -from to -to as csv with
+// "wego history fetch", page by page and waiting -history-delay between the
+// requests to stay within the rate limits of the backend.
+func runHistory(backend string, location string, numdays int, unit iface.UnitSystem) {
+	if len(commandArgs) == 0 || commandArgs[0] != "fetch" {
+		log.Fatal("Usage: wego history fetch -from 2020-01-01 -to 2023-12-31 [-out data.csv]")
+	}
+	sel, _ := iface.SelectBackend(backend)
+	be, ok := sel.(iface.HistoryBackend)
+	if !ok {
+		log.Fatalf("The %s backend cannot fetch past days, use one of: %s", backend, strings.Join(historyBackends(), ", "))
+	}
+	from, err := time.Parse("2006-01-02", historyFrom)
+	if err != nil {
+		log.Fatalf("Invalid -from date %q, use YYYY-MM-DD", historyFrom)
+	}
+	to := iface.Now().AddDate(0, 0, -1)
+	if historyTo != "" {
+		if to, err = time.Parse("2006-01-02", historyTo); err != nil {
+			log.Fatalf("Invalid -to date %q, use YYYY-MM-DD", historyTo)
+		}
+	}
+	if to.Before(from) {
+		log.Fatalf("-to %s is before -from %s", to.Format("2006-01-02"), from.Format("2006-01-02"))
+	}
+
+	var out io.Writer = os.Stdout
+	if historyOut != "" {
+		f, err := os.Create(historyOut)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer f.Close()
+		out = f
+	}
+	w := csv.NewWriter(out)
+	w.Write(historyColumns)
+
+	// rows are written page by page, so a failure keeps what was fetched
+	for next := from; !next.After(to); {
+		if next != from && historyDelay > 0 {
+			select {
+			case <-time.After(historyDelay):
+			case <-netCtx.Done():
+				w.Flush()
+				log.Fatalf("Interrupted, continue with -from %s", next.Format("2006-01-02"))
+			}
+		}
+		ctx, cancel := context.WithTimeout(netCtx, netTimeout)
+		days, err := be.FetchHistory(ctx, location, next, to)
+		cancel()
+		if err == nil && len(days) == 0 {
+			err = fmt.Errorf("no data for %s", next.Format("2006-01-02"))
+		}
+		if err != nil {
+			w.Flush()
+			log.Fatalf("Unable to fetch the history, continue with -from %s: %v", next.Format("2006-01-02"), err)
+		}
+
+		r := iface.Data{Forecast: days}
+		iface.FillDays(&r)
+		for _, d := range r.Forecast {
+			w.Write(historyRow(d))
+		}
+		w.Flush()
+		if err := w.Error(); err != nil {
+			log.Fatal(err)
+		}
+
+		y, m, d := days[len(days)-1].Date.Date()
+		last := time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+		if !last.After(next.AddDate(0, 0, -1)) {
+			log.Fatalf("The %s backend returned %s when asked for %s", backend, last.Format("2006-01-02"), next.Format("2006-01-02"))
+		}
+		next = last.AddDate(0, 0, 1)
+		if historyOut != "" {
+			log.Printf("Fetched until %s", last.Format("2006-01-02"))
+		}
+	}
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"strings"
+
+	starjson "go.starlark.net/lib/json"
+	"go.starlark.net/starlark"
+
+	"github.com/nafiz1001/wego/iface"
+)
+
+// hookScript is set by the -hook-script flag.
+var hookScript string
+
+// the functions of the -hook-script and the thread they run in
+var (
+	scriptThread     *starlark.Thread
+	scriptPostFetch  starlark.Callable
+	scriptPreRender  starlark.Callable
+	scriptHookByName = map[string]*starlark.Callable{
+		"post_fetch": &scriptPostFetch,
+		"pre_render": &scriptPreRender,
+	}
+)
+
+// onChange is set by the -on-change flag.
+var onChange string
+
+// loadHookScript runs the Starlark script at path, which defines the hooks
+// post_fetch and pre_render, or one of them.
+func loadHookScript(path string) error {
+	if path == "" {
+		return nil
+	}
+	scriptThread = &starlark.Thread{
+		Name:  "hook-script",
+		Print: func(_ *starlark.Thread, msg string) { log.Println("hook-script:", msg) },
+	}
+	globals, err := starlark.ExecFile(scriptThread, path, nil, starlark.StringDict{"json": starjson.Module})
+	if err != nil {
+		return fmt.Errorf("hook-script: %v", err)
+	}
+	found := false
+	for name, hook := range scriptHookByName {
+		v, ok := globals[name]
+		if !ok {
+			continue
+		}
+		if *hook, ok = v.(starlark.Callable); !ok {
+			return fmt.Errorf("hook-script: %s is a %s, not a function", name, v.Type())
+		}
+		found = true
+	}
+	if !found {
+		return fmt.Errorf("hook-script: %s defines neither post_fetch nor pre_render", path)
+	}
+	return nil
+}
+
+// toStarlark converts r to the dicts and lists of its json document.
+func toStarlark(r iface.Data) (starlark.Value, error) {
+	b, err := json.Marshal(r)
+	if err != nil {
+		return nil, err
+	}
+	return starlark.Call(scriptThread, starjson.Module.Members["decode"], starlark.Tuple{starlark.String(b)}, nil)
+}
+
+// fromStarlark converts v, which must have the shape of the json document of
+// iface.Data, back to the data.
+func fromStarlark(v starlark.Value) (r iface.Data, err error) {
+	s, err := starlark.Call(scriptThread, starjson.Module.Members["encode"], starlark.Tuple{v}, nil)
+	if err != nil {
+		return r, err
+	}
+	err = json.Unmarshal([]byte(s.(starlark.String)), &r)
+	return r, err
+}
+
+// applyPostFetch passes r to the post_fetch function of the -hook-script and
+// replaces it with the data returned. A function returning None keeps the
+// changes it made to its argument.
+func applyPostFetch(r *iface.Data) {
+	if scriptPostFetch == nil {
+		return
+	}
+	v, err := toStarlark(*r)
+	if err == nil {
+		var ret starlark.Value
+		if ret, err = starlark.Call(scriptThread, scriptPostFetch, starlark.Tuple{v}, nil); err == nil {
+			if ret != starlark.None {
+				v = ret
+			}
+			*r, err = fromStarlark(v)
+		}
+	}
+	if err != nil {
+		log.Fatalf("hook-script: post_fetch: %v", err)
+	}
+}
+
+// renderVetoed tells whether the pre_render function of the -hook-script
+// returns a false value for r, so nothing should be rendered.
+func renderVetoed(r iface.Data) bool {
+	if scriptPreRender == nil {
+		return false
+	}
+	v, err := toStarlark(r)
+	if err == nil {
+		if v, err = starlark.Call(scriptThread, scriptPreRender, starlark.Tuple{v}, nil); err == nil {
+			return !bool(v.Truth())
+		}
+	}
+	log.Fatalf("hook-script: pre_render: %v", err)
+	return false
+}
+
+// alertState returns the severity and headline of the most severe alert of r,
+// or empty strings if there is none.
+func alertState(r iface.Data) (severity, headline string) {
+	if len(r.Alerts) == 0 {
+		return "", ""
+	}
+	return r.Alerts[0].Severity, r.Alerts[0].Headline
+}
+
+// runOnChange runs the -on-change command if the weather code of the current
+// conditions or the most severe alert of cur differ from those of prev, or if
+// prev is nil as nothing is known about the previous state. The new state is
+// passed in WEGO_* environment variables. The command line is split at
+// spaces, it is not run by a shell.
+func runOnChange(prev *iface.Data, cur iface.Data) {
+	args := strings.Fields(onChange)
+	if len(args) == 0 {
+		return
+	}
+	severity, headline := alertState(cur)
+	var changed []string
+	prevCode := ""
+	if prev == nil {
+		changed = []string{"code", "alert"}
+	} else {
+		prevCode = prev.Current.Code.String()
+		if prev.Current.Code != cur.Current.Code {
+			changed = append(changed, "code")
+		}
+		if s, h := alertState(*prev); s != severity || h != headline {
+			changed = append(changed, "alert")
+		}
+	}
+	if len(changed) == 0 {
+		return
+	}
+
+	temp, isDay := "", ""
+	if cur.Current.TempC != nil {
+		temp = fmt.Sprintf("%.1f", *cur.Current.TempC)
+	}
+	if cur.Current.IsDay != nil {
+		isDay = fmt.Sprint(*cur.Current.IsDay)
+	}
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Env = append(os.Environ(),
+		"WEGO_CHANGED="+strings.Join(changed, ","),
+		"WEGO_LOCATION="+cur.Location,
+		"WEGO_CODE="+cur.Current.Code.String(),
+		"WEGO_PREV_CODE="+prevCode,
+		"WEGO_DESC="+cur.Current.Desc,
+		"WEGO_TEMP="+temp,
+		"WEGO_IS_DAY="+isDay,
+		"WEGO_ALERT="+severity,
+		"WEGO_ALERT_HEADLINE="+headline,
+	)
+	// keep the output of the command out of the one of the frontend
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		log.Printf("on-change: %v", err)
+	}
+}
@@ -0,0 +1,166 @@
+// Package httpclient is the HTTP client of wego for the requests to weather
+// services and other feeds. It sends the -user-agent, routes requests through
+// a proxy of their own or the one of the HTTP_PROXY and HTTPS_PROXY variables,
+// retries failed requests with exponential backoff and limits the size of
+// responses. The transport requests gzip and decompresses it transparently.
+package httpclient
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/nafiz1001/wego/iface"
+	"github.com/nafiz1001/wego/metrics"
+)
+
+var (
+	// UserAgent is set by the -user-agent flag.
+	UserAgent = "wego https://github.com/nafiz1001/wego"
+
+	// Retries is set by the -retries flag, the number of times a request is
+	// repeated after a network error, a timeout or a 5xx status.
+	Retries = 2
+
+	// Backoff is the delay before the first retry, doubled for every further
+	// one.
+	Backoff = time.Second
+)
+
+var (
+	requests = metrics.NewCounter("wego_upstream_requests_total", "Requests to web services by host and status code, or error.", "host", "code")
+	latency  = metrics.NewHistogram("wego_upstream_request_duration_seconds", "Time until the response header of web services, by host.", metrics.DefaultBuckets, "host")
+)
+
+// clients are the clients of the proxies used so far, so connections are
+// reused.
+var clients sync.Map
+
+// limitedBody is a response body failing with an error instead of returning
+// more than max bytes.
+type limitedBody struct {
+	io.ReadCloser
+	uri  string
+	max  int64
+	read int64
+}
+
+func (b *limitedBody) Read(p []byte) (n int, err error) {
+	if b.read > b.max {
+		return 0, fmt.Errorf("response of %s is larger than %d bytes", b.uri, b.max)
+	}
+	// read one byte more than allowed to tell a body of exactly max bytes
+	// from a larger one
+	if left := b.max + 1 - b.read; int64(len(p)) > left {
+		p = p[:left]
+	}
+	n, err = b.ReadCloser.Read(p)
+	b.read += int64(n)
+	if b.read > b.max {
+		return n - int(b.read-b.max), fmt.Errorf("response of %s is larger than %d bytes", b.uri, b.max)
+	}
+	return n, err
+}
+
+// client returns the client connecting through proxy, or the default client
+// if it is empty. The proxy is an http, https or socks5 URL, e.g.
+// socks5://127.0.0.1:9050 for Tor. Host names are resolved by a socks5 proxy,
+// so they do not leak to the local DNS server.
+func client(proxy string) (*http.Client, error) {
+	if proxy == "" {
+		return http.DefaultClient, nil
+	}
+	if c, ok := clients.Load(proxy); ok {
+		return c.(*http.Client), nil
+	}
+	u, err := url.Parse(proxy)
+	if err != nil {
+		return nil, fmt.Errorf("invalid proxy %q: %v", proxy, err)
+	}
+	switch u.Scheme {
+	case "http", "https", "socks5":
+	default:
+		return nil, fmt.Errorf("invalid proxy %q: unsupported scheme %q", proxy, u.Scheme)
+	}
+
+	// clone the default transport to keep the network settings of main
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.Proxy = http.ProxyURL(u)
+	c, _ := clients.LoadOrStore(proxy, &http.Client{Transport: t, Timeout: http.DefaultClient.Timeout})
+	return c.(*http.Client), nil
+}
+
+// retryable tells whether a request which returned res and err should be
+// repeated: after network errors and timeouts, unless ctx is done, and on
+// server errors.
+func retryable(ctx context.Context, res *http.Response, err error) bool {
+	if err != nil {
+		return ctx.Err() == nil
+	}
+	return res.StatusCode >= 500
+}
+
+// Get fetches uri with the deadline of ctx, through proxy if it is not empty
+// and with the additional header, e.g. a User-Agent required by the service.
+// Failed requests are retried up to Retries times, the response of the last
+// attempt is returned.
+//
+// Reading the body fails once it exceeds iface.MaxResponseSize. As the
+// transport decompresses gzip transparently, this limits the decompressed
+// size.
+func Get(ctx context.Context, proxy string, uri string, header http.Header) (*http.Response, error) {
+	c, err := client(proxy)
+	if err != nil {
+		return nil, err
+	}
+
+	var res *http.Response
+	for attempt := 0; ; attempt++ {
+		req, err := http.NewRequestWithContext(ctx, "GET", uri, nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("User-Agent", UserAgent)
+		for k, v := range header {
+			req.Header[k] = v
+		}
+		start := time.Now()
+		res, err = c.Do(req)
+		latency.Observe(time.Since(start).Seconds(), req.URL.Host)
+		if err != nil {
+			requests.Inc(req.URL.Host, "error")
+		} else {
+			requests.Inc(req.URL.Host, strconv.Itoa(res.StatusCode))
+		}
+		if attempt >= Retries || !retryable(ctx, res, err) {
+			if err != nil {
+				return nil, err
+			}
+			break
+		}
+		if res != nil {
+			io.Copy(io.Discard, io.LimitReader(res.Body, 64<<10))
+			res.Body.Close()
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(Backoff << attempt):
+		}
+	}
+
+	if iface.MaxResponseSize <= 0 {
+		return res, nil
+	}
+	if res.ContentLength > iface.MaxResponseSize {
+		res.Body.Close()
+		return nil, fmt.Errorf("response of %s is larger than %d bytes", uri, iface.MaxResponseSize)
+	}
+	res.Body = &limitedBody{ReadCloser: res.Body, uri: uri, max: iface.MaxResponseSize}
+	return res, nil
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/nafiz1001/wego/cache"
+	"github.com/nafiz1001/wego/iface"
+	"github.com/nafiz1001/wego/units"
+)
+
+// set by the -ice-name, -ice-water and -ice-freeze-up flags
+var (
+	iceName     string
+	iceWater    string
+	iceFreezeUp string
+)
+
+// iceCoefficients are the coefficients of Stefan's law in cm per square root of
+// a degree-day for the kinds of water bodies of -ice-water, after the
+// empirical ones by Michel for windy lakes without snow down to small rivers
+// sheltered by snow and trees.
+var iceCoefficients = map[string]float64{
+	"windy-lake":      2.7,
+	"lake":            2.0,
+	"river":           1.5,
+	"sheltered-river": 1.0,
+}
+
+// iceLimits are the thicknesses of clear ice in cm the Canadian Red Cross
+// recommends for going out on it, thickest first.
+var iceLimits = []struct {
+	cm   float64
+	what string
+}{
+	{25, "snowmobiles"},
+	{20, "groups, skating parties"},
+	{15, "one person walking or skating"},
+}
+
+// iceHistoryDays is the number of days before today listed by the ice command.
+const iceHistoryDays = 7
+
+// iceDay is a day of the accumulation of freezing degree-days.
+type iceDay struct {
+	date  time.Time
+	meanC *float32 // nil if the day is unknown
+	fdd   float64  // freezing degree-days in degrees celsius since freeze-up
+	cm    float64  // estimated ice thickness
+}
+
+// iceSafety returns what ice of thickness cm is safe for.
+func iceSafety(cm float64) string {
+	for _, l := range iceLimits {
+		if cm >= l.cm {
+			return l.what
+		}
+	}
+	return "stay off"
+}
+
+// accumulateIce sums up the freezing degree-days of the daily means of days,
+// keyed by date, from freezeUp (or the first day if it is zero) and estimates
+// the ice grown with Stefan's law: thickness = coef * √FDD. Thawing days
+// subtract from the sum, so it falls back to zero when the ice is gone and
+// starts again at the next freeze-up. Unknown days leave the sum as it is.
+// The second result is the day the water last froze over, or zero if there is
+// no ice.
+func accumulateIce(days map[string]historyDay, freezeUp time.Time, coef float64) ([]iceDay, time.Time) {
+	var dates []string
+	for date := range days {
+		dates = append(dates, date)
+	}
+	sort.Strings(dates)
+
+	var ret []iceDay
+	var fdd float64
+	var since time.Time
+	for _, date := range dates {
+		t, err := time.ParseInLocation("2006-01-02", date, time.Local)
+		if err != nil || t.Before(freezeUp) {
+			continue
+		}
+		d := iceDay{date: t}
+		s := days[date]
+		if s.MaxTempC != nil && s.MinTempC != nil {
+			mean := (*s.MaxTempC + *s.MinTempC) / 2
+			d.meanC = &mean
+			if fdd == 0 && mean < 0 {
+				since = t
+			}
+			fdd = math.Max(0, fdd-float64(mean))
+			if fdd == 0 {
+				since = time.Time{}
+			}
+		}
+		d.fdd, d.cm = fdd, coef*math.Sqrt(fdd)
+		ret = append(ret, d)
+	}
+	return ret, since
+}
+
+// runIce estimates the thickness of the ice on a lake or river near the
+// location from the freezing degree-days of the daily means of the history
+// cache and the forecast, for ice fishers and skaters. The history only goes
+// back to the first fetch for the location, so the daemon command should run
+// through the winter.
+func runIce(backend string, location string, numdays int, unit iface.UnitSystem) {
+	coef, ok := iceCoefficients[iceWater]
+	if !ok {
+		log.Fatalf("Unknown water body %q, choices are: windy-lake, lake, river, sheltered-river", iceWater)
+	}
+	var freezeUp time.Time
+	if iceFreezeUp != "" {
+		var err error
+		if freezeUp, err = time.ParseInLocation("2006-01-02", iceFreezeUp, time.Local); err != nil {
+			log.Fatalf("Invalid -ice-freeze-up %q, expected a date like 2026-12-01", iceFreezeUp)
+		}
+	}
+
+	r := fetch(backend, location, numdays)
+	days := make(map[string]historyDay)
+	if _, err := cache.Load(cache.HistoryKey(backend, location), &days); err != nil {
+		days = make(map[string]historyDay)
+	}
+	for _, d := range r.Forecast {
+		days[d.Date.Format("2006-01-02")] = summarizeDay(d)
+	}
+	acc, since := accumulateIce(days, freezeUp, coef)
+
+	fmt.Printf("Ice on %s at %s\n", iceName, r.Location)
+	if since.IsZero() {
+		fmt.Println("Open water, no freezing degree-days since the last thaw.")
+	} else {
+		fmt.Printf("Frozen over since %s\n", since.Format("Mon 02. Jan 2006"))
+	}
+	fmt.Println()
+
+	now := iface.Now()
+	first := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local).AddDate(0, 0, -iceHistoryDays)
+	_, tempUnit := unit.Temp(0)
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintf(w, "DAY\tMEAN\tFDD (%s·d)\tICE\tSAFE FOR\n", tempUnit)
+	for _, d := range acc {
+		if d.date.Before(first) {
+			continue
+		}
+		mean := "-"
+		if d.meanC != nil {
+			t, u := unit.Temp(*d.meanC)
+			mean = iface.FormatInt(int(math.Round(float64(t)))) + " " + u
+		}
+		// degree-days are a difference, so they only scale
+		fdd := d.fdd
+		if unit.TempUnit == units.Fahrenheit {
+			fdd *= 1.8
+		}
+		thickness, safety := "-", "-"
+		if d.cm > 0 {
+			thickness = agroRange([]float32{float32(d.cm)}, func(cm float32) (float32, string) { return convertCm(cm, unit) })
+			safety = iceSafety(d.cm)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", d.date.Format("Mon 02. Jan"), mean, iface.FormatInt(int(math.Round(fdd))), thickness, safety)
+	}
+	w.Flush()
+	fmt.Println("\nEstimated from the air temperature only. Snow, currents and springs make ice")
+	fmt.Println("thinner, and white ice is half as strong as clear ice: measure before going out.")
+}
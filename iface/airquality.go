@@ -0,0 +1,50 @@
+package iface
+
+// ShowAirQuality is set by the -aqi flag. If it is true, wego fetches the air
+// quality at the location and frontends should show Data.AirQuality.
+var ShowAirQuality bool
+
+// AirQuality is the current air pollution at a location. Concentrations are
+// in µg/m³ and nil if unknown.
+type AirQuality struct {
+	// AQI is the air quality index of the US EPA from 0 to 500, nil if
+	// unknown.
+	AQI *int
+
+	// Category is the name of the level of AQI, see AQICategory.
+	Category string
+
+	// Advice is the advice of the level of AQI on exercising outdoors.
+	Advice string
+
+	PM25Ugm3 *float32
+	PM10Ugm3 *float32
+	O3Ugm3   *float32
+	NO2Ugm3  *float32
+}
+
+// AQICategories are the levels of the AQI of the US EPA by their highest
+// index, with the advice of the EPA on exercising outdoors.
+var AQICategories = []struct {
+	Max    int
+	Name   string
+	Advice string
+}{
+	{50, "Good", "good for outdoor exercise"},
+	{100, "Moderate", "unusually sensitive people should take it easier outdoors"},
+	{150, "Unhealthy for Sensitive Groups", "sensitive groups should reduce outdoor exercise"},
+	{200, "Unhealthy", "unhealthy for outdoor exercise"},
+	{300, "Very Unhealthy", "avoid outdoor exercise"},
+	{500, "Hazardous", "stay indoors"},
+}
+
+// AQICategory returns the index into AQICategories of the level of aqi.
+// Values above 500 are Hazardous as well.
+func AQICategory(aqi int) int {
+	for i, c := range AQICategories {
+		if aqi <= c.Max {
+			return i
+		}
+	}
+	return len(AQICategories) - 1
+}
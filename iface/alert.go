@@ -0,0 +1,38 @@
+package iface
+
+import (
+	"sort"
+	"time"
+)
+
+// Alert is a weather warning, watch or advisory issued for the location.
+type Alert struct {
+	// Severity is one of the CAP severities Minor, Moderate, Severe and
+	// Extreme, or Unknown. See AlertSeverities.
+	Severity string
+	Headline string
+
+	// Effective is the time the alert was issued or takes effect, Expires the
+	// time it ends. Either is zero if the backend does not tell.
+	Effective time.Time
+	Expires   time.Time
+
+	// URL is the page with the full text of the alert, if any.
+	URL string
+}
+
+// AlertSeverities ranks the severities of Alert, anything else counts as
+// Unknown.
+var AlertSeverities = map[string]int{"Unknown": 0, "Minor": 1, "Moderate": 2, "Severe": 3, "Extreme": 4}
+
+// SortAlerts orders alerts by decreasing severity, then by the time they take
+// effect.
+func SortAlerts(alerts []Alert) {
+	sort.SliceStable(alerts, func(i, j int) bool {
+		si, sj := AlertSeverities[alerts[i].Severity], AlertSeverities[alerts[j].Severity]
+		if si != sj {
+			return si > sj
+		}
+		return alerts[i].Effective.Before(alerts[j].Effective)
+	})
+}
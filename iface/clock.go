@@ -0,0 +1,32 @@
+package iface
+
+import "time"
+
+// ClockChange returns the moment the clocks change for daylight saving time
+// on the day of date in its time zone, and the offsets from UTC in seconds
+// before and after. ok is false if they do not change that day, also for
+// fixed zones without the rules of the time zone database.
+func ClockChange(date time.Time) (at time.Time, before, after int, ok bool) {
+	loc := date.Location()
+	y, m, d := date.Date()
+	lo := time.Date(y, m, d, 0, 0, 0, 0, loc)
+	hi := time.Date(y, m, d+1, 0, 0, 0, 0, loc)
+	_, before = lo.Zone()
+	_, after = hi.Zone()
+	if before == after {
+		return time.Time{}, 0, 0, false
+	}
+	// changes happen on full minutes, so bisect down to one
+	for hi.Sub(lo) > time.Minute {
+		mid := lo.Add(hi.Sub(lo) / 2).Truncate(time.Minute)
+		if mid.Equal(lo) {
+			mid = lo.Add(time.Minute)
+		}
+		if _, off := mid.Zone(); off == before {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	return hi, before, after, true
+}
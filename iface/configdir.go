@@ -0,0 +1,21 @@
+package iface
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// ConfigDir returns the directory of the config files of wego:
+// $XDG_CONFIG_HOME/wego, or ~/.config/wego if it is unset, on all systems.
+func ConfigDir() (string, error) {
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	// relative paths are invalid and to be ignored by the XDG spec
+	if !filepath.IsAbs(dir) {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		dir = filepath.Join(home, ".config")
+	}
+	return filepath.Join(dir, "wego"), nil
+}
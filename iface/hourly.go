@@ -0,0 +1,38 @@
+package iface
+
+import (
+	"sort"
+	"time"
+)
+
+// Hourly is set by the -hourly flag, the number of hours from now whose slots
+// are listed one per row instead of the daily forecast. 0 disables it.
+var Hourly int
+
+// HourlySlots returns the slots of the forecast of r in the given number of
+// hours from the start of the hour of now, in order.
+func HourlySlots(r Data, now time.Time, hours int) (ret []Cond) {
+	from := time.Date(now.Year(), now.Month(), now.Day(), now.Hour(), 0, 0, 0, now.Location())
+	to := from.Add(time.Duration(hours) * time.Hour)
+	for _, d := range r.Forecast {
+		for _, s := range d.Slots {
+			if !s.Time.Before(from) && s.Time.Before(to) {
+				ret = append(ret, s)
+			}
+		}
+	}
+	sort.SliceStable(ret, func(i, j int) bool { return ret[i].Time.Before(ret[j].Time) })
+	return ret
+}
+
+// SlotHours returns how many hours slot i of slots, ordered by time, lasts to
+// weight its precipitation rate: until the next slot, the last one as long as
+// the one before it, or 1 h if it is the only one.
+func SlotHours(slots []Cond, i int) float32 {
+	if i+1 < len(slots) {
+		return float32(slots[i+1].Time.Sub(slots[i].Time).Hours())
+	} else if i > 0 {
+		return float32(slots[i].Time.Sub(slots[i-1].Time).Hours())
+	}
+	return 1
+}
@@ -1,8 +1,17 @@
 package iface
 
 import (
-	"log"
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
+
+	"github.com/nafiz1001/wego/astro"
+	"github.com/nafiz1001/wego/units"
 )
 
 type WeatherCode int
@@ -27,6 +36,69 @@ const (
 	CodeThunderyShowers
 	CodeThunderySnowShowers
 	CodeVeryCloudy
+	CodeFreezingRain
+	CodeIcePellets
+	CodeRainSnowMix
+	CodeBlowingSnow
+	CodeHail
+	CodeFunnelCloud
+	CodeSevereThunderstorm
+	CodeHaze
+	CodeSmoke
+)
+
+var codeNames = []string{
+	"Unknown", "Cloudy", "Fog", "HeavyRain", "HeavyShowers", "HeavySnow",
+	"HeavySnowShowers", "LightRain", "LightShowers", "LightSleet",
+	"LightSleetShowers", "LightSnow", "LightSnowShowers", "PartlyCloudy",
+	"Sunny", "ThunderyHeavyRain", "ThunderyShowers", "ThunderySnowShowers",
+	"VeryCloudy", "FreezingRain", "IcePellets", "RainSnowMix", "BlowingSnow",
+	"Hail", "FunnelCloud", "SevereThunderstorm", "Haze", "Smoke",
+}
+
+// String returns the name of the weather code without the Code prefix, e.g.
+// "LightRain".
+func (c WeatherCode) String() string {
+	if c < 0 || int(c) >= len(codeNames) {
+		return "WeatherCode(" + strconv.Itoa(int(c)) + ")"
+	}
+	return codeNames[c]
+}
+
+// ParseWeatherCode returns the weather code with the given name as returned by
+// String.
+func ParseWeatherCode(name string) (WeatherCode, bool) {
+	for i, n := range codeNames {
+		if n == name {
+			return WeatherCode(i), true
+		}
+	}
+	return CodeUnknown, false
+}
+
+// Severe reports whether the weather code describes dangerous convective
+// weather which frontends should highlight.
+func (c WeatherCode) Severe() bool {
+	return c == CodeHail || c == CodeFunnelCloud || c == CodeSevereThunderstorm
+}
+
+// Snow reports whether the weather code involves falling or blowing snow.
+func (c WeatherCode) Snow() bool {
+	switch c {
+	case CodeHeavySnow, CodeHeavySnowShowers, CodeLightSnow, CodeLightSnowShowers,
+		CodeThunderySnowShowers, CodeRainSnowMix, CodeBlowingSnow:
+		return true
+	}
+	return false
+}
+
+// Thresholds for SnowHazard, following the criteria used by Environment and
+// Climate Change Canada for blizzard warnings and blowing snow advisories.
+const (
+	BlizzardVisibleDistM    = 400
+	BlizzardWindKmph        = 40
+	BlowingSnowVisibleDistM = 800
+	BlowingSnowWindKmph     = 30
 )
 
 type Cond struct {
@@ -75,6 +147,160 @@ type Cond struct {
 
 	// Humidity is the *relative* humidity and must be in [0, 100].
 	Humidity *int
+
+	// PressureHPa is the air pressure reduced to mean sea level in hectopascal.
+	PressureHPa *float32
+
+	// PressureTendency is how the pressure changed in the hours before an
+	// observation, one of "rising", "falling" and "steady", or empty if the
+	// backend does not report it.
+	PressureTendency string
+
+	// StationPressureHPa is the air pressure at the height of the station in
+	// hectopascal, if the backend reports it.
+	StationPressureHPa *float32
+
+	// CloudCoverPercent is the share of the sky covered by clouds and must be
+	// in [0, 100].
+	CloudCoverPercent *int
+
+	// UVIndex is the UV index, if the backend reports it.
+	UVIndex *float32
+
+	// ShortwaveWm2 is the mean global horizontal irradiance (sunlight reaching
+	// a horizontal surface) in W/m² until the next slot, if the backend
+	// reports it.
+	ShortwaveWm2 *float32
+
+	// Soil is the temperature and moisture of the ground, nil if unknown.
+	Soil *Soil
+
+	// Marks are the -mark rules the slot crosses, see MarkSymbol.
+	Marks []string
+
+	// IsDay tells whether the sun is up at Time. It is nil if the backend
+	// does not know, frontends may then compute it from the location.
+	IsDay *bool
+
+	// NormalTempC is the climate normal of the temperature at Time in degrees
+	// celsius, nil if unknown. FillNormals sets it from the normals of the
+	// day.
+	NormalTempC *float32
+}
+
+// SnowHazard returns "blizzard" or "blowing snow" if snow, wind and visibility
+// of the condition meet the respective criteria and an empty string otherwise.
+func (c Cond) SnowHazard() string {
+	if c.VisibleDistM == nil || !c.Code.Snow() {
+		return ""
+	}
+	var wind float32
+	if c.WindspeedKmph != nil {
+		wind = *c.WindspeedKmph
+	}
+	if c.WindGustKmph != nil && *c.WindGustKmph > wind {
+		wind = *c.WindGustKmph
+	}
+
+	if *c.VisibleDistM <= BlizzardVisibleDistM && wind >= BlizzardWindKmph {
+		return "blizzard"
+	} else if *c.VisibleDistM <= BlowingSnowVisibleDistM && (wind >= BlowingSnowWindKmph || c.Code == CodeBlowingSnow) {
+		return "blowing snow"
+	}
+	return ""
+}
+
+// Magnus formula coefficients over water, good to 0.35 °C from -45 to 60 °C
+const (
+	magnusA = 17.62
+	magnusB = 243.12
+)
+
+// DewPointC returns the dew point of the condition in degrees celsius, or
+// false if the temperature or humidity is unknown.
+func (c Cond) DewPointC() (float32, bool) {
+	if c.TempC == nil || c.Humidity == nil || *c.Humidity <= 0 {
+		return 0, false
+	}
+	t := float64(*c.TempC)
+	g := math.Log(float64(*c.Humidity)/100) + magnusA*t/(magnusB+t)
+	return float32(magnusB * g / (magnusA - g)), true
+}
+
+// HumidexC returns the humidex of the condition, the temperature felt in humid
+// heat as defined by Environment Canada, or false if the temperature or
+// humidity is unknown.
+func (c Cond) HumidexC() (float32, bool) {
+	dp, ok := c.DewPointC()
+	if !ok {
+		return 0, false
+	}
+	e := 6.11 * math.Exp(5417.753*(1/273.16-1/(273.15+float64(dp))))
+	return *c.TempC + float32(0.5555*(e-10)), true
+}
+
+// RelativeHumidity returns the relative humidity in percent of air with the
+// given dew point when it is at tempC, e.g. outdoor air warmed up indoors. It
+// is at most 100, as the excess moisture condenses.
+func RelativeHumidity(tempC, dewPointC float32) float32 {
+	t, td := float64(tempC), float64(dewPointC)
+	return float32(math.Min(100, 100*math.Exp(magnusA*td/(magnusB+td)-magnusA*t/(magnusB+t))))
+}
+
+// Vars returns the fields of the condition by the names user expressions know
+// them by, in metric units with precipitation in mm/h. Numbers are float64,
+// unknown values are nil.
+func (c Cond) Vars() map[string]interface{} {
+	num32 := func(f *float32) interface{} {
+		if f == nil {
+			return nil
+		}
+		return float64(*f)
+	}
+	num := func(i *int) interface{} {
+		if i == nil {
+			return nil
+		}
+		return float64(*i)
+	}
+
+	v := map[string]interface{}{
+		"code":       c.Code.String(),
+		"desc":       c.Desc,
+		"temp":       num32(c.TempC),
+		"feels":      num32(c.FeelsLikeC),
+		"windchill":  num32(c.FeelsLikeC),
+		"chance":     num(c.ChanceOfRainPercent),
+		"precip":     nil,
+		"visibility": num32(c.VisibleDistM),
+		"wind":       num32(c.WindspeedKmph),
+		"gust":       num32(c.WindGustKmph),
+		"winddir":    num(c.WinddirDegree),
+		"humidity":   num(c.Humidity),
+		"pressure":   num32(c.PressureHPa),
+		"clouds":     num(c.CloudCoverPercent),
+		"uv":         num32(c.UVIndex),
+		"dewpoint":   nil,
+		"humidex":    nil,
+		"hour":       nil,
+		"day":        nil,
+	}
+	if c.PrecipM != nil {
+		v["precip"] = float64(*c.PrecipM * 1000)
+	}
+	if dp, ok := c.DewPointC(); ok {
+		v["dewpoint"] = float64(dp)
+	}
+	if hx, ok := c.HumidexC(); ok {
+		v["humidex"] = float64(hx)
+	}
+	if !c.Time.IsZero() {
+		v["hour"] = float64(c.Time.Hour())
+	}
+	if c.IsDay != nil {
+		v["day"] = *c.IsDay
+	}
+	return v
 }
 
 type Astro struct {
@@ -82,6 +308,10 @@ type Astro struct {
 	Moonset  time.Time
 	Sunrise  time.Time
 	Sunset   time.Time
+
+	// MoonPhase is the elapsed fraction of the lunation at noon in [0, 1): 0
+	// is new moon, 0.25 first quarter, 0.5 full moon and 0.75 last quarter.
+	MoonPhase *float32
 }
 
 type Day struct {
@@ -94,6 +324,78 @@ type Day struct {
 
 	// Astronomy contains planetary data.
 	Astronomy Astro
+
+	// Confidence is the confidence of the provider in the forecast for this
+	// day (e.g. derived from the ensemble spread). It must be in the range
+	// [0, 100] and is nil if the provider does not supply it.
+	Confidence *int
+
+	// MaxTempC and MinTempC are the highest and lowest temperature of the day
+	// in degrees celsius. Backends should set them if the provider has daily
+	// values, otherwise FillDays computes them from the slots.
+	MaxTempC *float32
+	MinTempC *float32
+
+	// NormalMaxC and NormalMinC are the climate normals of the highest and
+	// lowest temperature of the day in degrees celsius, nil if unknown.
+	NormalMaxC *float32
+	NormalMinC *float32
+
+	// PrecipOnset is the time of the first slot of the day with precipitation
+	// or a chance of rain of at least PrecipOnsetChance percent. It is nil if
+	// no precipitation is expected.
+	PrecipOnset *time.Time
+}
+
+// fillAstro computes the events missing from a for date, those depending on
+// the location only if it is known.
+func fillAstro(a *Astro, date time.Time, geo *LatLon) {
+	if a.MoonPhase == nil {
+		y, m, d := date.Date()
+		p := float32(astro.MoonPhase(time.Date(y, m, d, 12, 0, 0, 0, date.Location())))
+		a.MoonPhase = &p
+	}
+	if geo == nil {
+		return
+	}
+	lat, lon := float64(geo.Latitude), float64(geo.Longitude)
+	if a.Sunrise.IsZero() && a.Sunset.IsZero() {
+		a.Sunrise, a.Sunset = astro.SunriseSunset(date, lat, lon)
+	}
+	if a.Moonrise.IsZero() && a.Moonset.IsZero() {
+		a.Moonrise, a.Moonset = astro.MoonriseMoonset(date, lat, lon)
+	}
+}
+
+// PrecipOnsetChance is the chance of rain in percent from which on a slot
+// counts as the onset of precipitation.
+const PrecipOnsetChance = 50
+
+// FillDays sets the daily values of the forecast days the backend left out,
+// computing them from the slots of each day, and the astronomical events from
+// the location.
+func FillDays(r *Data) {
+	for i := range r.Forecast {
+		d := &r.Forecast[i]
+		fillAstro(&d.Astronomy, d.Date, r.GeoLoc)
+		fillMax, fillMin, fillOnset := d.MaxTempC == nil, d.MinTempC == nil, d.PrecipOnset == nil
+		for _, s := range d.Slots {
+			if s.TempC != nil {
+				t := *s.TempC
+				if fillMax && (d.MaxTempC == nil || t > *d.MaxTempC) {
+					d.MaxTempC = &t
+				}
+				if fillMin && (d.MinTempC == nil || t < *d.MinTempC) {
+					d.MinTempC = &t
+				}
+			}
+			if fillOnset && d.PrecipOnset == nil && ((s.PrecipM != nil && *s.PrecipM > 0) ||
+				(s.ChanceOfRainPercent != nil && *s.ChanceOfRainPercent >= PrecipOnsetChance)) {
+				t := s.Time
+				d.PrecipOnset = &t
+			}
+		}
+	}
 }
 
 type LatLon struct {
@@ -106,75 +408,308 @@ type Data struct {
 	Forecast []Day
 	Location string
 	GeoLoc   *LatLon
+
+	// CurrentSource and ForecastSource are the names of the backends the
+	// current conditions and the forecast come from. They differ if the data
+	// of two backends is blended with -current-backend.
+	CurrentSource  string
+	ForecastSource string
+
+	// FailedSources are the backends of a -backend chain which failed or
+	// returned incomplete data before ForecastSource was tried.
+	FailedSources []string
+
+	// CurrentSpread is set if the current conditions are combined from the
+	// observations of several stations, see CombineStations.
+	CurrentSpread *Spread
+
+	// Province is the code of the Canadian province or territory of the
+	// location, like ON, if the backend knows it. See WindChillLimit.
+	Province string
+
+	// Alerts are the weather warnings in effect for the location, most severe
+	// first, if the backend reports them.
+	Alerts []Alert
+
+	// Hourly are the slots of the next -hourly hours, see HourlySlots. They
+	// are set by wego after the fetch, not by the backends.
+	Hourly []Cond
+
+	// Stations are the weather stations the current conditions were
+	// observed at, nearest first, if the backend reports them.
+	Stations []Station
+
+	// AirQuality is the current air pollution at the location, set by wego
+	// with -aqi unless the backend reports it.
+	AirQuality *AirQuality
+
+	// FetchedAt is the time the data was fetched, set by wego.
+	FetchedAt time.Time
+
+	// Stale is set by wego if the data is taken from the cache with -offline
+	// or because the backend could not be reached. Frontends should tell so
+	// along with FetchedAt.
+	Stale bool
 }
 
-type UnitSystem int
+// DocumentVersion is the version of the format of Document. It is raised when
+// fields are renamed, removed or change their meaning, but not when fields
+// are added.
+const DocumentVersion = 2
 
-const (
-	UnitsMetric UnitSystem = iota
-	UnitsImperial
-	UnitsSi
-	UnitsMetricMs
+// Document is the output of the json frontend and the input of the json
+// backend: the data with the version of its format. Version 1 documents were
+// the data without the version.
+type Document struct {
+	Version int
+	Data
+}
+
+// UnitSystem are the units of the output, chosen by -units and the flags of
+// the single quantities. The zero value is metric.
+type UnitSystem struct {
+	TempUnit     units.Temp
+	SpeedUnit    units.Speed
+	PressureUnit units.Pressure
+	PrecipUnit   units.Precip
+	DistanceUnit units.Distance
+}
+
+var (
+	UnitsMetric   = UnitSystem{}
+	UnitsImperial = UnitSystem{units.Fahrenheit, units.Mph, units.InHg, units.In, units.Mi}
+	UnitsSi       = UnitSystem{TempUnit: units.Kelvin, SpeedUnit: units.Ms}
+	UnitsMetricMs = UnitSystem{SpeedUnit: units.Ms}
 )
 
 func (u UnitSystem) Temp(tempC float32) (res float32, unit string) {
-	if u == UnitsMetric || u == UnitsMetricMs {
-		return tempC, "°C"
-	} else if u == UnitsImperial {
-		return tempC*1.8 + 32, "°F"
-	} else if u == UnitsSi {
-		return tempC + 273.16, "°K"
-	}
-	log.Fatalln("Unknown unit system:", u)
-	return
+	return u.TempUnit.FromC(tempC)
 }
 
 func (u UnitSystem) Speed(spdKmph float32) (res float32, unit string) {
-	if u == UnitsMetric {
-		return spdKmph, "km/h"
-	} else if u == UnitsImperial {
-		return spdKmph / 1.609, "mph"
-	} else if u == UnitsSi || u == UnitsMetricMs {
-		return spdKmph / 3.6, "m/s"
-	}
-	log.Fatalln("Unknown unit system:", u)
-	return
+	res, unit = u.SpeedUnit.FromKmph(spdKmph)
+	return res, NumberLocale.Unit(unit)
 }
 
 func (u UnitSystem) Distance(distM float32) (res float32, unit string) {
-	if u == UnitsMetric || u == UnitsSi || u == UnitsMetricMs {
-		if distM < 1 {
-			return distM * 1000, "mm"
-		} else if distM < 1000 {
-			return distM, "m"
-		} else {
-			return distM / 1000, "km"
-		}
-	} else if u == UnitsImperial {
-		res, unit = distM/0.0254, "in"
-		if res < 3*12 { // 1yd = 3ft, 1ft = 12in
-			return
-		} else if res < 8*10*22*36 { //1mi = 8fur, 1fur = 10ch, 1ch = 22yd
-			return res / 36, "yd"
-		} else {
-			return res / 8 / 10 / 22 / 36, "mi"
-		}
+	res, unit = u.DistanceUnit.FromM(distM)
+	return res, NumberLocale.Unit(unit)
+}
+
+// Precip converts a precipitation amount in meters to mm or in.
+func (u UnitSystem) Precip(precipM float32) (res float32, unit string) {
+	res, unit = u.PrecipUnit.FromM(precipM)
+	return res, NumberLocale.Unit(unit)
+}
+
+// Pressure converts an air pressure in hPa to hPa, inHg or mmHg.
+func (u UnitSystem) Pressure(pHPa float32) (res float32, unit string) {
+	res, unit = u.PressureUnit.FromHPa(pHPa)
+	return res, NumberLocale.Unit(unit)
+}
+
+// CompassPoint returns the abbreviation of the 8-point compass direction of
+// bearing in degrees, e.g. "SW".
+func CompassPoint(bearing float64) string {
+	return []string{"N", "NE", "E", "SE", "S", "SW", "W", "NW"}[int(bearing+22.5)/45%8]
+}
+
+// ConvertSpeed converts spdKmph to the named unit: km/h, mph, m/s, kn or Bft.
+func ConvertSpeed(spdKmph float32, unit string) (float32, bool) {
+	u, err := units.ParseSpeed(unit)
+	if err != nil || u == "" {
+		return 0, false
 	}
-	log.Fatalln("Unknown unit system:", u)
-	return
+	ret, _ := u.FromKmph(spdKmph)
+	return ret, true
+}
+
+// ParseSpeed parses a speed with unit like "25kn" or "10 m/s" and returns it
+// in km/h.
+func ParseSpeed(s string) (float32, error) {
+	s = strings.TrimSpace(s)
+	i := strings.IndexFunc(s, func(r rune) bool { return (r < '0' || r > '9') && r != '.' })
+	if i <= 0 {
+		return 0, fmt.Errorf("invalid speed %q, expected a number and a unit like 25kn", s)
+	}
+	v, err := strconv.ParseFloat(s[:i], 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid speed %q: %v", s, err)
+	}
+	f, ok := units.SpeedFactors[strings.TrimSpace(s[i:])]
+	if !ok {
+		return 0, fmt.Errorf("unknown speed unit in %q, use km/h, mph, m/s or kn", s)
+	}
+	return float32(v) * f, nil
+}
+
+// ParseTemp parses a temperature like "20", "20C" or "68F" and returns it in
+// degrees celsius. Numbers without unit are celsius.
+func ParseTemp(s string) (float32, error) {
+	s = strings.TrimSpace(s)
+	unit := strings.TrimLeft(s, "+-0123456789. ")
+	v, err := strconv.ParseFloat(strings.TrimSpace(strings.TrimSuffix(s, unit)), 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid temperature %q, expected a number and optionally C or F", s)
+	}
+	switch strings.TrimPrefix(strings.ToUpper(unit), "°") {
+	case "", "C":
+		return float32(v), nil
+	case "F":
+		return float32((v - 32) * 5 / 9), nil
+	}
+	return 0, fmt.Errorf("unknown temperature unit in %q, use C or F", s)
 }
 
 type Backend interface {
+	// Setup registers the flags of the backend. It is called for all backends
+	// on every start, since the config file holds the options of all of them,
+	// so it must not construct anything else. That belongs in Init, see
+	// Initializer, or in Fetch.
 	Setup()
-	Fetch(location string, numdays int) Data
+
+	// Fetch returns the weather at location for numdays days. Problems with
+	// the location, the network or the response are returned as error, so
+	// the caller can report them or try another backend. Requests are given
+	// up when ctx is done.
+	Fetch(ctx context.Context, location string, numdays int) (Data, error)
+}
+
+// HistoryBackend is implemented by backends which can fetch the weather of
+// past days, for the history fetch command.
+type HistoryBackend interface {
+	Backend
+
+	// FetchHistory returns the weather at location of the days from the date
+	// of from on, at most until the date of to, in order. It may return fewer
+	// days, e.g. as many as a single request yields, but at least one unless
+	// it fails. The caller asks again for the rest.
+	FetchHistory(ctx context.Context, location string, from, to time.Time) ([]Day, error)
+}
+
+// Initializer is implemented by backends which need more than their flags,
+// like the tables mapping the codes of their provider. Init is called once
+// by SelectBackend, so only for the backends in use, after the flags are
+// parsed.
+type Initializer interface {
+	Init()
+}
+
+var (
+	initMu      sync.Mutex
+	initialized = make(map[string]bool)
+)
+
+// SelectBackend returns the backend called name, initialized on first use.
+// It is false for unknown backends.
+func SelectBackend(name string) (Backend, bool) {
+	be, ok := AllBackends[name]
+	if !ok {
+		return nil, false
+	}
+	if in, ok := be.(Initializer); ok {
+		initMu.Lock()
+		if !initialized[name] {
+			in.Init()
+			initialized[name] = true
+		}
+		initMu.Unlock()
+	}
+	return be, true
 }
 
 type Frontend interface {
+	// Setup registers the flags of the frontend. Like Backend.Setup it is
+	// called for all frontends on every start and must be cheap.
 	Setup()
-	Render(weather Data, unitSystem UnitSystem)
+	// Render writes weather to w, which is os.Stdout unless the serve
+	// command renders to a response. It returns an error instead of exiting,
+	// as the serve command renders many times.
+	Render(w io.Writer, weather Data, unitSystem UnitSystem) error
+}
+
+// MultiFrontend is implemented by frontends which render the weather of
+// several locations together, e.g. side by side. Other frontends render one
+// location after the other.
+type MultiFrontend interface {
+	Frontend
+	RenderAll(w io.Writer, weather []Data, unitSystem UnitSystem) error
+}
+
+// Capabilities describes a backend or frontend for the backends and frontends
+// commands and the setup wizard. The fields after Description only apply to
+// backends.
+type Capabilities struct {
+	// Description is a short sentence about the backend or frontend.
+	Description string
+
+	// APIKeyFlag is the flag of the API key a backend needs, empty if it
+	// needs none.
+	APIKeyFlag string
+
+	// Hourly tells whether the forecast has a slot for every hour instead of
+	// every few hours.
+	Hourly bool
+
+	// Alerts tells whether the backend provides weather alerts.
+	Alerts bool
+
+	// MaxDays is the number of days of forecast the backend can provide at
+	// most, 0 if it depends on the data.
+	MaxDays int
+}
+
+// Capable is implemented by backends and frontends which describe their
+// capabilities.
+type Capable interface {
+	Capabilities() Capabilities
 }
 
 var (
 	AllBackends  = make(map[string]Backend)
 	AllFrontends = make(map[string]Frontend)
+
+	// Strict is set by the -strict flag. If it is true, backends must fail on
+	// any field they cannot parse. Otherwise they should skip the field and
+	// return whatever data they could make sense of.
+	Strict bool
+
+	// Width is set by the -width flag. If it is > 0, frontends must lay out
+	// their output for that many columns instead of the terminal width.
+	Width int
+
+	// MaxResponseSize is set by the -max-response-size flag. Backends must
+	// not read more than that many bytes of a (decompressed) response body.
+	MaxResponseSize int64 = 8 << 20
+
+	// LowMemory is set by the -low-memory flag. If it is true, backends
+	// should decode responses while reading them instead of reading them
+	// into memory first.
+	LowMemory bool
+
+	// MarkSymbol is set by the -mark-symbol flag. Frontends should show it
+	// with the slots which cross a -mark rule, see Cond.Marks.
+	MarkSymbol = "⚠"
+
+	// GustLimitKmph is set by the -gust-limit flag. If it is > 0, frontends
+	// should highlight slots with wind or gusts reaching it.
+	GustLimitKmph float32
+
+	// IndoorTempC is set by the -indoor-temp flag. If it is not nil, frontends
+	// should tell whether airing out at that indoor temperature dries the
+	// rooms or adds moisture and risks mold.
+	IndoorTempC *float32
+
+	// SolarKWp is set by the -solar-kwp flag, the peak power of the PV system
+	// frontends should estimate the daily yield for.
+	SolarKWp float64 = 1
+
+	// Deterministic is set by the -deterministic flag. If it is true, Now
+	// returns the time of the current conditions and frontends must not show
+	// times relative to it, like "in 40 minutes", so the output only depends
+	// on the data.
+	Deterministic bool
+
+	// Now returns the time frontends should consider as the current one.
+	Now = time.Now
 )
@@ -0,0 +1,139 @@
+package iface
+
+import (
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Locale tells how numbers and unit labels are written in the output.
+type Locale struct {
+	// Decimal separates the integer from the fractional part of a number.
+	Decimal string
+
+	// Thousands groups the digits of the integer part by three, it is empty
+	// for no grouping.
+	Thousands string
+
+	// Units maps unit labels like "in" to the ones used in the language of the
+	// locale. Labels without entry are the same.
+	Units map[string]string
+}
+
+// NumberLocale is the locale all frontends format numbers and units with, set
+// by main from -locale or the environment.
+var NumberLocale = CLocale
+
+// CLocale writes numbers the way Go does, without grouping.
+var CLocale = Locale{Decimal: "."}
+
+// narrow no-break space, the thousands separator of French and others
+const nnbsp = " "
+
+// localeNumbers holds the separators by language, and by language and region
+// where they differ.
+var localeNumbers = map[string][2]string{
+	"en":    {".", ","},
+	"ja":    {".", ","},
+	"ko":    {".", ","},
+	"zh":    {".", ","},
+	"de":    {",", "."},
+	"de_CH": {".", "’"},
+	"da":    {",", "."},
+	"es":    {",", "."},
+	"id":    {",", "."},
+	"it":    {",", "."},
+	"nl":    {",", "."},
+	"pt":    {",", "."},
+	"tr":    {",", "."},
+	"cs":    {",", nnbsp},
+	"fi":    {",", nnbsp},
+	"fr":    {",", nnbsp},
+	"fr_CH": {".", nnbsp},
+	"hu":    {",", nnbsp},
+	"nb":    {",", nnbsp},
+	"pl":    {",", nnbsp},
+	"ru":    {",", nnbsp},
+	"sk":    {",", nnbsp},
+	"sv":    {",", nnbsp},
+	"uk":    {",", nnbsp},
+}
+
+// localeUnits holds the unit labels by language which differ from English.
+var localeUnits = map[string]map[string]string{
+	"fr": {"in": "po", "yd": "vg", "mph": "mi/h"},
+	"es": {"mph": "mi/h"},
+}
+
+// ParseLocale returns the locale named like the POSIX locales, e.g.
+// "de_DE.UTF-8". Unknown languages, "C" and "POSIX" get the C locale.
+func ParseLocale(name string) Locale {
+	name = strings.SplitN(strings.SplitN(name, ".", 2)[0], "@", 2)[0]
+	lang := strings.SplitN(name, "_", 2)[0]
+	ret := CLocale
+	seps, ok := localeNumbers[name]
+	if !ok {
+		seps, ok = localeNumbers[lang]
+	}
+	if ok {
+		ret.Decimal, ret.Thousands = seps[0], seps[1]
+	}
+	ret.Units = localeUnits[lang]
+	return ret
+}
+
+// LocaleFromEnv returns the locale for numbers selected by the environment,
+// LC_ALL, LC_NUMERIC or LANG, whichever is set first.
+func LocaleFromEnv() Locale {
+	for _, v := range []string{"LC_ALL", "LC_NUMERIC", "LANG"} {
+		if name := os.Getenv(v); name != "" {
+			return ParseLocale(name)
+		}
+	}
+	return CLocale
+}
+
+// Float formats f with prec decimals.
+func (l Locale) Float(f float64, prec int) string {
+	s := strconv.FormatFloat(f, 'f', prec, 64)
+	if s == "-"+strconv.FormatFloat(0, 'f', prec, 64) {
+		s = s[1:] // no "-0"
+	}
+	sign := ""
+	if strings.HasPrefix(s, "-") {
+		sign, s = "-", s[1:]
+	}
+	intPart, frac := s, ""
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		intPart, frac = s[:i], l.Decimal+s[i+1:]
+	}
+	if l.Thousands != "" {
+		for i := len(intPart) - 3; i > 0; i -= 3 {
+			intPart = intPart[:i] + l.Thousands + intPart[i:]
+		}
+	}
+	return sign + intPart + frac
+}
+
+// Int formats i with grouped thousands.
+func (l Locale) Int(i int) string {
+	return l.Float(float64(i), 0)
+}
+
+// Unit returns the label of unit in the language of the locale.
+func (l Locale) Unit(unit string) string {
+	if u, ok := l.Units[unit]; ok {
+		return u
+	}
+	return unit
+}
+
+// FormatFloat formats f with prec decimals in NumberLocale.
+func FormatFloat(f float32, prec int) string {
+	return NumberLocale.Float(float64(f), prec)
+}
+
+// FormatInt formats i in NumberLocale.
+func FormatInt(i int) string {
+	return NumberLocale.Int(i)
+}
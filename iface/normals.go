@@ -0,0 +1,56 @@
+package iface
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// TempScales are the scales frontends color temperatures by. "absolute" colors
+// the temperature itself, "anomaly" its difference to the climate normal.
+var TempScales = []string{"absolute", "anomaly"}
+
+// TempScale is set by the -temp-scale flag. With "anomaly", frontends should
+// color temperatures with a known normal by how much warmer or colder than
+// normal they are.
+var TempScale = "absolute"
+
+// CheckTempScale returns an error if scale is not one of TempScales.
+func CheckTempScale(scale string) error {
+	for _, s := range TempScales {
+		if s == scale {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown temperature scale %q, expected one of %v", scale, TempScales)
+}
+
+// normalAt returns the normal temperature at t, assuming the daily cycle of a
+// sine with the low at 3:00 and the high at 15:00.
+func (d *Day) normalAt(t time.Time) *float32 {
+	if d.NormalMaxC == nil || d.NormalMinC == nil {
+		return nil
+	}
+	mean := (*d.NormalMaxC + *d.NormalMinC) / 2
+	amp := (*d.NormalMaxC - *d.NormalMinC) / 2
+	hours := float64(t.Hour()) + float64(t.Minute())/60
+	ret := mean + amp*float32(math.Cos(2*math.Pi*(hours-15)/24))
+	return &ret
+}
+
+// FillNormals sets the normal temperature of the current conditions and the
+// slots the backend left out from the normals of their day.
+func FillNormals(r *Data) {
+	for i := range r.Forecast {
+		d := &r.Forecast[i]
+		for j := range d.Slots {
+			if d.Slots[j].NormalTempC == nil {
+				d.Slots[j].NormalTempC = d.normalAt(d.Slots[j].Time)
+			}
+		}
+		y, m, day := r.Current.Time.In(d.Date.Location()).Date()
+		if dy, dm, dd := d.Date.Date(); r.Current.NormalTempC == nil && dy == y && dm == m && dd == day {
+			r.Current.NormalTempC = d.normalAt(r.Current.Time)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package iface
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// constants of the International Standard Atmosphere
+const (
+	isaSeaLevelHPa = 1013.25
+	isaSeaLevelK   = 288.15
+	isaLapseRate   = 0.0065  // K per m
+	isaExponent    = 5.25588 // g*M/(R*L)
+)
+
+// hPaPerInHg converts inches of mercury to hectopascal.
+const hPaPerInHg = 33.8639
+
+var (
+	// ShowQNH is set by the -qnh flag. If it is true, frontends should show
+	// the QNH of the current conditions, see QNH.
+	ShowQNH bool
+
+	// ElevationM is set by the -elevation flag, the elevation of the location
+	// in meters or nil if unknown. It turns station pressure into QNH and QNH
+	// into the pressure altitude.
+	ElevationM *float32
+)
+
+// ParseElevation parses an elevation like "1200", "1200m" or "3900ft" and
+// returns it in meters. Numbers without unit are meters.
+func ParseElevation(s string) (float32, error) {
+	s = strings.TrimSpace(s)
+	unit := strings.TrimLeft(s, "+-0123456789. ")
+	v, err := strconv.ParseFloat(strings.TrimSpace(strings.TrimSuffix(s, unit)), 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid elevation %q, expected a number and optionally m or ft", s)
+	}
+	switch strings.ToLower(unit) {
+	case "", "m":
+		return float32(v), nil
+	case "ft":
+		return float32(v * 0.3048), nil
+	}
+	return 0, fmt.Errorf("unknown elevation unit in %q, use m or ft", s)
+}
+
+// HPaToInHg converts p from hectopascal to inches of mercury.
+func HPaToInHg(p float32) float32 {
+	return p / hPaPerInHg
+}
+
+// QNH returns the altimeter setting of c in hectopascal: the station pressure
+// reduced to sea level along the standard atmosphere, as used by pilots and
+// altimeter watches. Without station pressure or ElevationM, the sea level
+// pressure reported by the backend is returned, which only differs from the
+// QNH by the actual temperature being used for the reduction.
+func QNH(c Cond) (float32, bool) {
+	if c.StationPressureHPa != nil && ElevationM != nil {
+		f := 1 - isaLapseRate*float64(*ElevationM)/isaSeaLevelK
+		return float32(float64(*c.StationPressureHPa) * math.Pow(f, -isaExponent)), true
+	}
+	if c.PressureHPa != nil {
+		return *c.PressureHPa, true
+	}
+	return 0, false
+}
+
+// PressureAltitudeM returns the pressure altitude in meters at ElevationM
+// with an altimeter set to qnh: the altitude in the standard atmosphere with
+// the same pressure, which aircraft performance and altimeters set to the
+// standard pressure refer to.
+func PressureAltitudeM(qnh float32) (float32, bool) {
+	if ElevationM == nil {
+		return 0, false
+	}
+	f := 1 - isaLapseRate*float64(*ElevationM)/isaSeaLevelK
+	p := float64(qnh) * math.Pow(f, isaExponent)
+	alt := isaSeaLevelK / isaLapseRate * (1 - math.Pow(p/isaSeaLevelHPa, 1/isaExponent))
+	return float32(alt), true
+}
@@ -0,0 +1,47 @@
+package iface
+
+// SoilDepthsCm are the depths in cm below the surface of the soil
+// temperatures of Soil.
+var SoilDepthsCm = [4]float32{0, 6, 18, 54}
+
+// SoilLayersCm are the layers of the soil moisture of Soil, from and to the
+// depth in cm.
+var SoilLayersCm = [5][2]float32{{0, 1}, {1, 3}, {3, 9}, {9, 27}, {27, 81}}
+
+// Soil is the state of the ground for gardeners and farmers.
+type Soil struct {
+	// TempC are the temperatures in degrees celsius at SoilDepthsCm, nil
+	// where unknown.
+	TempC [len(SoilDepthsCm)]*float32
+
+	// Moisture is the volumetric water content in m³/m³ of the SoilLayersCm,
+	// nil where unknown. Most soils hold between 0.1 (dry) and 0.45
+	// (saturated).
+	Moisture [len(SoilLayersCm)]*float32
+}
+
+// FrostDepthCm returns how deep the ground is frozen in cm, interpolated
+// between the depths of the temperatures, or 0 if the surface is not frozen.
+// It is false if the temperature of the surface is unknown.
+func (s Soil) FrostDepthCm() (float32, bool) {
+	if s.TempC[0] == nil {
+		return 0, false
+	}
+	if *s.TempC[0] > 0 {
+		return 0, true
+	}
+	depth := SoilDepthsCm[0]
+	for i := 1; i < len(SoilDepthsCm); i++ {
+		t := s.TempC[i]
+		if t == nil {
+			break
+		}
+		above := *s.TempC[i-1]
+		if *t > 0 {
+			// the 0 °C line lies between the two depths
+			return depth + (SoilDepthsCm[i]-depth)*(-above)/(*t-above), true
+		}
+		depth = SoilDepthsCm[i]
+	}
+	return depth, true
+}
@@ -0,0 +1,143 @@
+package iface
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Range is the smallest and the largest of a set of values.
+type Range struct {
+	Min float32
+	Max float32
+}
+
+// Station is a weather station whose observation is part of the current
+// conditions.
+type Station struct {
+	// ID is the identifier of the station at the provider, like a climate ID.
+	ID     string
+	Name   string
+	GeoLoc *LatLon
+}
+
+// Spread tells how far the observations of the stations differ, the current
+// conditions are combined from. A large spread points to a faulty sensor or
+// to local weather like a shower passing over one of the stations.
+type Spread struct {
+	// Stations is the number of stations with an observation.
+	Stations int
+
+	// Method is how the values of the stations are combined, one of
+	// StationMethods.
+	Method string
+
+	// TempC, WindspeedKmph and Humidity are the ranges of the observations,
+	// nil if no station has the value.
+	TempC         *Range
+	WindspeedKmph *Range
+	Humidity      *Range
+}
+
+// StationMethods are the ways to combine the observations of several stations.
+var StationMethods = []string{"median", "mean"}
+
+var (
+	// Stations is set by the -stations flag. Backends exposing observations
+	// of several stations should combine the current conditions of that many
+	// stations nearest to the location with CombineStations.
+	Stations = 1
+
+	// StationMethod is set by the -stations-method flag.
+	StationMethod = "median"
+)
+
+// CheckStationMethod returns an error if method is not one of StationMethods.
+func CheckStationMethod(method string) error {
+	for _, m := range StationMethods {
+		if m == method {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown stations method %q, expected one of %v", method, StationMethods)
+}
+
+// combine returns the median or the mean of vals, which must not be empty.
+func combine(vals []float32, method string) float32 {
+	if method == "mean" {
+		var sum float32
+		for _, v := range vals {
+			sum += v
+		}
+		return sum / float32(len(vals))
+	}
+	s := append([]float32(nil), vals...)
+	sort.Slice(s, func(i, j int) bool { return s[i] < s[j] })
+	if len(s)%2 == 0 {
+		return (s[len(s)/2-1] + s[len(s)/2]) / 2
+	}
+	return s[len(s)/2]
+}
+
+// combineRange returns the combined value and the range of vals, or nils if
+// vals is empty.
+func combineRange(vals []float32) (*float32, *Range) {
+	if len(vals) == 0 {
+		return nil, nil
+	}
+	r := Range{vals[0], vals[0]}
+	for _, v := range vals {
+		if v < r.Min {
+			r.Min = v
+		}
+		if v > r.Max {
+			r.Max = v
+		}
+	}
+	v := combine(vals, StationMethod)
+	return &v, &r
+}
+
+// CombineStations combines the observations of several stations, ordered by
+// distance, into one with the median or mean of each value, see
+// StationMethod. The time, weather code, description and wind direction are
+// the ones of the nearest station, since they cannot be averaged in a
+// meaningful way. conds must not be empty.
+func CombineStations(conds []Cond) (Cond, *Spread) {
+	ret := conds[0]
+	spread := &Spread{Stations: len(conds), Method: StationMethod}
+
+	floats := func(field func(c *Cond) *float32) (*float32, *Range) {
+		var vals []float32
+		for i := range conds {
+			if v := field(&conds[i]); v != nil {
+				vals = append(vals, *v)
+			}
+		}
+		return combineRange(vals)
+	}
+	ints := func(field func(c *Cond) *int) (*int, *Range) {
+		var vals []float32
+		for i := range conds {
+			if v := field(&conds[i]); v != nil {
+				vals = append(vals, float32(*v))
+			}
+		}
+		v, r := combineRange(vals)
+		if v == nil {
+			return nil, nil
+		}
+		i := int(*v + 0.5)
+		return &i, r
+	}
+
+	ret.TempC, spread.TempC = floats(func(c *Cond) *float32 { return c.TempC })
+	ret.FeelsLikeC, _ = floats(func(c *Cond) *float32 { return c.FeelsLikeC })
+	ret.PrecipM, _ = floats(func(c *Cond) *float32 { return c.PrecipM })
+	ret.VisibleDistM, _ = floats(func(c *Cond) *float32 { return c.VisibleDistM })
+	ret.WindspeedKmph, spread.WindspeedKmph = floats(func(c *Cond) *float32 { return c.WindspeedKmph })
+	ret.WindGustKmph, _ = floats(func(c *Cond) *float32 { return c.WindGustKmph })
+	ret.PressureHPa, _ = floats(func(c *Cond) *float32 { return c.PressureHPa })
+	ret.ChanceOfRainPercent, _ = ints(func(c *Cond) *int { return c.ChanceOfRainPercent })
+	ret.Humidity, spread.Humidity = ints(func(c *Cond) *int { return c.Humidity })
+	return ret, spread
+}
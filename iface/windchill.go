@@ -0,0 +1,98 @@
+package iface
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// Province is set by the -province flag, the code of the Canadian province or
+// territory (e.g. AB) whose wind chill warning criterion applies if the
+// backend does not report one with Data.Province.
+var Province string
+
+// WindChillWarningC are the wind chill values in degrees celsius at which
+// Environment Canada warns of extreme cold, by province or territory. The
+// criteria vary within some of them, e.g. from the south to the north of
+// Ontario, so these are the ones of the populated south.
+var WindChillWarningC = map[string]float32{
+	"BC": -35,
+	"AB": -40,
+	"SK": -40,
+	"MB": -40,
+	"ON": -35,
+	"QC": -38,
+	"NB": -35,
+	"NS": -35,
+	"PE": -35,
+	"NL": -35,
+	"YT": -45,
+	"NT": -50,
+	"NU": -50,
+}
+
+// ProvinceNames are the names of the codes of WindChillWarningC.
+var ProvinceNames = map[string]string{
+	"BC": "British Columbia",
+	"AB": "Alberta",
+	"SK": "Saskatchewan",
+	"MB": "Manitoba",
+	"ON": "Ontario",
+	"QC": "Quebec",
+	"NB": "New Brunswick",
+	"NS": "Nova Scotia",
+	"PE": "Prince Edward Island",
+	"NL": "Newfoundland and Labrador",
+	"YT": "Yukon",
+	"NT": "Northwest Territories",
+	"NU": "Nunavut",
+}
+
+// ParseProvince returns the code of a province or territory given by code or
+// name, like "ab" or "Alberta".
+func ParseProvince(s string) (string, error) {
+	s = strings.TrimSpace(s)
+	for code, name := range ProvinceNames {
+		if strings.EqualFold(s, code) || strings.EqualFold(s, name) {
+			return code, nil
+		}
+	}
+	var codes []string
+	for code := range ProvinceNames {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	return "", fmt.Errorf("unknown province %q, expected one of %s", s, strings.Join(codes, ", "))
+}
+
+// WindChillC returns the wind chill of c, computed with the formula of
+// Environment Canada from the temperature and the wind speed when it is at or
+// below freezing, and otherwise the felt temperature of the backend. ok is
+// false if neither is known.
+func WindChillC(c Cond) (float32, bool) {
+	if c.TempC != nil && *c.TempC <= 0 && c.WindspeedKmph != nil {
+		t, v := float64(*c.TempC), float64(*c.WindspeedKmph)
+		if v < 5 {
+			return float32(t), true
+		}
+		v16 := math.Pow(v, 0.16)
+		return float32(13.12 + 0.6215*t - 11.37*v16 + 0.3965*t*v16), true
+	}
+	if c.FeelsLikeC != nil {
+		return *c.FeelsLikeC, true
+	}
+	return 0, false
+}
+
+// WindChillLimit returns the wind chill warning criterion of the province of
+// r, or of -province if the backend did not report one, and the code of the
+// province. ok is false if the province is unknown.
+func WindChillLimit(r Data) (limitC float32, province string, ok bool) {
+	province = r.Province
+	if province == "" {
+		province = Province
+	}
+	limitC, ok = WindChillWarningC[province]
+	return limitC, province, ok
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/nafiz1001/wego/iface"
+)
+
+// locationsFlag is set by the -locations flag.
+var locationsFlag string
+
+// splitLocations splits the -locations flag at semicolons, or at commas if
+// there are none. Numbers separated by commas are kept together as latitude,
+// longitude pairs, so "45.4,-75.7,43.6,-79.4" are two locations.
+func splitLocations(s string) []string {
+	sep := ";"
+	if !strings.Contains(s, sep) {
+		sep = ","
+	}
+	var ret []string
+	for _, l := range strings.Split(s, sep) {
+		l = strings.TrimSpace(l)
+		if l == "" {
+			continue
+		}
+		if n := len(ret); sep == "," && n > 0 && isNumber(l) && isNumber(ret[n-1]) {
+			ret[n-1] += "," + l
+			continue
+		}
+		ret = append(ret, l)
+	}
+	return ret
+}
+
+func isNumber(s string) bool {
+	_, err := strconv.ParseFloat(s, 64)
+	return err == nil
+}
+
+// fetchOrStale fetches the weather of location like fetch, but returns an
+// error instead of exiting if neither the backend nor the cache have it.
+func fetchOrStale(backend, location string, numdays int) (iface.Data, error) {
+	if offline {
+		return loadStale(backend, location)
+	}
+	r, err := fetchData(backend, location, numdays)
+	if err != nil {
+		if cached, cacheErr := loadStale(backend, location); cacheErr == nil {
+			log.Printf("Unable to get the weather for %q from %s, showing the cached one: %v", location, backend, err)
+			return cached, nil
+		}
+	}
+	return r, err
+}
+
+// fetchLocations fetches the weather of every location at once and returns
+// it in the order of locations. Like fetch, it falls back to the cached data
+// of a location if the backend fails, and leaves out the locations without
+// any. With -deterministic the locations are fetched one after the other, as
+// each fetch sets the time taken as now.
+func fetchLocations(backend string, locations []string, numdays int) []iface.Data {
+	rs := make([]iface.Data, len(locations))
+	ok := make([]bool, len(locations))
+	get := func(i int) {
+		var err error
+		if rs[i], err = fetchOrStale(backend, locations[i], numdays); err != nil {
+			log.Printf("Unable to get the weather for %q from %s: %v", locations[i], backend, err)
+			return
+		}
+		ok[i] = true
+	}
+
+	var wg sync.WaitGroup
+	for i := range locations {
+		if iface.Deterministic {
+			get(i)
+			continue
+		}
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			get(i)
+		}(i)
+	}
+	wg.Wait()
+
+	var ret []iface.Data
+	for i, r := range rs {
+		if ok[i] {
+			ret = append(ret, r)
+		}
+	}
+	if len(ret) == 0 {
+		log.Fatal("Unable to get the weather for any location")
+	}
+	return ret
+}
+
+// renderLocations renders the weather of several locations, together if the
+// frontend supports it, otherwise one after the other.
+func renderLocations(fe iface.Frontend, rs []iface.Data, unit iface.UnitSystem) error {
+	if mfe, ok := fe.(iface.MultiFrontend); ok {
+		return mfe.RenderAll(os.Stdout, rs, unit)
+	}
+	for i, r := range rs {
+		if i > 0 {
+			fmt.Println()
+		}
+		if err := fe.Render(os.Stdout, r, unit); err != nil {
+			return err
+		}
+	}
+	return nil
+}
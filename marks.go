@@ -0,0 +1,65 @@
+package main
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/nafiz1001/wego/expr"
+	"github.com/nafiz1001/wego/iface"
+)
+
+// markRules are parsed from the -mark flag, a semicolon separated list of
+// NAME=EXPRESSION rules like "sun=uv >= 6; heat=humidex >= 35".
+var markRules []expr.Assignment
+
+// setupMarks parses the -mark rules and checks that they only use known
+// variables.
+func setupMarks(rules string) (err error) {
+	if markRules, err = expr.ParseAssignments(rules); err != nil {
+		return fmt.Errorf("mark: %v", err)
+	}
+	for _, m := range markRules {
+		if err := checkVars("mark", m.Expr); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkVars returns an error if e uses a variable unknown to iface.Cond.Vars.
+func checkVars(hook string, e *expr.Expr) error {
+	known := iface.Cond{}.Vars()
+	for _, v := range e.Variables() {
+		if _, ok := known[v]; !ok {
+			return fmt.Errorf("%s: unknown variable %q", hook, v)
+		}
+	}
+	return nil
+}
+
+// applyMarks sets the marks of the current conditions and every slot of r to
+// the names of the -mark rules which are true for them. Rules on unknown
+// values are false.
+func applyMarks(r *iface.Data) {
+	if len(markRules) == 0 {
+		return
+	}
+	mark := func(c *iface.Cond) {
+		c.Marks = nil
+		for _, m := range markRules {
+			val, err := m.Expr.Eval(c.Vars())
+			if err != nil {
+				log.Fatalf("mark: %s: %v", m, err)
+			}
+			if expr.Truthy(val) {
+				c.Marks = append(c.Marks, m.Name)
+			}
+		}
+	}
+	mark(&r.Current)
+	for i := range r.Forecast {
+		for j := range r.Forecast[i].Slots {
+			mark(&r.Forecast[i].Slots[j])
+		}
+	}
+}
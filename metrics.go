@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/nafiz1001/wego/iface"
+	"github.com/nafiz1001/wego/metrics"
+)
+
+// set by the -metrics flag
+var metricsAddr string
+
+var (
+	fetches       = metrics.NewCounter("wego_fetches_total", "Fetches from backends by result: ok or error.", "backend", "result")
+	fetchDuration = metrics.NewHistogram("wego_fetch_duration_seconds", "Duration of fetches from backends, including all their requests.", metrics.DefaultBuckets, "backend")
+)
+
+// fetchBackend fetches from the backend be named name within the -timeout and
+// records the fetch in the metrics.
+func fetchBackend(name string, be iface.Backend, location string, numdays int) (iface.Data, error) {
+	ctx, cancel := context.WithTimeout(netCtx, netTimeout)
+	defer cancel()
+	start := time.Now()
+	r, err := be.Fetch(ctx, location, numdays)
+	fetchDuration.Observe(time.Since(start).Seconds(), name)
+	if err != nil {
+		fetches.Inc(name, "error")
+	} else {
+		fetches.Inc(name, "ok")
+	}
+	return r, err
+}
+
+// serveMetrics serves the metrics of wego itself on /metrics of the -metrics
+// address, for Prometheus to scrape from the daemon.
+func serveMetrics() {
+	if metricsAddr == "" {
+		return
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", metrics.Handler())
+	go func() {
+		log.Println("Serving metrics on", "http://"+metricsAddr+"/metrics")
+		log.Println("Unable to serve metrics:", http.ListenAndServe(metricsAddr, mux))
+	}()
+}
@@ -0,0 +1,210 @@
+// Package metrics collects counters and histograms about the operation of
+// wego itself, like the requests to web services and the cache lookups, and
+// serves them in the text format of Prometheus.
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// DefaultBuckets are the upper bounds in seconds of the buckets of latency
+// histograms, from fast cache-like responses to the default -timeout.
+var DefaultBuckets = []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30}
+
+var (
+	mu       sync.Mutex
+	families = map[string]*family{}
+)
+
+// family is a metric with all its label combinations.
+type family struct {
+	name    string
+	help    string
+	typ     string
+	labels  []string
+	buckets []float64
+	series  map[string]*series
+}
+
+// series is the value of a family for one combination of labels.
+type series struct {
+	labels string
+	value  float64
+	counts []uint64
+	sum    float64
+}
+
+func register(name, help, typ string, buckets []float64, labels []string) *family {
+	mu.Lock()
+	defer mu.Unlock()
+	if _, ok := families[name]; ok {
+		panic("metrics: " + name + " registered twice")
+	}
+	f := &family{name: name, help: help, typ: typ, labels: labels, buckets: buckets, series: map[string]*series{}}
+	if len(labels) == 0 {
+		// show metrics without labels before the first event
+		f.get(nil)
+	}
+	families[name] = f
+	return f
+}
+
+// get returns the series of f for the label values. mu must be held.
+func (f *family) get(values []string) *series {
+	if len(values) != len(f.labels) {
+		panic(fmt.Sprintf("metrics: %s takes %d labels, got %d", f.name, len(f.labels), len(values)))
+	}
+	var b strings.Builder
+	for i, l := range f.labels {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		fmt.Fprintf(&b, "%s=%q", l, values[i])
+	}
+	key := b.String()
+	s, ok := f.series[key]
+	if !ok {
+		s = &series{labels: key, counts: make([]uint64, len(f.buckets))}
+		f.series[key] = s
+	}
+	return s
+}
+
+// Counter is a count of events, partitioned by labels.
+type Counter struct{ f *family }
+
+// NewCounter registers the counter name with the given label names.
+func NewCounter(name, help string, labels ...string) Counter {
+	return Counter{register(name, help, "counter", nil, labels)}
+}
+
+// Inc counts an event with the label values, in the order of the names given
+// to NewCounter.
+func (c Counter) Inc(values ...string) {
+	mu.Lock()
+	c.f.get(values).value++
+	mu.Unlock()
+}
+
+// Histogram is a distribution of observed values, like latencies, partitioned
+// by labels.
+type Histogram struct{ f *family }
+
+// NewHistogram registers the histogram name with the given bucket upper
+// bounds in increasing order and label names.
+func NewHistogram(name, help string, buckets []float64, labels ...string) Histogram {
+	return Histogram{register(name, help, "histogram", buckets, labels)}
+}
+
+// Observe adds v to the distribution with the label values.
+func (h Histogram) Observe(v float64, values ...string) {
+	mu.Lock()
+	s := h.f.get(values)
+	for i, le := range h.f.buckets {
+		if v <= le {
+			s.counts[i]++
+		}
+	}
+	s.sum += v
+	s.value++
+	mu.Unlock()
+}
+
+// Write writes all metrics to w in the Prometheus text format.
+func Write(w io.Writer) error {
+	mu.Lock()
+	defer mu.Unlock()
+
+	var names []string
+	for name := range families {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	for _, name := range names {
+		f := families[name]
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s %s\n", name, f.help, name, f.typ)
+		var keys []string
+		for k := range f.series {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			s := f.series[k]
+			if f.typ == "counter" {
+				fmt.Fprintf(&b, "%s%s %s\n", name, braces(s.labels), formatFloat(s.value))
+				continue
+			}
+			for i, le := range f.buckets {
+				fmt.Fprintf(&b, "%s_bucket%s %d\n", name, braces(join(s.labels, fmt.Sprintf("le=%q", formatFloat(le)))), s.counts[i])
+			}
+			fmt.Fprintf(&b, "%s_bucket%s %s\n", name, braces(join(s.labels, `le="+Inf"`)), formatFloat(s.value))
+			fmt.Fprintf(&b, "%s_sum%s %s\n", name, braces(s.labels), formatFloat(s.sum))
+			fmt.Fprintf(&b, "%s_count%s %s\n", name, braces(s.labels), formatFloat(s.value))
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// Sample is a value of a gauge with its label names and values in pairs.
+type Sample struct {
+	Labels []string
+	Value  float64
+}
+
+// WriteGauge writes the gauge name with the samples to w in the Prometheus
+// text format. Unlike the counters and histograms, gauges are not collected
+// by wego but written from values known at the time, like the weather.
+func WriteGauge(w io.Writer, name, help string, samples []Sample) error {
+	if len(samples) == 0 {
+		return nil
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s gauge\n", name, help, name)
+	for _, s := range samples {
+		var labels []string
+		for i := 0; i+1 < len(s.Labels); i += 2 {
+			labels = append(labels, fmt.Sprintf("%s=%q", s.Labels[i], s.Labels[i+1]))
+		}
+		fmt.Fprintf(&b, "%s%s %s\n", name, braces(strings.Join(labels, ",")), formatFloat(s.Value))
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// Handler serves the metrics, to be mounted on /metrics.
+func Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		Write(w)
+	})
+}
+
+func braces(labels string) string {
+	if labels == "" {
+		return ""
+	}
+	return "{" + labels + "}"
+}
+
+func join(labels, label string) string {
+	if labels == "" {
+		return label
+	}
+	return labels + "," + label
+}
+
+func formatFloat(v float64) string {
+	if math.IsInf(v, 1) {
+		return "+Inf"
+	}
+	return fmt.Sprint(v)
+}
@@ -0,0 +1,238 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+)
+
+// set by the -timeout, -ipv4, -ipv6, -doh, -ca-bundle, -client-cert and
+// -client-key flags
+var (
+	netTimeout    = 30 * time.Second
+	netIPv4       bool
+	netIPv6       bool
+	netDoH        string
+	netCABundle   string
+	netClientCert string
+	netClientKey  string
+)
+
+// netFlags are the names of the flags setupNetwork depends on.
+var netFlags = []string{"timeout", "ipv4", "ipv6", "doh", "ca-bundle", "client-cert", "client-key"}
+
+// netCtx is the parent of the contexts of all requests. The daemon cancels it
+// on SIGTERM to abort the requests in flight.
+var netCtx, cancelNet = context.WithCancel(context.Background())
+
+// dohAnswer is the part of a DNS JSON API response we need. See
+// https://developers.google.com/speed/public-dns/docs/doh/json
+type dohAnswer struct {
+	Status int
+	Answer []struct {
+		Type int    `json:"type"`
+		Data string `json:"data"`
+	}
+}
+
+// dns record types
+const (
+	dohTypeA    = 1
+	dohTypeAAAA = 28
+)
+
+// dohResolver looks up host names with a DNS-over-HTTPS server speaking the
+// JSON API, e.g. https://cloudflare-dns.com/dns-query or
+// https://dns.google/resolve.
+type dohResolver struct {
+	endpoint string
+	client   *http.Client
+}
+
+func (r *dohResolver) query(ctx context.Context, host string, qtype int) ([]net.IP, error) {
+	u, err := url.Parse(r.endpoint)
+	if err != nil {
+		return nil, err
+	}
+	q := u.Query()
+	q.Set("name", host)
+	q.Set("type", fmt.Sprint(qtype))
+	u.RawQuery = q.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, "GET", u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/dns-json")
+	res, err := r.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("DNS-over-HTTPS server returned %s", res.Status)
+	}
+
+	var ans dohAnswer
+	if err = json.NewDecoder(res.Body).Decode(&ans); err != nil {
+		return nil, fmt.Errorf("unable to decode DNS-over-HTTPS response: %v", err)
+	}
+	if ans.Status != 0 {
+		return nil, fmt.Errorf("DNS-over-HTTPS lookup of %s failed with rcode %d", host, ans.Status)
+	}
+	var ips []net.IP
+	for _, a := range ans.Answer {
+		// CNAME records in between are resolved by the server already
+		if a.Type != qtype {
+			continue
+		}
+		if ip := net.ParseIP(a.Data); ip != nil {
+			ips = append(ips, ip)
+		}
+	}
+	return ips, nil
+}
+
+// lookup returns the addresses of host of the given network ("tcp", "tcp4"
+// or "tcp6"), IPv6 addresses first.
+func (r *dohResolver) lookup(ctx context.Context, network, host string) ([]net.IP, error) {
+	var ips []net.IP
+	var lastErr error
+	if network != "tcp4" {
+		v6, err := r.query(ctx, host, dohTypeAAAA)
+		ips, lastErr = append(ips, v6...), err
+	}
+	if network != "tcp6" {
+		v4, err := r.query(ctx, host, dohTypeA)
+		ips, lastErr = append(ips, v4...), err
+	}
+	if len(ips) == 0 {
+		if lastErr != nil {
+			return nil, lastErr
+		}
+		return nil, fmt.Errorf("no addresses found for %s", host)
+	}
+	return ips, nil
+}
+
+// tlsConfig returns the TLS settings for the -ca-bundle, -client-cert and
+// -client-key flags. The certificates of the bundle are trusted in addition to
+// the ones of the system.
+func tlsConfig() (*tls.Config, error) {
+	cfg := &tls.Config{}
+	if netCABundle != "" {
+		pem, err := os.ReadFile(netCABundle)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read CA bundle: %v", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in CA bundle %s", netCABundle)
+		}
+		cfg.RootCAs = pool
+	}
+
+	if (netClientCert == "") != (netClientKey == "") {
+		return nil, fmt.Errorf("-client-cert and -client-key must be given together")
+	}
+	if netClientCert != "" {
+		cert, err := tls.LoadX509KeyPair(netClientCert, netClientKey)
+		if err != nil {
+			return nil, fmt.Errorf("unable to load client certificate: %v", err)
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+	return cfg, nil
+}
+
+// setupNetwork sets the -timeout of the default HTTP client and replaces the
+// default HTTP transport used by the backends with one that only connects over
+// the address family selected with -ipv4 or -ipv6, resolves host names with the
+// DNS-over-HTTPS server given by -doh and uses the TLS certificates of
+// -ca-bundle and -client-cert.
+func setupNetwork() error {
+	if netTimeout <= 0 {
+		return fmt.Errorf("-timeout must be positive")
+	}
+	// backends bound their whole fetch with a context, this catches the
+	// requests without one, e.g. of the hazard feeds
+	http.DefaultClient.Timeout = netTimeout
+	if t, ok := http.DefaultTransport.(*http.Transport); ok {
+		t.ResponseHeaderTimeout = netTimeout
+	}
+
+	if netIPv4 && netIPv6 {
+		return fmt.Errorf("-ipv4 and -ipv6 cannot be used together")
+	}
+	if !netIPv4 && !netIPv6 && netDoH == "" && netCABundle == "" && netClientCert == "" && netClientKey == "" {
+		return nil
+	}
+	tlsCfg, err := tlsConfig()
+	if err != nil {
+		return err
+	}
+
+	family := "tcp"
+	if netIPv4 {
+		family = "tcp4"
+	} else if netIPv6 {
+		family = "tcp6"
+	}
+	dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}
+	base := http.DefaultTransport.(*http.Transport).Clone()
+	base.TLSClientConfig = tlsCfg
+	base.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		if network == "tcp" {
+			network = family
+		}
+		return dialer.DialContext(ctx, network, addr)
+	}
+	if netDoH == "" {
+		http.DefaultTransport = base
+		return nil
+	}
+
+	if u, err := url.Parse(netDoH); err != nil || u.Scheme != "https" || u.Host == "" {
+		return fmt.Errorf("invalid DNS-over-HTTPS server %q, it must be an https URL", netDoH)
+	}
+	// the DoH server itself is resolved with the system resolver, unless it is
+	// given by its IP address
+	resolver := &dohResolver{endpoint: netDoH, client: &http.Client{Transport: base, Timeout: 10 * time.Second}}
+	t := base.Clone()
+	t.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		host, port, err := net.SplitHostPort(addr)
+		if err != nil {
+			return nil, err
+		}
+		if network == "tcp" {
+			network = family
+		}
+		if net.ParseIP(host) != nil {
+			return dialer.DialContext(ctx, network, addr)
+		}
+
+		ips, err := resolver.lookup(ctx, network, host)
+		if err != nil {
+			return nil, fmt.Errorf("unable to resolve %s: %v", host, err)
+		}
+		for _, ip := range ips {
+			var conn net.Conn
+			if conn, err = dialer.DialContext(ctx, network, net.JoinHostPort(ip.String(), port)); err == nil {
+				return conn, nil
+			}
+		}
+		return nil, err
+	}
+	http.DefaultTransport = t
+	return nil
+}
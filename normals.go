@@ -0,0 +1,131 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/url"
+	"time"
+
+	"github.com/nafiz1001/wego/cache"
+	"github.com/nafiz1001/wego/iface"
+)
+
+// normalsURL is set by the -normals-url flag.
+var normalsURL string
+
+// The normals are the mean of the daily highs and lows of 1991 to 2020 within
+// normalsWindow days of each day of the year, to smooth out single years.
+const (
+	normalsStart  = "1991-01-01"
+	normalsEnd    = "2020-12-31"
+	normalsWindow = 7
+)
+
+// normals are the normal highs and lows by day of a leap year, starting at 0
+// for January 1st.
+type normals [366][2]float32
+
+// climateResponse is the daily data of the Open-Meteo climate API.
+type climateResponse struct {
+	Daily struct {
+		Time []string   `json:"time"`
+		Max  []*float32 `json:"temperature_2m_max"`
+		Min  []*float32 `json:"temperature_2m_min"`
+	} `json:"daily"`
+}
+
+// leapYearDay returns the index of the day of t in a leap year.
+func leapYearDay(t time.Time) int {
+	return time.Date(2000, t.Month(), t.Day(), 0, 0, 0, 0, time.UTC).YearDay() - 1
+}
+
+// fetchNormals computes the normals at loc from the daily values of the
+// climate model of the Open-Meteo climate API.
+func fetchNormals(loc iface.LatLon) (*normals, error) {
+	u, err := url.Parse(normalsURL)
+	if err != nil {
+		return nil, err
+	}
+	q := u.Query()
+	q.Set("latitude", fmt.Sprintf("%.2f", loc.Latitude))
+	q.Set("longitude", fmt.Sprintf("%.2f", loc.Longitude))
+	q.Set("start_date", normalsStart)
+	q.Set("end_date", normalsEnd)
+	q.Set("daily", "temperature_2m_max,temperature_2m_min")
+	u.RawQuery = q.Encode()
+
+	var resp climateResponse
+	if err := fetchFeed(u.String(), func(r io.Reader) error {
+		return json.NewDecoder(r).Decode(&resp)
+	}); err != nil {
+		return nil, err
+	}
+	d := resp.Daily
+	if len(d.Max) != len(d.Time) || len(d.Min) != len(d.Time) {
+		return nil, fmt.Errorf("%s: malformed daily data", normalsURL)
+	}
+
+	var sum [366][2]float64
+	var n [366]int
+	for i, date := range d.Time {
+		t, err := time.Parse("2006-01-02", date)
+		if err != nil || d.Max[i] == nil || d.Min[i] == nil {
+			continue
+		}
+		yd := leapYearDay(t)
+		for w := -normalsWindow; w <= normalsWindow; w++ {
+			k := (yd + w + 366) % 366
+			sum[k][0] += float64(*d.Max[i])
+			sum[k][1] += float64(*d.Min[i])
+			n[k]++
+		}
+	}
+
+	var ret normals
+	for k := range ret {
+		if n[k] == 0 {
+			return nil, fmt.Errorf("%s: no data for day %d of the year", normalsURL, k+1)
+		}
+		ret[k][0] = float32(sum[k][0] / float64(n[k]))
+		ret[k][1] = float32(sum[k][1] / float64(n[k]))
+	}
+	return &ret, nil
+}
+
+// applyNormals sets the normal highs and lows of the forecast days the backend
+// left out if temperatures are colored by their anomaly. The normals are
+// cached, since they only depend on the location.
+func applyNormals(r *iface.Data) {
+	if iface.TempScale != "anomaly" || r.GeoLoc == nil {
+		return
+	}
+	missing := false
+	for _, d := range r.Forecast {
+		missing = missing || d.NormalMaxC == nil || d.NormalMinC == nil
+	}
+	if !missing {
+		return
+	}
+
+	key := fmt.Sprintf("normals-%.2f,%.2f", r.GeoLoc.Latitude, r.GeoLoc.Longitude)
+	var norm *normals
+	if _, err := cache.Load(key, &norm); err != nil {
+		if norm, err = fetchNormals(*r.GeoLoc); err != nil {
+			log.Printf("Unable to get the climate normals: %v", err)
+			return
+		}
+		if err := cache.Store(key, norm); err != nil {
+			log.Printf("Unable to cache the climate normals: %v", err)
+		}
+	}
+
+	for i := range r.Forecast {
+		d := &r.Forecast[i]
+		if d.NormalMaxC == nil || d.NormalMinC == nil {
+			n := norm[leapYearDay(d.Date)]
+			d.NormalMaxC, d.NormalMinC = &n[0], &n[1]
+		}
+	}
+}
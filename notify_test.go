@@ -0,0 +1,23 @@
+package main
+
+import (
+	"net"
+	"testing"
+)
+
+// TestNotifyPublishRefused checks that a broker refusing the connection is an
+// error, with and without TLS.
+func TestNotifyPublishRefused(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := l.Addr().String()
+	l.Close()
+
+	for _, scheme := range []string{"mqtt", "mqtts"} {
+		if err := notifyPublish(scheme+"://"+addr+"/wego/alerts", []byte("{}")); err == nil {
+			t.Errorf("%s: publishing to the closed port %s succeeded", scheme, addr)
+		}
+	}
+}
@@ -0,0 +1,25 @@
+package main
+
+import (
+	"github.com/nafiz1001/wego/cache"
+	"github.com/nafiz1001/wego/iface"
+)
+
+// offline is set by the -offline flag.
+var offline bool
+
+// loadStale returns the data of the last successful fetch for location from
+// backend, marked as stale.
+func loadStale(backend, location string) (iface.Data, error) {
+	var r iface.Data
+	stored, err := cache.Load(cache.ForecastKey(backend, location), &r)
+	if err != nil {
+		return iface.Data{}, err
+	}
+	// caches written before FetchedAt was added
+	if r.FetchedAt.IsZero() {
+		r.FetchedAt = stored
+	}
+	r.Stale = true
+	return r, nil
+}
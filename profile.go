@@ -0,0 +1,83 @@
+package main
+
+import (
+	"log"
+	"net/http"
+	"net/http/pprof"
+	"os"
+	"runtime"
+	rpprof "runtime/pprof"
+	"runtime/trace"
+)
+
+// set by the -pprof and -trace flags
+var (
+	profileTarget string
+	traceFile     string
+)
+
+// startProfiling starts the profiling requested with -pprof and -trace and
+// returns a function finishing it, which must be called before exiting.
+//
+// In the daemon and the server, -pprof is the address to serve net/http/pprof on. Otherwise
+// it is the file to write a CPU profile to, the heap profile is written to the
+// same name with .heap appended when finishing.
+func startProfiling(daemon bool) (stop func()) {
+	var stops []func()
+	stop = func() {
+		for i := len(stops) - 1; i >= 0; i-- {
+			stops[i]()
+		}
+	}
+
+	if profileTarget != "" && daemon {
+		mux := http.NewServeMux()
+		mux.HandleFunc("/debug/pprof/", pprof.Index)
+		mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+		mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+		mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+		mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+		go func() {
+			log.Println("Serving profiles on", "http://"+profileTarget+"/debug/pprof/")
+			log.Println("Unable to serve profiles:", http.ListenAndServe(profileTarget, mux))
+		}()
+	} else if profileTarget != "" {
+		f, err := os.Create(profileTarget)
+		if err != nil {
+			log.Fatal("Unable to create CPU profile: ", err)
+		}
+		if err = rpprof.StartCPUProfile(f); err != nil {
+			log.Fatal("Unable to start CPU profile: ", err)
+		}
+		stops = append(stops, func() {
+			rpprof.StopCPUProfile()
+			f.Close()
+
+			h, err := os.Create(profileTarget + ".heap")
+			if err != nil {
+				log.Println("Unable to create heap profile:", err)
+				return
+			}
+			defer h.Close()
+			runtime.GC()
+			if err := rpprof.WriteHeapProfile(h); err != nil {
+				log.Println("Unable to write heap profile:", err)
+			}
+		})
+	}
+
+	if traceFile != "" {
+		f, err := os.Create(traceFile)
+		if err != nil {
+			log.Fatal("Unable to create trace: ", err)
+		}
+		if err = trace.Start(f); err != nil {
+			log.Fatal("Unable to start trace: ", err)
+		}
+		stops = append(stops, func() {
+			trace.Stop()
+			f.Close()
+		})
+	}
+	return stop
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// configWatch reloads the config file into the flags while the daemon runs,
+// so edits like a new location or a rotated API key apply without a restart.
+type configWatch struct {
+	path    string
+	modTime time.Time
+
+	// values are the settings of the config file as of the last load, so only
+	// the ones edited since are applied. Otherwise a reload would revert the
+	// location given as argument to the one of the config file.
+	values map[string]string
+
+	// given are the flags of the command line, which keep precedence over
+	// the config file.
+	given map[flag.Value]bool
+}
+
+// newConfigWatch returns a watch of the config file, whose settings were
+// applied by ingo at startup.
+func newConfigWatch() (*configWatch, error) {
+	p, err := configPath()
+	if err != nil {
+		return nil, err
+	}
+	w := &configWatch{path: p, given: cmdlineFlags()}
+	w.values, w.modTime, err = readConfigValues(p)
+	return w, err
+}
+
+// readConfigValues returns the settings of the config file at p, read like
+// ingo does: one KEY=VALUE or KEY:VALUE per line, the last one of a key wins.
+func readConfigValues(p string) (map[string]string, time.Time, error) {
+	f, err := os.Open(p)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+
+	ret := make(map[string]string)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+		if i := strings.IndexAny(line, "=:"); i >= 0 {
+			ret[strings.TrimSpace(line[:i])] = strings.TrimSpace(line[i+1:])
+		}
+	}
+	return ret, fi.ModTime(), scanner.Err()
+}
+
+// modified tells whether the config file changed since the last load.
+func (w *configWatch) modified() bool {
+	fi, err := os.Stat(w.path)
+	return err == nil && !fi.ModTime().Equal(w.modTime)
+}
+
+// reload applies the settings edited since the last load and returns the
+// names of the flags changed, sorted. Settings which fail to apply are logged
+// and skipped, so a typo does not stop the daemon.
+func (w *configWatch) reload() ([]string, error) {
+	values, modTime, err := readConfigValues(w.path)
+	if err != nil {
+		return nil, err
+	}
+	var changed []string
+	for key, val := range values {
+		if old, ok := w.values[key]; ok && old == val {
+			continue
+		}
+		fl := flag.Lookup(key)
+		if fl == nil || w.given[fl.Value] || key == "profile" {
+			continue
+		}
+		if err := fl.Value.Set(val); err != nil {
+			log.Printf("Unable to apply %s=%s of the config file: %v", key, val, err)
+			continue
+		}
+		changed = append(changed, key)
+	}
+	sort.Strings(changed)
+	w.values, w.modTime = values, modTime
+	return changed, nil
+}
+
+// flagValue returns the current value of the flag name.
+func flagValue(name string) string {
+	return flag.Lookup(name).Value.String()
+}
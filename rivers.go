@@ -0,0 +1,280 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	colorable "github.com/mattn/go-colorable"
+	"github.com/nafiz1001/wego/iface"
+)
+
+// set by the -rivers… flags
+var (
+	riversRadiusKm   float64
+	riversUSGSURL    string
+	riversECCCURL    string
+	riversFloodStage string
+)
+
+// riversMax is the number of nearest gauges shown.
+const riversMax = 10
+
+// riversSteady is the change of level in m within the last hours below which
+// a river is considered steady.
+const riversSteady = 0.02
+
+// gauge is the latest reading of a hydrometric station.
+type gauge struct {
+	ID, Name string
+	Lat, Lon float64
+	Unit     string // of the levels, m or ft
+	Level    float64
+	Previous float64 // the earliest level of the last hours, for the trend
+	Time     time.Time
+	DistKm   float64
+}
+
+// parseFloodStages parses the -rivers-flood-stage list like
+// "02KF005=59.5,01646500=10".
+func parseFloodStages(s string) (map[string]float64, error) {
+	ret := make(map[string]float64)
+	for _, entry := range strings.Split(s, ",") {
+		if entry = strings.TrimSpace(entry); entry == "" {
+			continue
+		}
+		kv := strings.SplitN(entry, "=", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("invalid flood stage %q, use STATION=LEVEL", entry)
+		}
+		level, err := strconv.ParseFloat(strings.TrimSpace(kv[1]), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid flood stage %q: %v", entry, err)
+		}
+		ret[strings.TrimSpace(kv[0])] = level
+	}
+	return ret, nil
+}
+
+// usgsGauges returns the gauge heights of the USGS stations in the box around
+// lat, lon during the last six hours.
+func usgsGauges(lat, lon float64) ([]gauge, error) {
+	dLat := riversRadiusKm / 111
+	dLon := dLat / math.Max(math.Cos(degToRad(lat)), 0.01)
+	q := url.Values{}
+	q.Set("format", "json")
+	q.Set("parameterCd", "00065") // gauge height
+	q.Set("siteStatus", "active")
+	q.Set("period", "PT6H")
+	q.Set("bBox", fmt.Sprintf("%.6f,%.6f,%.6f,%.6f", lon-dLon, lat-dLat, lon+dLon, lat+dLat))
+
+	var resp struct {
+		Value struct {
+			TimeSeries []struct {
+				SourceInfo struct {
+					SiteName string `json:"siteName"`
+					SiteCode []struct {
+						Value string `json:"value"`
+					} `json:"siteCode"`
+					GeoLocation struct {
+						GeogLocation struct {
+							Latitude  float64 `json:"latitude"`
+							Longitude float64 `json:"longitude"`
+						} `json:"geogLocation"`
+					} `json:"geoLocation"`
+				} `json:"sourceInfo"`
+				Variable struct {
+					Unit struct {
+						UnitCode string `json:"unitCode"`
+					} `json:"unit"`
+				} `json:"variable"`
+				Values []struct {
+					Value []struct {
+						Value    string    `json:"value"`
+						DateTime time.Time `json:"dateTime"`
+					} `json:"value"`
+				} `json:"values"`
+			} `json:"timeSeries"`
+		} `json:"value"`
+	}
+	if err := fetchFeed(riversUSGSURL+"?"+q.Encode(), func(r io.Reader) error {
+		return json.NewDecoder(r).Decode(&resp)
+	}); err != nil {
+		return nil, err
+	}
+
+	var ret []gauge
+	for _, ts := range resp.Value.TimeSeries {
+		si := ts.SourceInfo
+		if len(si.SiteCode) == 0 || len(ts.Values) == 0 {
+			continue
+		}
+		g := gauge{
+			ID:   si.SiteCode[0].Value,
+			Name: si.SiteName,
+			Lat:  si.GeoLocation.GeogLocation.Latitude,
+			Lon:  si.GeoLocation.GeogLocation.Longitude,
+			Unit: ts.Variable.Unit.UnitCode,
+		}
+		first := true
+		for _, v := range ts.Values[0].Value {
+			level, err := strconv.ParseFloat(v.Value, 64)
+			if err != nil || level <= -999999 { // no data marker
+				continue
+			}
+			if first {
+				g.Previous, first = level, false
+			}
+			g.Level, g.Time = level, v.DateTime
+		}
+		if !first {
+			ret = append(ret, g)
+		}
+	}
+	return ret, nil
+}
+
+// ecccGauges returns the water levels of the stations of the hydrometric
+// Datamart of Environment and Climate Change Canada within riversRadiusKm of
+// lat, lon.
+func ecccGauges(lat, lon float64) ([]gauge, error) {
+	var stations [][]string
+	if err := fetchFeed(riversECCCURL+"/doc/hydrometric_StationList.csv", func(r io.Reader) (err error) {
+		stations, err = csv.NewReader(r).ReadAll()
+		return
+	}); err != nil {
+		return nil, err
+	}
+
+	var ret []gauge
+	for _, s := range stations {
+		// ID, Name, Latitude, Longitude, Prov/Terr, Timezone
+		if len(s) < 5 {
+			continue
+		}
+		sLat, err1 := strconv.ParseFloat(s[2], 64)
+		sLon, err2 := strconv.ParseFloat(s[3], 64)
+		if err1 != nil || err2 != nil {
+			continue // header
+		}
+		if d, _ := greatCircle(lat, lon, sLat, sLon); d > riversRadiusKm {
+			continue
+		}
+
+		var rows [][]string
+		prov, id := s[4], s[0]
+		if err := fetchFeed(fmt.Sprintf("%s/csv/%s/hourly/%s_%s_hourly_hydrometric.csv", riversECCCURL, prov, prov, id), func(r io.Reader) (err error) {
+			cr := csv.NewReader(r)
+			cr.FieldsPerRecord = -1
+			rows, err = cr.ReadAll()
+			return
+		}); err != nil {
+			log.Printf("Unable to read station %s: %v", id, err)
+			continue
+		}
+
+		// ID, Date, Water Level (m), … in chronological order, every 5 minutes
+		type reading struct {
+			t     time.Time
+			level float64
+		}
+		var readings []reading
+		for _, row := range rows {
+			if len(row) < 3 {
+				continue
+			}
+			level, err := strconv.ParseFloat(row[2], 64)
+			t, terr := time.Parse(time.RFC3339, row[1])
+			if err == nil && terr == nil {
+				readings = append(readings, reading{t, level})
+			}
+		}
+		if len(readings) == 0 {
+			continue
+		}
+		last := readings[len(readings)-1]
+		g := gauge{ID: id, Name: s[1], Lat: sLat, Lon: sLon, Unit: "m", Level: last.level, Time: last.t}
+		for _, rd := range readings {
+			if last.t.Sub(rd.t) <= 6*time.Hour {
+				g.Previous = rd.level
+				break
+			}
+		}
+		ret = append(ret, g)
+	}
+	return ret, nil
+}
+
+// runRivers lists the river gauges near the location with their latest level
+// and trend, and flags those above the flood stage set with
+// -rivers-flood-stage.
+func runRivers(backend string, location string, numdays int, unit iface.UnitSystem) {
+	stages, err := parseFloodStages(riversFloodStage)
+	if err != nil {
+		log.Fatal(err)
+	}
+	r := fetch(backend, location, 1)
+	if r.GeoLoc == nil {
+		log.Fatal("The backend returned no coordinates for the location")
+	}
+	lat, lon := float64(r.GeoLoc.Latitude), float64(r.GeoLoc.Longitude)
+
+	var gauges []gauge
+	sources := []struct {
+		name  string
+		fetch func(float64, float64) ([]gauge, error)
+	}{{"USGS", usgsGauges}, {"ECCC", ecccGauges}}
+	for _, src := range sources {
+		g, err := src.fetch(lat, lon)
+		if err != nil {
+			log.Printf("Unable to read the %s gauges: %v", src.name, err)
+		}
+		gauges = append(gauges, g...)
+	}
+	for i := range gauges {
+		gauges[i].DistKm, _ = greatCircle(lat, lon, gauges[i].Lat, gauges[i].Lon)
+	}
+	sort.SliceStable(gauges, func(i, j int) bool { return gauges[i].DistKm < gauges[j].DistKm })
+	if len(gauges) > riversMax {
+		gauges = gauges[:riversMax]
+	}
+
+	stdout := colorable.NewColorableStdout()
+	fmt.Fprintf(stdout, "River gauges near %s\n\n", r.Location)
+	if len(gauges) == 0 {
+		fmt.Fprintf(stdout, "No gauges within %.0f km.\n", riversRadiusKm)
+		return
+	}
+	for _, g := range gauges {
+		steady := riversSteady
+		if g.Unit == "ft" {
+			steady /= 0.3048
+		}
+		trend := "steady"
+		if g.Level-g.Previous > steady {
+			trend = "rising"
+		} else if g.Previous-g.Level > steady {
+			trend = "falling"
+		}
+
+		_, bearing := greatCircle(lat, lon, g.Lat, g.Lon)
+		d, u := unit.Distance(float32(g.DistKm * 1000))
+		line := fmt.Sprintf("%-9s %-40.40s %3d %s %-2s %8.2f %-2s %-7s", g.ID, g.Name, int(d), u, iface.CompassPoint(bearing), g.Level, g.Unit, trend)
+		if stage, ok := stages[g.ID]; ok {
+			if g.Level >= stage {
+				line += fmt.Sprintf(" \033[1;38;5;196mabove flood stage %.2f %s\033[0m", stage, g.Unit)
+			} else {
+				line += fmt.Sprintf(" %.2f %s below flood stage", stage-g.Level, g.Unit)
+			}
+		}
+		fmt.Fprintln(stdout, line)
+	}
+}
@@ -0,0 +1,22 @@
+//go:build go1.20 && !minimal
+// +build go1.20,!minimal
+
+package main
+
+import (
+	"crypto/x509"
+	_ "embed"
+)
+
+// fallbackRoots are the CA certificates trusted on systems without any, like
+// scratch containers. They add about 220 KB, builds with the minimal tag
+// leave them out and need the ca-bundle setting there.
+//
+//go:embed certs/roots.pem
+var fallbackRoots []byte
+
+func init() {
+	pool := x509.NewCertPool()
+	pool.AppendCertsFromPEM(fallbackRoots)
+	x509.SetFallbackRoots(pool)
+}
@@ -0,0 +1,19 @@
+//go:build go1.20 && !minimal
+// +build go1.20,!minimal
+
+package main
+
+import (
+	"crypto/x509"
+	"testing"
+)
+
+func TestFallbackRoots(t *testing.T) {
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(fallbackRoots) {
+		t.Fatal("no certificates in certs/roots.pem")
+	}
+	if n := len(pool.Subjects()); n < 100 {
+		t.Errorf("certs/roots.pem holds %d certificates, want the whole bundle", n)
+	}
+}
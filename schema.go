@@ -0,0 +1,225 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/nafiz1001/wego/iface"
+)
+
+// schemaExample is set by the -schema-example flag. The schema command then
+// prints an example document instead of the schema.
+var schemaExample bool
+
+var (
+	timeType = reflect.TypeOf(time.Time{})
+	codeType = reflect.TypeOf(iface.WeatherCode(0))
+)
+
+// weatherCodeSchema describes a WeatherCode, which is encoded as a number.
+func weatherCodeSchema() map[string]interface{} {
+	var values []int
+	var names []string
+	for c := iface.WeatherCode(0); ; c++ {
+		if _, ok := iface.ParseWeatherCode(c.String()); !ok {
+			break
+		}
+		values = append(values, int(c))
+		names = append(names, c.String())
+	}
+	return map[string]interface{}{
+		"type":        "integer",
+		"enum":        values,
+		"description": "weather code, in order: " + strings.Join(names, ", "),
+	}
+}
+
+// fieldDocs describe the fields of the output of the json frontend in the
+// schema, by type and field name. Units are those of the field names, e.g. C
+// for degrees celsius and M for meters. Times are RFC 3339 in the time zone of
+// the location, absent values are null.
+var fieldDocs = map[string]string{
+	"Document.Version": "version of the format, raised when fields are renamed, removed or change their meaning",
+
+	"Data.Current":        "current conditions",
+	"Data.Forecast":       "forecast by day, the first day is today",
+	"Data.Location":       "name of the location as known to the backend",
+	"Data.GeoLoc":         "coordinates of the location",
+	"Data.CurrentSource":  "backend of the current conditions",
+	"Data.ForecastSource": "backend of the forecast",
+	"Data.FailedSources":  "backends of the -backend chain which failed before ForecastSource",
+	"Data.CurrentSpread":  "spread of the observations of several stations combined into the current conditions",
+	"Data.Province":       "code of the Canadian province or territory of the location, like ON",
+	"Data.Alerts":         "weather warnings in effect, most severe first",
+	"Data.Hourly":         "slots of the next -hourly hours",
+	"Data.Stations":       "weather stations of the current conditions, nearest first",
+	"Data.FetchedAt":      "time of the fetch from the backend",
+	"Data.AirQuality":     "current air pollution at the location",
+	"Data.Stale":          "whether the data is taken from the cache because the backend could not be reached or with -offline",
+
+	"Day.Date":       "date of the day",
+	"Day.Slots":      "conditions at several times of the day, in order",
+	"Day.Astronomy":  "sunrise, sunset, moonrise and moonset, zero times if unknown",
+	"Day.Confidence": "confidence of the provider in the forecast of the day from 0 to 100",
+	"Day.MaxTempC":   "highest temperature of the day",
+	"Day.MinTempC":   "lowest temperature of the day",
+	"Day.NormalMaxC": "climate normal of the highest temperature",
+	"Day.NormalMinC": "climate normal of the lowest temperature",
+
+	"Cond.Time":                "time the conditions apply to",
+	"Cond.Code":                "general weather condition",
+	"Cond.Desc":                "short description of the conditions in the language of the backend",
+	"Cond.TempC":               "temperature",
+	"Cond.FeelsLikeC":          "felt temperature, e.g. the wind chill",
+	"Cond.ChanceOfRainPercent": "probability of precipitation",
+	"Cond.PrecipM":             "precipitation per hour in meters",
+	"Cond.VisibleDistM":        "visibility",
+	"Cond.WindspeedKmph":       "average wind speed",
+	"Cond.WindGustKmph":        "speed of the gusts",
+	"Cond.WinddirDegree":       "direction the wind blows from, 0 is north and 90 east",
+	"Cond.Humidity":            "relative humidity in percent",
+	"Cond.PressureHPa":         "air pressure reduced to mean sea level",
+	"Cond.PressureTendency":    "change of the pressure before an observation: rising, falling, steady or empty",
+	"Cond.StationPressureHPa":  "air pressure at the height of the station",
+	"Cond.CloudCoverPercent":   "share of the sky covered by clouds",
+	"Cond.UVIndex":             "UV index",
+	"Cond.ShortwaveWm2":        "mean global horizontal irradiance until the next slot",
+	"Cond.Soil":                "temperature and moisture of the ground",
+	"Cond.Marks":               "names of the -mark rules the conditions cross",
+	"Cond.IsDay":               "whether the sun is up",
+	"Cond.NormalTempC":         "climate normal of the temperature",
+
+	"Alert.Severity":  "Minor, Moderate, Severe, Extreme or Unknown",
+	"Alert.Headline":  "headline of the alert",
+	"Alert.Effective": "time the alert was issued or takes effect, zero if unknown",
+	"Alert.Expires":   "time the alert ends, zero if unknown",
+	"Alert.URL":       "page with the full text of the alert",
+
+	"AirQuality.AQI":      "air quality index of the US EPA from 0 to 500",
+	"AirQuality.Category": "Good, Moderate, Unhealthy for Sensitive Groups, Unhealthy, Very Unhealthy or Hazardous",
+	"AirQuality.Advice":   "advice of the US EPA on exercising outdoors at the level of the AQI",
+	"AirQuality.PM25Ugm3": "concentration of fine particles up to 2.5 µm in µg/m³",
+	"AirQuality.PM10Ugm3": "concentration of particles up to 10 µm in µg/m³",
+	"AirQuality.O3Ugm3":   "concentration of ozone in µg/m³",
+	"AirQuality.NO2Ugm3":  "concentration of nitrogen dioxide in µg/m³",
+
+	"Station.ID":     "identifier of the station at the provider",
+	"Station.Name":   "name of the station",
+	"Station.GeoLoc": "coordinates of the station",
+}
+
+// typeSchema returns the JSON Schema of values of type t as encoded by
+// encoding/json. Structs are added to defs and referenced.
+func typeSchema(t reflect.Type, defs map[string]interface{}) map[string]interface{} {
+	switch {
+	case t == timeType:
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	case t == codeType:
+		return map[string]interface{}{"$ref": "#/$defs/WeatherCode"}
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		s := typeSchema(t.Elem(), defs)
+		if ref, ok := s["$ref"]; ok {
+			return map[string]interface{}{"oneOf": []interface{}{map[string]interface{}{"$ref": ref}, map[string]interface{}{"type": "null"}}}
+		}
+		s["type"] = []interface{}{s["type"], "null"}
+		return s
+	case reflect.Slice:
+		return map[string]interface{}{"type": []string{"array", "null"}, "items": typeSchema(t.Elem(), defs)}
+	case reflect.Array:
+		return map[string]interface{}{"type": "array", "items": typeSchema(t.Elem(), defs), "minItems": t.Len(), "maxItems": t.Len()}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Struct:
+		if _, ok := defs[t.Name()]; !ok {
+			defs[t.Name()] = nil // guards against recursive types
+			props := map[string]interface{}{}
+			var required []string
+			var addFields func(t reflect.Type)
+			addFields = func(t reflect.Type) {
+				for i := 0; i < t.NumField(); i++ {
+					f := t.Field(i)
+					if f.Anonymous && f.Type.Kind() == reflect.Struct {
+						// encoding/json inlines the fields of embedded structs
+						addFields(f.Type)
+						continue
+					}
+					if f.PkgPath != "" || f.Tag.Get("json") == "-" {
+						continue
+					}
+					s := typeSchema(f.Type, defs)
+					if doc, ok := fieldDocs[t.Name()+"."+f.Name]; ok {
+						if _, isRef := s["$ref"]; isRef {
+							// siblings of $ref are ignored by older validators
+							s = map[string]interface{}{"allOf": []interface{}{s}}
+						}
+						s["description"] = doc
+					}
+					props[f.Name] = s
+					required = append(required, f.Name)
+				}
+			}
+			addFields(t)
+			defs[t.Name()] = map[string]interface{}{
+				"type":                 "object",
+				"properties":           props,
+				"required":             required,
+				"additionalProperties": false,
+			}
+		}
+		return map[string]interface{}{"$ref": "#/$defs/" + t.Name()}
+	}
+	log.Fatalf("Unable to describe type %v in the schema", t)
+	return nil
+}
+
+// dataSchema returns the JSON Schema of iface.Document as output by the json
+// frontend. It is generated from the type, so it never gets out of date.
+func dataSchema() map[string]interface{} {
+	defs := map[string]interface{}{"WeatherCode": weatherCodeSchema()}
+	root := typeSchema(reflect.TypeOf(iface.Document{}), defs)
+	doc := defs["Document"].(map[string]interface{})
+	doc["properties"].(map[string]interface{})["Version"] = map[string]interface{}{
+		"const":       iface.DocumentVersion,
+		"description": fieldDocs["Document.Version"],
+	}
+	return map[string]interface{}{
+		"$schema": "https://json-schema.org/draft/2020-12/schema",
+		"$id":     fmt.Sprintf("https://github.com/nafiz1001/wego/schema/data-v%d.json", iface.DocumentVersion),
+		"title":   fmt.Sprintf("wego weather data, version %d", iface.DocumentVersion),
+		"$ref":    root["$ref"],
+		"$defs":   defs,
+	}
+}
+
+// runSchema prints the JSON Schema of the output of the json frontend, or an
+// example document with -schema-example.
+func runSchema(backend string, location string, numdays int, unit iface.UnitSystem) {
+	var v interface{} = dataSchema()
+	if schemaExample {
+		r, err := iface.AllBackends["mock"].Fetch(context.Background(), "", 1)
+		if err != nil {
+			log.Fatal(err)
+		}
+		v = iface.Document{Version: iface.DocumentVersion, Data: r}
+	}
+	b, err := json.MarshalIndent(v, "", "\t")
+	if err != nil {
+		log.Fatal(err)
+	}
+	os.Stdout.Write(append(b, '\n'))
+}
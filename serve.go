@@ -0,0 +1,351 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/nafiz1001/wego/frontends"
+	"github.com/nafiz1001/wego/iface"
+	"github.com/nafiz1001/wego/metrics"
+)
+
+// set by the -serve-addr, -serve-interval, -serve-rate, -serve-token-file and
+// -serve-trust-proxy flags
+var (
+	serveAddr       string
+	serveInterval   time.Duration
+	serveRate       int
+	serveTokenFile  string
+	serveTrustProxy bool
+)
+
+var serveRequests = metrics.NewCounter("wego_serve_requests_total", "Requests to the serve command by format and status code.", "format", "code")
+
+// server serves the forecasts of its locations, refreshed by refresh.
+type server struct {
+	backend string
+	numdays int
+	tokens  map[string]string
+	limiter *rateLimiter
+
+	// location is the -location the locations default to, see
+	// serveLocations.
+	location string
+
+	// mu guards the fields below, which change when the config file is
+	// reloaded, and the forecasts.
+	mu        sync.RWMutex
+	unit      iface.UnitSystem
+	locations []string
+	data      map[string]iface.Data
+}
+
+// refresh fetches the forecasts of all locations. A location keeps its
+// previous forecast if the fetch fails.
+func (s *server) refresh() {
+	for _, l := range s.locations {
+		r, err := fetchOrStale(s.backend, l, s.numdays)
+		if netCtx.Err() != nil {
+			return
+		}
+		if err != nil {
+			log.Printf("Unable to get the weather for %q from %s, retrying in %v: %v", l, s.backend, serveInterval, err)
+			continue
+		}
+		s.mu.Lock()
+		s.data[l] = r
+		s.mu.Unlock()
+	}
+}
+
+// reload applies the edits of the config file to the settings of s, like
+// the daemon does. Rendering waits for it, as it changes the settings of the
+// frontends.
+func (s *server) reload(config *configWatch) {
+	renderMu.Lock()
+	defer renderMu.Unlock()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.backend, s.location, s.numdays, s.unit = reloadDaemonConfig(config, s.backend, s.location, s.numdays, s.unit)
+	s.locations = serveLocations(s.location)
+}
+
+// lookup returns the forecast of the location named in path, which may be a
+// configured location or the name the backend gave it. Pluses and
+// underscores stand for spaces, like in /New_York.
+func (s *server) lookup(path string) (data iface.Data, found, ok bool) {
+	name := strings.NewReplacer("+", " ", "_", " ").Replace(strings.Trim(path, "/"))
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, l := range s.locations {
+		r, fetched := s.data[l]
+		if name == "" || strings.EqualFold(name, l) || fetched && strings.EqualFold(name, r.Location) {
+			return r, true, fetched
+		}
+	}
+	return iface.Data{}, false, false
+}
+
+// clientIP returns the address req came from, taken from the last entry of
+// X-Forwarded-For with -serve-trust-proxy.
+func clientIP(req *http.Request) string {
+	if serveTrustProxy {
+		if fwd := req.Header.Values("X-Forwarded-For"); len(fwd) > 0 {
+			hops := strings.Split(fwd[len(fwd)-1], ",")
+			if ip := strings.TrimSpace(hops[len(hops)-1]); ip != "" {
+				return ip
+			}
+		}
+	}
+	host, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		return req.RemoteAddr
+	}
+	return host
+}
+
+// client returns the key req is rate limited by: the name of its token if
+// -serve-token-file is set, otherwise its address. It is false for a missing or
+// unknown token.
+func (s *server) client(req *http.Request) (string, bool) {
+	if len(s.tokens) == 0 {
+		return "ip " + clientIP(req), true
+	}
+	name := tokenName(s.tokens, requestToken(req))
+	return "token " + name, name != ""
+}
+
+// statusWriter remembers the status code for the metrics.
+type statusWriter struct {
+	http.ResponseWriter
+	code int
+}
+
+func (w *statusWriter) WriteHeader(code int) {
+	w.code = code
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (s *server) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	format := req.URL.Query().Get("format")
+	if format == "" {
+		format = "ansi"
+	}
+	sw := &statusWriter{ResponseWriter: w, code: http.StatusOK}
+	s.serve(sw, req, format)
+	if req.URL.Path == "/metrics" {
+		format = "metrics"
+	} else if format != "ansi" && format != "text" && format != "json" {
+		format = "unknown"
+	}
+	serveRequests.Inc(format, strconv.Itoa(sw.code))
+}
+
+func (s *server) serve(w http.ResponseWriter, req *http.Request, format string) {
+	client, ok := s.client(req)
+	if !ok {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="wego"`)
+		http.Error(w, "A valid token is required", http.StatusUnauthorized)
+		return
+	}
+	if ok, wait := s.limiter.allow(client, time.Now()); !ok {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+		http.Error(w, "Too many requests, try again later", http.StatusTooManyRequests)
+		return
+	}
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if req.URL.Path == "/metrics" {
+		metrics.Handler().ServeHTTP(w, req)
+		s.mu.RLock()
+		var rs []iface.Data
+		for _, l := range s.locations {
+			if r, ok := s.data[l]; ok {
+				rs = append(rs, r)
+			}
+		}
+		s.mu.RUnlock()
+		frontends.WritePrometheus(w, rs)
+		return
+	}
+
+	r, found, ok := s.lookup(req.URL.Path)
+	s.mu.RLock()
+	unit, locations := s.unit, s.locations
+	s.mu.RUnlock()
+	if !found {
+		http.Error(w, fmt.Sprintf("Unknown location, this server shows the weather of: %s", strings.Join(locations, "; ")), http.StatusNotFound)
+		return
+	}
+	if !ok {
+		http.Error(w, "The weather of this location could not be fetched yet, try again later", http.StatusServiceUnavailable)
+		return
+	}
+	if u := req.URL.Query().Get("units"); u != "" {
+		unit = parseUnits(u)
+	}
+
+	var b bytes.Buffer
+	switch format {
+	case "json":
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(&b).Encode(iface.Document{Version: iface.DocumentVersion, Data: r}); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	case "ansi", "text":
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		fe := iface.AllFrontends["ascii-art-table"]
+		if format == "text" {
+			fe = iface.AllFrontends["oneline"]
+		}
+		if err := render(&b, fe, r, unit); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	default:
+		http.Error(w, "Unknown format, use ansi, text or json", http.StatusBadRequest)
+		return
+	}
+	if !r.FetchedAt.IsZero() {
+		w.Header().Set("Last-Modified", r.FetchedAt.UTC().Format(http.TimeFormat))
+	}
+	w.Header().Set("Content-Length", strconv.Itoa(b.Len()))
+	if req.Method == http.MethodGet {
+		w.Write(b.Bytes())
+	}
+}
+
+// renderMu serializes render, as the frontends keep state while they render.
+var renderMu sync.Mutex
+
+// render renders r with fe to w.
+func render(w io.Writer, fe iface.Frontend, r iface.Data, unit iface.UnitSystem) error {
+	renderMu.Lock()
+	defer renderMu.Unlock()
+	return fe.Render(w, r, unit)
+}
+
+// serveLocations returns the locations given as arguments of the serve
+// command, or by -locations, or else location.
+func serveLocations(location string) []string {
+	var ret []string
+	for _, arg := range commandArgs {
+		if _, err := strconv.Atoi(arg); err != nil || len(arg) != 1 {
+			ret = append(ret, arg)
+		}
+	}
+	if len(ret) == 0 && locationsFlag != "" {
+		ret = splitLocations(locationsFlag)
+	}
+	if len(ret) == 0 {
+		ret = []string{location}
+	}
+	return ret
+}
+
+// runServe serves the forecasts of the locations over HTTP on -serve-addr,
+// refreshing them every -serve-interval. GET / shows the first location,
+// GET /NAME the one called NAME, as the ANSI colored table for curl, as the
+// -format line of the oneline frontend with ?format=text or as json with
+// ?format=json. The metrics of wego itself and the current conditions of all
+// locations as gauges are on /metrics.
+//
+// The config file is reloaded like by the daemon, on SIGHUP, which also
+// refreshes the forecasts, and before every refresh if it changed.
+//
+// Every client may make -serve-rate requests per minute. With
+// -serve-token-file, only requests with one of its tokens are answered and the
+// limit applies per token instead of per address.
+func runServe(backend string, location string, numdays int, unit iface.UnitSystem) {
+	if serveInterval < time.Minute {
+		log.Fatal("The serve interval must be at least one minute")
+	}
+	if serveRate < 1 {
+		log.Fatal("-serve-rate must be at least 1")
+	}
+	var tokens map[string]string
+	if serveTokenFile != "" {
+		var err error
+		if tokens, err = readServeTokens(serveTokenFile); err != nil {
+			log.Fatal("Unable to read the API tokens: ", err)
+		}
+		if len(tokens) == 0 {
+			log.Fatal("No API tokens in ", serveTokenFile)
+		}
+	}
+	s := &server{
+		backend:   backend,
+		numdays:   numdays,
+		tokens:    tokens,
+		limiter:   newRateLimiter(serveRate),
+		location:  location,
+		unit:      unit,
+		locations: serveLocations(location),
+		data:      make(map[string]iface.Data),
+	}
+	config, err := newConfigWatch()
+	if err != nil {
+		log.Println("Unable to watch the config file, changes need a restart:", err)
+	}
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+
+	srv := &http.Server{Addr: serveAddr, Handler: s, ReadHeaderTimeout: 10 * time.Second}
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, syscall.SIGTERM, os.Interrupt)
+	go func() {
+		<-stop
+		log.Println("Stopping")
+		cancelNet()
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		srv.Shutdown(ctx)
+	}()
+
+	s.refresh()
+	refreshed := make(chan struct{})
+	go func() {
+		defer close(refreshed)
+		for {
+			select {
+			case <-netCtx.Done():
+				return
+			case <-time.After(serveInterval):
+			case <-hup:
+				log.Println("Reloading the config file")
+				if config != nil {
+					config.modTime = time.Time{}
+				}
+			}
+			if config != nil && config.modified() {
+				s.reload(config)
+			}
+			s.refresh()
+		}
+	}()
+
+	log.Println("Serving the weather on", "http://"+serveAddr+"/")
+	if err := srv.ListenAndServe(); err != http.ErrServerClosed {
+		log.Fatal("Unable to serve the weather: ", err)
+	}
+	// exit once a refresh in flight wrote the cache
+	<-refreshed
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"bufio"
+	"crypto/subtle"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// rateLimiter allows every client rate requests per second in the long run
+// and bursts of up to burst requests.
+type rateLimiter struct {
+	rate  float64
+	burst float64
+
+	mu      sync.Mutex
+	buckets map[string]*bucket
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+func newRateLimiter(perMinute int) *rateLimiter {
+	return &rateLimiter{
+		rate:    float64(perMinute) / 60,
+		burst:   float64(perMinute),
+		buckets: make(map[string]*bucket),
+	}
+}
+
+// allow takes a request of client from its bucket. If the bucket is empty, it
+// returns false and how long the client has to wait.
+func (l *rateLimiter) allow(client string, now time.Time) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	b, ok := l.buckets[client]
+	if !ok {
+		// forget the clients whose buckets filled up again
+		if len(l.buckets) >= 1000 {
+			for c, b := range l.buckets {
+				if b.tokens+now.Sub(b.last).Seconds()*l.rate >= l.burst {
+					delete(l.buckets, c)
+				}
+			}
+		}
+		b = &bucket{tokens: l.burst, last: now}
+		l.buckets[client] = b
+	}
+	b.tokens = math.Min(l.burst, b.tokens+now.Sub(b.last).Seconds()*l.rate)
+	b.last = now
+	if b.tokens < 1 {
+		return false, time.Duration((1 - b.tokens) / l.rate * float64(time.Second))
+	}
+	b.tokens--
+	return true, 0
+}
+
+// parseServeTokens parses the API tokens of the server, one NAME:TOKEN pair
+// per line, into a map from token to name. Empty lines and lines starting
+// with # are skipped.
+func parseServeTokens(r io.Reader) (map[string]string, error) {
+	ret := make(map[string]string)
+	s := bufio.NewScanner(r)
+	for n := 1; s.Scan(); n++ {
+		line := strings.TrimSpace(s.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		i := strings.Index(line, ":")
+		if i <= 0 || i == len(line)-1 {
+			return nil, fmt.Errorf("line %d: invalid token, use NAME:TOKEN", n)
+		}
+		name, token := strings.TrimSpace(line[:i]), strings.TrimSpace(line[i+1:])
+		if _, ok := ret[token]; ok {
+			return nil, fmt.Errorf("line %d: the token of %s is used twice", n, name)
+		}
+		ret[token] = name
+	}
+	return ret, s.Err()
+}
+
+// readServeTokens reads the API tokens from the file at path. The tokens are
+// kept in a file rather than given as flags, which every user could see in
+// the process list.
+func readServeTokens(path string) (map[string]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	tokens, err := parseServeTokens(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return tokens, nil
+}
+
+// requestToken returns the API token of req from its Authorization header or
+// its token parameter.
+func requestToken(req *http.Request) string {
+	if h := req.Header.Get("Authorization"); strings.HasPrefix(h, "Bearer ") {
+		return strings.TrimSpace(strings.TrimPrefix(h, "Bearer "))
+	}
+	return req.URL.Query().Get("token")
+}
+
+// tokenName returns the name of token t in tokens, or "" for an unknown
+// token. The tokens are compared in constant time.
+func tokenName(tokens map[string]string, t string) string {
+	var name string
+	for known, n := range tokens {
+		if subtle.ConstantTimeCompare([]byte(t), []byte(known)) == 1 {
+			name = n
+		}
+	}
+	return name
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRateLimiterAllow(t *testing.T) {
+	l := newRateLimiter(2)
+	now := time.Date(2023, 4, 1, 12, 0, 0, 0, time.UTC)
+
+	for i := 0; i < 2; i++ {
+		if ok, _ := l.allow("a", now); !ok {
+			t.Fatalf("request %d of the burst was refused", i+1)
+		}
+	}
+	ok, wait := l.allow("a", now)
+	if ok {
+		t.Fatal("request beyond the burst was allowed")
+	}
+	if wait != 30*time.Second {
+		t.Errorf("wait = %v, want 30s", wait)
+	}
+	if ok, _ := l.allow("b", now); !ok {
+		t.Error("another client was refused")
+	}
+	if ok, _ := l.allow("a", now.Add(10*time.Second)); ok {
+		t.Error("request allowed before a token was refilled")
+	}
+	if ok, _ := l.allow("a", now.Add(30*time.Second)); !ok {
+		t.Error("request refused after a token was refilled")
+	}
+}
+
+func TestRateLimiterForgetsIdleClients(t *testing.T) {
+	l := newRateLimiter(60)
+	now := time.Date(2023, 4, 1, 12, 0, 0, 0, time.UTC)
+	for i := 0; i < 1000; i++ {
+		l.allow(strings.Repeat("x", i+1), now)
+	}
+	l.allow("new", now.Add(time.Minute))
+	if len(l.buckets) != 1 {
+		t.Errorf("%d buckets left, want 1", len(l.buckets))
+	}
+}
+
+func TestParseServeTokens(t *testing.T) {
+	tests := []struct {
+		in   string
+		want map[string]string
+		err  string
+	}{
+		{"", map[string]string{}, ""},
+		{"phone:0f3c9a\n\n# friends\n anna : 77d1e2 \n", map[string]string{"0f3c9a": "phone", "77d1e2": "anna"}, ""},
+		{"phone:a:b", map[string]string{"a:b": "phone"}, ""},
+		{"phone", nil, "line 1: invalid token"},
+		{"# none\n:0f3c9a", nil, "line 2: invalid token"},
+		{"phone:", nil, "line 1: invalid token"},
+		{"phone:0f3c9a\nanna:0f3c9a", nil, "line 2: the token of anna is used twice"},
+	}
+	for _, tt := range tests {
+		got, err := parseServeTokens(strings.NewReader(tt.in))
+		if tt.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("parseServeTokens(%q) error = %v, want %q", tt.in, err, tt.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parseServeTokens(%q): %v", tt.in, err)
+			continue
+		}
+		if len(got) != len(tt.want) {
+			t.Errorf("parseServeTokens(%q) = %v, want %v", tt.in, got, tt.want)
+			continue
+		}
+		for token, name := range tt.want {
+			if got[token] != name {
+				t.Errorf("parseServeTokens(%q) = %v, want %v", tt.in, got, tt.want)
+				break
+			}
+		}
+	}
+}
+
+func TestRequestToken(t *testing.T) {
+	tokens := map[string]string{"0f3c9a": "phone", "77d1e2": "anna"}
+
+	req := httptest.NewRequest("GET", "/Ottawa", nil)
+	req.Header.Set("Authorization", "Bearer 77d1e2")
+	if got := tokenName(tokens, requestToken(req)); got != "anna" {
+		t.Errorf("bearer token: got %q, want anna", got)
+	}
+	req = httptest.NewRequest("GET", "/Ottawa?token=0f3c9a", nil)
+	if got := tokenName(tokens, requestToken(req)); got != "phone" {
+		t.Errorf("token parameter: got %q, want phone", got)
+	}
+	req = httptest.NewRequest("GET", "/Ottawa?token=0f3c9", nil)
+	if got := tokenName(tokens, requestToken(req)); got != "" {
+		t.Errorf("unknown token: got %q, want none", got)
+	}
+	if got := tokenName(tokens, requestToken(httptest.NewRequest("GET", "/", nil))); got != "" {
+		t.Errorf("no token: got %q, want none", got)
+	}
+}
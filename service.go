@@ -0,0 +1,208 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/nafiz1001/wego/iface"
+)
+
+// set by the -service-mode and -service-time flags
+var (
+	serviceMode string
+	serviceTime string
+)
+
+// serviceArgs returns the arguments wego was called with, without the service
+// command and its flags. They are passed on to the installed service.
+func serviceArgs() (ret []string) {
+	args := os.Args[1:]
+	for i := 0; i < len(args); i++ {
+		a := args[i]
+		switch {
+		case a == "service" || a == "install":
+		case strings.HasPrefix(strings.TrimLeft(a, "-"), "service-") && strings.HasPrefix(a, "-"):
+			if !strings.Contains(a, "=") {
+				i++ // skip the value
+			}
+		default:
+			ret = append(ret, a)
+		}
+	}
+	return
+}
+
+// systemdQuote quotes s for an ExecStart line if needed.
+func systemdQuote(s string) string {
+	if s != "" && !strings.ContainsAny(s, " \t\"'\\%$;") {
+		return s
+	}
+	return strconv.Quote(strings.Replace(strings.Replace(s, "%", "%%", -1), "$", "$$", -1))
+}
+
+// systemdEnv returns the quoted assignment of an Environment line, which
+// systemd splits at spaces.
+func systemdEnv(key, value string) string {
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "%", "%%")
+	return `"` + r.Replace(key+"="+value) + `"`
+}
+
+// systemdUnits returns the systemd user units to run cmd, by file name.
+func systemdUnits(cmd []string, rc string, hour, minute int) map[string]string {
+	quoted := make([]string, len(cmd))
+	for i, c := range cmd {
+		quoted[i] = systemdQuote(c)
+	}
+	exec := strings.Join(quoted, " ")
+
+	if serviceMode == "daemon" {
+		return map[string]string{"wego-daemon.service": fmt.Sprintf(`[Unit]
+Description=wego weather forecast daemon
+After=network-online.target
+Wants=network-online.target
+
+[Service]
+Type=simple
+Environment=%s
+ExecStart=%s
+ExecReload=/bin/kill -HUP $MAINPID
+Restart=on-failure
+RestartSec=60
+
+[Install]
+WantedBy=default.target
+`, systemdEnv("WEGORC", rc), exec)}
+	}
+	return map[string]string{
+		"wego-digest.service": fmt.Sprintf(`[Unit]
+Description=wego daily weather digest
+After=network-online.target
+Wants=network-online.target
+
+[Service]
+Type=oneshot
+Environment=%s
+ExecStart=%s
+`, systemdEnv("WEGORC", rc), exec),
+		"wego-digest.timer": fmt.Sprintf(`[Unit]
+Description=wego daily weather digest
+
+[Timer]
+OnCalendar=*-*-* %02d:%02d:00
+Persistent=true
+
+[Install]
+WantedBy=timers.target
+`, hour, minute),
+	}
+}
+
+// launchdPlist returns the launchd agent running cmd.
+func launchdPlist(label string, cmd []string, rc string, hour, minute int, logPath string) string {
+	var args []string
+	for _, c := range cmd {
+		args = append(args, "\t\t<string>"+html.EscapeString(c)+"</string>")
+	}
+	schedule := "\t<key>KeepAlive</key>\n\t<true/>\n\t<key>RunAtLoad</key>\n\t<true/>\n"
+	if serviceMode == "digest" {
+		schedule = fmt.Sprintf("\t<key>StartCalendarInterval</key>\n\t<dict>\n\t\t<key>Hour</key>\n\t\t<integer>%d</integer>\n\t\t<key>Minute</key>\n\t\t<integer>%d</integer>\n\t</dict>\n", hour, minute)
+	}
+	return fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>Label</key>
+	<string>%s</string>
+	<key>ProgramArguments</key>
+	<array>
+%s
+	</array>
+	<key>EnvironmentVariables</key>
+	<dict>
+		<key>WEGORC</key>
+		<string>%s</string>
+	</dict>
+%s	<key>StandardOutPath</key>
+	<string>%s</string>
+	<key>StandardErrorPath</key>
+	<string>%s</string>
+</dict>
+</plist>
+`, label, strings.Join(args, "\n"), html.EscapeString(rc), schedule, html.EscapeString(logPath), html.EscapeString(logPath))
+}
+
+// runService installs a systemd user unit (or a launchd agent on macOS) running
+// wego in daemon or digest mode with the current config file and the flags
+// given on the command line.
+func runService(backend string, location string, numdays int, unit iface.UnitSystem) {
+	if len(commandArgs) == 0 || commandArgs[0] != "install" {
+		log.Fatal("Usage: wego service install -service-mode daemon|digest")
+	}
+	if serviceMode != "daemon" && serviceMode != "digest" {
+		log.Fatalf("Unknown service mode %q, use daemon or digest", serviceMode)
+	}
+	var hour, minute int
+	if _, err := fmt.Sscanf(serviceTime, "%d:%d", &hour, &minute); err != nil || hour < 0 || hour > 23 || minute < 0 || minute > 59 {
+		log.Fatalf("Invalid service time %q, use HH:MM", serviceTime)
+	}
+
+	exe, err := os.Executable()
+	if err != nil {
+		log.Fatal("Unable to find the wego executable: ", err)
+	}
+	rc, err := configPath()
+	if err != nil {
+		log.Fatal("Unable to find the config file: ", err)
+	}
+	cmd := append([]string{exe, serviceMode}, serviceArgs()...)
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		log.Fatal(err)
+	}
+	files := map[string]string{}
+	var hint string
+	if runtime.GOOS == "darwin" {
+		label := "com.github.nafiz1001.wego." + serviceMode
+		logPath := filepath.Join(home, "Library", "Logs", "wego-"+serviceMode+".log")
+		path := filepath.Join(home, "Library", "LaunchAgents", label+".plist")
+		files[path] = launchdPlist(label, cmd, rc, hour, minute, logPath)
+		hint = "launchctl load " + path
+	} else {
+		dir, err := os.UserConfigDir()
+		if err != nil {
+			log.Fatal(err)
+		}
+		for name, content := range systemdUnits(cmd, rc, hour, minute) {
+			files[filepath.Join(dir, "systemd", "user", name)] = content
+		}
+		if serviceMode == "daemon" {
+			hint = "systemctl --user daemon-reload && systemctl --user enable --now wego-daemon.service"
+		} else {
+			hint = "systemctl --user daemon-reload && systemctl --user enable --now wego-digest.timer"
+		}
+	}
+
+	for path, content := range files {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			log.Fatal(err)
+		}
+		// the arguments may include API keys, also keep a file of an
+		// earlier install private
+		if err := ioutil.WriteFile(path, []byte(content), 0600); err != nil {
+			log.Fatal(err)
+		}
+		if err := os.Chmod(path, 0600); err != nil {
+			log.Fatal(err)
+		}
+		fmt.Println("Wrote", path)
+	}
+	fmt.Println("Start it with:", hint)
+}
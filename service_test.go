@@ -0,0 +1,28 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSystemdUnits(t *testing.T) {
+	defer func(mode string) { serviceMode = mode }(serviceMode)
+	rc := `/home/me/my "wego"/100%.toml`
+
+	for _, mode := range []string{"daemon", "digest"} {
+		serviceMode = mode
+		cmd := []string{"/usr/bin/wego", mode, "-l", "New York"}
+		service := systemdUnits(cmd, rc, 7, 30)["wego-"+mode+".service"]
+		for _, want := range []string{
+			`Environment="WEGORC=/home/me/my \"wego\"/100%%.toml"` + "\n",
+			`ExecStart=/usr/bin/wego ` + mode + ` -l "New York"` + "\n",
+		} {
+			if !strings.Contains(service, want) {
+				t.Errorf("%s service lacks %q:\n%s", mode, want, service)
+			}
+		}
+	}
+	if timer := systemdUnits([]string{"/usr/bin/wego", "digest"}, rc, 7, 30)["wego-digest.timer"]; !strings.Contains(timer, "OnCalendar=*-*-* 07:30:00\n") {
+		t.Errorf("digest timer lacks the time:\n%s", timer)
+	}
+}
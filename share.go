@@ -0,0 +1,82 @@
+package main
+
+import (
+	"fmt"
+	"image/png"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+
+	"github.com/nafiz1001/wego/frontends"
+	"github.com/nafiz1001/wego/iface"
+)
+
+// set by the -share-output and -share-clipboard flags
+var (
+	shareOutput    string
+	shareClipboard bool
+)
+
+// copyImage puts the png file at path on the clipboard with whatever tool the
+// platform offers.
+func copyImage(path string) error {
+	var cmd *exec.Cmd
+	switch {
+	case runtime.GOOS == "darwin":
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			return err
+		}
+		cmd = exec.Command("osascript", "-e", fmt.Sprintf("set the clipboard to (read (POSIX file %q) as «class PNGf»)", abs))
+	case os.Getenv("WAYLAND_DISPLAY") != "":
+		cmd = exec.Command("wl-copy", "--type", "image/png")
+	default:
+		cmd = exec.Command("xclip", "-selection", "clipboard", "-t", "image/png")
+	}
+
+	if cmd.Args[0] != "osascript" {
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		cmd.Stdin = f
+	}
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+// runShare saves a picture of the forecast suitable for sharing on social
+// media.
+func runShare(backend string, location string, numdays int, unit iface.UnitSystem) {
+	// the card always shows ShareDays days
+	if numdays < frontends.ShareDays {
+		numdays = frontends.ShareDays
+	}
+	r := fetch(backend, location, numdays)
+	img, err := frontends.ShareCard(r, unit)
+	if err != nil {
+		log.Fatal("Unable to draw the share card: ", err)
+	}
+
+	f, err := os.Create(shareOutput)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err = png.Encode(f, img); err != nil {
+		f.Close()
+		log.Fatal("Unable to write the share card: ", err)
+	}
+	if err = f.Close(); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println("Saved", shareOutput)
+
+	if shareClipboard {
+		if err = copyImage(shareOutput); err != nil {
+			log.Println("Unable to copy the share card to the clipboard:", err)
+		}
+	}
+}
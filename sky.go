@@ -0,0 +1,125 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/nafiz1001/wego/astro"
+	"github.com/nafiz1001/wego/iface"
+)
+
+// skyDays is set by the -sky-days flag.
+var skyDays int
+
+// meteors are hard to see with the radiant lower than this in degrees
+const skyRadiantMinDeg = 10
+
+// skyEvent is an astronomical event visible from the location, at the time
+// to look at the sky.
+type skyEvent struct {
+	at     time.Time
+	name   string
+	detail string
+}
+
+// skyClouds describes the sky of the slot of r closest to t, empty if the
+// forecast does not cover t.
+func skyClouds(r iface.Data, t time.Time) string {
+	s, ok := closestSlot(r, t)
+	if !ok {
+		return ""
+	}
+	if s.CloudCoverPercent != nil {
+		return fmt.Sprintf("%s, %d%% clouds", s.Desc, *s.CloudCoverPercent)
+	}
+	if c, known := auroraClearSky[s.Code]; known && s.Code != iface.CodeUnknown {
+		return fmt.Sprintf("%s, %d%% chance of clear sky", s.Desc, int(c*100+0.5))
+	}
+	return s.Desc
+}
+
+// skyEvents returns the eclipses and meteor shower peaks from from to to
+// visible at the coordinates in degrees, in the time zone loc.
+func skyEvents(from, to time.Time, lat, lon float64, loc *time.Location) (ret []skyEvent) {
+	for _, e := range astro.Eclipses {
+		if e.Greatest.Before(from.Add(-4*time.Hour)) || e.Greatest.After(to) {
+			continue
+		}
+		if e.Solar {
+			max, mag, kind := astro.LocalSolarEclipse(e, lat, lon)
+			if mag <= 0 {
+				continue
+			}
+			detail := fmt.Sprintf("%s here, magnitude %.2f", kind, mag)
+			ret = append(ret, skyEvent{max.In(loc), fmt.Sprintf("Solar eclipse (%s)", e.Kind), detail})
+		} else if ok, alt := astro.LunarEclipseVisible(e, lat, lon); ok {
+			detail := fmt.Sprintf("moon %d° above the horizon", int(math.Round(alt)))
+			ret = append(ret, skyEvent{e.Greatest.In(loc), fmt.Sprintf("Lunar eclipse (%s)", e.Kind), detail})
+		}
+	}
+
+	for year := from.Year(); year <= to.Year(); year++ {
+		for _, m := range astro.MeteorShowers {
+			alt := astro.RadiantMaxAltitude(m.RadiantDecl, lat)
+			if alt < skyRadiantMinDeg {
+				continue
+			}
+			// look at the darkest time of the night of the peak
+			at := astro.SolarNoon(time.Date(year, m.Month, m.Day, 12, 0, 0, 0, loc), lat, lon).Add(12 * time.Hour)
+			if at.Before(from) || at.After(to) {
+				continue
+			}
+			detail := fmt.Sprintf("up to %d meteors an hour, radiant up to %d°, moon %d%% lit",
+				m.ZHR, int(math.Round(alt)), int(math.Round(astro.MoonIllumination(at)*100)))
+			ret = append(ret, skyEvent{at, m.Name + " peak", detail})
+		}
+	}
+
+	sort.Slice(ret, func(i, j int) bool { return ret[i].at.Before(ret[j].at) })
+	return
+}
+
+// runSky lists the eclipses and the peaks of the major meteor showers of the
+// next -sky-days days which can be seen from the location, with the cloud
+// forecast for those within the forecast.
+func runSky(backend string, location string, numdays int, unit iface.UnitSystem) {
+	r := fetch(backend, location, numdays)
+	if r.GeoLoc == nil {
+		log.Fatal("The backend returned no coordinates for the location")
+	}
+	lat, lon := float64(r.GeoLoc.Latitude), float64(r.GeoLoc.Longitude)
+	loc := time.Local
+	for _, d := range r.Forecast {
+		if len(d.Slots) > 0 {
+			loc = d.Slots[0].Time.Location()
+			break
+		}
+	}
+	now := iface.Now().In(loc)
+	to := now.AddDate(0, 0, skyDays)
+
+	fmt.Printf("Sky events in %s\n\n", r.Location)
+	events := skyEvents(now, to, lat, lon, loc)
+	if len(events) == 0 {
+		fmt.Printf("No eclipse or major meteor shower can be seen in the next %d days.\n", skyDays)
+	} else {
+		w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+		fmt.Fprintf(w, "WHEN\tEVENT\tVISIBILITY\tSKY\n")
+		for _, e := range events {
+			clouds := skyClouds(r, e.at)
+			if clouds == "" {
+				clouds = "-"
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", e.at.Format("Mon 02. Jan 2006 15:04"), e.name, e.detail, clouds)
+		}
+		w.Flush()
+	}
+	if last := astro.Eclipses[len(astro.Eclipses)-1].Greatest; to.After(last) {
+		fmt.Printf("\nEclipses are known until %d.\n", last.Year())
+	}
+}
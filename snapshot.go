@@ -0,0 +1,91 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"time"
+
+	"github.com/nafiz1001/wego/iface"
+)
+
+// snapshotVersion is the version of the snapshot file format. It must be
+// increased on incompatible changes of iface.Data.
+const snapshotVersion = 1
+
+// set by the -file and -from-file flags
+var (
+	snapshotFile     string
+	snapshotFromFile string
+)
+
+// snapshot is the content of a file written by the export command.
+type snapshot struct {
+	Version  int
+	Backend  string
+	Location string
+	Fetched  time.Time
+	Data     iface.Data
+}
+
+// runExport fetches the forecast and saves it to a snapshot file, which can be
+// rendered later without network access by the render command.
+func runExport(backend string, location string, numdays int, unit iface.UnitSystem) {
+	r := fetch(backend, location, numdays)
+	s := snapshot{
+		Version:  snapshotVersion,
+		Backend:  backend,
+		Location: location,
+		Fetched:  iface.Now(),
+		Data:     r,
+	}
+	b, err := json.Marshal(s)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err = ioutil.WriteFile(snapshotFile, b, 0644); err != nil {
+		log.Fatal("Unable to write snapshot: ", err)
+	}
+	fmt.Println("Saved", snapshotFile)
+}
+
+// runRender renders the forecast of a snapshot file with the selected
+// frontend.
+func runRender(backend string, location string, numdays int, unit iface.UnitSystem) {
+	if snapshotFromFile == "" {
+		log.Fatal("No snapshot given, use -from-file FILE")
+	}
+	b, err := ioutil.ReadFile(snapshotFromFile)
+	if err != nil {
+		log.Fatal("Unable to read snapshot: ", err)
+	}
+	var s snapshot
+	if err = json.Unmarshal(b, &s); err != nil {
+		log.Fatalf("Unable to read snapshot %s: %v", snapshotFromFile, err)
+	}
+	if s.Version != snapshotVersion {
+		log.Fatalf("Snapshot %s has version %d, this wego reads version %d", snapshotFromFile, s.Version, snapshotVersion)
+	}
+
+	r := s.Data
+	makeDeterministic(&r)
+	if len(r.Forecast) > numdays {
+		r.Forecast = r.Forecast[:numdays]
+	}
+	name := flag.Lookup("frontend").Value.String()
+	fe, ok := iface.AllFrontends[name]
+	if !ok {
+		log.Fatalf("Could not find selected frontend \"%s\"", name)
+	}
+	if iface.Deterministic {
+		fmt.Fprintf(os.Stderr, "Forecast of %s fetched %s\n", s.Backend, s.Fetched.Format("2006-01-02 15:04 MST"))
+	} else {
+		fmt.Fprintf(os.Stderr, "Forecast of %s fetched %s ago\n", s.Backend, iface.Now().Sub(s.Fetched).Round(time.Minute))
+	}
+	if err := fe.Render(os.Stdout, r, unit); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/nafiz1001/wego/iface"
+)
+
+// soilURL is set by the -soil-url flag.
+var soilURL string
+
+// soilMaxDays is the number of days of soil data of the Open-Meteo forecast.
+const soilMaxDays = 16
+
+// soilTempVars and soilMoistureVars are the hourly variables of the Open-Meteo
+// forecast API for iface.SoilDepthsCm and iface.SoilLayersCm.
+var (
+	soilTempVars     = [len(iface.SoilDepthsCm)]string{"soil_temperature_0cm", "soil_temperature_6cm", "soil_temperature_18cm", "soil_temperature_54cm"}
+	soilMoistureVars = [len(iface.SoilLayersCm)]string{"soil_moisture_0_to_1cm", "soil_moisture_1_to_3cm", "soil_moisture_3_to_9cm", "soil_moisture_9_to_27cm", "soil_moisture_27_to_81cm"}
+)
+
+// fetchSoil returns the hourly soil data at loc for the next days from the
+// Open-Meteo forecast API, by the unix time of the hour.
+func fetchSoil(loc iface.LatLon, days int) (map[int64]iface.Soil, error) {
+	u, err := url.Parse(soilURL)
+	if err != nil {
+		return nil, err
+	}
+	if days > soilMaxDays {
+		days = soilMaxDays
+	}
+	q := u.Query()
+	q.Set("latitude", fmt.Sprintf("%.2f", loc.Latitude))
+	q.Set("longitude", fmt.Sprintf("%.2f", loc.Longitude))
+	q.Set("hourly", strings.Join(append(soilTempVars[:], soilMoistureVars[:]...), ","))
+	q.Set("timeformat", "unixtime")
+	q.Set("past_days", "1")
+	q.Set("forecast_days", fmt.Sprint(days))
+	u.RawQuery = q.Encode()
+
+	var resp struct {
+		Hourly map[string]json.RawMessage `json:"hourly"`
+	}
+	if err := fetchFeed(u.String(), func(r io.Reader) error {
+		return json.NewDecoder(r).Decode(&resp)
+	}); err != nil {
+		return nil, err
+	}
+	var times []int64
+	if err := json.Unmarshal(resp.Hourly["time"], &times); err != nil {
+		return nil, fmt.Errorf("%s: malformed hourly times: %v", soilURL, err)
+	}
+	values := func(name string) ([]*float32, error) {
+		var v []*float32
+		if err := json.Unmarshal(resp.Hourly[name], &v); err != nil {
+			return nil, fmt.Errorf("%s: malformed %s: %v", soilURL, name, err)
+		}
+		if len(v) != len(times) {
+			return nil, fmt.Errorf("%s: %d values of %s for %d hours", soilURL, len(v), name, len(times))
+		}
+		return v, nil
+	}
+
+	ret := make(map[int64]iface.Soil, len(times))
+	for i, name := range soilTempVars {
+		v, err := values(name)
+		if err != nil {
+			return nil, err
+		}
+		for h, t := range times {
+			s := ret[t]
+			s.TempC[i] = v[h]
+			ret[t] = s
+		}
+	}
+	for i, name := range soilMoistureVars {
+		v, err := values(name)
+		if err != nil {
+			return nil, err
+		}
+		for h, t := range times {
+			s := ret[t]
+			s.Moisture[i] = v[h]
+			ret[t] = s
+		}
+	}
+	return ret, nil
+}
+
+// applySoil sets the soil data of the current conditions and the slots the
+// backend left out for the -agro view, from the hour each one starts in.
+func applySoil(r *iface.Data, numdays int) {
+	if r.GeoLoc == nil {
+		log.Println("Unable to get the soil data: the backend returned no coordinates")
+		return
+	}
+	soil, err := fetchSoil(*r.GeoLoc, numdays)
+	if err != nil {
+		log.Printf("Unable to get the soil data: %v", err)
+		return
+	}
+	apply := func(c *iface.Cond) {
+		if c.Soil != nil || c.Time.IsZero() {
+			return
+		}
+		if s, ok := soil[c.Time.Truncate(time.Hour).Unix()]; ok {
+			c.Soil = &s
+		}
+	}
+	apply(&r.Current)
+	for i := range r.Forecast {
+		for j := range r.Forecast[i].Slots {
+			apply(&r.Forecast[i].Slots[j])
+		}
+	}
+}
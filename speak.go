@@ -0,0 +1,40 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"github.com/nafiz1001/wego/frontends"
+	"github.com/nafiz1001/wego/iface"
+)
+
+// defaultSpeakCommand returns a text to speech command which is usually
+// available on the current platform and reads the text from stdin.
+func defaultSpeakCommand() string {
+	if runtime.GOOS == "darwin" {
+		return "say"
+	}
+	return "espeak"
+}
+
+// speak pipes the summary of r in the given language to the text to speech
+// command. The command line is split at spaces, it is not run by a shell.
+func speak(command string, lang string, r iface.Data) error {
+	text := frontends.Summary(r, lang, iface.Now())
+	if text == "" {
+		return fmt.Errorf("no summary available in language %q", lang)
+	}
+
+	args := strings.Fields(command)
+	if len(args) == 0 {
+		return fmt.Errorf("no speak command given")
+	}
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stdin = strings.NewReader(text + "\n")
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/nafiz1001/wego/iface"
+)
+
+// set by the -spray-… flags, see setupSpray
+var (
+	sprayMinWindKmph float32
+	sprayMaxWindKmph float32
+	sprayMaxChance   int
+	sprayMinHumidity int
+	sprayMaxTempC    float32
+	sprayRainfree    time.Duration
+	sprayMinWindFlag string
+	sprayMaxWindFlag string
+	sprayMaxTempFlag string
+)
+
+// Leaves are wet from dew at a relative humidity of leafWetHumidity or when
+// the temperature is within leafWetDewC of the dew point.
+const (
+	leafWetHumidity = 90
+	leafWetDewC     = 2
+)
+
+// sprayReasons are the reasons against spraying in the order they are listed.
+var sprayReasons = []string{"rain", "wet leaves", "too windy", "too calm", "too hot", "too dry"}
+
+// setupSpray parses the thresholds of the spray window given with units.
+func setupSpray() (err error) {
+	if sprayMinWindKmph, err = iface.ParseSpeed(sprayMinWindFlag); err != nil {
+		return fmt.Errorf("-spray-min-wind: %v", err)
+	}
+	if sprayMaxWindKmph, err = iface.ParseSpeed(sprayMaxWindFlag); err != nil {
+		return fmt.Errorf("-spray-max-wind: %v", err)
+	}
+	if sprayMaxTempC, err = iface.ParseTemp(sprayMaxTempFlag); err != nil {
+		return fmt.Errorf("-spray-max-temp: %v", err)
+	}
+	return nil
+}
+
+// leafWet tells whether leaves are likely wet during c: when it rains, or
+// from dew when the air is close to saturation.
+func leafWet(c iface.Cond) bool {
+	if c.PrecipM != nil && *c.PrecipM > 0 {
+		return true
+	}
+	if c.Humidity != nil && *c.Humidity >= leafWetHumidity {
+		return true
+	}
+	if dp, ok := c.DewPointC(); ok && c.TempC != nil && *c.TempC-dp < leafWetDewC {
+		return true
+	}
+	return false
+}
+
+// sprayReason returns why slots[i] is no good for spraying crops, or an empty
+// string if it is. Spray drifts in strong wind and in the inversions of calm
+// air, evaporates in hot and dry air, runs off wet leaves and is washed off
+// by rain within sprayRainfree after spraying. Unknown values do not count
+// against a slot.
+func sprayReason(slots []iface.Cond, i int) string {
+	c := slots[i]
+	for _, s := range slots[i:] {
+		if s.Time.Sub(c.Time) >= sprayRainfree {
+			break
+		}
+		if (s.PrecipM != nil && *s.PrecipM > 0) || (s.ChanceOfRainPercent != nil && *s.ChanceOfRainPercent > sprayMaxChance) {
+			return "rain"
+		}
+	}
+	switch {
+	case leafWet(c):
+		return "wet leaves"
+	case c.WindspeedKmph != nil && *c.WindspeedKmph > sprayMaxWindKmph:
+		return "too windy"
+	case c.WindGustKmph != nil && *c.WindGustKmph > sprayMaxWindKmph*1.5:
+		return "too windy"
+	case c.WindspeedKmph != nil && *c.WindspeedKmph < sprayMinWindKmph:
+		return "too calm"
+	case c.TempC != nil && *c.TempC > sprayMaxTempC:
+		return "too hot"
+	case c.Humidity != nil && *c.Humidity < sprayMinHumidity:
+		return "too dry"
+	}
+	return ""
+}
+
+// sprayWindows returns the spray windows of every day of r, like "08:00–14:00,
+// 17:00–20:00", or why there is none, like "none (rain, too windy)". A slot
+// lasts until the next one, the last one for an hour.
+func sprayWindows(r iface.Data) []string {
+	var slots []iface.Cond
+	for _, d := range r.Forecast {
+		slots = append(slots, d.Slots...)
+	}
+	until := func(j int) time.Time {
+		if j+1 < len(slots) {
+			return slots[j+1].Time
+		}
+		return slots[j].Time.Add(time.Hour)
+	}
+
+	var ret []string
+	first := 0
+	for _, d := range r.Forecast {
+		var windows []string
+		reasons := map[string]bool{}
+		start := -1
+		for j := first; j < first+len(d.Slots); j++ {
+			reason := sprayReason(slots, j)
+			if reason == "" && start < 0 {
+				start = j
+			}
+			if reason != "" {
+				reasons[reason] = true
+			}
+			if start >= 0 && (reason != "" || j == first+len(d.Slots)-1) {
+				last := j
+				if reason != "" {
+					last = j - 1
+				}
+				windows = append(windows, slots[start].Time.Format("15:04")+"–"+until(last).Format("15:04"))
+				start = -1
+			}
+		}
+		first += len(d.Slots)
+
+		if len(windows) > 0 {
+			ret = append(ret, strings.Join(windows, ", "))
+			continue
+		}
+		var why []string
+		for _, reason := range sprayReasons {
+			if reasons[reason] {
+				why = append(why, reason)
+			}
+		}
+		if len(why) == 0 {
+			ret = append(ret, "-")
+			continue
+		}
+		ret = append(ret, "none ("+strings.Join(why, ", ")+")")
+	}
+	return ret
+}
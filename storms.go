@@ -0,0 +1,169 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nafiz1001/wego/iface"
+)
+
+// set by the -storms, -storms-url and -storms-radius flags
+var (
+	stormsEnabled  bool
+	stormsURL      string
+	stormsRadiusKm float64
+)
+
+// stormsHours is how far ahead the track of a storm is extrapolated.
+const stormsHours = 120
+
+const earthRadiusKm = 6371
+
+// nhcNumber is a number in the NHC feed, which encodes some of them as
+// strings.
+type nhcNumber float64
+
+func (n *nhcNumber) UnmarshalJSON(b []byte) error {
+	s := strings.Trim(string(b), `"`)
+	if s == "" || s == "null" {
+		return nil
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	*n = nhcNumber(f)
+	return err
+}
+
+// nhcStorm is an active storm of the CurrentStorms.json feed of the National
+// Hurricane Center, which covers the Atlantic, eastern and central Pacific.
+type nhcStorm struct {
+	ID               string    `json:"id"`
+	Name             string    `json:"name"`
+	Classification   string    `json:"classification"`
+	Intensity        nhcNumber `json:"intensity"` // kt
+	LatitudeNumeric  nhcNumber `json:"latitudeNumeric"`
+	LongitudeNumeric nhcNumber `json:"longitudeNumeric"`
+	MovementDir      nhcNumber `json:"movementDir"`   // degrees
+	MovementSpeed    nhcNumber `json:"movementSpeed"` // mph
+	LastUpdate       time.Time `json:"lastUpdate"`
+}
+
+var stormClasses = map[string]string{
+	"HU":  "Hurricane",
+	"TS":  "Tropical Storm",
+	"TD":  "Tropical Depression",
+	"STS": "Subtropical Storm",
+	"STD": "Subtropical Depression",
+	"PTC": "Potential Tropical Cyclone",
+	"PC":  "Post-Tropical Cyclone",
+}
+
+// stormCategory returns the Saffir-Simpson category for sustained winds of kt
+// knots or 0 below hurricane strength.
+func stormCategory(kt float64) int {
+	for i, limit := range []float64{137, 113, 96, 83, 64} {
+		if kt >= limit {
+			return 5 - i
+		}
+	}
+	return 0
+}
+
+func degToRad(d float64) float64 { return d * math.Pi / 180 }
+func radToDeg(r float64) float64 { return r * 180 / math.Pi }
+
+// greatCircle returns the distance in km and the initial bearing in degrees
+// from the first to the second point.
+func greatCircle(lat1, lon1, lat2, lon2 float64) (distKm, bearing float64) {
+	p1, p2 := degToRad(lat1), degToRad(lat2)
+	dp, dl := p2-p1, degToRad(lon2-lon1)
+	a := math.Sin(dp/2)*math.Sin(dp/2) + math.Cos(p1)*math.Cos(p2)*math.Sin(dl/2)*math.Sin(dl/2)
+	distKm = 2 * earthRadiusKm * math.Asin(math.Sqrt(a))
+	y := math.Sin(dl) * math.Cos(p2)
+	x := math.Cos(p1)*math.Sin(p2) - math.Sin(p1)*math.Cos(p2)*math.Cos(dl)
+	bearing = math.Mod(radToDeg(math.Atan2(y, x))+360, 360)
+	return
+}
+
+// moveAlong returns the point distKm away from lat, lon in direction bearing.
+func moveAlong(lat, lon, bearing, distKm float64) (float64, float64) {
+	p1, l1, b := degToRad(lat), degToRad(lon), degToRad(bearing)
+	d := distKm / earthRadiusKm
+	p2 := math.Asin(math.Sin(p1)*math.Cos(d) + math.Cos(p1)*math.Sin(d)*math.Cos(b))
+	l2 := l1 + math.Atan2(math.Sin(b)*math.Sin(d)*math.Cos(p1), math.Cos(d)-math.Sin(p1)*math.Sin(p2))
+	return radToDeg(p2), math.Mod(radToDeg(l2)+540, 360) - 180
+}
+
+// closestApproach extrapolates the current motion of s in a straight line for
+// stormsHours and returns the smallest distance to lat, lon and the time after
+// the last update it is reached.
+func closestApproach(s nhcStorm, lat, lon float64) (distKm float64, after time.Duration) {
+	distKm, _ = greatCircle(lat, lon, float64(s.LatitudeNumeric), float64(s.LongitudeNumeric))
+	speedKmph := float64(s.MovementSpeed) * 1.609344
+	for h := 1; h <= stormsHours; h++ {
+		sLat, sLon := moveAlong(float64(s.LatitudeNumeric), float64(s.LongitudeNumeric), float64(s.MovementDir), speedKmph*float64(h))
+		if d, _ := greatCircle(lat, lon, sLat, sLon); d < distKm {
+			distKm, after = d, time.Duration(h)*time.Hour
+		}
+	}
+	return
+}
+
+// fetchStorms returns the active storms of the NHC feed.
+func fetchStorms() ([]nhcStorm, error) {
+	var feed struct {
+		ActiveStorms []nhcStorm `json:"activeStorms"`
+	}
+	err := fetchFeed(stormsURL, func(r io.Reader) error {
+		return json.NewDecoder(r).Decode(&feed)
+	})
+	return feed.ActiveStorms, err
+}
+
+// stormWarnings returns one line for each active storm which is or is expected
+// to come within stormsRadiusKm of loc, e.g. "Hurricane Ian (category 4,
+// 135 kt) 420 km SW, closest approach about 80 km in 18 hours".
+func stormWarnings(loc iface.LatLon, unit iface.UnitSystem) ([]string, error) {
+	storms, err := fetchStorms()
+	if err != nil {
+		return nil, err
+	}
+	lat, lon := float64(loc.Latitude), float64(loc.Longitude)
+
+	var ret []string
+	for _, s := range storms {
+		dist, bearing := greatCircle(lat, lon, float64(s.LatitudeNumeric), float64(s.LongitudeNumeric))
+		closest, after := closestApproach(s, lat, lon)
+		if dist > stormsRadiusKm && closest > stormsRadiusKm {
+			continue
+		}
+
+		class, ok := stormClasses[s.Classification]
+		if !ok {
+			class = "Storm"
+		}
+		strength := fmt.Sprintf("%d kt", int(s.Intensity))
+		if c := stormCategory(float64(s.Intensity)); c > 0 && s.Classification == "HU" {
+			strength = fmt.Sprintf("category %d, %s", c, strength)
+		}
+		d, u := unit.Distance(float32(dist * 1000))
+		line := fmt.Sprintf("%s %s (%s) %d %s %s", class, s.Name, strength, int(d), u, iface.CompassPoint(bearing))
+		if after > 0 {
+			if at := s.LastUpdate.Add(after); at.After(iface.Now()) {
+				cd, cu := unit.Distance(float32(closest * 1000))
+				line += fmt.Sprintf(", closest approach about %d %s ", int(cd), cu)
+				if iface.Deterministic {
+					line += "at " + at.UTC().Format("Mon 15:04") + " UTC"
+				} else {
+					line += fmt.Sprintf("in %d hours", int(at.Sub(iface.Now()).Hours()+0.5))
+				}
+			}
+		}
+		ret = append(ret, line)
+	}
+	return ret, nil
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/nafiz1001/wego/frontends"
+	"github.com/nafiz1001/wego/iface"
+)
+
+// themePreviewData returns made up weather data which shows off most colors
+// and icons a theme can change.
+func themePreviewData() iface.Data {
+	f := func(v float32) *float32 { return &v }
+	i := func(v int) *int { return &v }
+	y, m, d := iface.Now().Date()
+	day := time.Date(y, m, d, 0, 0, 0, 0, time.Local)
+	slot := func(hour int, code iface.WeatherCode, desc string, temp, wind float32, rain int) iface.Cond {
+		return iface.Cond{
+			Time:                day.Add(time.Duration(hour) * time.Hour),
+			Code:                code,
+			Desc:                desc,
+			TempC:               f(temp),
+			WindspeedKmph:       f(wind),
+			WinddirDegree:       i(hour * 15),
+			ChanceOfRainPercent: i(rain),
+		}
+	}
+
+	return iface.Data{
+		Location: "Preview",
+		Current:  slot(12, iface.CodePartlyCloudy, "Partly cloudy", 17, 12, 10),
+		Forecast: []iface.Day{{
+			Date: day,
+			Slots: []iface.Cond{
+				slot(8, iface.CodeFog, "Fog", -4, 3, 0),
+				slot(12, iface.CodeSunny, "Sunny", 24, 18, 0),
+				slot(18, iface.CodeThunderyShowers, "Thundery showers", 31, 34, 80),
+				slot(22, iface.CodeHeavySnow, "Heavy snow", -16, 45, 90),
+			},
+		}},
+	}
+}
+
+// runThemes previews all themes in the themes directory with the
+// ascii-art-table frontend.
+func runThemes(backend string, location string, numdays int, unit iface.UnitSystem) {
+	names, err := frontends.ThemeNames()
+	if err != nil {
+		log.Fatal("Unable to list themes: ", err)
+	}
+	if len(names) == 0 {
+		dir, _ := frontends.ThemeDir()
+		fmt.Printf("No themes found in %s\n", dir)
+		return
+	}
+
+	fe := iface.AllFrontends["ascii-art-table"]
+	data := themePreviewData()
+	iface.FillDays(&data)
+	for _, name := range names {
+		fmt.Printf("\033[1m%s\033[0m\n", name)
+		if err := flag.Set("aat-theme", name); err != nil {
+			log.Fatal(err)
+		}
+		if err := fe.Render(os.Stdout, data, unit); err != nil {
+			log.Fatal(err)
+		}
+		fmt.Println()
+	}
+}
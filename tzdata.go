@@ -0,0 +1,9 @@
+//go:build !minimal
+// +build !minimal
+
+package main
+
+// The time zone database is embedded, so the time zones of the backends are
+// known on systems without one, like scratch containers and Windows. It adds
+// about 450 KB, builds with the minimal tag leave it out.
+import _ "time/tzdata"
@@ -0,0 +1,243 @@
+// Package units converts the quantities of the weather data from the units
+// wego keeps them in (°C, km/h, hPa and meters) to the units chosen for the
+// output, and parses the names of those units.
+//
+// The zero value of each unit type is the metric unit, so an empty setting
+// keeps the metric output.
+package units
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Temp is a unit of temperature.
+type Temp string
+
+const (
+	Celsius    Temp = "C"
+	Fahrenheit Temp = "F"
+	Kelvin     Temp = "K"
+)
+
+// FromC converts tempC to u and returns the unit label.
+func (u Temp) FromC(tempC float32) (float32, string) {
+	switch u {
+	case Fahrenheit:
+		return tempC*1.8 + 32, "°F"
+	case Kelvin:
+		return tempC + 273.15, "°K"
+	}
+	return tempC, "°C"
+}
+
+// Speed is a unit of wind speed.
+type Speed string
+
+const (
+	Kmph     Speed = "km/h"
+	Mph      Speed = "mph"
+	Ms       Speed = "m/s"
+	Knots    Speed = "kn"
+	Beaufort Speed = "Bft"
+)
+
+// SpeedFactors are the factors to convert speeds in the named units to km/h,
+// including the common spellings.
+var SpeedFactors = map[string]float32{
+	"km/h": 1,
+	"kmh":  1,
+	"mph":  1.609,
+	"m/s":  3.6,
+	"ms":   3.6,
+	"kn":   1.852,
+	"kt":   1.852,
+}
+
+// beaufortKmph are the lowest speeds in km/h of the Beaufort forces 1 to 12.
+var beaufortKmph = []float32{1, 6, 12, 20, 29, 39, 50, 62, 75, 89, 103, 118}
+
+// FromKmph converts spdKmph to u and returns the unit label. Beaufort forces
+// are whole numbers.
+func (u Speed) FromKmph(spdKmph float32) (float32, string) {
+	switch u {
+	case Mph, Ms, Knots:
+		return spdKmph / SpeedFactors[string(u)], string(u)
+	case Beaufort:
+		force := 0
+		for force < len(beaufortKmph) && spdKmph >= beaufortKmph[force] {
+			force++
+		}
+		return float32(force), string(u)
+	}
+	return spdKmph, string(Kmph)
+}
+
+// Pressure is a unit of air pressure.
+type Pressure string
+
+const (
+	HPa  Pressure = "hPa"
+	InHg Pressure = "inHg"
+	MmHg Pressure = "mmHg"
+)
+
+// FromHPa converts pHPa to u and returns the unit label.
+func (u Pressure) FromHPa(pHPa float32) (float32, string) {
+	switch u {
+	case InHg:
+		return pHPa / 33.8639, string(u)
+	case MmHg:
+		return pHPa / 1.333224, string(u)
+	}
+	return pHPa, string(HPa)
+}
+
+// Precip is a unit of precipitation amounts.
+type Precip string
+
+const (
+	Mm Precip = "mm"
+	In Precip = "in"
+)
+
+// FromM converts the precipitation amount precipM in meters to u and returns
+// the unit label.
+func (u Precip) FromM(precipM float32) (float32, string) {
+	if u == In {
+		return precipM / 0.0254, string(In)
+	}
+	return precipM * 1000, string(Mm)
+}
+
+// Distance is a unit system of distances and lengths, named after its unit
+// of long distances.
+type Distance string
+
+const (
+	Km Distance = "km"
+	Mi Distance = "mi"
+)
+
+// FromM converts distM in meters to the unit of u fitting its size and
+// returns the unit label: mm, m or km, or in, yd or mi.
+func (u Distance) FromM(distM float32) (float32, string) {
+	if u == Mi {
+		in := distM / 0.0254
+		if in < 3*12 { // 1yd = 3ft, 1ft = 12in
+			return in, "in"
+		} else if in < 8*10*22*36 { // 1mi = 8fur, 1fur = 10ch, 1ch = 22yd
+			return in / 36, "yd"
+		}
+		return in / 8 / 10 / 22 / 36, "mi"
+	}
+	if distM < 1 {
+		return distM * 1000, "mm"
+	} else if distM < 1000 {
+		return distM, "m"
+	}
+	return distM / 1000, "km"
+}
+
+// FromCm converts a depth or thickness in cm to u, in or cm, and returns the
+// unit label.
+func (u Distance) FromCm(cm float32) (float32, string) {
+	if u == Mi {
+		return cm / 2.54, "in"
+	}
+	return cm, "cm"
+}
+
+// FromMShort converts a height like an altitude in meters to u, ft or m, and
+// returns the unit label.
+func (u Distance) FromMShort(m float32) (float32, string) {
+	if u == Mi {
+		return m / 0.3048, "ft"
+	}
+	return m, "m"
+}
+
+// parse returns the unit of choices whose lowercase spellings include s, or
+// an empty unit for an empty s.
+func parse(kind, s string, choices map[string][]string) (string, error) {
+	s = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(s), "°"))
+	if s == "" {
+		return "", nil
+	}
+	var names []string
+	for unit, spellings := range choices {
+		for _, sp := range spellings {
+			if s == sp {
+				return unit, nil
+			}
+		}
+		names = append(names, spellings[0])
+	}
+	sort.Strings(names)
+	return "", fmt.Errorf("unknown %s unit %q, use %s", kind, s, strings.Join(names, ", "))
+}
+
+// ParseTemp parses a temperature unit like "F" or "celsius".
+func ParseTemp(s string) (Temp, error) {
+	u, err := parse("temperature", s, map[string][]string{
+		string(Celsius):    {"c", "celsius"},
+		string(Fahrenheit): {"f", "fahrenheit"},
+		string(Kelvin):     {"k", "kelvin"},
+	})
+	return Temp(u), err
+}
+
+// ParseSpeed parses a wind speed unit like "kn" or "beaufort".
+func ParseSpeed(s string) (Speed, error) {
+	u, err := parse("wind speed", s, map[string][]string{
+		string(Kmph):     {"km/h", "kmh", "kph"},
+		string(Mph):      {"mph"},
+		string(Ms):       {"m/s", "ms"},
+		string(Knots):    {"kn", "kt", "knots"},
+		string(Beaufort): {"bft", "beaufort"},
+	})
+	return Speed(u), err
+}
+
+// ParsePressure parses a pressure unit like "inHg".
+func ParsePressure(s string) (Pressure, error) {
+	u, err := parse("pressure", s, map[string][]string{
+		string(HPa):  {"hpa", "mbar", "mb"},
+		string(InHg): {"inhg"},
+		string(MmHg): {"mmhg"},
+	})
+	return Pressure(u), err
+}
+
+// ParsePrecip parses a precipitation unit, "mm" or "in".
+func ParsePrecip(s string) (Precip, error) {
+	u, err := parse("precipitation", s, map[string][]string{
+		string(Mm): {"mm"},
+		string(In): {"in", "inch"},
+	})
+	return Precip(u), err
+}
+
+// ParseDistance parses a distance unit, "km" or "mi".
+func ParseDistance(s string) (Distance, error) {
+	u, err := parse("distance", s, map[string][]string{
+		string(Km): {"km"},
+		string(Mi): {"mi", "miles"},
+	})
+	return Distance(u), err
+}
+
+// The unit types are flag values, which are empty unless set.
+
+func (u Temp) String() string     { return string(u) }
+func (u Speed) String() string    { return string(u) }
+func (u Pressure) String() string { return string(u) }
+func (u Precip) String() string   { return string(u) }
+func (u Distance) String() string { return string(u) }
+
+func (u *Temp) Set(s string) (err error)     { *u, err = ParseTemp(s); return }
+func (u *Speed) Set(s string) (err error)    { *u, err = ParseSpeed(s); return }
+func (u *Pressure) Set(s string) (err error) { *u, err = ParsePressure(s); return }
+func (u *Precip) Set(s string) (err error)   { *u, err = ParsePrecip(s); return }
+func (u *Distance) Set(s string) (err error) { *u, err = ParseDistance(s); return }
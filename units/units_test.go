@@ -0,0 +1,133 @@
+package units
+
+import (
+	"flag"
+	"math"
+	"testing"
+)
+
+// conversion is a value converted to a unit and the label it should have.
+type conversion struct {
+	unit  string
+	in    float32
+	want  float32
+	label string
+}
+
+func checkConversions(t *testing.T, name string, tests []conversion, convert func(unit string, v float32) (float32, string)) {
+	t.Helper()
+	for _, tt := range tests {
+		got, label := convert(tt.unit, tt.in)
+		if math.Abs(float64(got-tt.want)) > 0.01 || label != tt.label {
+			t.Errorf("%s(%q).(%v) = %v %s, want %v %s", name, tt.unit, tt.in, got, label, tt.want, tt.label)
+		}
+	}
+}
+
+func TestTempFromC(t *testing.T) {
+	checkConversions(t, "Temp", []conversion{
+		{"", 21.5, 21.5, "°C"},
+		{"C", -40, -40, "°C"},
+		{"F", -40, -40, "°F"},
+		{"F", 100, 212, "°F"},
+		{"F", 0, 32, "°F"},
+		{"K", 0, 273.15, "°K"},
+		{"K", -273.15, 0, "°K"},
+	}, func(u string, v float32) (float32, string) { return Temp(u).FromC(v) })
+}
+
+func TestSpeedFromKmph(t *testing.T) {
+	checkConversions(t, "Speed", []conversion{
+		{"", 36, 36, "km/h"},
+		{"km/h", 36, 36, "km/h"},
+		{"mph", 16.09, 10, "mph"},
+		{"m/s", 36, 10, "m/s"},
+		{"kn", 18.52, 10, "kn"},
+		{"Bft", 0, 0, "Bft"},
+		{"Bft", 0.9, 0, "Bft"},
+		{"Bft", 1, 1, "Bft"},
+		{"Bft", 28.9, 4, "Bft"},
+		{"Bft", 29, 5, "Bft"},
+		{"Bft", 117.9, 11, "Bft"},
+		{"Bft", 118, 12, "Bft"},
+		{"Bft", 300, 12, "Bft"},
+	}, func(u string, v float32) (float32, string) { return Speed(u).FromKmph(v) })
+}
+
+func TestPressureFromHPa(t *testing.T) {
+	checkConversions(t, "Pressure", []conversion{
+		{"", 1013.25, 1013.25, "hPa"},
+		{"hPa", 1013.25, 1013.25, "hPa"},
+		{"inHg", 1013.25, 29.92, "inHg"},
+		{"mmHg", 1013.25, 760, "mmHg"},
+	}, func(u string, v float32) (float32, string) { return Pressure(u).FromHPa(v) })
+}
+
+func TestPrecipFromM(t *testing.T) {
+	checkConversions(t, "Precip", []conversion{
+		{"", 0.0025, 2.5, "mm"},
+		{"mm", 0.0025, 2.5, "mm"},
+		{"in", 0.0254, 1, "in"},
+		{"in", 0, 0, "in"},
+	}, func(u string, v float32) (float32, string) { return Precip(u).FromM(v) })
+}
+
+func TestDistanceFromM(t *testing.T) {
+	checkConversions(t, "Distance.FromM", []conversion{
+		{"", 0.5, 500, "mm"},
+		{"km", 1, 1, "m"},
+		{"km", 999, 999, "m"},
+		{"km", 1000, 1, "km"},
+		{"km", 24140, 24.14, "km"},
+		{"mi", 0.0254, 1, "in"},
+		{"mi", 0.9, 35.43, "in"},
+		{"mi", 0.9144, 1, "yd"},
+		{"mi", 1609, 1759.62, "yd"},
+		{"mi", 1609.344, 1, "mi"},
+		{"mi", 16093.44, 10, "mi"},
+	}, func(u string, v float32) (float32, string) { return Distance(u).FromM(v) })
+
+	checkConversions(t, "Distance.FromCm", []conversion{
+		{"", 12, 12, "cm"},
+		{"km", 12, 12, "cm"},
+		{"mi", 2.54, 1, "in"},
+	}, func(u string, v float32) (float32, string) { return Distance(u).FromCm(v) })
+
+	checkConversions(t, "Distance.FromMShort", []conversion{
+		{"", 120, 120, "m"},
+		{"km", 120, 120, "m"},
+		{"mi", 30.48, 100, "ft"},
+	}, func(u string, v float32) (float32, string) { return Distance(u).FromMShort(v) })
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		unit flag.Value
+		s    string
+		want string
+	}{
+		{new(Temp), "°F", "F"},
+		{new(Temp), " Kelvin ", "K"},
+		{new(Temp), "", ""},
+		{new(Speed), "kph", "km/h"},
+		{new(Speed), "KT", "kn"},
+		{new(Speed), "beaufort", "Bft"},
+		{new(Pressure), "mbar", "hPa"},
+		{new(Pressure), "inHg", "inHg"},
+		{new(Precip), "inch", "in"},
+		{new(Distance), "Miles", "mi"},
+	}
+	for _, tt := range tests {
+		if err := tt.unit.Set(tt.s); err != nil || tt.unit.String() != tt.want {
+			t.Errorf("Set(%q) = %q, %v, want %q", tt.s, tt.unit, err, tt.want)
+		}
+	}
+
+	var u Speed
+	if err := u.Set("furlongs/fortnight"); err == nil {
+		t.Errorf("Set(furlongs/fortnight) = %q, want an error", u)
+	}
+	if _, err := ParseTemp("r"); err == nil || err.Error() != `unknown temperature unit "r", use c, f, k` {
+		t.Errorf("ParseTemp(r) error = %v", err)
+	}
+}
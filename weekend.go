@@ -0,0 +1,160 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	colorable "github.com/mattn/go-colorable"
+	"github.com/nafiz1001/wego/iface"
+)
+
+// weekendDays is the number of days requested by the weekend command. Backends
+// return fewer if they do not forecast that far.
+const weekendDays = 14
+
+// set by the -days-off and -activity flags
+var (
+	weekendDaysOff  string
+	weekendActivity string
+)
+
+// activity describes the weather an activity of the weekend command is rated
+// by. Temperatures outside of [MinC, MaxC] and wind above WindKmph lower the
+// score, as does the chance of rain and snow weighted by RainWeight and
+// SnowWeight.
+type activity struct {
+	MinC, MaxC             float32
+	WindKmph               float32
+	RainWeight, SnowWeight float32
+}
+
+var activities = map[string]activity{
+	"beach":   {MinC: 24, MaxC: 32, WindKmph: 25, RainWeight: 1, SnowWeight: 1},
+	"cycling": {MinC: 12, MaxC: 26, WindKmph: 20, RainWeight: 1, SnowWeight: 1},
+	"hiking":  {MinC: 10, MaxC: 24, WindKmph: 35, RainWeight: 0.8, SnowWeight: 0.8},
+	"picnic":  {MinC: 18, MaxC: 28, WindKmph: 20, RainWeight: 1, SnowWeight: 1},
+	"skiing":  {MinC: -12, MaxC: 2, WindKmph: 40, RainWeight: 1, SnowWeight: 0.2},
+}
+
+// daytime hours considered by the weekend command
+const (
+	weekendFirstHour = 8
+	weekendLastHour  = 20
+)
+
+// parseDaysOff parses a comma separated list of weekdays like "sat,sun".
+func parseDaysOff(s string) (map[time.Weekday]bool, error) {
+	ret := make(map[time.Weekday]bool)
+	for _, name := range strings.Split(s, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		found := false
+		for d := time.Sunday; d <= time.Saturday; d++ {
+			if long := strings.ToLower(d.String()); len(name) >= 2 && strings.HasPrefix(long, name) {
+				ret[d], found = true, true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("unknown day %q in %q, use e.g. sat,sun", name, s)
+		}
+	}
+	return ret, nil
+}
+
+// scoreDay returns the suitability of d for a in [0, 100] and a short note of
+// what it is based on. Only slots during the day are taken into account.
+func scoreDay(d iface.Day, a activity, unit iface.UnitSystem) (int, string) {
+	var slots []iface.Cond
+	for _, s := range d.Slots {
+		if h := s.Time.Hour(); h >= weekendFirstHour && h <= weekendLastHour {
+			slots = append(slots, s)
+		}
+	}
+	if len(slots) == 0 {
+		slots = d.Slots
+	}
+
+	var rain, snow int
+	var wind float32
+	for _, s := range slots {
+		if s.ChanceOfRainPercent != nil {
+			if s.Code.Snow() && *s.ChanceOfRainPercent > snow {
+				snow = *s.ChanceOfRainPercent
+			} else if !s.Code.Snow() && *s.ChanceOfRainPercent > rain {
+				rain = *s.ChanceOfRainPercent
+			}
+		}
+		if s.WindspeedKmph != nil && *s.WindspeedKmph > wind {
+			wind = *s.WindspeedKmph
+		}
+	}
+
+	score := float32(100)
+	var notes []string
+	if d.MaxTempC != nil {
+		if *d.MaxTempC < a.MinC {
+			score -= 5 * (a.MinC - *d.MaxTempC)
+		} else if *d.MaxTempC > a.MaxC {
+			score -= 5 * (*d.MaxTempC - a.MaxC)
+		}
+		t, u := unit.Temp(*d.MaxTempC)
+		notes = append(notes, fmt.Sprintf("high %d %s", int(t), u))
+	}
+	score -= float32(rain)*a.RainWeight + float32(snow)*a.SnowWeight
+	if snow > rain {
+		notes = append(notes, fmt.Sprintf("snow %d%%", snow))
+	} else {
+		notes = append(notes, fmt.Sprintf("rain %d%%", rain))
+	}
+	if wind > a.WindKmph {
+		score -= 2 * (wind - a.WindKmph)
+	}
+	s, u := unit.Speed(wind)
+	notes = append(notes, fmt.Sprintf("wind %d %s", int(s), u))
+
+	if score < 0 {
+		score = 0
+	}
+	return int(score + 0.5), strings.Join(notes, ", ")
+}
+
+// runWeekend lists every forecast day with a suitability score for the
+// -activity, highlighting the -days-off.
+func runWeekend(backend string, location string, numdays int, unit iface.UnitSystem) {
+	a, ok := activities[weekendActivity]
+	if !ok {
+		var names []string
+		for name := range activities {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		log.Fatalf("Unknown activity %q, choices are: %s", weekendActivity, strings.Join(names, ", "))
+	}
+	daysOff, err := parseDaysOff(weekendDaysOff)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	r := fetch(backend, location, weekendDays)
+	stdout := colorable.NewColorableStdout()
+	fmt.Fprintf(stdout, "Days for %s in %s\n\n", weekendActivity, r.Location)
+	for _, d := range r.Forecast {
+		score, note := scoreDay(d, a, unit)
+		color := 196
+		if score >= 70 {
+			color = 46
+		} else if score >= 40 {
+			color = 226
+		}
+		bar := strings.Repeat("█", (score+5)/10) + strings.Repeat("░", 10-(score+5)/10)
+
+		style := "\033[38;5;245m"
+		if daysOff[d.Date.Weekday()] {
+			style = "\033[1m"
+		}
+		fmt.Fprintf(stdout, "%s%s\033[0m  \033[38;5;%dm%s\033[0m %3d  %s%s\033[0m\n", style, d.Date.Format("Mon Jan 02"), color, bar, score, style, note)
+	}
+}
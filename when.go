@@ -0,0 +1,26 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/nafiz1001/wego/frontends"
+	"github.com/nafiz1001/wego/iface"
+)
+
+// printWhen prints only when the next weather of kind is expected in r, e.g.
+// "in 40 minutes (14:20)", for the -when flag. In deterministic mode only the
+// time is printed.
+func printWhen(r iface.Data, kind string, numdays int) {
+	now := iface.Now()
+	t, ok := frontends.NextOnset(r, kind, now)
+	switch {
+	case !ok:
+		fmt.Printf("not within the next %d days\n", numdays)
+	case !t.After(now):
+		fmt.Println("now")
+	case iface.Deterministic:
+		fmt.Println(t.Format("Mon 15:04"))
+	default:
+		fmt.Printf("%s (%s)\n", frontends.RelativeTime(t, now, "en"), t.Format("Mon 15:04"))
+	}
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/mattn/go-isatty"
+	"github.com/nafiz1001/wego/iface"
+)
+
+// wizardGeoIPURL is asked for the approximate location of the user.
+const wizardGeoIPURL = "https://ipinfo.io/json"
+
+// wizardDefaultBackend is suggested, as it still hands out free API keys.
+const wizardDefaultBackend = "openweathermap"
+
+// wizardHidden are backends not meant for everyday use.
+var wizardHidden = map[string]bool{"json": true, "mock": true}
+
+// errWizardSkipped is returned when the user ends the input early.
+var errWizardSkipped = errors.New("setup skipped")
+
+// interactive tells whether wego runs in a terminal, so it can ask questions.
+func interactive() bool {
+	return isatty.IsTerminal(os.Stdin.Fd()) && isatty.IsTerminal(os.Stdout.Fd())
+}
+
+// runWizard asks for the settings most users need to change and writes them to
+// the config file at path. ingo adds the remaining settings later.
+func runWizard(in io.Reader, out io.Writer, path string) error {
+	scanner := bufio.NewScanner(in)
+	ask := func(question, def string) (string, error) {
+		if def != "" {
+			question += " [" + def + "]"
+		}
+		fmt.Fprint(out, question+": ")
+		if !scanner.Scan() {
+			fmt.Fprintln(out)
+			return "", errWizardSkipped
+		}
+		if a := strings.TrimSpace(scanner.Text()); a != "" {
+			return a, nil
+		}
+		return def, nil
+	}
+
+	fmt.Fprintf(out, "No config file found at %s, let's create one.\n", path)
+	fmt.Fprintln(out, "Press Enter to take the suggestion in brackets or Ctrl-D to skip the setup.")
+	fmt.Fprintln(out)
+
+	var names []string
+	for name := range iface.AllBackends {
+		if !wizardHidden[name] {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	def := ""
+	fmt.Fprintln(out, "Backends:")
+	for i, name := range names {
+		note := "no API key needed"
+		if capabilities(iface.AllBackends[name]).APIKeyFlag != "" {
+			note = "needs an API key"
+		}
+		fmt.Fprintf(out, "  %d) %-20s %s\n", i+1, name, note)
+		if name == wizardDefaultBackend {
+			def = strconv.Itoa(i + 1)
+		}
+	}
+	var backend string
+	for backend == "" {
+		a, err := ask("Backend", def)
+		if err != nil {
+			return err
+		}
+		if n, err := strconv.Atoi(a); err == nil && n >= 1 && n <= len(names) {
+			backend = names[n-1]
+		} else if _, ok := iface.AllBackends[a]; ok {
+			backend = a
+		}
+	}
+	settings := [][2]string{{"backend", backend}}
+
+	if keyFlag := capabilities(iface.AllBackends[backend]).APIKeyFlag; keyFlag != "" {
+		key, err := ask("API key for "+backend, "")
+		if err != nil {
+			return err
+		}
+		settings = append(settings, [2]string{keyFlag, key})
+	}
+
+	var loc, country string
+	lookup, err := ask("Look up your approximate location by IP address at ipinfo.io? (y/n)", "n")
+	if err != nil {
+		return err
+	}
+	if strings.HasPrefix(strings.ToLower(lookup), "y") {
+		fmt.Fprint(out, "Detecting your location... ")
+		if loc, country, err = detectLocation(); err != nil {
+			fmt.Fprintln(out, "failed:", err)
+		} else {
+			fmt.Fprintln(out, loc)
+		}
+	}
+	var location string
+	for location == "" {
+		if location, err = ask("Location (latitude,longitude or a place name)", loc); err != nil {
+			return err
+		}
+	}
+	settings = append(settings, [2]string{"location", location})
+
+	units := "metric"
+	if country == "US" || country == "LR" || country == "MM" {
+		units = "imperial"
+	}
+	for {
+		if units, err = ask("Units (metric, imperial, si, metric-ms)", units); err != nil {
+			return err
+		}
+		if units == "metric" || units == "imperial" || units == "si" || units == "metric-ms" {
+			break
+		}
+	}
+	settings = append(settings, [2]string{"units", units})
+
+	var conf strings.Builder
+	for _, s := range settings {
+		fmt.Fprintf(&conf, "%s=%s\n", s[0], s[1])
+	}
+	if err := ioutil.WriteFile(path, []byte(conf.String()), 0600); err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "\nSaved %s, fetching a first forecast to test it:\n\n", path)
+	return nil
+}
+
+// detectLocation returns the approximate coordinates of the user like
+// "45.42,-75.69" and the country code, looked up by IP address.
+func detectLocation() (loc, country string, err error) {
+	var resp struct {
+		Loc     string `json:"loc"`
+		Country string `json:"country"`
+	}
+	if err := fetchFeed(wizardGeoIPURL, func(r io.Reader) error {
+		return json.NewDecoder(r).Decode(&resp)
+	}); err != nil {
+		return "", "", err
+	}
+	if resp.Loc == "" {
+		return "", "", fmt.Errorf("%s: no location in the response", wizardGeoIPURL)
+	}
+	return resp.Loc, resp.Country, nil
+}
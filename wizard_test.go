@@ -0,0 +1,56 @@
+package main
+
+import (
+	"flag"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRunWizard(t *testing.T) {
+	p := filepath.Join(t.TempDir(), "config.toml")
+	// the location is not looked up unless asked for
+	in := strings.NewReader("openweathermap\nKEY\n\nBerlin\n\n")
+	var out strings.Builder
+	if err := runWizard(in, &out, p); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(out.String(), "Detecting") {
+		t.Errorf("the wizard looked up the location without asking:\n%s", out.String())
+	}
+	b, err := ioutil.ReadFile(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"backend=openweathermap\n", "=KEY\n", "location=Berlin\n", "units=metric\n"} {
+		if !strings.Contains(string(b), want) {
+			t.Errorf("config lacks %q:\n%s", want, b)
+		}
+	}
+
+	if err := runWizard(strings.NewReader("openweathermap\n"), &out, p+".skipped"); err != errWizardSkipped {
+		t.Errorf("runWizard at the end of the input = %v, want %v", err, errWizardSkipped)
+	}
+}
+
+func TestSetCmdlineFlags(t *testing.T) {
+	fs := flag.NewFlagSet("wego", flag.ContinueOnError)
+	location := fs.String("location", "", "")
+	timeout := fs.Duration("timeout", time.Minute, "")
+	ipv4 := fs.Bool("ipv4", false, "")
+	doh := fs.String("doh", "", "")
+
+	args := []string{"-location", "Berlin", "-timeout", "5s", "--ipv4", "-doh=https://dns.example/q", "3"}
+	if err := setCmdlineFlags(fs, args, netFlags...); err != nil {
+		t.Fatal(err)
+	}
+	if *timeout != 5*time.Second || !*ipv4 || *doh != "https://dns.example/q" || *location != "" {
+		t.Errorf("timeout %v, ipv4 %v, doh %q, location %q", *timeout, *ipv4, *doh, *location)
+	}
+
+	if err := setCmdlineFlags(fs, []string{"-timeout", "soon"}, netFlags...); err == nil {
+		t.Error("an invalid -timeout was accepted")
+	}
+}